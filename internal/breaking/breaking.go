@@ -0,0 +1,316 @@
+package breaking
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+type Rule string
+
+const (
+	RuleMessageRemoved         Rule = "MESSAGE_REMOVED"
+	RuleFieldRemoved           Rule = "FIELD_REMOVED"
+	RuleFieldNumberChanged     Rule = "FIELD_NUMBER_CHANGED"
+	RuleFieldTypeChanged       Rule = "FIELD_TYPE_CHANGED"
+	RuleFieldLabelChanged      Rule = "FIELD_LABEL_CHANGED"
+	RuleEnumRemoved            Rule = "ENUM_REMOVED"
+	RuleEnumValueRemoved       Rule = "ENUM_VALUE_REMOVED"
+	RuleEnumValueNumberChanged Rule = "ENUM_VALUE_NUMBER_CHANGED"
+	RuleServiceRemoved         Rule = "SERVICE_REMOVED"
+	RuleRPCRemoved             Rule = "RPC_REMOVED"
+	RuleRPCSignatureChanged    Rule = "RPC_SIGNATURE_CHANGED"
+)
+
+// Violation describes a single incompatible change between two schema revisions.
+type Violation struct {
+	Rule      Rule   `json:"rule"`
+	File      string `json:"file"`
+	Subject   string `json:"subject"`
+	Message   string `json:"message"`
+	Against   string `json:"against,omitempty"`
+	Direction string `json:"direction,omitempty"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s (%s)", v.Rule, v.Message, v.File)
+}
+
+// LoadDescriptorSet reads a FileDescriptorSet written by protoc --descriptor_set_out
+func LoadDescriptorSet(path string) (*descriptorpb.FileDescriptorSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set: %w", err)
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("failed to parse descriptor set: %w", err)
+	}
+	return set, nil
+}
+
+// Compare reports every element of previous that was removed or changed
+// incompatibly in current. Additions in current are not reported.
+func Compare(previous, current *descriptorpb.FileDescriptorSet) []Violation {
+	prev := index(previous)
+	curr := index(current)
+
+	var violations []Violation
+
+	for name, msg := range prev.messages {
+		currMsg, ok := curr.messages[name]
+		if !ok {
+			violations = append(violations, Violation{
+				Rule:    RuleMessageRemoved,
+				File:    msg.file,
+				Subject: name,
+				Message: fmt.Sprintf("message %s was removed", name),
+			})
+			continue
+		}
+		violations = append(violations, compareFields(name, msg, currMsg)...)
+	}
+
+	for name, enum := range prev.enums {
+		currEnum, ok := curr.enums[name]
+		if !ok {
+			violations = append(violations, Violation{
+				Rule:    RuleEnumRemoved,
+				File:    enum.file,
+				Subject: name,
+				Message: fmt.Sprintf("enum %s was removed", name),
+			})
+			continue
+		}
+		violations = append(violations, compareEnumValues(name, enum, currEnum)...)
+	}
+
+	for name, svc := range prev.services {
+		currSvc, ok := curr.services[name]
+		if !ok {
+			violations = append(violations, Violation{
+				Rule:    RuleServiceRemoved,
+				File:    svc.file,
+				Subject: name,
+				Message: fmt.Sprintf("service %s was removed or renamed", name),
+			})
+			continue
+		}
+		violations = append(violations, compareMethods(name, svc, currSvc)...)
+	}
+
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].Subject != violations[j].Subject {
+			return violations[i].Subject < violations[j].Subject
+		}
+		return violations[i].Rule < violations[j].Rule
+	})
+	return violations
+}
+
+func compareFields(msgName string, prev, curr *messageInfo) []Violation {
+	currFields := make(map[string]*descriptorpb.FieldDescriptorProto, len(curr.desc.GetField()))
+	for _, field := range curr.desc.GetField() {
+		currFields[field.GetName()] = field
+	}
+
+	var violations []Violation
+	for _, field := range prev.desc.GetField() {
+		subject := msgName + "." + field.GetName()
+		currField, ok := currFields[field.GetName()]
+		if !ok {
+			violations = append(violations, Violation{
+				Rule:    RuleFieldRemoved,
+				File:    prev.file,
+				Subject: subject,
+				Message: fmt.Sprintf("field %s (%d) was removed from message %s", field.GetName(), field.GetNumber(), msgName),
+			})
+			continue
+		}
+
+		if field.GetNumber() != currField.GetNumber() {
+			violations = append(violations, Violation{
+				Rule:    RuleFieldNumberChanged,
+				File:    prev.file,
+				Subject: subject,
+				Message: fmt.Sprintf("field %s changed number from %d to %d", subject, field.GetNumber(), currField.GetNumber()),
+			})
+		}
+
+		if prevType, currType := fieldType(field), fieldType(currField); prevType != currType {
+			violations = append(violations, Violation{
+				Rule:    RuleFieldTypeChanged,
+				File:    prev.file,
+				Subject: subject,
+				Message: fmt.Sprintf("field %s changed type from %s to %s", subject, prevType, currType),
+			})
+		}
+
+		if isRepeated(field) != isRepeated(currField) {
+			violations = append(violations, Violation{
+				Rule:    RuleFieldLabelChanged,
+				File:    prev.file,
+				Subject: subject,
+				Message: fmt.Sprintf("field %s changed label from %s to %s", subject, labelName(field), labelName(currField)),
+			})
+		}
+	}
+	return violations
+}
+
+func compareEnumValues(enumName string, prev, curr *enumInfo) []Violation {
+	currValues := make(map[string]int32, len(curr.desc.GetValue()))
+	for _, value := range curr.desc.GetValue() {
+		currValues[value.GetName()] = value.GetNumber()
+	}
+
+	var violations []Violation
+	for _, value := range prev.desc.GetValue() {
+		subject := enumName + "." + value.GetName()
+		number, ok := currValues[value.GetName()]
+		if !ok {
+			violations = append(violations, Violation{
+				Rule:    RuleEnumValueRemoved,
+				File:    prev.file,
+				Subject: subject,
+				Message: fmt.Sprintf("enum value %s (%d) was removed from enum %s", value.GetName(), value.GetNumber(), enumName),
+			})
+			continue
+		}
+		if number != value.GetNumber() {
+			violations = append(violations, Violation{
+				Rule:    RuleEnumValueNumberChanged,
+				File:    prev.file,
+				Subject: subject,
+				Message: fmt.Sprintf("enum value %s changed number from %d to %d", subject, value.GetNumber(), number),
+			})
+		}
+	}
+	return violations
+}
+
+func compareMethods(svcName string, prev, curr *serviceInfo) []Violation {
+	currMethods := make(map[string]*descriptorpb.MethodDescriptorProto, len(curr.desc.GetMethod()))
+	for _, method := range curr.desc.GetMethod() {
+		currMethods[method.GetName()] = method
+	}
+
+	var violations []Violation
+	for _, method := range prev.desc.GetMethod() {
+		subject := svcName + "." + method.GetName()
+		currMethod, ok := currMethods[method.GetName()]
+		if !ok {
+			violations = append(violations, Violation{
+				Rule:    RuleRPCRemoved,
+				File:    prev.file,
+				Subject: subject,
+				Message: fmt.Sprintf("rpc %s was removed or renamed", subject),
+			})
+			continue
+		}
+		if prevSig, currSig := methodSignature(method), methodSignature(currMethod); prevSig != currSig {
+			violations = append(violations, Violation{
+				Rule:    RuleRPCSignatureChanged,
+				File:    prev.file,
+				Subject: subject,
+				Message: fmt.Sprintf("rpc %s changed signature from %s to %s", subject, prevSig, currSig),
+			})
+		}
+	}
+	return violations
+}
+
+type messageInfo struct {
+	file string
+	desc *descriptorpb.DescriptorProto
+}
+
+type enumInfo struct {
+	file string
+	desc *descriptorpb.EnumDescriptorProto
+}
+
+type serviceInfo struct {
+	file string
+	desc *descriptorpb.ServiceDescriptorProto
+}
+
+type schemaIndex struct {
+	messages map[string]*messageInfo
+	enums    map[string]*enumInfo
+	services map[string]*serviceInfo
+}
+
+// index flattens a descriptor set into maps keyed by fully-qualified element name
+func index(set *descriptorpb.FileDescriptorSet) *schemaIndex {
+	idx := &schemaIndex{
+		messages: make(map[string]*messageInfo),
+		enums:    make(map[string]*enumInfo),
+		services: make(map[string]*serviceInfo),
+	}
+	for _, file := range set.GetFile() {
+		prefix := file.GetPackage()
+		for _, msg := range file.GetMessageType() {
+			idx.addMessage(file.GetName(), prefix, msg)
+		}
+		for _, enum := range file.GetEnumType() {
+			idx.enums[qualify(prefix, enum.GetName())] = &enumInfo{file: file.GetName(), desc: enum}
+		}
+		for _, svc := range file.GetService() {
+			idx.services[qualify(prefix, svc.GetName())] = &serviceInfo{file: file.GetName(), desc: svc}
+		}
+	}
+	return idx
+}
+
+func (idx *schemaIndex) addMessage(file, prefix string, msg *descriptorpb.DescriptorProto) {
+	name := qualify(prefix, msg.GetName())
+	idx.messages[name] = &messageInfo{file: file, desc: msg}
+	for _, nested := range msg.GetNestedType() {
+		idx.addMessage(file, name, nested)
+	}
+	for _, enum := range msg.GetEnumType() {
+		idx.enums[qualify(name, enum.GetName())] = &enumInfo{file: file, desc: enum}
+	}
+}
+
+func qualify(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func fieldType(field *descriptorpb.FieldDescriptorProto) string {
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_ENUM, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		return field.GetTypeName()
+	}
+	return field.GetType().String()
+}
+
+func isRepeated(field *descriptorpb.FieldDescriptorProto) bool {
+	return field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+}
+
+func labelName(field *descriptorpb.FieldDescriptorProto) string {
+	if isRepeated(field) {
+		return "repeated"
+	}
+	return "singular"
+}
+
+func methodSignature(method *descriptorpb.MethodDescriptorProto) string {
+	input := method.GetInputType()
+	if method.GetClientStreaming() {
+		input = "stream " + input
+	}
+	output := method.GetOutputType()
+	if method.GetServerStreaming() {
+		output = "stream " + output
+	}
+	return fmt.Sprintf("(%s) returns (%s)", input, output)
+}
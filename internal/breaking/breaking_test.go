@@ -0,0 +1,402 @@
+package breaking
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/sirrobot01/protodex/internal/semver"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		mutate   func(file *descriptorpb.FileDescriptorProto)
+		expected []Rule
+	}{
+		{
+			name:     "no changes",
+			mutate:   func(file *descriptorpb.FileDescriptorProto) {},
+			expected: nil,
+		},
+		{
+			name: "additions are compatible",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.MessageType[0].Field = append(file.MessageType[0].Field, scalarField("email", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING))
+				file.MessageType = append(file.MessageType, &descriptorpb.DescriptorProto{Name: proto.String("Extra")})
+			},
+			expected: nil,
+		},
+		{
+			name: "message removed",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.MessageType = file.MessageType[:1]
+			},
+			expected: []Rule{RuleMessageRemoved},
+		},
+		{
+			name: "field removed",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.MessageType[0].Field = file.MessageType[0].Field[:1]
+			},
+			expected: []Rule{RuleFieldRemoved},
+		},
+		{
+			name: "field number changed",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.MessageType[0].Field[1].Number = proto.Int32(5)
+			},
+			expected: []Rule{RuleFieldNumberChanged},
+		},
+		{
+			name: "field type changed",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.MessageType[0].Field[1].Type = descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()
+			},
+			expected: []Rule{RuleFieldTypeChanged},
+		},
+		{
+			name: "field label changed",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.MessageType[0].Field[0].Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+			},
+			expected: []Rule{RuleFieldLabelChanged},
+		},
+		{
+			name: "enum value removed",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.EnumType[0].Value = file.EnumType[0].Value[:1]
+			},
+			expected: []Rule{RuleEnumValueRemoved},
+		},
+		{
+			name: "enum value number changed",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.EnumType[0].Value[1].Number = proto.Int32(7)
+			},
+			expected: []Rule{RuleEnumValueNumberChanged},
+		},
+		{
+			name: "service renamed",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.Service[0].Name = proto.String("Users")
+			},
+			expected: []Rule{RuleServiceRemoved},
+		},
+		{
+			name: "rpc renamed",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.Service[0].Method[0].Name = proto.String("FetchUser")
+			},
+			expected: []Rule{RuleRPCRemoved},
+		},
+		{
+			name: "rpc made streaming",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.Service[0].Method[0].ServerStreaming = proto.Bool(true)
+			},
+			expected: []Rule{RuleRPCSignatureChanged},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := testFile()
+			current := testFile()
+			tt.mutate(current)
+
+			violations := Compare(descriptorSet(previous), descriptorSet(current))
+
+			var rules []Rule
+			for _, v := range violations {
+				rules = append(rules, v.Rule)
+				assert.Equal(t, "user.proto", v.File)
+				assert.NotEmpty(t, v.Message)
+			}
+			assert.Equal(t, tt.expected, rules)
+		})
+	}
+}
+
+func TestCompareNestedTypes(t *testing.T) {
+	previous := testFile()
+	previous.MessageType[0].NestedType = []*descriptorpb.DescriptorProto{
+		{Name: proto.String("Address")},
+	}
+	current := testFile()
+
+	violations := Compare(descriptorSet(previous), descriptorSet(current))
+	require.Len(t, violations, 1)
+	assert.Equal(t, RuleMessageRemoved, violations[0].Rule)
+	assert.Equal(t, "user.v1.User.Address", violations[0].Subject)
+}
+
+func TestLoadDescriptorSet(t *testing.T) {
+	data, err := proto.Marshal(descriptorSet(testFile()))
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "schema.pb")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	set, err := LoadDescriptorSet(path)
+	require.NoError(t, err)
+	require.Len(t, set.GetFile(), 1)
+	assert.Equal(t, "user.proto", set.GetFile()[0].GetName())
+
+	_, err = LoadDescriptorSet(filepath.Join(t.TempDir(), "missing.pb"))
+	assert.Error(t, err)
+}
+
+func descriptorSet(files ...*descriptorpb.FileDescriptorProto) *descriptorpb.FileDescriptorSet {
+	return &descriptorpb.FileDescriptorSet{File: files}
+}
+
+func scalarField(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(number),
+		Type:   typ.Enum(),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+}
+
+func testFile() *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("user.proto"),
+		Package: proto.String("user.v1"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("User"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					scalarField("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+					scalarField("age", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+				},
+			},
+			{
+				Name: proto.String("GetUserRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					scalarField("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				},
+			},
+		},
+		EnumType: []*descriptorpb.EnumDescriptorProto{
+			{
+				Name: proto.String("Status"),
+				Value: []*descriptorpb.EnumValueDescriptorProto{
+					{Name: proto.String("STATUS_UNSPECIFIED"), Number: proto.Int32(0)},
+					{Name: proto.String("STATUS_ACTIVE"), Number: proto.Int32(1)},
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String("UserService"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String("GetUser"),
+						InputType:  proto.String(".user.v1.GetUserRequest"),
+						OutputType: proto.String(".user.v1.User"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	v1 := testFile()
+	v2 := testFile()
+	v2.MessageType[0].Field = append(v2.MessageType[0].Field, scalarField("email", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING))
+
+	// current drops the "age" field that only v1 and v2 had
+	current := testFile()
+	current.MessageType[0].Field = []*descriptorpb.FieldDescriptorProto{
+		scalarField("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		scalarField("email", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		scalarField("nickname", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+	}
+
+	history := []Revision{
+		{Version: "v2", Set: descriptorSet(v2)},
+		{Version: "v1", Set: descriptorSet(v1)},
+	}
+
+	tests := []struct {
+		name     string
+		mode     Mode
+		expected []string
+	}{
+		{
+			name:     "none",
+			mode:     ModeNone,
+			expected: nil,
+		},
+		{
+			name:     "backward checks latest only",
+			mode:     ModeBackward,
+			expected: []string{"v2/backward/FIELD_REMOVED"},
+		},
+		{
+			name:     "backward transitive checks all versions",
+			mode:     ModeBackwardTransitive,
+			expected: []string{"v2/backward/FIELD_REMOVED", "v1/backward/FIELD_REMOVED"},
+		},
+		{
+			name:     "forward allows additions and optional field removals",
+			mode:     ModeForward,
+			expected: nil,
+		},
+		{
+			name:     "full allows additions",
+			mode:     ModeFull,
+			expected: []string{"v2/backward/FIELD_REMOVED"},
+		},
+		{
+			name:     "full transitive",
+			mode:     ModeFullTransitive,
+			expected: []string{"v2/backward/FIELD_REMOVED", "v1/backward/FIELD_REMOVED"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, v := range Check(tt.mode, descriptorSet(current), history) {
+				got = append(got, v.Against+"/"+v.Direction+"/"+string(v.Rule))
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestCheckForward(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous func(file *descriptorpb.FileDescriptorProto)
+		mutate   func(file *descriptorpb.FileDescriptorProto)
+		expected []Rule
+	}{
+		{
+			name: "additions are compatible",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.MessageType[0].Field = append(file.MessageType[0].Field, scalarField("email", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING))
+				file.EnumType[0].Value = append(file.EnumType[0].Value, &descriptorpb.EnumValueDescriptorProto{Name: proto.String("STATUS_BANNED"), Number: proto.Int32(2)})
+				file.Service[0].Method = append(file.Service[0].Method, &descriptorpb.MethodDescriptorProto{
+					Name:       proto.String("DeleteUser"),
+					InputType:  proto.String(".user.v1.GetUserRequest"),
+					OutputType: proto.String(".user.v1.User"),
+				})
+			},
+			expected: nil,
+		},
+		{
+			name: "optional field removed",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.MessageType[0].Field = file.MessageType[0].Field[:1]
+			},
+			expected: nil,
+		},
+		{
+			name: "required field removed",
+			previous: func(file *descriptorpb.FileDescriptorProto) {
+				file.Syntax = proto.String("proto2")
+				file.MessageType[0].Field[1].Label = descriptorpb.FieldDescriptorProto_LABEL_REQUIRED.Enum()
+			},
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.MessageType[0].Field = file.MessageType[0].Field[:1]
+			},
+			expected: []Rule{RuleFieldRemoved},
+		},
+		{
+			name: "oneof member removed",
+			previous: func(file *descriptorpb.FileDescriptorProto) {
+				file.MessageType[0].OneofDecl = []*descriptorpb.OneofDescriptorProto{{Name: proto.String("details")}}
+				file.MessageType[0].Field[1].OneofIndex = proto.Int32(0)
+			},
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.MessageType[0].Field = file.MessageType[0].Field[:1]
+			},
+			expected: []Rule{RuleFieldRemoved},
+		},
+		{
+			name: "field type changed",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.MessageType[0].Field[1].Type = descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()
+			},
+			expected: []Rule{RuleFieldTypeChanged},
+		},
+		{
+			name: "field number changed",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.MessageType[0].Field[1].Number = proto.Int32(5)
+			},
+			expected: []Rule{RuleFieldNumberChanged},
+		},
+		{
+			name: "enum value removed",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.EnumType[0].Value = file.EnumType[0].Value[:1]
+			},
+			expected: []Rule{RuleEnumValueRemoved},
+		},
+		{
+			name: "message removed",
+			mutate: func(file *descriptorpb.FileDescriptorProto) {
+				file.MessageType = file.MessageType[:1]
+			},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := testFile()
+			if tt.previous != nil {
+				tt.previous(previous)
+			}
+			current := testFile()
+			tt.mutate(current)
+
+			history := []Revision{{Version: "v1", Set: descriptorSet(previous)}}
+			var rules []Rule
+			for _, v := range Check(ModeForward, descriptorSet(current), history) {
+				assert.Equal(t, DirectionForward, v.Direction)
+				rules = append(rules, v.Rule)
+			}
+			assert.Equal(t, tt.expected, rules)
+		})
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	mode, err := ParseMode("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultMode, mode)
+
+	mode, err = ParseMode("full_transitive")
+	require.NoError(t, err)
+	assert.Equal(t, ModeFullTransitive, mode)
+	assert.True(t, mode.Transitive())
+	assert.False(t, ModeFull.Transitive())
+
+	_, err = ParseMode("sideways")
+	assert.Error(t, err)
+}
+
+func TestRequiredBump(t *testing.T) {
+	withField := testFile()
+	withField.MessageType[0].Field = append(withField.MessageType[0].Field, scalarField("email", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING))
+
+	withOption := testFile()
+	withOption.Options = &descriptorpb.FileOptions{GoPackage: proto.String("example.com/user/v1")}
+
+	assert.Equal(t, semver.BumpNone, RequiredBump(descriptorSet(testFile()), descriptorSet(testFile())))
+	assert.Equal(t, semver.BumpPatch, RequiredBump(descriptorSet(testFile()), descriptorSet(withOption)))
+	assert.Equal(t, semver.BumpMinor, RequiredBump(descriptorSet(testFile()), descriptorSet(withField)))
+	assert.Equal(t, semver.BumpMajor, RequiredBump(descriptorSet(withField), descriptorSet(testFile())))
+}
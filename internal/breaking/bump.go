@@ -0,0 +1,24 @@
+package breaking
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/sirrobot01/protodex/internal/semver"
+)
+
+// RequiredBump returns the smallest version bump that describes the change from previous to current:
+// major for removals or incompatible changes, minor for additions and patch for anything else.
+// BumpNone is returned when both descriptor sets are identical.
+func RequiredBump(previous, current *descriptorpb.FileDescriptorSet) semver.Bump {
+	if len(Compare(previous, current)) > 0 {
+		return semver.BumpMajor
+	}
+	if len(Compare(current, previous)) > 0 {
+		return semver.BumpMinor
+	}
+	if proto.Equal(previous, current) {
+		return semver.BumpNone
+	}
+	return semver.BumpPatch
+}
@@ -0,0 +1,133 @@
+package breaking
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Mode is a package compatibility level, modeled after Confluent Schema Registry
+type Mode string
+
+const (
+	ModeNone               Mode = "NONE"
+	ModeBackward           Mode = "BACKWARD"
+	ModeBackwardTransitive Mode = "BACKWARD_TRANSITIVE"
+	ModeForward            Mode = "FORWARD"
+	ModeForwardTransitive  Mode = "FORWARD_TRANSITIVE"
+	ModeFull               Mode = "FULL"
+	ModeFullTransitive     Mode = "FULL_TRANSITIVE"
+
+	DefaultMode = ModeBackward
+)
+
+const (
+	DirectionBackward = "backward"
+	DirectionForward  = "forward"
+)
+
+var modes = []Mode{ModeNone, ModeBackward, ModeBackwardTransitive, ModeForward, ModeForwardTransitive, ModeFull, ModeFullTransitive}
+
+// Revision is a compiled, previously published version of a schema
+type Revision struct {
+	Version string
+	Set     *descriptorpb.FileDescriptorSet
+}
+
+func Modes() []Mode {
+	return modes
+}
+
+func ParseMode(s string) (Mode, error) {
+	if s == "" {
+		return DefaultMode, nil
+	}
+	mode := Mode(strings.ToUpper(s))
+	for _, m := range modes {
+		if m == mode {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown compatibility mode %q", s)
+}
+
+// Transitive reports whether the mode checks against all prior versions instead of only the latest
+func (m Mode) Transitive() bool {
+	return strings.HasSuffix(string(m), "_TRANSITIVE")
+}
+
+func (m Mode) backward() bool {
+	return m == ModeBackward || m == ModeBackwardTransitive || m == ModeFull || m == ModeFullTransitive
+}
+
+func (m Mode) forward() bool {
+	return m == ModeForward || m == ModeForwardTransitive || m == ModeFull || m == ModeFullTransitive
+}
+
+// Check validates current against history (newest first) according to mode.
+// Non-transitive modes only look at the most recent revision.
+//
+// Backward checks report elements of a revision that were removed or changed in current,
+// so consumers built against current can still read old data. Forward checks report the
+// changes that consumers of the revision cannot handle when they read data written with
+// current: removed required fields, oneof members and enum values, and changed types or
+// numbers. Additions are compatible in both directions, they are skipped by old consumers.
+func Check(mode Mode, current *descriptorpb.FileDescriptorSet, history []Revision) []Violation {
+	if mode == ModeNone || len(history) == 0 {
+		return nil
+	}
+	if !mode.Transitive() {
+		history = history[:1]
+	}
+
+	var violations []Violation
+	for _, rev := range history {
+		// Every forward violation is a backward one too, so full modes only need the backward check
+		var previous *schemaIndex
+		if !mode.backward() {
+			previous = index(rev.Set)
+		}
+		for _, v := range Compare(rev.Set, current) {
+			v.Against = rev.Version
+			v.Direction = DirectionBackward
+			if previous != nil {
+				if !breaksForward(v, previous) {
+					continue
+				}
+				v.Direction = DirectionForward
+			}
+			violations = append(violations, v)
+		}
+	}
+	return violations
+}
+
+// breaksForward reports whether consumers of previous fail on data written with a schema that
+// has the violation. They ignore removed optional fields and never see removed messages, enums
+// or services, but they still expect required fields, the members of their oneofs and every
+// enum value they know.
+func breaksForward(v Violation, previous *schemaIndex) bool {
+	switch v.Rule {
+	case RuleMessageRemoved, RuleEnumRemoved, RuleServiceRemoved, RuleRPCRemoved:
+		return false
+	case RuleFieldRemoved:
+		i := strings.LastIndex(v.Subject, ".")
+		msg, ok := previous.messages[v.Subject[:i]]
+		if !ok {
+			return true
+		}
+		for _, field := range msg.desc.GetField() {
+			if field.GetName() != v.Subject[i+1:] {
+				continue
+			}
+			if field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REQUIRED {
+				return true
+			}
+			// proto3 optional fields live in a synthetic oneof of their own
+			return field.OneofIndex != nil && !field.GetProto3Optional()
+		}
+		return true
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+var accessCmd = &cobra.Command{
+	Use:   "access",
+	Short: "Manage who can read, push or administer a package",
+	Long: `Manage the collaborators of a package. Only package owners can grant or revoke roles.
+
+Roles:
+- owner: manages the package settings and its collaborators, deletes versions
+- maintainer: pushes, yanks and unyanks versions
+- reader: pulls versions
+
+Members of an organization also get a role on its packages: organization owners are owners,
+maintainers are maintainers and members are readers.`,
+}
+
+var accessGrantCmd = &cobra.Command{
+	Use:   "grant <package> <username>",
+	Short: "Give a user a role on a package or change their role",
+	Example: `  protodex access grant user-service janedoe
+  protodex access grant acme/payments janedoe --role maintainer`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		role, _ := cmd.Flags().GetString("role")
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if err := c.GrantAccess(args[0], args[1], role); err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("%s is now %s of %s", args[1], role, args[0])))
+		return nil
+	},
+}
+
+var accessRevokeCmd = &cobra.Command{
+	Use:   "revoke <package> <username>",
+	Short: "Remove the role of a user on a package",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if err := c.RevokeAccess(args[0], args[1]); err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Revoked the access of %s to %s", args[1], args[0])))
+		return nil
+	},
+}
+
+var accessListCmd = &cobra.Command{
+	Use:   "list <package>",
+	Short: "List the owner and collaborators of a package",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		collaborators, err := c.ListCollaborators(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s\n", style.Bold(args[0]))
+		for _, collaborator := range collaborators {
+			fmt.Printf("  %s %s\n", collaborator.Username, style.Subtle("("+collaborator.Role+")"))
+		}
+		return nil
+	},
+}
+
+func init() {
+	accessGrantCmd.Flags().String("role", "reader", "Role on the package (owner, maintainer, reader)")
+
+	accessCmd.AddCommand(accessGrantCmd)
+	accessCmd.AddCommand(accessRevokeCmd)
+	accessCmd.AddCommand(accessListCmd)
+}
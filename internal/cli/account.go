@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+var accountCmd = &cobra.Command{
+	Use:   "account",
+	Short: "Manage your account",
+}
+
+var accountPasswordCmd = &cobra.Command{
+	Use:   "password",
+	Short: "Change your password",
+	Long: `Change your password. Every other session of your account is ended, the current one stays
+logged in.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		current, err := promptPassword("Current password: ")
+		if err != nil {
+			return err
+		}
+		newPassword, err := promptNewPassword()
+		if err != nil {
+			return err
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if err := c.ChangePassword(current, newPassword); err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success("Password changed, other sessions were logged out"))
+		return nil
+	},
+}
+
+var accountDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete your account",
+	Long: `Delete your account with its sessions, tokens and package roles. Packages you own must be
+transferred or deleted first.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		password, err := promptPassword("Password: ")
+		if err != nil {
+			return err
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if err := c.DeleteAccount(password); err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success("Account deleted"))
+		return nil
+	},
+}
+
+// promptPassword reads a password from the terminal without echoing it
+func promptPassword(label string) (string, error) {
+	fmt.Print(label)
+	bytePassword, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	if len(bytePassword) == 0 {
+		return "", fmt.Errorf("password is required")
+	}
+	return string(bytePassword), nil
+}
+
+// promptNewPassword reads a new password twice and checks both entries match
+func promptNewPassword() (string, error) {
+	password, err := promptPassword("New password: ")
+	if err != nil {
+		return "", err
+	}
+	confirm, err := promptPassword("Confirm new password: ")
+	if err != nil {
+		return "", err
+	}
+	if password != confirm {
+		return "", fmt.Errorf("passwords do not match")
+	}
+	return password, nil
+}
+
+func init() {
+	accountCmd.AddCommand(accountPasswordCmd)
+	accountCmd.AddCommand(accountDeleteCmd)
+	accountCmd.AddCommand(accountTwoFactorCmd)
+}
@@ -0,0 +1,377 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+var adminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Administer the registry",
+	Long:  `Administer the registry. These commands require an administrator account.`,
+}
+
+var adminUserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage user accounts",
+}
+
+var adminUserListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List users",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		users, err := c.ListUsers()
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			var labels []string
+			if user.IsAdmin {
+				labels = append(labels, "admin")
+			}
+			if user.ServiceAccount {
+				labels = append(labels, "service account")
+			}
+			if user.Disabled {
+				labels = append(labels, "disabled")
+			}
+			if user.TwoFactor {
+				labels = append(labels, "2fa")
+			}
+			line := style.Bold(user.Username)
+			if user.Email != "" {
+				line += " " + user.Email
+			}
+			if len(labels) > 0 {
+				line += " " + style.Subtle("("+strings.Join(labels, ", ")+")")
+			}
+			fmt.Println(line)
+		}
+		return nil
+	},
+}
+
+var adminUserCreateCmd = &cobra.Command{
+	Use:   "create <username>",
+	Short: "Create a user",
+	Long: `Create an account on behalf of someone, for registries where they cannot register themselves.
+With --generate the registry generates a password and prints it, otherwise you are prompted for one.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		generate, _ := cmd.Flags().GetBool("generate")
+		email, _ := cmd.Flags().GetString("email")
+		admin, _ := cmd.Flags().GetBool("admin")
+
+		req := client.CreateUserRequest{Username: args[0], Email: email, Admin: admin}
+		if !generate {
+			var err error
+			if req.Password, err = promptNewPassword(); err != nil {
+				return err
+			}
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		created, err := c.CreateUser(req)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Created user %s", created.User.Username)))
+		if created.Password != "" {
+			fmt.Println(created.Password)
+			fmt.Println(style.Warning("Share the password securely, it will not be shown again"))
+		}
+		return nil
+	},
+}
+
+var adminUserPromoteCmd = &cobra.Command{
+	Use:   "promote <username>",
+	Short: "Make a user an administrator",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setAdmin(args[0], true)
+	},
+}
+
+var adminUserDemoteCmd = &cobra.Command{
+	Use:   "demote <username>",
+	Short: "Take administrator access away from a user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setAdmin(args[0], false)
+	},
+}
+
+var adminUserResetPasswordCmd = &cobra.Command{
+	Use:   "reset-password <username>",
+	Short: "Set a new password for a user",
+	Long: `Set a new password for a user who forgot theirs and end all their sessions. With --generate
+the registry generates a password and prints it, otherwise you are prompted for one.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		generate, _ := cmd.Flags().GetBool("generate")
+
+		var password string
+		if !generate {
+			var err error
+			if password, err = promptNewPassword(); err != nil {
+				return err
+			}
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		generated, err := c.ResetPassword(args[0], password)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Reset the password of %s", args[0])))
+		if generated != "" {
+			fmt.Println(generated)
+			fmt.Println(style.Warning("Share the password securely, it will not be shown again"))
+		}
+		return nil
+	},
+}
+
+var adminUserDisableCmd = &cobra.Command{
+	Use:   "disable <username>",
+	Short: "Disable a user and revoke their sessions and tokens",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setUserDisabled(args[0], true)
+	},
+}
+
+var adminUserEnableCmd = &cobra.Command{
+	Use:   "enable <username>",
+	Short: "Re-enable a disabled user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setUserDisabled(args[0], false)
+	},
+}
+
+var adminUserDeleteCmd = &cobra.Command{
+	Use:   "delete <username>",
+	Short: "Delete a user",
+	Long: `Delete a user with their sessions, tokens and package roles. Packages they own must be
+transferred or deleted first.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if err := c.DeleteUser(args[0]); err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Deleted user %s", args[0])))
+		return nil
+	},
+}
+
+var adminUserResetTwoFactorCmd = &cobra.Command{
+	Use:   "reset-2fa <username>",
+	Short: "Turn off two-factor authentication of a user",
+	Long: `Turn off two-factor authentication of a user who lost their authenticator app and recovery
+codes. Their sessions are ended, so they log in again with their password only.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if err := c.ResetTwoFactor(args[0]); err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Turned off two-factor authentication of %s", args[0])))
+		return nil
+	},
+}
+
+func setUserDisabled(username string, disabled bool) error {
+	c, err := client.New()
+	if err != nil {
+		return fmt.Errorf("failed to initialize client: %w", err)
+	}
+
+	if err := c.SetUserDisabled(username, disabled); err != nil {
+		return err
+	}
+
+	if disabled {
+		fmt.Println(style.Success(fmt.Sprintf("Disabled %s, their sessions and tokens were revoked", username)))
+	} else {
+		fmt.Println(style.Success(fmt.Sprintf("Enabled %s", username)))
+	}
+	return nil
+}
+
+func setAdmin(username string, admin bool) error {
+	c, err := client.New()
+	if err != nil {
+		return fmt.Errorf("failed to initialize client: %w", err)
+	}
+
+	if err := c.SetAdmin(username, admin); err != nil {
+		return err
+	}
+
+	if admin {
+		fmt.Println(style.Success(fmt.Sprintf("%s is now an administrator", username)))
+	} else {
+		fmt.Println(style.Success(fmt.Sprintf("%s is no longer an administrator", username)))
+	}
+	return nil
+}
+
+var adminInviteCmd = &cobra.Command{
+	Use:   "invite",
+	Short: "Manage invites to register",
+	Long: `Manage invites to register. When the registry runs with invite-only registration, people need
+an invite code to create an account. Each invite can be used once.`,
+}
+
+var adminInviteCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create an invite",
+	Example: `  protodex admin invite create
+  protodex admin invite create --email jane@example.com --expires 48h`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		email, _ := cmd.Flags().GetString("email")
+		expires, _ := cmd.Flags().GetString("expires")
+
+		req := client.CreateInviteRequest{Email: email}
+		if expires != "" {
+			ttl, err := parseTTL(expires)
+			if err != nil {
+				return err
+			}
+			expiresAt := time.Now().Add(ttl)
+			req.ExpiresAt = &expiresAt
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		created, err := c.CreateInvite(req)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Created invite %s, valid until %s", created.Invite.ID,
+			created.Invite.ExpiresAt.Format("2006-01-02 15:04"))))
+		fmt.Println(created.Code)
+		fmt.Println(style.Warning("Share the invite code securely, it will not be shown again"))
+		return nil
+	},
+}
+
+var adminInviteListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List invites that have not been used",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		invites, err := c.ListInvites()
+		if err != nil {
+			return err
+		}
+
+		if len(invites) == 0 {
+			fmt.Println(style.Subtle("No open invites"))
+			return nil
+		}
+		for _, invite := range invites {
+			details := []string{"created by " + invite.CreatedBy}
+			if invite.Email != "" {
+				details = append(details, "for "+invite.Email)
+			}
+			if invite.ExpiresAt.Before(time.Now()) {
+				details = append(details, "expired")
+			} else {
+				details = append(details, "expires "+invite.ExpiresAt.Format("2006-01-02"))
+			}
+			fmt.Printf("%s %s\n", style.Bold(invite.ID), style.Subtle("("+strings.Join(details, ", ")+")"))
+		}
+		return nil
+	},
+}
+
+var adminInviteRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Revoke an invite",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if err := c.RevokeInvite(args[0]); err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Revoked invite %s", args[0])))
+		return nil
+	},
+}
+
+func init() {
+	adminUserCreateCmd.Flags().Bool("generate", false, "Generate a random password instead of prompting for one")
+	adminUserCreateCmd.Flags().String("email", "", "Email address of the user")
+	adminUserCreateCmd.Flags().Bool("admin", false, "Make the user an administrator")
+	adminUserResetPasswordCmd.Flags().Bool("generate", false, "Generate a random password instead of prompting for one")
+	adminInviteCreateCmd.Flags().String("email", "", "Only allow this email address to use the invite")
+	adminInviteCreateCmd.Flags().String("expires", "", "Expire the invite after a duration such as 48h or 30d (default 7d)")
+
+	adminUserCmd.AddCommand(adminUserListCmd)
+	adminUserCmd.AddCommand(adminUserCreateCmd)
+	adminUserCmd.AddCommand(adminUserPromoteCmd)
+	adminUserCmd.AddCommand(adminUserDemoteCmd)
+	adminUserCmd.AddCommand(adminUserResetPasswordCmd)
+	adminUserCmd.AddCommand(adminUserDisableCmd)
+	adminUserCmd.AddCommand(adminUserEnableCmd)
+	adminUserCmd.AddCommand(adminUserDeleteCmd)
+	adminUserCmd.AddCommand(adminUserResetTwoFactorCmd)
+	adminCmd.AddCommand(adminUserCmd)
+
+	adminInviteCmd.AddCommand(adminInviteCreateCmd)
+	adminInviteCmd.AddCommand(adminInviteListCmd)
+	adminInviteCmd.AddCommand(adminInviteRevokeCmd)
+	adminCmd.AddCommand(adminInviteCmd)
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+var adminAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the audit log",
+	Long: `Show the audit log of the registry, newest first. Every change is recorded with who made it,
+from which address and with which client.
+
+--action takes an action such as version.push, or a group of actions such as version.
+--since and --until take a time such as 2024-01-02 or 2024-01-02T15:04:05Z, or a duration such
+as 24h or 7d before now.
+
+Examples:
+  protodex admin audit
+  protodex admin audit --actor janedoe --since 7d
+  protodex admin audit --action version --target acme/payments@v1.2.0
+  protodex admin audit --action user.login_failed --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		if format != "text" && format != "json" {
+			return fmt.Errorf("unsupported format %q, expected text or json", format)
+		}
+
+		filter := client.AuditFilter{}
+		filter.Actor, _ = cmd.Flags().GetString("actor")
+		filter.Action, _ = cmd.Flags().GetString("action")
+		filter.TargetType, _ = cmd.Flags().GetString("target-type")
+		filter.Target, _ = cmd.Flags().GetString("target")
+		filter.Before, _ = cmd.Flags().GetInt64("before")
+		filter.Limit, _ = cmd.Flags().GetInt("limit")
+		for flag, value := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+			raw, _ := cmd.Flags().GetString(flag)
+			if raw == "" {
+				continue
+			}
+			parsed, err := parseAuditTime(raw)
+			if err != nil {
+				return fmt.Errorf("invalid --%s: %w", flag, err)
+			}
+			*value = parsed
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		entries, err := c.ListAuditEntries(filter)
+		if err != nil {
+			return err
+		}
+
+		if format == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(entries)
+		}
+
+		if len(entries) == 0 {
+			fmt.Println(style.Subtle("No audit entries"))
+			return nil
+		}
+		for _, entry := range entries {
+			actor := entry.Actor
+			if actor == "" {
+				actor = "anonymous"
+			}
+			fmt.Printf("%s %s %s %s\n", entry.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+				style.Bold(actor), entry.Action, entry.Target)
+
+			details := []string{fmt.Sprintf("#%d", entry.ID), "from " + entry.IP}
+			keys := make([]string, 0, len(entry.Details))
+			for key := range entry.Details {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				details = append(details, key+"="+entry.Details[key])
+			}
+			fmt.Println("    " + style.Subtle(strings.Join(details, ", ")))
+		}
+		if len(entries) == filter.Limit {
+			fmt.Println(style.Subtle(fmt.Sprintf("Older entries: --before %d", entries[len(entries)-1].ID)))
+		}
+		return nil
+	},
+}
+
+// parseAuditTime parses a point in time, or a duration before now such as 24h or 7d
+func parseAuditTime(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if parsed, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return parsed, nil
+		}
+	}
+	ago, err := parseTTL(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a time such as 2024-01-02 or a duration such as 7d", value)
+	}
+	return time.Now().Add(-ago), nil
+}
+
+func init() {
+	adminAuditCmd.Flags().String("actor", "", "Only show changes made by this user")
+	adminAuditCmd.Flags().String("action", "", "Only show this action or group of actions")
+	adminAuditCmd.Flags().String("target-type", "", "Only show changes to this kind of target: user, session, token, invite, org, package or version")
+	adminAuditCmd.Flags().String("target", "", "Only show changes to this target, such as a username, package or package@version")
+	adminAuditCmd.Flags().String("since", "", "Only show changes made at or after this time")
+	adminAuditCmd.Flags().String("until", "", "Only show changes made before this time")
+	adminAuditCmd.Flags().Int64("before", 0, "Only show entries older than the entry with this ID")
+	adminAuditCmd.Flags().Int("limit", 100, "Maximum number of entries to show")
+	adminAuditCmd.Flags().String("format", "text", "Output format: text or json")
+
+	adminCmd.AddCommand(adminAuditCmd)
+}
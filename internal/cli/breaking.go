@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/sirrobot01/protodex/internal/breaking"
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/manager"
+	"github.com/sirrobot01/protodex/internal/manager/fetcher"
+)
+
+type breakingReport struct {
+	Against    string               `json:"against"`
+	Mode       breaking.Mode        `json:"mode"`
+	Breaking   bool                 `json:"breaking"`
+	Violations []breaking.Violation `json:"violations"`
+}
+
+var breakingCmd = &cobra.Command{
+	Use:   "breaking [dir] --against [source]",
+	Short: "Check the local schema for breaking changes against a baseline",
+	Long: `Compile the local project and compare it with a baseline schema, reporting removed or
+incompatibly changed messages, fields, enums, services and RPCs.
+
+The baseline can be any source understood by protodex:
+  protodex:       protodex://package@version (e.g., protodex://user-service@v1.2.0)
+  github:         github://user/repo@[ref] (e.g., github://org/repo@main)
+  http/https:     Direct HTTP(S) URL to schema archive
+  local:          Local directory (e.g., ../previous-schemas)
+
+Changes are checked with a compatibility mode, like the registry does on push. It is the --mode
+flag, or the compatibility policy of the package for protodex baselines, or BACKWARD otherwise.
+
+The command exits with a non-zero status when breaking changes are found, which makes it
+suitable for running in CI before protodex push.
+
+Examples:
+  protodex breaking --against protodex://user-service@v1.2.0
+  protodex breaking ./dir --against github://org/repo@main --mode FORWARD
+  protodex breaking --against ../baseline --format json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		against, _ := cmd.Flags().GetString("against")
+		format, _ := cmd.Flags().GetString("format")
+		if format != "text" && format != "json" {
+			return fmt.Errorf("unsupported format %q, expected text or json", format)
+		}
+
+		stdout := cmd.OutOrStdout()
+		progress := stdout
+		if format == "json" {
+			// Keep stdout clean for the report, progress output from fetchers and protoc goes to stderr
+			progress = cmd.ErrOrStderr()
+		}
+
+		modeFlag, _ := cmd.Flags().GetString("mode")
+		mode, err := breakingMode(modeFlag, against)
+		if err != nil {
+			return err
+		}
+
+		projectDir, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve project directory: %w", err)
+		}
+		if _, err := os.Stat(projectDir); os.IsNotExist(err) {
+			return fmt.Errorf("project directory does not exist: %s", projectDir)
+		}
+
+		pm, err := manager.NewManager(projectDir)
+		if err != nil {
+			return fmt.Errorf("failed to initialize project manager: %w", err)
+		}
+		pm.SetOutput(progress)
+		current, err := pm.DescriptorSet()
+		if err != nil {
+			return fmt.Errorf("failed to compile local schema: %w", err)
+		}
+
+		baseline, err := compileSource(against, progress)
+		if err != nil {
+			return fmt.Errorf("failed to compile baseline %s: %w", against, err)
+		}
+
+		violations := breaking.Check(mode, current, []breaking.Revision{{Version: against, Set: baseline}})
+		if len(violations) > 0 {
+			cmd.SilenceUsage = true
+		}
+		return reportBreaking(stdout, format, against, mode, violations)
+	},
+}
+
+// reportBreaking writes the breaking changes found against a baseline in format. It returns an
+// error when there are any, so the command exits with a non-zero status.
+func reportBreaking(w io.Writer, format, against string, mode breaking.Mode, violations []breaking.Violation) error {
+	if format == "json" {
+		report := breakingReport{
+			Against:    against,
+			Mode:       mode,
+			Breaking:   len(violations) > 0,
+			Violations: violations,
+		}
+		if report.Violations == nil {
+			report.Violations = []breaking.Violation{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+	} else if len(violations) == 0 {
+		fmt.Fprintf(w, "%s\n", style.Success(fmt.Sprintf("No %s breaking changes against %s", mode, against)))
+	} else {
+		fmt.Fprintf(w, "%s\n", style.Error(fmt.Sprintf("Found %d %s breaking change(s) against %s", len(violations), mode, against)))
+		for _, v := range violations {
+			printViolation(w, string(v.Rule), v.Message, v.File)
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("found %d breaking change(s) against %s", len(violations), against)
+	}
+	return nil
+}
+
+// breakingMode returns the compatibility mode to check with: the mode flag when it is set, the
+// policy of the package for protodex baselines and the default mode otherwise
+func breakingMode(flag, against string) (breaking.Mode, error) {
+	if flag != "" {
+		mode, err := breaking.ParseMode(flag)
+		if err != nil {
+			return "", fmt.Errorf("invalid mode, expected one of %v", breaking.Modes())
+		}
+		return mode, nil
+	}
+
+	source, err := fetcher.ParseSource(against)
+	if err != nil || source.Type != fetcher.SourceProtodex {
+		return breaking.DefaultMode, nil
+	}
+	c, err := client.New()
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize client: %w", err)
+	}
+	policy, err := c.GetCompatibility(source.Source)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the compatibility policy of %s: %w", source.Source, err)
+	}
+	return breaking.ParseMode(policy.Compatibility)
+}
+
+// compileSource fetches a schema source into a temporary directory and compiles it, progress
+// messages go to progress
+func compileSource(source string, progress io.Writer) (*descriptorpb.FileDescriptorSet, error) {
+	fch, err := fetcher.NewFetcherFromURL(source, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize fetcher: %w", err)
+	}
+	fch.Output = progress
+
+	// Local sources are compiled in place
+	if fch.SourceType != fetcher.SourceLocal {
+		tempDir, err := os.MkdirTemp("", "protodex-baseline-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		defer func(path string) {
+			err := os.RemoveAll(path)
+			if err != nil {
+				fmt.Fprintf(progress, "Warning: failed to remove temp directory %s: %v\n", path, err)
+			}
+		}(tempDir)
+		fch.Dest = tempDir
+	}
+
+	if err := fch.Fetch(); err != nil {
+		return nil, fmt.Errorf("failed to fetch source: %w", err)
+	}
+
+	pm, err := manager.NewManager(fch.Dest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize project manager: %w", err)
+	}
+	pm.SetOutput(progress)
+	return pm.DescriptorSet()
+}
+
+// printViolation prints a single breaking change reported by the registry or a local comparison
+func printViolation(w io.Writer, rule, message, file string) {
+	fmt.Fprintf(w, "  %s %s\n", style.Error(rule), message)
+	if file != "" {
+		fmt.Fprintf(w, "    %s\n", style.Subtle(file))
+	}
+}
+
+func init() {
+	breakingCmd.Flags().String("against", "", "Baseline source to compare against (required)")
+	breakingCmd.Flags().String("format", "text", "Output format (text, json)")
+	breakingCmd.Flags().String("mode", "", "Compatibility mode to check with, the policy of the package for protodex baselines by default")
+	_ = breakingCmd.MarkFlagRequired("against")
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sirrobot01/protodex/internal/breaking"
+	"github.com/sirrobot01/protodex/internal/config"
+)
+
+func TestReportBreaking(t *testing.T) {
+	removed := []breaking.Violation{{
+		Rule:    breaking.RuleFieldRemoved,
+		File:    "proto/user.proto",
+		Subject: "user.User.email",
+		Message: `field "email" (2) was removed from message "user.User"`,
+	}}
+
+	tests := []struct {
+		name        string
+		format      string
+		violations  []breaking.Violation
+		expectError bool
+		contains    []string
+	}{
+		{
+			name:     "Text without breaking changes",
+			format:   "text",
+			contains: []string{"No BACKWARD breaking changes against ../baseline"},
+		},
+		{
+			name:        "Text with breaking changes",
+			format:      "text",
+			violations:  removed,
+			expectError: true,
+			contains:    []string{"Found 1 BACKWARD breaking change(s) against ../baseline", "FIELD_REMOVED", "proto/user.proto"},
+		},
+		{
+			name:   "JSON without breaking changes",
+			format: "json",
+		},
+		{
+			name:        "JSON with breaking changes",
+			format:      "json",
+			violations:  removed,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			err := reportBreaking(out, tt.format, "../baseline", breaking.ModeBackward, tt.violations)
+			if tt.expectError {
+				assert.EqualError(t, err, "found 1 breaking change(s) against ../baseline")
+			} else {
+				assert.NoError(t, err)
+			}
+			for _, s := range tt.contains {
+				assert.Contains(t, out.String(), s)
+			}
+
+			if tt.format == "json" {
+				// The report is the only output, so it can be piped into other tools
+				var report breakingReport
+				require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+				assert.Equal(t, "../baseline", report.Against)
+				assert.Equal(t, breaking.ModeBackward, report.Mode)
+				assert.Equal(t, tt.expectError, report.Breaking)
+				assert.NotNil(t, report.Violations)
+				assert.Len(t, report.Violations, len(tt.violations))
+			}
+		})
+	}
+}
+
+func TestBreakingMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/packages/user-service/compatibility":
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"package": "user-service", "compatibility": "FULL_TRANSITIVE"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error": "package not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("PROTODEX_TOKEN", "test-token")
+	cfg := config.Get()
+	registry := cfg.Registry
+	cfg.Registry = server.URL
+	t.Cleanup(func() { cfg.Registry = registry })
+
+	tests := []struct {
+		name         string
+		flag         string
+		against      string
+		expectedMode breaking.Mode
+		expectError  bool
+	}{
+		{
+			name:         "Flag wins over the package policy",
+			flag:         "forward",
+			against:      "protodex://user-service@v1.2.0",
+			expectedMode: breaking.ModeForward,
+		},
+		{
+			name:        "Invalid flag",
+			flag:        "sideways",
+			against:     "../baseline",
+			expectError: true,
+		},
+		{
+			name:         "Policy of the package for protodex baselines",
+			against:      "protodex://user-service@v1.2.0",
+			expectedMode: breaking.ModeFullTransitive,
+		},
+		{
+			name:        "Unknown protodex package",
+			against:     "protodex://billing-service@v1.0.0",
+			expectError: true,
+		},
+		{
+			name:         "Default mode for other baselines",
+			against:      "../baseline",
+			expectedMode: breaking.DefaultMode,
+		},
+		{
+			name:         "Default mode for GitHub baselines",
+			against:      "github://org/repo@main",
+			expectedMode: breaking.DefaultMode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, err := breakingMode(tt.flag, tt.against)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedMode, mode)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/breaking"
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+var compatibilityCmd = &cobra.Command{
+	Use:     "compatibility",
+	Aliases: []string{"compat"},
+	Short:   "Manage package compatibility policies",
+	Long: `Manage the compatibility mode the registry enforces when new versions of a package are pushed.
+
+Modes:
+- NONE: no checks
+- BACKWARD: new version can read data written with the latest version (default)
+- BACKWARD_TRANSITIVE: new version can read data written with any previous version
+- FORWARD: latest version can read data written with the new version
+- FORWARD_TRANSITIVE: every previous version can read data written with the new version
+- FULL: both BACKWARD and FORWARD
+- FULL_TRANSITIVE: both BACKWARD_TRANSITIVE and FORWARD_TRANSITIVE`,
+}
+
+var compatibilityGetCmd = &cobra.Command{
+	Use:   "get <package>",
+	Short: "Show the compatibility mode of a package",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		policy, err := c.GetCompatibility(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s: %s\n", style.Bold(policy.Package), policy.Compatibility)
+		return nil
+	},
+}
+
+var compatibilitySetCmd = &cobra.Command{
+	Use:   "set <package> <mode>",
+	Short: "Set the compatibility mode of a package",
+	Example: `  protodex compatibility set user-service FULL
+  protodex compat set user-service backward_transitive`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mode, err := breaking.ParseMode(args[1])
+		if err != nil {
+			modes := make([]string, 0, len(breaking.Modes()))
+			for _, m := range breaking.Modes() {
+				modes = append(modes, string(m))
+			}
+			return fmt.Errorf("%w (expected one of %s)", err, strings.Join(modes, ", "))
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		policy, err := c.SetCompatibility(args[0], string(mode))
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Compatibility of %s set to %s", policy.Package, policy.Compatibility)))
+		return nil
+	},
+}
+
+func init() {
+	compatibilityCmd.AddCommand(compatibilityGetCmd)
+	compatibilityCmd.AddCommand(compatibilitySetCmd)
+}
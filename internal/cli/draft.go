@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+var draftCmd = &cobra.Command{
+	Use:   "draft",
+	Short: "Review draft versions",
+	Long: `Review the drafts of a package. Drafts are versions pushed with --draft, or to a package that
+requires approvals. They can be viewed but are not resolved for consumers until package
+maintainers approve them, which publishes them.
+
+A draft needs one approval, or the number of required approvals of its package. On packages that
+require approvals, authors cannot approve their own drafts. A rejected draft is published only
+by pushing it again, which restarts the review.`,
+}
+
+var draftListCmd = &cobra.Command{
+	Use:   "list <package>",
+	Short: "List the drafts of a package",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		drafts, err := c.ListDrafts(args[0])
+		if err != nil {
+			return err
+		}
+
+		if len(drafts) == 0 {
+			fmt.Println(style.Subtle("No drafts for " + args[0]))
+			return nil
+		}
+		for _, draft := range drafts {
+			fmt.Printf("%s %s %s\n", style.Bold(draft.Version.Version), draft.Status,
+				style.Subtle(fmt.Sprintf("(%d/%d approvals, pushed %s)", draft.Approvals, draft.RequiredApprovals, draft.CreatedAt.Local().Format("2006-01-02 15:04"))))
+		}
+		return nil
+	},
+}
+
+var draftShowCmd = &cobra.Command{
+	Use:     "show <package:version>",
+	Short:   "Show a draft and its reviews",
+	Example: `  protodex draft show user-service:v1.3.0`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pkg, version, err := client.ParsePackageRef(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid package reference: %w", err)
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		draft, err := c.GetDraft(pkg, version)
+		if err != nil {
+			return err
+		}
+
+		printDraft(pkg, draft)
+		return nil
+	},
+}
+
+var draftDiffCmd = &cobra.Command{
+	Use:   "diff <package:version>",
+	Short: "Compare a draft with the latest release of its package",
+	Long: `Show the file changes of a draft against the latest release of its package as a unified
+diff, followed by the breaking changes it introduces and the version bump they require.`,
+	Example: `  protodex draft diff user-service:v1.3.0`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pkg, version, err := client.ParsePackageRef(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid package reference: %w", err)
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		diff, err := c.DiffDraft(pkg, version)
+		if err != nil {
+			return err
+		}
+
+		if diff.BaseVersion == "" {
+			fmt.Println(style.Subtle(fmt.Sprintf("%s has no release yet, showing every file of the draft", pkg)))
+		} else {
+			fmt.Println(style.Subtle(fmt.Sprintf("Comparing %s with %s", style.Version(pkg, version), style.Version(pkg, diff.BaseVersion))))
+		}
+		if len(diff.Files) == 0 {
+			fmt.Println(style.Subtle("No file changes"))
+		}
+		for _, file := range diff.Files {
+			fmt.Printf("\n%s %s\n", style.Bold(file.Path), style.Subtle("("+file.Change+")"))
+			fmt.Print(file.Diff)
+		}
+
+		if diff.CompileError != "" {
+			fmt.Printf("\n%s\n", style.Warning("Breaking changes could not be checked: "+diff.CompileError))
+			return nil
+		}
+		if diff.BaseVersion == "" {
+			return nil
+		}
+		fmt.Println()
+		if len(diff.Violations) == 0 {
+			fmt.Println(style.Success("No breaking changes"))
+		} else {
+			fmt.Printf("%s\n", style.Error(fmt.Sprintf("%d breaking change(s)", len(diff.Violations))))
+			for _, v := range diff.Violations {
+				printViolation(os.Stdout, v.Rule, v.Message, v.File)
+			}
+		}
+		fmt.Printf("%s %s\n", style.Subtle("Required bump:"), style.Bold(diff.RequiredBump))
+		return nil
+	},
+}
+
+var draftApproveCmd = &cobra.Command{
+	Use:   "approve <package:version>",
+	Short: "Approve a draft, publishing it once it has enough approvals",
+	Example: `  protodex draft approve user-service:v1.3.0
+  protodex draft approve user-service:v1.3.0 --comment "LGTM"
+  protodex draft approve user-service:v2.0.0 --allow-breaking`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return reviewDraft(cmd, args[0], true)
+	},
+}
+
+var draftRejectCmd = &cobra.Command{
+	Use:     "reject <package:version>",
+	Short:   "Reject a draft",
+	Example: `  protodex draft reject user-service:v1.3.0 --comment "removes a field still in use"`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return reviewDraft(cmd, args[0], false)
+	},
+}
+
+func reviewDraft(cmd *cobra.Command, ref string, approve bool) error {
+	pkg, version, err := client.ParsePackageRef(ref)
+	if err != nil {
+		return fmt.Errorf("invalid package reference: %w", err)
+	}
+	comment, _ := cmd.Flags().GetString("comment")
+
+	c, err := client.New()
+	if err != nil {
+		return fmt.Errorf("failed to initialize client: %w", err)
+	}
+
+	if !approve {
+		if _, err := c.RejectDraft(pkg, version, comment); err != nil {
+			return err
+		}
+		fmt.Println(style.Success(fmt.Sprintf("Rejected %s", style.Version(pkg, version))))
+		return nil
+	}
+
+	allowBreaking, _ := cmd.Flags().GetBool("allow-breaking")
+	draft, err := c.ApproveDraft(pkg, version, comment, allowBreaking)
+	if err != nil {
+		var breakingErr *client.BreakingChangeError
+		if errors.As(err, &breakingErr) {
+			for _, v := range breakingErr.Violations {
+				printViolation(os.Stdout, v.Rule, v.Message, v.File)
+			}
+			fmt.Printf("%s\n", style.Subtle("The approval was recorded but the draft was not published, use --allow-breaking to publish it anyway"))
+		}
+		return err
+	}
+	if draft.Status == "published" {
+		fmt.Println(style.Success(fmt.Sprintf("Approved and published %s", style.Version(pkg, version))))
+		return nil
+	}
+	fmt.Println(style.Success(fmt.Sprintf("Approved %s", style.Version(pkg, version))))
+	fmt.Println(style.Subtle(fmt.Sprintf("%d of %d approvals, it is published once it has enough", draft.Approvals, draft.RequiredApprovals)))
+	return nil
+}
+
+func printDraft(pkg string, draft *client.Draft) {
+	fmt.Printf("%s\n", style.Bold(style.Version(pkg, draft.Version.Version)))
+	fmt.Printf("  %s %s\n", style.Subtle("Status:"), draft.Status)
+	fmt.Printf("  %s %d/%d\n", style.Subtle("Approvals:"), draft.Approvals, draft.RequiredApprovals)
+	fmt.Printf("  %s %s\n", style.Subtle("Pushed at:"), draft.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("  %s %s\n", style.Subtle("Checksum:"), draft.Checksum)
+	if len(draft.Reviews) == 0 {
+		fmt.Printf("  %s\n", style.Subtle("No reviews yet"))
+		return
+	}
+	fmt.Printf("  %s\n", style.Subtle("Reviews:"))
+	for _, review := range draft.Reviews {
+		line := fmt.Sprintf("    %s %s", style.Bold(review.Username), review.Decision)
+		if review.Comment != "" {
+			line += ": " + review.Comment
+		}
+		fmt.Println(line)
+	}
+}
+
+func init() {
+	draftApproveCmd.Flags().String("comment", "", "Comment recorded with the approval")
+	draftApproveCmd.Flags().Bool("allow-breaking", false, "Publish the draft even if it breaks compatibility with the previous version")
+	draftRejectCmd.Flags().String("comment", "", "Reason for rejecting the draft")
+
+	draftCmd.AddCommand(draftListCmd)
+	draftCmd.AddCommand(draftShowCmd)
+	draftCmd.AddCommand(draftDiffCmd)
+	draftCmd.AddCommand(draftApproveCmd)
+	draftCmd.AddCommand(draftRejectCmd)
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+var labelCmd = &cobra.Command{
+	Use:   "label",
+	Short: "Manage labels pointing at package versions",
+	Long: `Manage labels, mutable names such as stable, beta or prod that point at a published version of
+a package. Consumers use a label wherever a version is accepted, for example
+protodex://user-service@prod in protodex.yaml, and get the version the label points at when they
+pull.
+
+Maintainers move labels, every move is kept in the history of the label. Label names are
+lowercase and cannot look like versions, latest and latest-stable are reserved.`,
+}
+
+var labelListCmd = &cobra.Command{
+	Use:   "list <package>",
+	Short: "List the labels of a package",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		labels, err := c.ListLabels(args[0])
+		if err != nil {
+			return err
+		}
+
+		if len(labels) == 0 {
+			fmt.Println(style.Subtle("No labels for " + args[0]))
+			return nil
+		}
+		for _, label := range labels {
+			fmt.Printf("%s -> %s %s\n", style.Bold(label.Name), label.Version,
+				style.Subtle(fmt.Sprintf("(moved by %s on %s)", label.UpdatedBy, label.UpdatedAt.Local().Format("2006-01-02 15:04"))))
+		}
+		return nil
+	},
+}
+
+var labelSetCmd = &cobra.Command{
+	Use:   "set <package> <label> <version>",
+	Short: "Point a label at a version, creating it if needed",
+	Long: `Point a label at a version. The version can be any version query, including another label,
+so promoting a release is a matter of moving one label to another.`,
+	Example: `  protodex label set user-service beta v1.4.0-rc.1
+  protodex label set user-service stable latest-stable
+  protodex label set user-service prod stable`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		label, err := c.SetLabel(args[0], args[1], args[2])
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("%s now points at %s", style.Version(args[0], label.Name), style.Version(args[0], label.Version))))
+		return nil
+	},
+}
+
+var labelRemoveCmd = &cobra.Command{
+	Use:   "remove <package> <label>",
+	Short: "Remove a label, its history is kept",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if err := c.DeleteLabel(args[0], args[1]); err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Removed label %s", style.Version(args[0], args[1]))))
+		return nil
+	},
+}
+
+var labelHistoryCmd = &cobra.Command{
+	Use:     "history <package> <label>",
+	Short:   "Show every move of a label, newest first",
+	Example: `  protodex label history user-service prod`,
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		moves, err := c.LabelHistory(args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		for _, move := range moves {
+			var change string
+			switch {
+			case move.From == "":
+				change = "set to " + move.To
+			case move.To == "":
+				change = "removed from " + move.From
+			default:
+				change = fmt.Sprintf("moved from %s to %s", move.From, move.To)
+			}
+			fmt.Printf("%s %s %s\n", move.CreatedAt.Local().Format("2006-01-02 15:04:05"), style.Bold(move.MovedBy), change)
+		}
+		return nil
+	},
+}
+
+func init() {
+	labelCmd.AddCommand(labelListCmd)
+	labelCmd.AddCommand(labelSetCmd)
+	labelCmd.AddCommand(labelRemoveCmd)
+	labelCmd.AddCommand(labelHistoryCmd)
+}
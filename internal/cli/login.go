@@ -1,8 +1,10 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
@@ -15,6 +17,7 @@ import (
 func init() {
 	loginCmd.Flags().StringP("username", "u", "", "Username (required)")
 	loginCmd.Flags().StringP("password", "p", "", "Password (will prompt if not provided)")
+	loginCmd.Flags().Bool("sso", false, "Log in through the registry's single sign-on provider in a browser")
 }
 
 var loginCmd = &cobra.Command{
@@ -24,13 +27,24 @@ var loginCmd = &cobra.Command{
 
 This command will create a session token and save it to your configuration file.
 
+With --sso, you log in at the registry's identity provider in a browser, which can be on
+another device, while protodex waits for the login to finish.
+
+When you have two-factor authentication enabled, you are asked for a code from your
+authenticator app or one of your recovery codes.
+
 Examples:
   protodex login --username johndoe
-  protodex login --username johndoe --password mypass`,
+  protodex login --username johndoe --password mypass
+  protodex login --sso`,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		username, _ := cmd.Flags().GetString("username")
 		password, _ := cmd.Flags().GetString("password")
 
+		if sso, _ := cmd.Flags().GetBool("sso"); sso {
+			return loginSSO()
+		}
+
 		// If username not provided, show a prompt
 		if username == "" {
 			fmt.Print("Username: ")
@@ -65,16 +79,72 @@ Examples:
 			return fmt.Errorf("login failed: %w", err)
 		}
 
-		// Saving to config
-		cfg := config.Get()
-		cfg.HashedToken = response.Token
-		if err := cfg.Save(); err != nil {
-			return fmt.Errorf("failed to save configuration: %w", err)
+		return saveLogin(c, response)
+	},
+}
+
+// loginSSO logs in with the device authorization flow: the user approves the login in a browser
+// while the CLI polls the registry until they are done
+func loginSSO() error {
+	c, err := client.New()
+	if err != nil {
+		return fmt.Errorf("failed to initialize client: %w", err)
+	}
+
+	login, err := c.StartSSOLogin()
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+
+	if login.VerificationURIComplete != "" {
+		fmt.Printf("Open %s to log in\n", login.VerificationURIComplete)
+		fmt.Printf("and check that it shows the code %s\n", login.UserCode)
+	} else {
+		fmt.Printf("Open %s and enter the code %s\n", login.VerificationURI, login.UserCode)
+	}
+	fmt.Println("Waiting for the login to finish...")
+
+	interval := time.Duration(max(login.Interval, 1)) * time.Second
+	deadline := time.Now().Add(time.Duration(login.ExpiresIn) * time.Second)
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		response, err := c.PollSSOLogin(login.DeviceCode)
+		switch {
+		case errors.Is(err, client.ErrLoginPending):
+			continue
+		case errors.Is(err, client.ErrSlowDown):
+			interval += 5 * time.Second
+			continue
+		case err != nil:
+			return err
+		}
+		return saveLogin(c, response)
+	}
+	return fmt.Errorf("login timed out, run protodex login --sso again")
+}
+
+// saveLogin saves the session token of a login, after asking for the two-factor code when the
+// user has two-factor authentication enabled
+func saveLogin(c client.Client, response *client.LoginResponse) error {
+	if response.TwoFactorChallenge != "" {
+		code, err := promptTwoFactorCode()
+		if err != nil {
+			return err
 		}
+		if response, err = c.CompleteTwoFactorLogin(response.TwoFactorChallenge, code); err != nil {
+			return err
+		}
+	}
 
-		fmt.Printf("Successfully authenticated as %s\n", response.User.Username)
-		fmt.Printf("Session token saved to configuration\n")
+	cfg := config.Get()
+	cfg.HashedToken = response.Token
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
 
-		return nil
-	},
+	fmt.Printf("Successfully authenticated as %s\n", response.User.Username)
+	fmt.Printf("Session token saved to configuration\n")
+
+	return nil
 }
@@ -1,17 +1,19 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
 
+	"github.com/sirrobot01/protodex/internal/cli/style"
 	"github.com/sirrobot01/protodex/internal/client"
 )
 
 var logoutCmd = &cobra.Command{
 	Use:   "logout",
 	Short: "Logout and clear authentication token",
-	Long:  `Logout and clear the stored authentication token from configuration.`,
+	Long:  `End the current session on the registry and clear the stored authentication token from configuration.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		c, err := client.New()
 		if err != nil {
@@ -19,7 +21,10 @@ var logoutCmd = &cobra.Command{
 		}
 
 		err = c.Logout()
-		if err != nil {
+		if errors.Is(err, client.ErrSessionNotEnded) {
+			fmt.Println(style.Warning(fmt.Sprintf("Warning: %v", err)))
+			fmt.Println(style.Subtle("Log in again and run protodex session revoke --others to end it"))
+		} else if err != nil {
 			return fmt.Errorf("logout failed: %w", err)
 		}
 
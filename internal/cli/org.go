@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+var orgCmd = &cobra.Command{
+	Use:   "org",
+	Short: "Manage organizations",
+	Long: `Manage organizations. Packages named organization/name (e.g., acme/payments) belong to the
+organization.
+
+Roles:
+- owner: manages members and owns every package of the organization, the creator of an
+  organization is its first owner
+- maintainer: creates packages in the organization and pushes versions to them
+- member: reads the packages of the organization`,
+}
+
+var orgCreateCmd = &cobra.Command{
+	Use:     "create <name>",
+	Short:   "Create an organization",
+	Example: `  protodex org create acme`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		org, err := c.CreateOrganization(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Created organization %s", org.Name)))
+		fmt.Printf("%s %s\n", style.Subtle("Publish packages as"), style.Bold(org.Name+"/<package>"))
+		return nil
+	},
+}
+
+var orgListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the organizations you are a member of",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		orgs, err := c.ListOrganizations()
+		if err != nil {
+			return err
+		}
+
+		if len(orgs) == 0 {
+			fmt.Println(style.Subtle("You are not a member of any organization"))
+			return nil
+		}
+		for _, org := range orgs {
+			fmt.Println(style.Bold(org.Name))
+		}
+		return nil
+	},
+}
+
+var orgMembersCmd = &cobra.Command{
+	Use:   "members <org>",
+	Short: "List the members of an organization",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		org, err := c.GetOrganization(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s\n", style.Bold(org.Name))
+		// Organizations always have an owner, the registry leaves the members out for outsiders
+		if len(org.Members) == 0 {
+			fmt.Println(style.Subtle("  Only members of the organization can see its members"))
+			return nil
+		}
+		for _, member := range org.Members {
+			fmt.Printf("  %s %s\n", member.Username, style.Subtle("("+member.Role+")"))
+		}
+		return nil
+	},
+}
+
+var orgAddMemberCmd = &cobra.Command{
+	Use:   "add-member <org> <username>",
+	Short: "Add a user to an organization or change their role",
+	Example: `  protodex org add-member acme janedoe
+  protodex org add-member acme janedoe --role maintainer`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		role, _ := cmd.Flags().GetString("role")
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if err := c.SetMember(args[0], args[1], role); err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("%s is now %s of %s", args[1], role, args[0])))
+		return nil
+	},
+}
+
+var orgRemoveMemberCmd = &cobra.Command{
+	Use:   "remove-member <org> <username>",
+	Short: "Remove a user from an organization",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if err := c.RemoveMember(args[0], args[1]); err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Removed %s from %s", args[1], args[0])))
+		return nil
+	},
+}
+
+func init() {
+	orgAddMemberCmd.Flags().String("role", "member", "Role in the organization (owner, maintainer, member)")
+
+	orgCmd.AddCommand(orgCreateCmd)
+	orgCmd.AddCommand(orgListCmd)
+	orgCmd.AddCommand(orgMembersCmd)
+	orgCmd.AddCommand(orgAddMemberCmd)
+	orgCmd.AddCommand(orgRemoveMemberCmd)
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+var packageCmd = &cobra.Command{
+	Use:     "package",
+	Aliases: []string{"pkg"},
+	Short:   "Delete, rename or transfer registry packages",
+	Long:    `Manage packages in the registry. Only the package owner can delete, rename or transfer a package.`,
+}
+
+var packageDeleteCmd = &cobra.Command{
+	Use:   "delete <package>",
+	Short: "Delete a package with all its versions",
+	Example: `  protodex package delete user-service
+  protodex package delete user-service --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if yes, _ := cmd.Flags().GetBool("yes"); !yes && !confirm(fmt.Sprintf("Permanently delete %s and all its versions?", name)) {
+			return fmt.Errorf("aborted")
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if err := c.DeletePackage(name); err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Deleted package %s", name)))
+		return nil
+	},
+}
+
+var packageRenameCmd = &cobra.Command{
+	Use:   "rename <package> <new-name>",
+	Short: "Rename a package",
+	Long: `Rename a package. The old name is kept as an alias, so dependencies such as
+protodex://old-name@v1.0.0 keep resolving to the renamed package.`,
+	Example: `  protodex package rename user-service accounts`,
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		pkg, err := c.RenamePackage(args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Renamed %s to %s", args[0], pkg.Name)))
+		fmt.Println(style.Subtle("Update package.name in protodex.yaml before pushing new versions"))
+		return nil
+	},
+}
+
+var packageTransferCmd = &cobra.Command{
+	Use:     "transfer <package> <username>",
+	Short:   "Transfer ownership of a package to another user",
+	Example: `  protodex package transfer user-service janedoe`,
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		pkg, err := c.TransferPackage(args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Transferred %s to %s", pkg.Name, args[1])))
+		return nil
+	},
+}
+
+func init() {
+	packageDeleteCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt")
+
+	packageCmd.AddCommand(packageDeleteCmd)
+	packageCmd.AddCommand(packageRenameCmd)
+	packageCmd.AddCommand(packageTransferCmd)
+}
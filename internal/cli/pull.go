@@ -16,11 +16,17 @@ var pullCmd = &cobra.Command{
 	Short: "Pull protobuf schema(s) from the registry",
 	Long: `Pull protobuf schema(s) from the registry. If no output path is specified, saves to current directory.
 
+The version can be an exact version, latest, latest-stable (excluding pre-releases) or a
+semantic version range such as ^1.2, ~1.4.0 or ">=2.0 <3". Ranges select the highest match.
+
 When run within a protodex project, this command can also add the package as a dependency to your protodex.yaml.
 
 Examples:
   protodex pull user-service:v1.0.0
   protodex pull user-service:latest ./schemas
+  protodex pull user-service:latest-stable
+  protodex pull "user-service:^1.2"
+  protodex pull "user-service:>=2.0 <3"
 `,
 	Args: cobra.RangeArgs(1, 2),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -59,14 +65,28 @@ Examples:
 		done := spinner.Start(fmt.Sprintf("Downloading %s", packageRef))
 
 		// Pull the package (downloads and extracts zip)
-		err = c.PullVersion(pkg, version, absPath)
+		result, err := c.PullVersion(pkg, version, absPath)
 		done <- true
 
 		if err != nil {
 			return fmt.Errorf("pull failed: %w", err)
 		}
 
-		fmt.Printf("\r%s\n", style.Success(fmt.Sprintf("Successfully pulled and extracted %s to %s", packageRef, absPath)))
+		fmt.Printf("\r%s\n", style.Success(fmt.Sprintf("Successfully pulled and extracted %s to %s", style.Version(pkg, result.Version), absPath)))
+		if result.Version != version {
+			fmt.Printf("%s\n", style.Subtle(fmt.Sprintf("Resolved %s to %s", version, result.Version)))
+		}
+		if result.Yanked {
+			fmt.Printf("%s\n", style.Warning(yankedWarning(pkg, result)))
+		}
 		return nil
 	},
 }
+
+func yankedWarning(pkg string, result *client.PullResult) string {
+	warning := fmt.Sprintf("%s %s has been yanked", pkg, result.Version)
+	if result.YankReason != "" {
+		warning += ": " + result.YankReason
+	}
+	return warning
+}
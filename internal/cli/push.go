@@ -3,6 +3,7 @@ package cli
 import (
 	"archive/zip"
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,30 +11,54 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/sirrobot01/protodex/internal/breaking"
 	"github.com/sirrobot01/protodex/internal/cli/style"
 	"github.com/sirrobot01/protodex/internal/client"
 	"github.com/sirrobot01/protodex/internal/manager"
+	"github.com/sirrobot01/protodex/internal/semver"
 )
 
+// initialVersion is used when pushing the first semantic version of a package
+const initialVersion = "v1.0.0"
+
 var pushCmd = &cobra.Command{
-	Use:   "push version",
+	Use:   "push [version] [dir]",
 	Short: "Push protobuf schema(s) to the registry",
 	Long: `Push one or more protobuf schemas to the registry with the specified package name and version.
 
 If no proto files are specified, the command will look for a protodex.yaml project config file
 and use the files specified there.
 
+With --draft, the version is stored for review and only published once package maintainers
+approve it. Packages that require approvals only accept drafts.
+
+If no version is given, the next version is computed from the latest semantic version in the
+registry and the schema changes since then: a major bump for breaking changes, a minor bump for
+additions and a patch bump otherwise.
+
 Examples:
   protodex push v1.0.0 # Push a single file to version v1.0.0
   protodex push v1.0.0 ./dir # Push all proto files in the specified directory to version v1.0.0
+  protodex push v2.0.0 --allow-breaking # Push a version that breaks compatibility with the previous one
+  protodex push v1.0.0 --private # Create the package as private on its first push
+  protodex push v1.1.0 --draft # Upload a draft for the package maintainers to review
+  protodex push # Push with an automatically computed version
+  protodex push ./dir # Push the specified directory with an automatically computed version
 `,
 	Args: cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// First argument is always the version
-		version := args[0]
+		version := ""
 		dir := "."
-		if len(args) == 2 {
-			dir = args[1]
+		switch len(args) {
+		case 2:
+			version, dir = args[0], args[1]
+		case 1:
+			// A single argument naming an existing directory is the project directory, not a version
+			if info, err := os.Stat(args[0]); err == nil && info.IsDir() && !semver.IsValid(args[0]) {
+				dir = args[0]
+			} else {
+				version = args[0]
+			}
 		}
 
 		// Resolve absolute path
@@ -102,24 +127,116 @@ Examples:
 			return fmt.Errorf("failed to initialize client: %w", err)
 		}
 
+		if version == "" {
+			version, err = nextVersion(c, pm, packageName)
+			if err != nil {
+				return fmt.Errorf("failed to compute next version: %w", err)
+			}
+		}
+
 		// Show spinner during push
 		spinner := style.NewSpinner()
 		done := spinner.Start(fmt.Sprintf("Pushing %s:%s", packageName, version))
 
-		pushedVersion, err := c.PushVersion(packageName, version, zipData)
+		allowBreaking, _ := cmd.Flags().GetBool("allow-breaking")
+		opts := client.PushOptions{AllowBreaking: allowBreaking}
+		if private, _ := cmd.Flags().GetBool("private"); private {
+			opts.Visibility = "private"
+		}
+		opts.Draft, _ = cmd.Flags().GetBool("draft")
+		pushedVersion, err := c.PushVersion(packageName, version, zipData, opts)
 		done <- true
 
 		if err != nil {
+			var breakingErr *client.BreakingChangeError
+			if errors.As(err, &breakingErr) {
+				for _, v := range breakingErr.Violations {
+					printViolation(os.Stdout, v.Rule, v.Message, v.File)
+				}
+				fmt.Printf("%s\n", style.Subtle("Use --allow-breaking to push anyway"))
+			}
+			var bumpErr *client.VersionBumpError
+			if errors.As(err, &bumpErr) {
+				fmt.Printf("%s %s\n", style.Subtle("Suggested version:"), style.Bold(bumpErr.SuggestedVersion))
+			}
+			var admissionErr *client.AdmissionError
+			if errors.As(err, &admissionErr) && admissionErr.Reason != "" {
+				fmt.Printf("%s %s\n", style.Subtle(admissionErr.Hook+":"), admissionErr.Reason)
+			}
+			if errors.Is(err, client.ErrVersionExists) {
+				fmt.Printf("%s\n", style.Subtle("Published versions are immutable, push a new version instead"))
+			}
+			if errors.Is(err, client.ErrForbidden) {
+				fmt.Printf("%s\n", style.Subtle("Ask a package owner to run: protodex access grant "+packageName+" <username> --role maintainer"))
+			}
 			return fmt.Errorf("failed to push to registry: %w", err)
 		}
 
-		fmt.Printf("\r%s\n", style.Success(fmt.Sprintf("Successfully pushed %s to registry", style.Version(packageName, pushedVersion.Version))))
+		if pushedVersion.Status == "draft" {
+			fmt.Printf("\r%s\n", style.Success(fmt.Sprintf("Successfully pushed %s as a draft", style.Version(packageName, pushedVersion.Version))))
+			fmt.Printf("%s\n", style.Subtle("It is published once approved: protodex draft approve "+packageName+":"+pushedVersion.Version))
+		} else {
+			fmt.Printf("\r%s\n", style.Success(fmt.Sprintf("Successfully pushed %s to registry", style.Version(packageName, pushedVersion.Version))))
+		}
+		for _, warning := range pushedVersion.Warnings {
+			fmt.Printf("%s\n", style.Warning(warning))
+		}
 		fmt.Printf("%s %s\n", style.Subtle("Version ID:"), style.Bold(pushedVersion.ID))
 		fmt.Printf("%s %s\n", style.Subtle("Created at:"), style.Bold(pushedVersion.CreatedAt.Format("2006-01-02 15:04:05")))
 		return nil
 	},
 }
 
+func init() {
+	pushCmd.Flags().Bool("allow-breaking", false, "Push even if the schema breaks compatibility with the previous version")
+	pushCmd.Flags().Bool("private", false, "Create the package as private if it does not exist yet")
+	pushCmd.Flags().Bool("draft", false, "Store the version as a draft that is published once maintainers approve it")
+}
+
+// nextVersion computes the version to push from the latest semantic version in the registry
+// and the bump required by the schema changes since that version
+func nextVersion(c client.Client, pm *manager.Manager, packageName string) (string, error) {
+	if _, err := c.GetPackage(packageName); err != nil {
+		fmt.Printf("%s\n", style.Info(fmt.Sprintf("New package, using initial version %s", initialVersion)))
+		return initialVersion, nil
+	}
+
+	versions, err := c.ListVersions(packageName)
+	if err != nil {
+		return "", err
+	}
+
+	// The registry lists semantic versions first, highest precedence first
+	var latest *semver.Version
+	for _, v := range versions {
+		if parsed, err := semver.Parse(v.Version); err == nil {
+			latest = &parsed
+			break
+		}
+	}
+	if latest == nil {
+		fmt.Printf("%s\n", style.Info(fmt.Sprintf("No semantic versions found, using initial version %s", initialVersion)))
+		return initialVersion, nil
+	}
+
+	current, err := pm.DescriptorSet()
+	if err != nil {
+		return "", fmt.Errorf("failed to compile local schema: %w", err)
+	}
+	baseline, err := compileSource(fmt.Sprintf("protodex://%s@%s", packageName, latest), os.Stdout)
+	if err != nil {
+		return "", fmt.Errorf("failed to compile %s: %w", latest, err)
+	}
+
+	bump := breaking.RequiredBump(baseline, current)
+	if bump == semver.BumpNone {
+		bump = semver.BumpPatch
+	}
+	next := latest.Next(bump).String()
+	fmt.Printf("%s\n", style.Info(fmt.Sprintf("Computed version %s (%s bump from %s)", next, bump, latest)))
+	return next, nil
+}
+
 // createProjectZip creates a zip archive containing all project files with proper directory structure
 func createProjectZip(filePaths []string, projectDir string) ([]byte, error) {
 	var buf bytes.Buffer
@@ -49,4 +49,21 @@ protoc version: ` + protocVersion + `
 	rootCmd.AddCommand(logoutCmd)
 	rootCmd.AddCommand(sourceCmd)
 	rootCmd.AddCommand(depsCmd)
+	rootCmd.AddCommand(breakingCmd)
+	rootCmd.AddCommand(compatibilityCmd)
+	rootCmd.AddCommand(versioningCmd)
+	rootCmd.AddCommand(yankCmd)
+	rootCmd.AddCommand(unyankCmd)
+	rootCmd.AddCommand(draftCmd)
+	rootCmd.AddCommand(labelCmd)
+	rootCmd.AddCommand(packageCmd)
+	rootCmd.AddCommand(orgCmd)
+	rootCmd.AddCommand(accessCmd)
+	rootCmd.AddCommand(webhookCmd)
+	rootCmd.AddCommand(watchRegistryCmd)
+	rootCmd.AddCommand(visibilityCmd)
+	rootCmd.AddCommand(tokenCmd)
+	rootCmd.AddCommand(sessionCmd)
+	rootCmd.AddCommand(accountCmd)
+	rootCmd.AddCommand(adminCmd)
 }
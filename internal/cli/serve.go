@@ -30,8 +30,51 @@ var serveCmd = &cobra.Command{
 			fmt.Printf("%s %s\n", style.Subtle("Using default data directory:"), style.Bold(dataDir))
 		}
 
+		cfg := config.Get().Server
+		if cmd.Flags().Changed("password-min-length") {
+			cfg.Auth.PasswordMinLength, _ = cmd.Flags().GetInt("password-min-length")
+		}
+		if cmd.Flags().Changed("breached-passwords") {
+			cfg.Auth.BreachedPasswordsFile, _ = cmd.Flags().GetString("breached-passwords")
+		}
+		if cmd.Flags().Changed("registration") {
+			cfg.Registration.Mode, _ = cmd.Flags().GetString("registration")
+		}
+		if cmd.Flags().Changed("auth-provider") {
+			cfg.Auth.Provider, _ = cmd.Flags().GetString("auth-provider")
+		}
+		if cmd.Flags().Changed("htpasswd-file") {
+			cfg.Auth.HtpasswdFile, _ = cmd.Flags().GetString("htpasswd-file")
+		}
+		if cmd.Flags().Changed("trusted-proxies") {
+			cfg.TrustedProxies, _ = cmd.Flags().GetStringSlice("trusted-proxies")
+		}
+		if cmd.Flags().Changed("require-2fa") {
+			cfg.Auth.RequireTwoFactor, _ = cmd.Flags().GetBool("require-2fa")
+		}
+		switch cfg.Registration.Mode {
+		case config.RegistrationOpen, config.RegistrationInvite, config.RegistrationClosed:
+		default:
+			return fmt.Errorf("invalid registration mode %q, use open, invite or closed", cfg.Registration.Mode)
+		}
+
+		// The administrator created on first run comes from the flag, the environment or the config file
+		if username := os.Getenv("PROTODEX_ADMIN_USERNAME"); username != "" {
+			cfg.AdminUsername = username
+		}
+		if cmd.Flags().Changed("admin-username") {
+			cfg.AdminUsername, _ = cmd.Flags().GetString("admin-username")
+		}
+		cfg.AdminPassword = os.Getenv("PROTODEX_ADMIN_PASSWORD")
+		if secret := os.Getenv("PROTODEX_SSO_CLIENT_SECRET"); secret != "" {
+			cfg.SSO.ClientSecret = secret
+		}
+		if secret := os.Getenv("PROTODEX_LDAP_BIND_PASSWORD"); secret != "" {
+			cfg.Auth.LDAP.BindPassword = secret
+		}
+
 		// Start API server
-		server := server.New(dataDir, port)
+		server := server.New(dataDir, port, cfg)
 
 		fmt.Printf("%s\n", style.Info(fmt.Sprintf("Starting protodex server on port %d", port)))
 		fmt.Printf("%s %s\n", style.Subtle("API:"), style.Bold(fmt.Sprintf("http://localhost:%d/api", port)))
@@ -44,4 +87,12 @@ var serveCmd = &cobra.Command{
 func init() {
 	serveCmd.Flags().IntP("port", "p", 8080, "Port to run the server on")
 	serveCmd.Flags().StringP("data-dir", "d", "", "Directory to store data")
+	serveCmd.Flags().Int("password-min-length", 0, "Minimum length of user passwords, overrides server.auth.password_min_length")
+	serveCmd.Flags().String("breached-passwords", "", "File of SHA-1 hashes of breached passwords to refuse, sorted by hash, one per line")
+	serveCmd.Flags().String("registration", "", "Who can create an account: open, invite or closed, overrides server.registration.mode")
+	serveCmd.Flags().String("auth-provider", "", "Where passwords are checked: local, htpasswd or ldap, overrides server.auth.provider")
+	serveCmd.Flags().String("htpasswd-file", "", "htpasswd file of the htpasswd auth provider, overrides server.auth.htpasswd_file")
+	serveCmd.Flags().Bool("require-2fa", false, "Only let users with two-factor authentication publish packages, overrides server.auth.require_two_factor")
+	serveCmd.Flags().StringSlice("trusted-proxies", nil, "Addresses or CIDR ranges of reverse proxies whose X-Forwarded-For header is trusted, overrides server.trusted_proxies")
+	serveCmd.Flags().String("admin-username", "", "Administrator to create on first run, with the password from PROTODEX_ADMIN_PASSWORD")
 }
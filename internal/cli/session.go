@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "List and revoke your login sessions",
+	Long: `List and revoke the login sessions of your account, for example after losing a laptop.
+
+Sessions expire after 30 days without use. Using a session extends it, so active users stay
+logged in.`,
+}
+
+var sessionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List your active sessions",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		sessions, err := c.ListSessions()
+		if err != nil {
+			return err
+		}
+
+		if len(sessions) == 0 {
+			fmt.Println(style.Subtle("No active sessions"))
+			return nil
+		}
+		for _, session := range sessions {
+			userAgent := session.UserAgent
+			if userAgent == "" {
+				userAgent = "unknown client"
+			}
+			details := []string{"created " + session.CreatedAt.Format("2006-01-02")}
+			if session.LastUsed != nil {
+				details = append(details, "last used "+session.LastUsed.Format("2006-01-02 15:04"))
+			}
+			line := fmt.Sprintf("%s %s %s", session.ID, style.Bold(userAgent), style.Subtle("("+strings.Join(details, ", ")+")"))
+			if session.Current {
+				line += " " + style.Success("current")
+			}
+			fmt.Println(line)
+		}
+		return nil
+	},
+}
+
+var sessionRevokeCmd = &cobra.Command{
+	Use:   "revoke [id]",
+	Short: "Revoke a session, or all sessions but the current one",
+	Example: `  protodex session revoke 3f2b9c4e-...
+  protodex session revoke --others`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		others, _ := cmd.Flags().GetBool("others")
+		if others == (len(args) == 1) {
+			return fmt.Errorf("specify either a session id or --others")
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if others {
+			revoked, err := c.RevokeOtherSessions()
+			if err != nil {
+				return err
+			}
+			fmt.Println(style.Success(fmt.Sprintf("Revoked %d other session(s)", revoked)))
+			return nil
+		}
+
+		if err := c.RevokeSession(args[0]); err != nil {
+			return err
+		}
+		fmt.Println(style.Success(fmt.Sprintf("Revoked session %s", args[0])))
+		return nil
+	},
+}
+
+func init() {
+	sessionRevokeCmd.Flags().Bool("others", false, "Revoke every session except the current one")
+
+	sessionCmd.AddCommand(sessionListCmd)
+	sessionCmd.AddCommand(sessionRevokeCmd)
+}
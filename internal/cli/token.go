@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage API tokens for CI and scripts",
+	Long: `Manage long-lived API tokens. Tokens authenticate like a login session but carry scopes,
+can be limited to a single package and can expire.
+
+Scopes:
+- read: pull packages
+- push: also create packages and push, yank and unyank versions
+- admin: also manage packages, organizations and tokens
+
+A token never has more permissions than the user it belongs to. Use --service-account to create
+a token for a service account instead of yourself, so CI does not depend on a human's account.
+Set PROTODEX_TOKEN to use a token without running protodex login.`,
+}
+
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create an API token",
+	Example: `  protodex token create laptop
+  protodex token create ci --scope push --package user-service --expires 90d
+  protodex token create release --service-account ci-bot --scope push`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scopes, _ := cmd.Flags().GetStringSlice("scope")
+		pkg, _ := cmd.Flags().GetString("package")
+		serviceAccount, _ := cmd.Flags().GetString("service-account")
+		expires, _ := cmd.Flags().GetString("expires")
+
+		req := client.CreateTokenRequest{
+			Name:           args[0],
+			Scopes:         scopes,
+			Package:        pkg,
+			ServiceAccount: serviceAccount,
+		}
+		if expires != "" {
+			ttl, err := parseTTL(expires)
+			if err != nil {
+				return err
+			}
+			expiresAt := time.Now().Add(ttl)
+			req.ExpiresAt = &expiresAt
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		created, err := c.CreateToken(req)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Created token %s for %s", created.APIToken.Name, created.APIToken.Username)))
+		fmt.Println(created.Token)
+		fmt.Println(style.Warning("Copy the token now, it will not be shown again"))
+		return nil
+	},
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the API tokens you created",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		tokens, err := c.ListTokens()
+		if err != nil {
+			return err
+		}
+
+		if len(tokens) == 0 {
+			fmt.Println(style.Subtle("No API tokens"))
+			return nil
+		}
+		for _, token := range tokens {
+			details := []string{token.Username, strings.Join(token.Scopes, ",")}
+			if token.Package != "" {
+				details = append(details, "package "+token.Package)
+			}
+			if token.ExpiresAt != nil {
+				details = append(details, "expires "+token.ExpiresAt.Format("2006-01-02"))
+			}
+			fmt.Printf("%s %s %s\n", style.Bold(token.Name), token.ID, style.Subtle("("+strings.Join(details, ", ")+")"))
+		}
+		return nil
+	},
+}
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "Revoke an API token",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if err := c.RevokeToken(args[0]); err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Revoked token %s", args[0])))
+		return nil
+	},
+}
+
+// parseTTL parses a duration that may also be given in days, such as 90d
+func parseTTL(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid expiry %q, use a number of days such as 90d or a duration such as 12h", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("invalid expiry %q, use a number of days such as 90d or a duration such as 12h", value)
+	}
+	return ttl, nil
+}
+
+func init() {
+	tokenCreateCmd.Flags().StringSlice("scope", []string{"read"}, "Scopes of the token (read, push, admin)")
+	tokenCreateCmd.Flags().String("package", "", "Restrict the token to a single package")
+	tokenCreateCmd.Flags().String("service-account", "", "Create the token for a service account, created if it does not exist")
+	tokenCreateCmd.Flags().String("expires", "", "Expire the token after a duration such as 90d or 12h")
+
+	tokenCmd.AddCommand(tokenCreateCmd)
+	tokenCmd.AddCommand(tokenListCmd)
+	tokenCmd.AddCommand(tokenRevokeCmd)
+}
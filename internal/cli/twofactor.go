@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+var accountTwoFactorCmd = &cobra.Command{
+	Use:   "2fa",
+	Short: "Manage two-factor authentication",
+	Long: `Manage two-factor authentication of your account. Once enabled, logging in asks for a code
+from an authenticator app, or one of your recovery codes when you do not have the app at hand.`,
+}
+
+var accountTwoFactorStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether two-factor authentication is enabled",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		status, err := c.GetTwoFactorStatus()
+		if err != nil {
+			return err
+		}
+
+		if status.Enabled {
+			fmt.Println(style.Success("Two-factor authentication is enabled"))
+			fmt.Printf("%d recovery codes left\n", status.RecoveryCodesLeft)
+		} else {
+			fmt.Println("Two-factor authentication is not enabled")
+			if status.Required {
+				fmt.Println(style.Warning("This registry requires it to publish packages, run protodex account 2fa enable"))
+			}
+		}
+		return nil
+	},
+}
+
+var accountTwoFactorEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Enable two-factor authentication",
+	Long: `Enable two-factor authentication. protodex prints a secret to add to your authenticator app and
+asks for a code from the app to confirm it. You then get recovery codes, each of which can be used
+once instead of a code.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		setup, err := c.SetupTOTP()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Add this account to your authenticator app with the secret:")
+		fmt.Println()
+		fmt.Println("  " + style.Bold(setup.Secret))
+		fmt.Println()
+		fmt.Println("or with the URI:")
+		fmt.Println()
+		fmt.Println("  " + setup.URI)
+		fmt.Println()
+
+		code, err := promptTwoFactorCode()
+		if err != nil {
+			return err
+		}
+		codes, err := c.EnableTOTP(code)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success("Two-factor authentication enabled"))
+		printRecoveryCodes(codes)
+		return nil
+	},
+}
+
+var accountTwoFactorDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Disable two-factor authentication",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		code, err := promptTwoFactorCode()
+		if err != nil {
+			return err
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if err := c.DisableTOTP(code); err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success("Two-factor authentication disabled"))
+		return nil
+	},
+}
+
+var accountTwoFactorRecoveryCodesCmd = &cobra.Command{
+	Use:   "recovery-codes",
+	Short: "Replace your recovery codes",
+	Long:  `Replace your recovery codes with new ones. The old codes stop working.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		code, err := promptTwoFactorCode()
+		if err != nil {
+			return err
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		codes, err := c.RegenerateRecoveryCodes(code)
+		if err != nil {
+			return err
+		}
+
+		printRecoveryCodes(codes)
+		return nil
+	},
+}
+
+// promptTwoFactorCode reads a code from the authenticator app or a recovery code
+func promptTwoFactorCode() (string, error) {
+	fmt.Print("Two-factor code: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	code := strings.TrimSpace(line)
+	if code == "" {
+		if err != nil {
+			return "", fmt.Errorf("failed to read two-factor code: %w", err)
+		}
+		return "", fmt.Errorf("two-factor code is required")
+	}
+	return code, nil
+}
+
+func printRecoveryCodes(codes []string) {
+	fmt.Println()
+	fmt.Println("Recovery codes:")
+	for _, code := range codes {
+		fmt.Println("  " + code)
+	}
+	fmt.Println()
+	fmt.Println(style.Warning("Store the recovery codes somewhere safe, they will not be shown again"))
+}
+
+func init() {
+	accountTwoFactorCmd.AddCommand(accountTwoFactorStatusCmd)
+	accountTwoFactorCmd.AddCommand(accountTwoFactorEnableCmd)
+	accountTwoFactorCmd.AddCommand(accountTwoFactorDisableCmd)
+	accountTwoFactorCmd.AddCommand(accountTwoFactorRecoveryCodesCmd)
+}
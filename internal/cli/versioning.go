@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/semver"
+)
+
+var versioningCmd = &cobra.Command{
+	Use:   "versioning",
+	Short: "Manage package versioning policies",
+	Long: `Manage how the registry validates version numbers pushed to a package.
+
+Strict semver rejects versions that are not semantic versions (e.g., v1.2.3 or 1.2.3-rc.1).
+
+The bump policy compares the schema with the closest earlier semantic version and checks the
+version bump matches the changes: major for breaking changes, minor for additions, patch otherwise.
+- off: no checks
+- suggest: accept the push and warn with the suggested version (default)
+- require: reject pushes whose bump is too small
+
+Required approvals turns every push into a draft that is published once that many maintainers
+other than its author approve it.`,
+}
+
+var versioningGetCmd = &cobra.Command{
+	Use:   "get <package>",
+	Short: "Show the versioning policy of a package",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		policy, err := c.GetVersioning(args[0])
+		if err != nil {
+			return err
+		}
+
+		printVersioningPolicy(policy)
+		return nil
+	},
+}
+
+var versioningSetCmd = &cobra.Command{
+	Use:   "set <package>",
+	Short: "Change the versioning policy of a package",
+	Example: `  protodex versioning set user-service --strict-semver
+  protodex versioning set user-service --bump-policy require
+  protodex versioning set user-service --required-approvals 2`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		policy, err := c.GetVersioning(args[0])
+		if err != nil {
+			return err
+		}
+
+		if cmd.Flags().Changed("strict-semver") {
+			policy.StrictSemver, _ = cmd.Flags().GetBool("strict-semver")
+		}
+		if cmd.Flags().Changed("bump-policy") {
+			value, _ := cmd.Flags().GetString("bump-policy")
+			bumpPolicy, err := semver.ParsePolicy(value)
+			if err != nil {
+				return fmt.Errorf("%w (expected one of %v)", err, semver.Policies())
+			}
+			policy.BumpPolicy = string(bumpPolicy)
+		}
+		if cmd.Flags().Changed("required-approvals") {
+			policy.RequiredApprovals, _ = cmd.Flags().GetInt("required-approvals")
+		}
+
+		updated, err := c.SetVersioning(args[0], *policy)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Versioning policy of %s updated", updated.Package)))
+		printVersioningPolicy(updated)
+		return nil
+	},
+}
+
+func printVersioningPolicy(policy *client.VersioningPolicy) {
+	fmt.Printf("%s\n", style.Bold(policy.Package))
+	fmt.Printf("  %s %t\n", style.Subtle("Strict semver:"), policy.StrictSemver)
+	fmt.Printf("  %s %s\n", style.Subtle("Bump policy:"), policy.BumpPolicy)
+	fmt.Printf("  %s %d\n", style.Subtle("Required approvals:"), policy.RequiredApprovals)
+}
+
+func init() {
+	versioningSetCmd.Flags().Bool("strict-semver", false, "Reject versions that are not semantic versions")
+	versioningSetCmd.Flags().String("bump-policy", "", "Version bump policy (off, suggest, require)")
+	versioningSetCmd.Flags().Int("required-approvals", 0, "Maintainer approvals a push needs to be published, 0 publishes pushes directly")
+
+	versioningCmd.AddCommand(versioningGetCmd)
+	versioningCmd.AddCommand(versioningSetCmd)
+}
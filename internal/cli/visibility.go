@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+var visibilityCmd = &cobra.Command{
+	Use:   "visibility <package> [public|private]",
+	Short: "Show or change the visibility of a package",
+	Long: `Show or change the visibility of a package.
+
+Public packages can be listed, viewed and pulled by anyone, including users that are not logged
+in. Private packages are only visible to their owner, their collaborators and the members of
+their organization. Only package owners can change the visibility.`,
+	Example: `  protodex visibility user-service
+  protodex visibility acme/payments private`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if len(args) == 1 {
+			pkg, err := c.GetPackage(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s: %s\n", style.Bold(pkg.Name), pkg.Visibility)
+			return nil
+		}
+
+		pkg, err := c.SetVisibility(args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("%s is now %s", pkg.Name, pkg.Visibility)))
+		return nil
+	},
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+// watchReconnectDelay is how long watch-registry waits before reconnecting a lost stream
+const watchReconnectDelay = 5 * time.Second
+
+var watchRegistryCmd = &cobra.Command{
+	Use:   "watch-registry",
+	Short: "Show registry activity as it happens",
+	Long: `Tail the live event stream of the registry: pushes, yanks, and packages being created and
+deleted. Only the events of packages you can see are shown. When the connection is lost, the
+command reconnects and shows the events it missed.`,
+	Example: `  protodex watch-registry
+  protodex watch-registry --org acme --event version.pushed
+  protodex watch-registry --package user-service --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		if format != "text" && format != "json" {
+			return fmt.Errorf("unsupported format %q, expected text or json", format)
+		}
+
+		filter := client.EventFilter{}
+		filter.Packages, _ = cmd.Flags().GetStringSlice("package")
+		filter.Org, _ = cmd.Flags().GetString("org")
+		filter.Events, _ = cmd.Flags().GetStringSlice("event")
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		encoder := json.NewEncoder(os.Stdout)
+		handle := func(event *client.RegistryEvent) {
+			filter.LastEventID = event.ID
+			if format == "json" {
+				_ = encoder.Encode(event)
+				return
+			}
+			printRegistryEvent(event)
+		}
+
+		if format == "text" {
+			fmt.Println(style.Subtle("Watching registry events, press Ctrl+C to stop"))
+		}
+		// Failing to connect the first time is an error, later the stream is reconnected
+		for attempt := 1; ; attempt++ {
+			err := c.WatchEvents(ctx, filter, handle)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err != nil && attempt == 1 {
+				return err
+			}
+
+			reason := "stream closed"
+			if err != nil {
+				reason = err.Error()
+			}
+			fmt.Fprintln(os.Stderr, style.Subtle(fmt.Sprintf("Disconnected (%s), reconnecting in %s", reason, watchReconnectDelay)))
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(watchReconnectDelay):
+			}
+		}
+	},
+}
+
+func printRegistryEvent(event *client.RegistryEvent) {
+	target := event.Package.Name
+	if event.Version != nil {
+		target = style.Version(event.Package.Name, event.Version.Version)
+	}
+	actor := event.Actor
+	if actor == "" {
+		actor = "anonymous"
+	}
+	fmt.Printf("%s %s %s %s\n", event.Timestamp.Local().Format("2006-01-02 15:04:05"),
+		style.Bold(actor), event.Event, target)
+	if event.Version != nil && event.Version.YankReason != "" {
+		fmt.Println("    " + style.Subtle("reason: "+event.Version.YankReason))
+	}
+}
+
+func init() {
+	watchRegistryCmd.Flags().StringSlice("package", nil, "Only show events of this package, repeat for more")
+	watchRegistryCmd.Flags().String("org", "", "Only show events of the packages of this organization")
+	watchRegistryCmd.Flags().StringSlice("event", nil, "Only show this event, repeat for more (package.created, package.deleted, version.pushed, version.yanked)")
+	watchRegistryCmd.Flags().String("format", "text", "Output format: text or json")
+}
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Send registry events to other services",
+	Long: `Manage webhooks, which receive a POST request for every event they subscribe to.
+
+Events:
+- package.created: a package was created
+- package.deleted: a package was deleted
+- version.pushed: a version was pushed
+- version.yanked: a version was yanked
+
+Package owners manage the webhooks of their packages with --package. Without it, the commands
+manage registry-wide webhooks that receive the events of every package, which only
+administrators can do.
+
+Payloads are signed with the webhook secret: the X-Protodex-Signature-256 header is sha256=
+followed by the hex encoded HMAC-SHA256 of the request body. Failed deliveries are retried with
+increasing delays.`,
+}
+
+var webhookAddCmd = &cobra.Command{
+	Use:   "add <url>",
+	Short: "Register a webhook",
+	Example: `  protodex webhook add https://ci.example.com/hooks/protodex --package user-service --event version.pushed
+  protodex webhook add https://hooks.slack.example.com/protodex --event version.pushed --event version.yanked`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pkg, _ := cmd.Flags().GetString("package")
+		events, _ := cmd.Flags().GetStringSlice("event")
+		secret, _ := cmd.Flags().GetString("secret")
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		created, err := c.CreateWebhook(pkg, client.CreateWebhookRequest{URL: args[0], Events: events, Secret: secret})
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Registered webhook %s for %s", created.Webhook.ID, webhookScope(pkg))))
+		if secret == "" {
+			fmt.Printf("Secret: %s\n", created.Secret)
+			fmt.Println(style.Warning("Copy the secret now, it will not be shown again"))
+		}
+		return nil
+	},
+}
+
+var webhookListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List webhooks",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pkg, _ := cmd.Flags().GetString("package")
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		webhooks, err := c.ListWebhooks(pkg)
+		if err != nil {
+			return err
+		}
+
+		if len(webhooks) == 0 {
+			fmt.Println(style.Subtle("No webhooks for " + webhookScope(pkg)))
+			return nil
+		}
+		for _, webhook := range webhooks {
+			fmt.Printf("%s %s %s\n", style.Bold(webhook.ID), webhook.URL,
+				style.Subtle("("+strings.Join(webhook.Events, ",")+", created by "+webhook.CreatedBy+")"))
+		}
+		return nil
+	},
+}
+
+var webhookRemoveCmd = &cobra.Command{
+	Use:   "remove <id>",
+	Short: "Remove a webhook, its pending deliveries are not sent",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pkg, _ := cmd.Flags().GetString("package")
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if err := c.DeleteWebhook(pkg, args[0]); err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Removed webhook %s", args[0])))
+		return nil
+	},
+}
+
+var webhookDeliveriesCmd = &cobra.Command{
+	Use:   "deliveries <id>",
+	Short: "Show the recent deliveries of a webhook",
+	Example: `  protodex webhook deliveries 5f0c... --package user-service
+  protodex webhook deliveries 5f0c... --failed`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pkg, _ := cmd.Flags().GetString("package")
+		failed, _ := cmd.Flags().GetBool("failed")
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		deliveries, err := c.ListWebhookDeliveries(pkg, args[0], failed)
+		if err != nil {
+			return err
+		}
+
+		if len(deliveries) == 0 {
+			fmt.Println(style.Subtle("No deliveries"))
+			return nil
+		}
+		for _, delivery := range deliveries {
+			fmt.Printf("%s %s %s %s\n", style.Bold(delivery.ID), delivery.Event, delivery.Status,
+				style.Subtle(delivery.CreatedAt.Local().Format("2006-01-02 15:04:05")))
+
+			details := []string{fmt.Sprintf("%d attempts", delivery.Attempts)}
+			if delivery.ResponseStatus != 0 {
+				details = append(details, fmt.Sprintf("responded %d", delivery.ResponseStatus))
+			}
+			if delivery.NextAttemptAt != nil && delivery.Status == "pending" {
+				details = append(details, "next attempt in "+time.Until(*delivery.NextAttemptAt).Round(time.Second).String())
+			}
+			fmt.Printf("  %s\n", style.Subtle(strings.Join(details, ", ")))
+			if delivery.Error != "" {
+				fmt.Printf("  %s\n", delivery.Error)
+			}
+		}
+		return nil
+	},
+}
+
+var webhookRedeliverCmd = &cobra.Command{
+	Use:   "redeliver <id> <delivery-id>",
+	Short: "Send the payload of a delivery to its webhook again",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pkg, _ := cmd.Flags().GetString("package")
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		delivery, err := c.Redeliver(pkg, args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(style.Success(fmt.Sprintf("Queued delivery %s of %s", delivery.ID, delivery.Event)))
+		return nil
+	},
+}
+
+// webhookScope describes whose webhooks the commands manage
+func webhookScope(pkg string) string {
+	if pkg == "" {
+		return "the registry"
+	}
+	return pkg
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{webhookAddCmd, webhookListCmd, webhookRemoveCmd, webhookDeliveriesCmd, webhookRedeliverCmd} {
+		cmd.Flags().String("package", "", "Package whose webhooks to manage, registry-wide webhooks when omitted")
+		webhookCmd.AddCommand(cmd)
+	}
+	webhookAddCmd.Flags().StringSlice("event", nil, "Event to send, repeat for more (package.created, package.deleted, version.pushed, version.yanked)")
+	webhookAddCmd.Flags().String("secret", "", "Secret to sign payloads with, generated when omitted")
+	_ = webhookAddCmd.MarkFlagRequired("event")
+	webhookDeliveriesCmd.Flags().Bool("failed", false, "Only show deliveries whose last attempt failed")
+}
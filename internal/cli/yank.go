@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sirrobot01/protodex/internal/cli/style"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+var yankCmd = &cobra.Command{
+	Use:   "yank [package:version]",
+	Short: "Yank a version so it is no longer selected by latest or version ranges",
+	Long: `Yank a published version. A yanked version stays downloadable by its exact version, so
+existing consumers keep working, but it is skipped when resolving latest, latest-stable and
+version ranges, and pulling it prints a warning.
+
+Use --delete to permanently remove the version and its files instead. Deleted versions cannot
+be restored and break every consumer that pins them.
+
+Examples:
+  protodex yank user-service:v1.2.0
+  protodex yank user-service:v1.2.0 --reason "drops the email field by mistake"
+  protodex yank user-service:v1.2.0 --delete`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pkg, version, err := client.ParsePackageRef(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid package reference: %w", err)
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if del, _ := cmd.Flags().GetBool("delete"); del {
+			if yes, _ := cmd.Flags().GetBool("yes"); !yes && !confirm(fmt.Sprintf("Permanently delete %s:%s?", pkg, version)) {
+				return fmt.Errorf("aborted")
+			}
+			if err := c.DeleteVersion(pkg, version); err != nil {
+				return err
+			}
+			fmt.Println(style.Success(fmt.Sprintf("Deleted %s", style.Version(pkg, version))))
+			return nil
+		}
+
+		reason, _ := cmd.Flags().GetString("reason")
+		if _, err := c.YankVersion(pkg, version, reason); err != nil {
+			return err
+		}
+		fmt.Println(style.Success(fmt.Sprintf("Yanked %s", style.Version(pkg, version))))
+		return nil
+	},
+}
+
+var unyankCmd = &cobra.Command{
+	Use:     "unyank [package:version]",
+	Short:   "Restore a yanked version",
+	Example: `  protodex unyank user-service:v1.2.0`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pkg, version, err := client.ParsePackageRef(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid package reference: %w", err)
+		}
+
+		c, err := client.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize client: %w", err)
+		}
+
+		if _, err := c.UnyankVersion(pkg, version); err != nil {
+			return err
+		}
+		fmt.Println(style.Success(fmt.Sprintf("Restored %s", style.Version(pkg, version))))
+		return nil
+	},
+}
+
+// confirm asks a yes/no question on the terminal, anything but y or yes is a no
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	var answer string
+	if _, err := fmt.Scanln(&answer); err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func init() {
+	yankCmd.Flags().String("reason", "", "Reason shown to consumers that pull the yanked version")
+	yankCmd.Flags().Bool("delete", false, "Permanently delete the version instead of yanking it")
+	yankCmd.Flags().BoolP("yes", "y", false, "Skip the confirmation prompt when deleting")
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// SetVisibility makes a package public or private
+func (c *HTTPClient) SetVisibility(packageName, visibility string) (*Package, error) {
+	jsonData, err := json.Marshal(VisibilityRequest{Visibility: visibility})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/packages/%s/visibility", c.baseURL, escapePackage(packageName))
+	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to set visibility: %s - %s", resp.Status, string(body))
+	}
+
+	var pkg Package
+	if err := json.NewDecoder(resp.Body).Decode(&pkg); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &pkg, nil
+}
+
+// ListCollaborators lists the owner of a package and the users granted a role on it
+func (c *HTTPClient) ListCollaborators(packageName string) ([]*Collaborator, error) {
+	url := fmt.Sprintf("%s/api/packages/%s/access", c.baseURL, escapePackage(packageName))
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to list collaborators: %s - %s", resp.Status, string(body))
+	}
+
+	var collaborators []*Collaborator
+	if err := json.NewDecoder(resp.Body).Decode(&collaborators); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return collaborators, nil
+}
+
+// GrantAccess gives a user a role on a package or changes the role they have
+func (c *HTTPClient) GrantAccess(packageName, username, role string) error {
+	jsonData, err := json.Marshal(Collaborator{Username: username, Role: role})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/packages/%s/access", c.baseURL, escapePackage(packageName))
+	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to grant access: %s - %s", resp.Status, string(body))
+	}
+
+	return nil
+}
+
+func (c *HTTPClient) RevokeAccess(packageName, username string) error {
+	reqURL := fmt.Sprintf("%s/api/packages/%s/access/%s", c.baseURL, escapePackage(packageName), url.PathEscape(username))
+	req, err := http.NewRequest("DELETE", reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to revoke access: %s - %s", resp.Status, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ChangePassword changes the password of the current user. The registry ends every other session.
+func (c *HTTPClient) ChangePassword(currentPassword, newPassword string) error {
+	jsonData, err := json.Marshal(ChangePasswordRequest{
+		CurrentPassword: currentPassword,
+		NewPassword:     newPassword,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/auth/password", c.baseURL)
+	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to change password: %s - %s", resp.Status, string(body))
+	}
+
+	return nil
+}
+
+// DeleteAccount deletes the current user and clears the stored token
+func (c *HTTPClient) DeleteAccount(password string) error {
+	jsonData, err := json.Marshal(DeleteAccountRequest{Password: password})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/auth/me", c.baseURL)
+	req, err := http.NewRequest("DELETE", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete account: %s - %s", resp.Status, string(body))
+	}
+
+	c.token = ""
+	c.config.HashedToken = ""
+	return c.config.Save()
+}
+
+// ListUsers lists every user of the registry. Only administrators can list users.
+func (c *HTTPClient) ListUsers() ([]*User, error) {
+	url := fmt.Sprintf("%s/api/admin/users", c.baseURL)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to list users: %s - %s", resp.Status, string(body))
+	}
+
+	var users []*User
+	if err := json.NewDecoder(resp.Body).Decode(&users); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return users, nil
+}
+
+// ResetPassword sets the password of a user. When password is empty the registry generates one,
+// which is returned.
+func (c *HTTPClient) ResetPassword(username, password string) (string, error) {
+	jsonData, err := json.Marshal(ResetPasswordRequest{Password: password})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	reqURL := fmt.Sprintf("%s/api/admin/users/%s/password", c.baseURL, url.PathEscape(username))
+	req, err := http.NewRequest("PUT", reqURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to reset password: %s - %s", resp.Status, string(body))
+	}
+
+	var reset ResetPasswordResponse
+	if err := json.NewDecoder(resp.Body).Decode(&reset); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return reset.Password, nil
+}
+
+// SetUserDisabled disables or re-enables a user. Disabling ends all their sessions and revokes their tokens.
+func (c *HTTPClient) SetUserDisabled(username string, disabled bool) error {
+	action := "enable"
+	if disabled {
+		action = "disable"
+	}
+
+	reqURL := fmt.Sprintf("%s/api/admin/users/%s/%s", c.baseURL, url.PathEscape(username), action)
+	req, err := http.NewRequest("POST", reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to %s user: %s - %s", action, resp.Status, string(body))
+	}
+
+	return nil
+}
+
+func (c *HTTPClient) DeleteUser(username string) error {
+	reqURL := fmt.Sprintf("%s/api/admin/users/%s", c.baseURL, url.PathEscape(username))
+	req, err := http.NewRequest("DELETE", reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete user: %s - %s", resp.Status, string(body))
+	}
+
+	return nil
+}
+
+// CreateUser creates an account on behalf of someone. When the request has no password the
+// registry generates one, which is returned in the response.
+func (c *HTTPClient) CreateUser(createReq CreateUserRequest) (*CreateUserResponse, error) {
+	jsonData, err := json.Marshal(createReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	reqURL := fmt.Sprintf("%s/api/admin/users", c.baseURL)
+	req, err := http.NewRequest("POST", reqURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to create user: %s - %s", resp.Status, string(body))
+	}
+
+	var created CreateUserResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &created, nil
+}
+
+// SetAdmin makes a user an administrator of the registry or takes that away
+func (c *HTTPClient) SetAdmin(username string, admin bool) error {
+	jsonData, err := json.Marshal(SetAdminRequest{Admin: admin})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	reqURL := fmt.Sprintf("%s/api/admin/users/%s/admin", c.baseURL, url.PathEscape(username))
+	req, err := http.NewRequest("PUT", reqURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to update user: %s - %s", resp.Status, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ListAuditEntries returns the audit entries matching filter, newest first
+func (c *HTTPClient) ListAuditEntries(filter AuditFilter) ([]*AuditEntry, error) {
+	query := url.Values{}
+	for param, value := range map[string]string{
+		"actor":       filter.Actor,
+		"action":      filter.Action,
+		"target_type": filter.TargetType,
+		"target":      filter.Target,
+	} {
+		if value != "" {
+			query.Set(param, value)
+		}
+	}
+	if !filter.Since.IsZero() {
+		query.Set("since", filter.Since.Format(time.RFC3339))
+	}
+	if !filter.Until.IsZero() {
+		query.Set("until", filter.Until.Format(time.RFC3339))
+	}
+	if filter.Before > 0 {
+		query.Set("before", strconv.FormatInt(filter.Before, 10))
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/audit?%s", c.baseURL, query.Encode()), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to list audit entries: %s - %s", resp.Status, string(body))
+	}
+
+	var entries []*AuditEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return entries, nil
+}
@@ -2,7 +2,9 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,21 +18,91 @@ import (
 
 type Client interface {
 	Login(username, password string) (*LoginResponse, error)
+	StartSSOLogin() (*DeviceLogin, error)
+	PollSSOLogin(deviceCode string) (*LoginResponse, error)
+	CompleteTwoFactorLogin(challenge, code string) (*LoginResponse, error)
 	Register(username, password string) (*RegisterResponse, error)
 	GetCurrentUser() (*User, error)
 	Logout() error
+	ListSessions() ([]*Session, error)
+	RevokeSession(id string) error
+	RevokeOtherSessions() (int64, error)
+	ChangePassword(currentPassword, newPassword string) error
+	DeleteAccount(password string) error
+	GetTwoFactorStatus() (*TwoFactorStatus, error)
+	SetupTOTP() (*TOTPSetup, error)
+	EnableTOTP(code string) ([]string, error)
+	DisableTOTP(code string) error
+	RegenerateRecoveryCodes(code string) ([]string, error)
+
+	ListUsers() ([]*User, error)
+	ResetPassword(username, password string) (string, error)
+	SetUserDisabled(username string, disabled bool) error
+	DeleteUser(username string) error
+	CreateUser(req CreateUserRequest) (*CreateUserResponse, error)
+	SetAdmin(username string, admin bool) error
+	ResetTwoFactor(username string) error
+	CreateInvite(req CreateInviteRequest) (*CreateInviteResponse, error)
+	ListInvites() ([]*Invite, error)
+	RevokeInvite(id string) error
+	ListAuditEntries(filter AuditFilter) ([]*AuditEntry, error)
 
 	ListPackages() ([]*Package, error)
 	GetPackage(name string) (*Package, error)
 	CreatePackage(name, description string, tags []string) (*Package, error)
 	SearchPackages(query string, tags []string) ([]*Package, error)
-
-	PushVersion(packageName, version string, zipData []byte) (*Version, error)
-	PullVersion(packageName, version, outputDir string) error
+	DeletePackage(name string) error
+	RenamePackage(name, newName string) (*Package, error)
+	TransferPackage(name, owner string) (*Package, error)
+	GetCompatibility(packageName string) (*CompatibilityPolicy, error)
+	SetCompatibility(packageName, mode string) (*CompatibilityPolicy, error)
+	GetVersioning(packageName string) (*VersioningPolicy, error)
+	SetVersioning(packageName string, policy VersioningPolicy) (*VersioningPolicy, error)
+	SetVisibility(packageName, visibility string) (*Package, error)
+
+	PushVersion(packageName, version string, zipData []byte, opts PushOptions) (*Version, error)
+	PullVersion(packageName, version, outputDir string) (*PullResult, error)
+	ResolveVersion(packageName, version string) (*Version, error)
 	ListVersions(packageName string) ([]*Version, error)
+	YankVersion(packageName, version, reason string) (*Version, error)
+	UnyankVersion(packageName, version string) (*Version, error)
+	DeleteVersion(packageName, version string) error
 	ViewSchema(packageName, version string) (*SchemaView, error)
 
+	ListDrafts(packageName string) ([]*Draft, error)
+	GetDraft(packageName, version string) (*Draft, error)
+	DiffDraft(packageName, version string) (*DraftDiff, error)
+	ApproveDraft(packageName, version, comment string, allowBreaking bool) (*Draft, error)
+	RejectDraft(packageName, version, comment string) (*Draft, error)
+
+	ListLabels(packageName string) ([]*Label, error)
+	SetLabel(packageName, label, version string) (*Label, error)
+	DeleteLabel(packageName, label string) error
+	LabelHistory(packageName, label string) ([]*LabelMove, error)
+
 	GenerateCode(packageName, version, language, outputDir string, options GenerateOptions) (*GenerateResult, error)
+
+	CreateOrganization(name string) (*Organization, error)
+	ListOrganizations() ([]*Organization, error)
+	GetOrganization(name string) (*Organization, error)
+	SetMember(org, username, role string) error
+	RemoveMember(org, username string) error
+
+	CreateToken(req CreateTokenRequest) (*CreateTokenResponse, error)
+	ListTokens() ([]*APIToken, error)
+	RevokeToken(id string) error
+
+	ListCollaborators(packageName string) ([]*Collaborator, error)
+	GrantAccess(packageName, username, role string) error
+	RevokeAccess(packageName, username string) error
+
+	ListWebhooks(packageName string) ([]*Webhook, error)
+	CreateWebhook(packageName string, req CreateWebhookRequest) (*CreateWebhookResponse, error)
+	DeleteWebhook(packageName, id string) error
+	ListWebhookDeliveries(packageName, id string, failedOnly bool) ([]*WebhookDelivery, error)
+	Redeliver(packageName, id, deliveryID string) (*WebhookDelivery, error)
+
+	WatchEvents(ctx context.Context, filter EventFilter, handle func(*RegistryEvent)) error
 }
 
 type HTTPClient struct {
@@ -47,13 +119,81 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token string `json:"token,omitempty"`
 	User  User   `json:"user"`
+	// TwoFactorChallenge is set instead of Token when the user has to enter a two-factor code,
+	// which CompleteTwoFactorLogin exchanges for a session
+	TwoFactorChallenge string `json:"two_factor_challenge,omitempty"`
+}
+
+type TwoFactorLoginRequest struct {
+	Challenge string `json:"challenge" binding:"required"`
+	// Code is a code from the authenticator app or a recovery code
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorStatus describes the two-factor authentication of the current user
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+	// Required is set when the registry only lets users with two-factor authentication publish
+	Required bool `json:"required"`
+}
+
+// TOTPSetup is the secret to add to an authenticator app, also as an otpauth:// URI
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
 }
 
 type RegisterRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	// Email is required when the registry only accepts some email domains
+	Email string `json:"email,omitempty"`
+	// Invite is the invite code needed when the registry is invite-only
+	Invite string `json:"invite,omitempty"`
+}
+
+// SSOInfo tells clients whether the registry offers single sign-on
+type SSOInfo struct {
+	Enabled bool   `json:"enabled"`
+	Name    string `json:"name,omitempty"`
+}
+
+// SSOLink is the identity provider URL a logged-in user visits to link their identity to their
+// account
+type SSOLink struct {
+	URL string `json:"url"`
+}
+
+// DeviceLogin is a single sign-on login started from the CLI. The user opens VerificationURI in a
+// browser and enters UserCode while the CLI polls with DeviceCode.
+type DeviceLogin struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type DeviceTokenRequest struct {
+	DeviceCode string `json:"device_code" binding:"required"`
+}
+
+// RegistrationInfo tells clients how accounts are created on the registry
+type RegistrationInfo struct {
+	Mode                string   `json:"mode"`
+	AllowedEmailDomains []string `json:"allowed_email_domains,omitempty"`
 }
 
 type RegisterResponse struct {
@@ -62,26 +202,417 @@ type RegisterResponse struct {
 }
 
 type User struct {
-	ID       string `json:"id"`
-	Username string `json:"username"`
+	ID             string `json:"id"`
+	Username       string `json:"username"`
+	Email          string `json:"email,omitempty"`
+	IsAdmin        bool   `json:"is_admin,omitempty"`
+	ServiceAccount bool   `json:"service_account,omitempty"`
+	Disabled       bool   `json:"disabled,omitempty"`
+	TwoFactor      bool   `json:"two_factor,omitempty"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// DeleteAccountRequest confirms the deletion of the current account with its password
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// ResetPasswordRequest sets the password of a user. The registry generates one when it is empty
+// and returns it in the response.
+type ResetPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+type ResetPasswordResponse struct {
+	Password string `json:"password,omitempty"`
+}
+
+type CreateUserRequest struct {
+	Username string `json:"username" binding:"required"`
+	// Password is generated by the registry when empty
+	Password string `json:"password,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Admin    bool   `json:"admin,omitempty"`
+}
+
+// CreateUserResponse carries the generated password, which the registry only returns once
+type CreateUserResponse struct {
+	User     User   `json:"user"`
+	Password string `json:"password,omitempty"`
+}
+
+type SetAdminRequest struct {
+	Admin bool `json:"admin"`
+}
+
+// AuditEntry records one change to the registry: who made it, from where and to what
+type AuditEntry struct {
+	ID         int64             `json:"id"`
+	Action     string            `json:"action"`
+	ActorID    string            `json:"actor_id,omitempty"`
+	Actor      string            `json:"actor,omitempty"`
+	IP         string            `json:"ip"`
+	UserAgent  string            `json:"user_agent,omitempty"`
+	TargetType string            `json:"target_type"`
+	Target     string            `json:"target"`
+	Details    map[string]string `json:"details,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// AuditFilter selects audit entries. Empty fields match everything.
+type AuditFilter struct {
+	Actor string
+	// Action is an action such as version.push, or a group of actions such as version
+	Action     string
+	TargetType string
+	Target     string
+	Since      time.Time
+	Until      time.Time
+	// Before only returns entries older than the entry with this ID
+	Before int64
+	Limit  int
+}
+
+// Invite lets one person register on an invite-only registry
+type Invite struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email,omitempty"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type CreateInviteRequest struct {
+	// Email restricts the invite to one address
+	Email     string     `json:"email,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateInviteResponse carries the invite code, which the registry only returns once
+type CreateInviteResponse struct {
+	Code   string `json:"code"`
+	Invite Invite `json:"invite"`
+}
+
+// Session is a login session of the current user
+type Session struct {
+	ID        string     `json:"id"`
+	UserAgent string     `json:"user_agent"`
+	CreatedAt time.Time  `json:"created_at"`
+	LastUsed  *time.Time `json:"last_used,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Current is set on the session the request was made with
+	Current bool `json:"current"`
+}
+
+type RevokeSessionsResponse struct {
+	Revoked int64 `json:"revoked"`
 }
 
 type Package struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Tags        []string  `json:"tags"`
-	CreatedAt   time.Time `json:"created_at"`
-	OwnerID     string    `json:"owner_id"`
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	Tags          []string  `json:"tags"`
+	CreatedAt     time.Time `json:"created_at"`
+	OwnerID       string    `json:"owner_id"`
+	Compatibility string    `json:"compatibility,omitempty"`
+	Visibility    string    `json:"visibility,omitempty"`
 }
 
-type Version struct {
+type Organization struct {
 	ID        string    `json:"id"`
-	Version   string    `json:"version"`
+	Name      string    `json:"name"`
 	CreatedAt time.Time `json:"created_at"`
+	Members   []Member  `json:"members,omitempty"`
+}
+
+type Member struct {
+	Username string `json:"username" binding:"required"`
+	Role     string `json:"role"`
+}
+
+// Collaborator is a user with a role on a package
+type Collaborator struct {
+	Username string `json:"username" binding:"required"`
+	Role     string `json:"role"`
+}
+
+// APIToken describes a token without its secret value
+type APIToken struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Username  string     `json:"username"`
+	Scopes    []string   `json:"scopes"`
+	Package   string     `json:"package,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	LastUsed  *time.Time `json:"last_used,omitempty"`
+}
+
+type CreateTokenRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes"`
+	// Package restricts the token to a single package
+	Package string `json:"package,omitempty"`
+	// ServiceAccount creates the token for a service account instead of the current user
+	ServiceAccount string     `json:"service_account,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateTokenResponse carries the token value, which the registry only returns once
+type CreateTokenResponse struct {
+	Token    string   `json:"token"`
+	APIToken APIToken `json:"api_token"`
+}
+
+// Webhook sends the events of a package to a URL. Registry-wide webhooks have no package and
+// receive the events of every package.
+type Webhook struct {
+	ID        string    `json:"id"`
+	Package   string    `json:"package,omitempty"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
 	CreatedBy string    `json:"created_by"`
-	Metadata  string    `json:"metadata,omitempty"`
-	Checksum  string    `json:"checksum,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateWebhookRequest registers a webhook. The registry generates a secret when it is empty.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+// CreateWebhookResponse carries the secret payloads are signed with, which the registry only
+// returns once
+type CreateWebhookResponse struct {
+	Secret  string  `json:"secret"`
+	Webhook Webhook `json:"webhook"`
+}
+
+// WebhookDelivery is one event sent to a webhook, with the outcome of its last attempt
+type WebhookDelivery struct {
+	ID             string     `json:"id"`
+	Event          string     `json:"event"`
+	URL            string     `json:"url"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	ResponseBody   string     `json:"response_body,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+}
+
+// EventFilter selects the events of the live registry stream, empty fields match everything
+type EventFilter struct {
+	Packages []string
+	Org      string
+	Events   []string
+	// LastEventID resumes a stream after the event with this ID
+	LastEventID string
+}
+
+// RegistryEvent is an activity of the registry, the payload is the one sent to webhooks
+type RegistryEvent struct {
+	ID        string        `json:"-"`
+	Event     string        `json:"event"`
+	Timestamp time.Time     `json:"timestamp"`
+	Actor     string        `json:"actor,omitempty"`
+	Package   EventPackage  `json:"package"`
+	Version   *EventVersion `json:"version,omitempty"`
+}
+
+type EventPackage struct {
+	Name       string `json:"name"`
+	Visibility string `json:"visibility"`
+}
+
+type EventVersion struct {
+	Version    string `json:"version"`
+	Checksum   string `json:"checksum,omitempty"`
+	YankReason string `json:"yank_reason,omitempty"`
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type RenameRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type TransferRequest struct {
+	Owner string `json:"owner" binding:"required"`
+}
+
+type VisibilityRequest struct {
+	Visibility string `json:"visibility" binding:"required"`
+}
+
+type CompatibilityPolicy struct {
+	Package       string `json:"package,omitempty"`
+	Compatibility string `json:"compatibility"`
+}
+
+type VersioningPolicy struct {
+	Package      string `json:"package,omitempty"`
+	StrictSemver bool   `json:"strict_semver"`
+	BumpPolicy   string `json:"bump_policy"`
+	// RequiredApprovals is how many maintainers must approve a draft to publish it, when set every
+	// push is a draft
+	RequiredApprovals int `json:"required_approvals"`
+}
+
+type Version struct {
+	ID         string    `json:"id"`
+	Version    string    `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	CreatedBy  string    `json:"created_by"`
+	Metadata   string    `json:"metadata,omitempty"`
+	Checksum   string    `json:"checksum,omitempty"`
+	Yanked     bool      `json:"yanked,omitempty"`
+	YankReason string    `json:"yank_reason,omitempty"`
+	// Status is published, or draft and rejected for versions awaiting or failing review
+	Status   string   `json:"status,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Label is a mutable name such as stable or prod pointing at a published version of a package
+type Label struct {
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LabelMove is an entry of the history of a label, From is empty when the label was created and
+// To when it was removed
+type LabelMove struct {
+	Label     string    `json:"label"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+	MovedBy   string    `json:"moved_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SetLabelRequest struct {
+	// Version is a version query, so a label can be moved to latest or to what another label points at
+	Version string `json:"version" binding:"required"`
+}
+
+type PushOptions struct {
+	AllowBreaking bool
+	// Visibility of the package when the push creates it, public when empty
+	Visibility string
+	// Draft stores the version for review instead of publishing it
+	Draft bool
+}
+
+// Draft is a version waiting for the approval of the package maintainers
+type Draft struct {
+	Version
+	Approvals         int           `json:"approvals"`
+	RequiredApprovals int           `json:"required_approvals"`
+	Reviews           []DraftReview `json:"reviews"`
+}
+
+type DraftReview struct {
+	Username  string    `json:"username"`
+	Decision  string    `json:"decision"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ReviewRequest struct {
+	Comment string `json:"comment"`
+	// AllowBreaking publishes an approved draft even if it breaks the compatibility policy
+	AllowBreaking bool `json:"allow_breaking,omitempty"`
+}
+
+// DraftDiff compares a draft with the latest release of its package
+type DraftDiff struct {
+	Package string `json:"package"`
+	Version string `json:"version"`
+	// BaseVersion is the release the draft is compared with, empty for the first version
+	BaseVersion string     `json:"base_version,omitempty"`
+	Files       []FileDiff `json:"files"`
+	// Violations are the breaking changes of the draft and RequiredBump the version bump they call
+	// for, both are left empty when either version does not compile
+	Violations   []Violation `json:"violations"`
+	RequiredBump string      `json:"required_bump,omitempty"`
+	CompileError string      `json:"compile_error,omitempty"`
+}
+
+// FileDiff is a file the draft added, removed or modified with its changes in the unified diff format
+type FileDiff struct {
+	Path   string `json:"path"`
+	Change string `json:"change"`
+	Diff   string `json:"diff"`
+}
+
+type Violation struct {
+	Rule      string `json:"rule"`
+	File      string `json:"file"`
+	Subject   string `json:"subject"`
+	Message   string `json:"message"`
+	Against   string `json:"against,omitempty"`
+	Direction string `json:"direction,omitempty"`
+}
+
+// ErrVersionExists is returned when pushing a version that has already been published
+var ErrVersionExists = errors.New("version already exists")
+
+// ErrForbidden is returned when the current user lacks the role a request needs
+var ErrForbidden = errors.New("permission denied")
+
+// ErrSessionNotEnded is returned by Logout when the local token was cleared but the registry could
+// not end the session
+var ErrSessionNotEnded = errors.New("session was not ended on the registry")
+
+// BreakingChangeError is returned when the registry rejects a push because it
+// breaks compatibility with the previous version
+type BreakingChangeError struct {
+	Message         string      `json:"error"`
+	Compatibility   string      `json:"compatibility"`
+	PreviousVersion string      `json:"previous_version"`
+	Violations      []Violation `json:"violations"`
+}
+
+func (e *BreakingChangeError) Error() string {
+	return e.Message
+}
+
+// VersionBumpError is returned when the registry rejects a push because the version
+// bump is smaller than the schema changes require
+type VersionBumpError struct {
+	Message          string `json:"error"`
+	PreviousVersion  string `json:"previous_version"`
+	RequiredBump     string `json:"required_bump"`
+	SuggestedVersion string `json:"suggested_version"`
+}
+
+func (e *VersionBumpError) Error() string {
+	return e.Message
+}
+
+// AdmissionError is returned when an admission hook of the registry rejects a push
+type AdmissionError struct {
+	Message string `json:"error"`
+	Hook    string `json:"hook"`
+	// Reason is the message of the hook
+	Reason string `json:"reason"`
+}
+
+func (e *AdmissionError) Error() string {
+	return e.Message
 }
 
 type GenerateOptions struct {
@@ -89,7 +620,24 @@ type GenerateOptions struct {
 	ModulePath  string `json:"module_path,omitempty"`
 }
 
+// VersionHeader is set by the registry to the concrete version a version query resolved to
+const VersionHeader = "X-Protodex-Version"
+
+// YankedHeader and YankReasonHeader are set by the registry when the served version has been yanked
+const (
+	YankedHeader     = "X-Protodex-Yanked"
+	YankReasonHeader = "X-Protodex-Yank-Reason"
+)
+
+type PullResult struct {
+	Version    string `json:"version"`
+	OutputDir  string `json:"output_dir"`
+	Yanked     bool   `json:"yanked,omitempty"`
+	YankReason string `json:"yank_reason,omitempty"`
+}
+
 type GenerateResult struct {
+	Version        string   `json:"version,omitempty"`
 	OutputDir      string   `json:"output_dir"`
 	GeneratedFiles []string `json:"generated_files"`
 }
@@ -114,7 +662,7 @@ func New() (Client, error) {
 	cfg := config.Get()
 	return &HTTPClient{
 		baseURL:    cfg.Registry,
-		token:      cfg.HashedToken,
+		token:      cfg.GetToken(""),
 		httpClient: &http.Client{},
 		logger:     logger.Get(),
 		config:     cfg,
@@ -259,8 +807,23 @@ func (c *HTTPClient) GetCurrentUser() (*User, error) {
 	return &user, nil
 }
 
+// Logout ends the stored login session on the registry and clears it from the configuration.
+// The token is cleared even when the registry cannot be reached, in which case the returned
+// error wraps ErrSessionNotEnded.
 func (c *HTTPClient) Logout() error {
+	var endErr error
+	if c.config.HashedToken != "" {
+		endErr = c.endSession(c.config.HashedToken)
+	}
+
 	c.token = ""
 	c.config.HashedToken = ""
-	return c.config.Save()
+	if err := c.config.Save(); err != nil {
+		return err
+	}
+
+	if endErr != nil {
+		return fmt.Errorf("%w: %v", ErrSessionNotEnded, endErr)
+	}
+	return nil
 }
@@ -1,12 +1,16 @@
 package client
 
 import (
+	"archive/zip"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -248,6 +252,651 @@ func TestClientSearchPackages(t *testing.T) {
 	assert.Equal(t, "user-service", packages[0].Name)
 }
 
+func TestClientSetCompatibility(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/packages/user-service/compatibility", r.URL.Path)
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+
+		var body CompatibilityPolicy
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "FULL", body.Compatibility)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"package": "user-service", "compatibility": "FULL"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	policy, err := client.SetCompatibility("user-service", "FULL")
+	require.NoError(t, err)
+	assert.Equal(t, "user-service", policy.Package)
+	assert.Equal(t, "FULL", policy.Compatibility)
+}
+
+func TestClientPushVersionRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		validate func(t *testing.T, err error)
+	}{
+		{
+			name: "breaking change",
+			body: `{"error": "breaking", "compatibility": "BACKWARD", "previous_version": "v1.0.0", "violations": [{"rule": "FIELD_REMOVED", "file": "user.proto", "subject": "user.v1.User.age", "message": "removed"}]}`,
+			validate: func(t *testing.T, err error) {
+				var breakingErr *BreakingChangeError
+				require.ErrorAs(t, err, &breakingErr)
+				assert.Equal(t, "v1.0.0", breakingErr.PreviousVersion)
+				require.Len(t, breakingErr.Violations, 1)
+				assert.Equal(t, "FIELD_REMOVED", breakingErr.Violations[0].Rule)
+			},
+		},
+		{
+			name: "insufficient version bump",
+			body: `{"error": "bump too small", "previous_version": "v1.0.0", "required_bump": "minor", "suggested_version": "v1.1.0"}`,
+			validate: func(t *testing.T, err error) {
+				var bumpErr *VersionBumpError
+				require.ErrorAs(t, err, &bumpErr)
+				assert.Equal(t, "minor", bumpErr.RequiredBump)
+				assert.Equal(t, "v1.1.0", bumpErr.SuggestedVersion)
+			},
+		},
+		{
+			name: "rejected by admission hook",
+			body: `{"error": "version v1.0.1 of user-service was rejected by admission hook ownership", "hook": "ownership", "reason": "needs a sign-off"}`,
+			validate: func(t *testing.T, err error) {
+				var admissionErr *AdmissionError
+				require.ErrorAs(t, err, &admissionErr)
+				assert.Equal(t, "ownership", admissionErr.Hook)
+				assert.Equal(t, "needs a sign-off", admissionErr.Reason)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/packages/user-service/versions", r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := newTestClient(server.URL, "test-token")
+			_, err := client.PushVersion("user-service", "v1.0.1", []byte("zip"), PushOptions{})
+			tt.validate(t, err)
+		})
+	}
+}
+
+func TestClientPullVersionResolvesRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/packages/user-service/versions/>=2.0 <3/files", r.URL.Path)
+		assert.Equal(t, "/api/packages/user-service/versions/%3E=2.0%20%3C3/files", r.URL.EscapedPath())
+
+		w.Header().Set(VersionHeader, "v2.4.1")
+		w.Header().Set("Content-Type", "application/zip")
+		w.WriteHeader(http.StatusOK)
+
+		zipWriter := zip.NewWriter(w)
+		f, err := zipWriter.Create("user.proto")
+		require.NoError(t, err)
+		_, _ = f.Write([]byte(`syntax = "proto3";`))
+		require.NoError(t, zipWriter.Close())
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+	outputDir := t.TempDir()
+
+	result, err := client.PullVersion("user-service", ">=2.0 <3", outputDir)
+	require.NoError(t, err)
+	assert.Equal(t, "v2.4.1", result.Version)
+	assert.FileExists(t, filepath.Join(outputDir, "user.proto"))
+}
+
+func TestClientPushVersionExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"error": "version v1.0.0 of user-service already exists"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+	_, err := client.PushVersion("user-service", "v1.0.0", []byte("zip"), PushOptions{})
+	assert.ErrorIs(t, err, ErrVersionExists)
+	assert.Contains(t, err.Error(), "v1.0.0 of user-service already exists")
+}
+
+func TestClientRenamePackage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/packages/user-service/rename", r.URL.Path)
+		assert.Equal(t, "POST", r.Method)
+
+		var body RenameRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "accounts", body.Name)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"id": "pkg1", "name": "accounts"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	pkg, err := client.RenamePackage("user-service", "accounts")
+	require.NoError(t, err)
+	assert.Equal(t, "accounts", pkg.Name)
+}
+
+func TestClientGetScopedPackage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/packages/acme%2Fpayments", r.URL.EscapedPath())
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"id": "pkg1", "name": "acme/payments"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	pkg, err := client.GetPackage("acme/payments")
+	require.NoError(t, err)
+	assert.Equal(t, "acme/payments", pkg.Name)
+}
+
+func TestClientPushVersionForbidden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error": "you need the maintainer role on user-service to push versions"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	_, err := client.PushVersion("user-service", "v1.1.0", []byte("zip"), PushOptions{})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.Contains(t, err.Error(), "maintainer role")
+}
+
+func TestClientSetVisibility(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/packages/user-service/visibility", r.URL.Path)
+		assert.Equal(t, "PUT", r.Method)
+
+		var body VisibilityRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "private", body.Visibility)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"id": "pkg1", "name": "user-service", "visibility": "private"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	pkg, err := client.SetVisibility("user-service", "private")
+	require.NoError(t, err)
+	assert.Equal(t, "private", pkg.Visibility)
+}
+
+func TestClientPushPrivatePackage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "private", r.FormValue("visibility"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": "v1", "version": "v1.0.0"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	_, err := client.PushVersion("user-service", "v1.0.0", []byte("zip"), PushOptions{Visibility: "private"})
+	require.NoError(t, err)
+}
+
+func TestClientGrantAccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/packages/user-service/access", r.URL.Path)
+		assert.Equal(t, "PUT", r.Method)
+
+		var body Collaborator
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "janedoe", body.Username)
+		assert.Equal(t, "maintainer", body.Role)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"username": "janedoe", "role": "maintainer"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	require.NoError(t, client.GrantAccess("user-service", "janedoe", "maintainer"))
+}
+
+func TestClientLogoutEndsSession(t *testing.T) {
+	ended := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/auth/logout", r.URL.Path)
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "Bearer session-token", r.Header.Get("Authorization"))
+		ended = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "session-token")
+	client.config.HashedToken = "session-token"
+
+	require.NoError(t, client.Logout())
+	assert.True(t, ended)
+	assert.Empty(t, client.config.HashedToken)
+}
+
+func TestClientLogoutRegistryUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "session-token")
+	client.config.HashedToken = "session-token"
+
+	err := client.Logout()
+	assert.ErrorIs(t, err, ErrSessionNotEnded)
+	assert.Empty(t, client.config.HashedToken)
+}
+
+func TestClientResetPassword(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/admin/users/janedoe/password", r.URL.Path)
+		assert.Equal(t, "PUT", r.Method)
+
+		var body ResetPasswordRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Empty(t, body.Password)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"password": "generated-password"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	password, err := client.ResetPassword("janedoe", "")
+	require.NoError(t, err)
+	assert.Equal(t, "generated-password", password)
+}
+
+func TestClientPollSSOLogin(t *testing.T) {
+	approved := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/auth/sso/device/token", r.URL.Path)
+		assert.Equal(t, "POST", r.Method)
+
+		var body DeviceTokenRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "device-code", body.DeviceCode)
+
+		w.Header().Set("Content-Type", "application/json")
+		if !approved {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": "authorization_pending"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"token": "pg_session", "user": {"id": "user-1", "username": "jane"}}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "")
+
+	_, err := client.PollSSOLogin("device-code")
+	assert.ErrorIs(t, err, ErrLoginPending)
+
+	approved = true
+	login, err := client.PollSSOLogin("device-code")
+	require.NoError(t, err)
+	assert.Equal(t, "pg_session", login.Token)
+	assert.Equal(t, "jane", login.User.Username)
+}
+
+func TestClientTwoFactorLogin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/api/auth/login":
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"two_factor_challenge": "pc_challenge", "user": {"id": "user-1", "username": "jane"}}`))
+		case "/api/auth/login/2fa":
+			var body TwoFactorLoginRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "pc_challenge", body.Challenge)
+			if body.Code != "123456" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error": "Invalid two-factor code"}`))
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"token": "pg_session", "user": {"id": "user-1", "username": "jane"}}`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "")
+
+	login, err := client.Login("jane", "password123")
+	require.NoError(t, err)
+	assert.Empty(t, login.Token)
+	assert.Equal(t, "pc_challenge", login.TwoFactorChallenge)
+
+	_, err = client.CompleteTwoFactorLogin(login.TwoFactorChallenge, "000000")
+	assert.ErrorContains(t, err, "Invalid two-factor code")
+
+	login, err = client.CompleteTwoFactorLogin(login.TwoFactorChallenge, "123456")
+	require.NoError(t, err)
+	assert.Equal(t, "pg_session", login.Token)
+}
+
+func TestClientListAuditEntries(t *testing.T) {
+	since := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/audit", r.URL.Path)
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "jane", r.URL.Query().Get("actor"))
+		assert.Equal(t, "version", r.URL.Query().Get("action"))
+		assert.Equal(t, "2024-01-02T15:04:05Z", r.URL.Query().Get("since"))
+		assert.Equal(t, "42", r.URL.Query().Get("before"))
+		assert.False(t, r.URL.Query().Has("target"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"id": 41, "action": "version.push", "actor": "jane", "ip": "192.0.2.1",
+			"target_type": "version", "target": "payments@v1.0.0", "details": {"checksum": "abc"},
+			"created_at": "2024-01-03T10:00:00Z"}]`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "token")
+
+	entries, err := client.ListAuditEntries(AuditFilter{Actor: "jane", Action: "version", Since: since, Before: 42})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(41), entries[0].ID)
+	assert.Equal(t, "payments@v1.0.0", entries[0].Target)
+	assert.Equal(t, "abc", entries[0].Details["checksum"])
+}
+
+func TestClientWebhooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.EscapedPath() {
+		case "POST /api/packages/acme%2Fpayments/webhooks":
+			var body CreateWebhookRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "https://ci.example.com/hook", body.URL)
+			assert.Equal(t, []string{"version.pushed"}, body.Events)
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"secret": "s3cret", "webhook": {"id": "hook-1", "package": "acme/payments",
+				"url": "https://ci.example.com/hook", "events": ["version.pushed"]}}`))
+		case "GET /api/admin/webhooks":
+			_, _ = w.Write([]byte(`[{"id": "hook-2", "url": "https://chat.example.com/hook", "events": ["package.deleted"]}]`))
+		case "GET /api/packages/acme%2Fpayments/webhooks/hook-1/deliveries":
+			assert.Equal(t, "true", r.URL.Query().Get("failed"))
+			_, _ = w.Write([]byte(`[{"id": "delivery-1", "event": "version.pushed", "status": "pending", "attempts": 2,
+				"response_status": 500, "error": "webhook responded with status 500"}]`))
+		case "POST /api/packages/acme%2Fpayments/webhooks/hook-1/deliveries/delivery-1/redeliver":
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"id": "delivery-2", "event": "version.pushed", "status": "pending"}`))
+		case "DELETE /api/admin/webhooks/hook-2":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "token")
+
+	created, err := client.CreateWebhook("acme/payments", CreateWebhookRequest{URL: "https://ci.example.com/hook", Events: []string{"version.pushed"}})
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", created.Secret)
+	assert.Equal(t, "hook-1", created.Webhook.ID)
+
+	// Without a package the registry-wide webhooks are managed
+	webhooks, err := client.ListWebhooks("")
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, []string{"package.deleted"}, webhooks[0].Events)
+
+	deliveries, err := client.ListWebhookDeliveries("acme/payments", "hook-1", true)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, 500, deliveries[0].ResponseStatus)
+
+	redelivery, err := client.Redeliver("acme/payments", "hook-1", "delivery-1")
+	require.NoError(t, err)
+	assert.Equal(t, "delivery-2", redelivery.ID)
+
+	require.NoError(t, client.DeleteWebhook("", "hook-2"))
+}
+
+func TestClientCreateInvite(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/admin/invites", r.URL.Path)
+		assert.Equal(t, "POST", r.Method)
+
+		var body CreateInviteRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "jane@example.com", body.Email)
+		assert.Nil(t, body.ExpiresAt)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"code": "pdi_abc", "invite": {"id": "invite-1", "email": "jane@example.com", "created_by": "admin"}}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	created, err := client.CreateInvite(CreateInviteRequest{Email: "jane@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "pdi_abc", created.Code)
+	assert.Equal(t, "invite-1", created.Invite.ID)
+	assert.Equal(t, "admin", created.Invite.CreatedBy)
+}
+
+func TestClientCreateToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/tokens", r.URL.Path)
+		assert.Equal(t, "POST", r.Method)
+
+		var body CreateTokenRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "ci", body.Name)
+		assert.Equal(t, []string{"push"}, body.Scopes)
+		assert.Equal(t, "user-service", body.Package)
+		assert.Equal(t, "ci-bot", body.ServiceAccount)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{
+			"token": "pdx_secret",
+			"api_token": {"id": "tok-1", "name": "ci", "username": "ci-bot", "scopes": ["push"], "package": "user-service"}
+		}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	created, err := client.CreateToken(CreateTokenRequest{
+		Name:           "ci",
+		Scopes:         []string{"push"},
+		Package:        "user-service",
+		ServiceAccount: "ci-bot",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "pdx_secret", created.Token)
+	assert.Equal(t, "ci-bot", created.APIToken.Username)
+	assert.Equal(t, "user-service", created.APIToken.Package)
+}
+
+func TestClientYankVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/packages/user-service/versions/v1.2.0", r.URL.Path)
+		assert.Equal(t, "DELETE", r.Method)
+		assert.Equal(t, "bad release", r.URL.Query().Get("reason"))
+		assert.Empty(t, r.URL.Query().Get("purge"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"id": "ver1", "version": "v1.2.0", "yanked": true, "yank_reason": "bad release"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	version, err := client.YankVersion("user-service", "v1.2.0", "bad release")
+	require.NoError(t, err)
+	assert.True(t, version.Yanked)
+	assert.Equal(t, "bad release", version.YankReason)
+}
+
+func TestClientDeleteVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/packages/user-service/versions/v1.2.0", r.URL.Path)
+		assert.Equal(t, "DELETE", r.Method)
+		assert.Equal(t, "true", r.URL.Query().Get("purge"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+	require.NoError(t, client.DeleteVersion("user-service", "v1.2.0"))
+}
+
+func TestClientPushDraft(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "true", r.FormValue("draft"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": "v1", "version": "v1.1.0", "status": "draft"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	version, err := client.PushVersion("user-service", "v1.1.0", []byte("zip"), PushOptions{Draft: true})
+	require.NoError(t, err)
+	assert.Equal(t, "draft", version.Status)
+}
+
+func TestClientApproveDraft(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/packages/user-service/drafts/v1.1.0/approve", r.URL.Path)
+		assert.Equal(t, "POST", r.Method)
+
+		var req ReviewRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "looks good", req.Comment)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"id": "ver1", "version": "v1.1.0", "status": "draft", "approvals": 1, "required_approvals": 2,
+			"reviews": [{"username": "alice", "decision": "approve", "comment": "looks good"}]}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	draft, err := client.ApproveDraft("user-service", "v1.1.0", "looks good", false)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0", draft.Version.Version)
+	assert.Equal(t, "draft", draft.Status)
+	assert.Equal(t, 1, draft.Approvals)
+	assert.Equal(t, 2, draft.RequiredApprovals)
+	require.Len(t, draft.Reviews, 1)
+	assert.Equal(t, "alice", draft.Reviews[0].Username)
+}
+
+func TestClientApproveBreakingDraft(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ReviewRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.False(t, req.AllowBreaking)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"error": "version v1.1.0 violates BACKWARD compatibility with 1 change(s)", "compatibility": "BACKWARD",
+			"previous_version": "v1.2.0", "violations": [{"rule": "FIELD_REMOVED", "message": "field removed"}]}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	_, err := client.ApproveDraft("user-service", "v1.1.0", "", false)
+	var breakingErr *BreakingChangeError
+	require.ErrorAs(t, err, &breakingErr)
+	assert.Equal(t, "v1.2.0", breakingErr.PreviousVersion)
+	require.Len(t, breakingErr.Violations, 1)
+	assert.Equal(t, "FIELD_REMOVED", breakingErr.Violations[0].Rule)
+}
+
+func TestClientRejectMissingDraft(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/packages/user-service/drafts/v1.1.0/reject", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error": "draft v1.1.0 of user-service not found"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	_, err := client.RejectDraft("user-service", "v1.1.0", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "draft v1.1.0 of user-service not found")
+}
+
+func TestClientPullYankedVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(VersionHeader, "v1.2.0")
+		w.Header().Set(YankedHeader, "true")
+		w.Header().Set(YankReasonHeader, "bad release")
+		w.Header().Set("Content-Type", "application/zip")
+		w.WriteHeader(http.StatusOK)
+		require.NoError(t, zip.NewWriter(w).Close())
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	result, err := client.PullVersion("user-service", "v1.2.0", t.TempDir())
+	require.NoError(t, err)
+	assert.True(t, result.Yanked)
+	assert.Equal(t, "bad release", result.YankReason)
+}
+
 // Helper function to create test client
 func newTestClient(baseURL, token string) *HTTPClient {
 	tmpDir, _ := os.MkdirTemp("", "protodex-test-config-")
@@ -261,3 +910,85 @@ func newTestClient(baseURL, token string) *HTTPClient {
 		config:     &config.Config{ConfigPath: configPath},
 	}
 }
+
+func TestClientWatchEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/events", r.URL.Path)
+		assert.Equal(t, []string{"acme/payments"}, r.URL.Query()["package"])
+		assert.Equal(t, []string{"version.pushed", "version.yanked"}, r.URL.Query()["event"])
+		assert.Equal(t, "41", r.Header.Get("Last-Event-ID"))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, "id:42\nevent:version.pushed\n"+
+			`data:{"event":"version.pushed","actor":"jane","package":{"name":"acme/payments","visibility":"private"},"version":{"version":"v1.2.0"}}`+"\n\n")
+		fmt.Fprint(w, "id:43\nevent:version.yanked\n"+
+			`data:{"event":"version.yanked","package":{"name":"acme/payments"},"version":{"version":"v1.1.0","yank_reason":"bad field"}}`+"\n\n")
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+	var received []*RegistryEvent
+	err := client.WatchEvents(context.Background(), EventFilter{
+		Packages:    []string{"acme/payments"},
+		Events:      []string{"version.pushed", "version.yanked"},
+		LastEventID: "41",
+	}, func(event *RegistryEvent) {
+		received = append(received, event)
+	})
+	require.NoError(t, err)
+
+	require.Len(t, received, 2)
+	assert.Equal(t, "42", received[0].ID)
+	assert.Equal(t, "version.pushed", received[0].Event)
+	assert.Equal(t, "jane", received[0].Actor)
+	assert.Equal(t, "v1.2.0", received[0].Version.Version)
+	assert.Equal(t, "43", received[1].ID)
+	assert.Equal(t, "bad field", received[1].Version.YankReason)
+}
+
+func TestClientSetLabel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/packages/user-service/labels/prod", r.URL.Path)
+		assert.Equal(t, "PUT", r.Method)
+
+		var req SetLabelRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "stable", req.Version)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"name": "prod", "version": "v1.4.0", "updated_by": "alice"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	label, err := client.SetLabel("user-service", "prod", "stable")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", label.Name)
+	assert.Equal(t, "v1.4.0", label.Version)
+	assert.Equal(t, "alice", label.UpdatedBy)
+}
+
+func TestClientLabelHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/packages/user-service/labels/prod/history", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`[{"label": "prod", "from": "v1.3.0", "to": "v1.4.0", "moved_by": "alice"},
+			{"label": "prod", "to": "v1.3.0", "moved_by": "bob"}]`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL, "test-token")
+
+	moves, err := client.LabelHistory("user-service", "prod")
+	require.NoError(t, err)
+	require.Len(t, moves, 2)
+	assert.Equal(t, "v1.3.0", moves[0].From)
+	assert.Equal(t, "v1.4.0", moves[0].To)
+	assert.Empty(t, moves[1].From)
+	assert.Equal(t, "bob", moves[1].MovedBy)
+}
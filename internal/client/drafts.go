@@ -0,0 +1,126 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ListDrafts returns the drafts of a package that have not been published, including rejected ones
+func (c *HTTPClient) ListDrafts(packageName string) ([]*Draft, error) {
+	url := fmt.Sprintf("%s/api/packages/%s/drafts", c.baseURL, escapePackage(packageName))
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to list drafts: %s - %s", resp.Status, string(body))
+	}
+
+	var drafts []*Draft
+	if err := json.NewDecoder(resp.Body).Decode(&drafts); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return drafts, nil
+}
+
+// GetDraft returns a draft with its reviews
+func (c *HTTPClient) GetDraft(packageName, version string) (*Draft, error) {
+	url := fmt.Sprintf("%s/api/packages/%s/drafts/%s", c.baseURL, escapePackage(packageName), escapeVersion(version))
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return c.doDraft(req, "get draft")
+}
+
+// DiffDraft compares a draft with the latest release of its package
+func (c *HTTPClient) DiffDraft(packageName, version string) (*DraftDiff, error) {
+	url := fmt.Sprintf("%s/api/packages/%s/drafts/%s/diff", c.baseURL, escapePackage(packageName), escapeVersion(version))
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to diff draft: %s - %s", resp.Status, string(body))
+	}
+
+	var diff DraftDiff
+	if err := json.NewDecoder(resp.Body).Decode(&diff); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &diff, nil
+}
+
+// ApproveDraft approves a draft, which publishes it once it has enough approvals. Publishing fails
+// with a BreakingChangeError when the draft breaks the compatibility policy, unless allowBreaking
+// is set.
+func (c *HTTPClient) ApproveDraft(packageName, version, comment string, allowBreaking bool) (*Draft, error) {
+	return c.reviewDraft(packageName, version, "approve", ReviewRequest{Comment: comment, AllowBreaking: allowBreaking})
+}
+
+// RejectDraft rejects a draft, it can only be published by pushing it again
+func (c *HTTPClient) RejectDraft(packageName, version, comment string) (*Draft, error) {
+	return c.reviewDraft(packageName, version, "reject", ReviewRequest{Comment: comment})
+}
+
+func (c *HTTPClient) reviewDraft(packageName, version, decision string, review ReviewRequest) (*Draft, error) {
+	jsonData, err := json.Marshal(review)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/packages/%s/drafts/%s/%s", c.baseURL, escapePackage(packageName), escapeVersion(version), decision)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.doDraft(req, decision+" draft")
+}
+
+func (c *HTTPClient) doDraft(req *http.Request, action string) (*Draft, error) {
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusUnprocessableEntity {
+			var breakingErr BreakingChangeError
+			if err := json.Unmarshal(body, &breakingErr); err == nil && breakingErr.Compatibility != "" {
+				return nil, &breakingErr
+			}
+		}
+		return nil, fmt.Errorf("failed to %s: %s - %s", action, resp.Status, string(body))
+	}
+
+	var draft Draft
+	if err := json.NewDecoder(resp.Body).Decode(&draft); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &draft, nil
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WatchEvents streams the activity of the registry and calls handle with every event until ctx is
+// cancelled or the registry closes the stream. Only the events of packages the caller can see are
+// sent.
+func (c *HTTPClient) WatchEvents(ctx context.Context, filter EventFilter, handle func(*RegistryEvent)) error {
+	query := url.Values{}
+	for _, pkg := range filter.Packages {
+		query.Add("package", pkg)
+	}
+	if filter.Org != "" {
+		query.Set("org", filter.Org)
+	}
+	for _, event := range filter.Events {
+		query.Add("event", event)
+	}
+	reqURL := fmt.Sprintf("%s/api/events", c.baseURL)
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if filter.LastEventID != "" {
+		req.Header.Set("Last-Event-ID", filter.LastEventID)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to watch events: %s - %s", resp.Status, string(body))
+	}
+
+	// Events are blocks of field lines ended by a blank line, lines starting with : are comments
+	var id string
+	var data strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch {
+		case line == "":
+			if data.Len() > 0 {
+				var event RegistryEvent
+				if err := json.Unmarshal([]byte(data.String()), &event); err != nil {
+					return fmt.Errorf("failed to decode event: %w", err)
+				}
+				event.ID = id
+				handle(&event)
+			}
+			data.Reset()
+		case field == "id":
+			id = value
+		case field == "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read events: %w", err)
+	}
+	return nil
+}
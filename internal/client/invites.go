@@ -0,0 +1,91 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// CreateInvite creates an invite to register on the registry. The invite code is only part of this response.
+func (c *HTTPClient) CreateInvite(inviteReq CreateInviteRequest) (*CreateInviteResponse, error) {
+	jsonData, err := json.Marshal(inviteReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/admin/invites", c.baseURL)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to create invite: %s - %s", resp.Status, string(body))
+	}
+
+	var created CreateInviteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &created, nil
+}
+
+// ListInvites lists the invites that have not been used yet
+func (c *HTTPClient) ListInvites() ([]*Invite, error) {
+	url := fmt.Sprintf("%s/api/admin/invites", c.baseURL)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to list invites: %s - %s", resp.Status, string(body))
+	}
+
+	var invites []*Invite
+	if err := json.NewDecoder(resp.Body).Decode(&invites); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return invites, nil
+}
+
+func (c *HTTPClient) RevokeInvite(id string) error {
+	reqURL := fmt.Sprintf("%s/api/admin/invites/%s", c.baseURL, url.PathEscape(id))
+	req, err := http.NewRequest("DELETE", reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to revoke invite: %s - %s", resp.Status, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ListLabels returns the labels of a package with the versions they point at
+func (c *HTTPClient) ListLabels(packageName string) ([]*Label, error) {
+	req, err := http.NewRequest("GET", c.labelsURL(packageName), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to list labels: %s - %s", resp.Status, string(body))
+	}
+
+	var labels []*Label
+	if err := json.NewDecoder(resp.Body).Decode(&labels); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return labels, nil
+}
+
+// SetLabel points a label at the version a query resolves to, creating the label if needed
+func (c *HTTPClient) SetLabel(packageName, label, version string) (*Label, error) {
+	jsonData, err := json.Marshal(SetLabelRequest{Version: version})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("PUT", c.labelsURL(packageName)+"/"+url.PathEscape(label), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to set label: %s - %s", resp.Status, string(body))
+	}
+
+	var updated Label
+	if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// DeleteLabel removes a label, its history is kept
+func (c *HTTPClient) DeleteLabel(packageName, label string) error {
+	req, err := http.NewRequest("DELETE", c.labelsURL(packageName)+"/"+url.PathEscape(label), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete label: %s - %s", resp.Status, string(body))
+	}
+
+	return nil
+}
+
+// LabelHistory returns every move of a label, newest first
+func (c *HTTPClient) LabelHistory(packageName, label string) ([]*LabelMove, error) {
+	req, err := http.NewRequest("GET", c.labelsURL(packageName)+"/"+url.PathEscape(label)+"/history", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to get label history: %s - %s", resp.Status, string(body))
+	}
+
+	var moves []*LabelMove
+	if err := json.NewDecoder(resp.Body).Decode(&moves); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return moves, nil
+}
+
+func (c *HTTPClient) labelsURL(packageName string) string {
+	return fmt.Sprintf("%s/api/packages/%s/labels", c.baseURL, escapePackage(packageName))
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -44,7 +45,7 @@ func (c *HTTPClient) ListPackages() ([]*Package, error) {
 }
 
 func (c *HTTPClient) GetPackage(name string) (*Package, error) {
-	url := fmt.Sprintf("%s/api/packages/%s", c.baseURL, name)
+	url := fmt.Sprintf("%s/api/packages/%s", c.baseURL, escapePackage(name))
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -135,7 +136,187 @@ func (c *HTTPClient) SearchPackages(query string, tags []string) ([]*Package, er
 	return packages, nil
 }
 
-func (c *HTTPClient) PushVersion(packageName, version string, zipData []byte) (*Version, error) {
+// DeletePackage permanently deletes a package with all its versions
+func (c *HTTPClient) DeletePackage(name string) error {
+	url := fmt.Sprintf("%s/api/packages/%s", c.baseURL, escapePackage(name))
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete package: %s - %s", resp.Status, string(body))
+	}
+
+	return nil
+}
+
+// RenamePackage renames a package, the old name keeps resolving to it
+func (c *HTTPClient) RenamePackage(name, newName string) (*Package, error) {
+	return c.updatePackage(name, "rename", RenameRequest{Name: newName})
+}
+
+// TransferPackage hands a package over to the user with the given username
+func (c *HTTPClient) TransferPackage(name, owner string) (*Package, error) {
+	return c.updatePackage(name, "transfer", TransferRequest{Owner: owner})
+}
+
+func (c *HTTPClient) updatePackage(name, action string, body any) (*Package, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/packages/%s/%s", c.baseURL, escapePackage(name), action)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to %s package: %s - %s", action, resp.Status, string(body))
+	}
+
+	var pkg Package
+	if err := json.NewDecoder(resp.Body).Decode(&pkg); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &pkg, nil
+}
+
+func (c *HTTPClient) GetCompatibility(packageName string) (*CompatibilityPolicy, error) {
+	url := fmt.Sprintf("%s/api/packages/%s/compatibility", c.baseURL, escapePackage(packageName))
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to get compatibility: %s - %s", resp.Status, string(body))
+	}
+
+	var policy CompatibilityPolicy
+	if err := json.NewDecoder(resp.Body).Decode(&policy); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &policy, nil
+}
+
+func (c *HTTPClient) SetCompatibility(packageName, mode string) (*CompatibilityPolicy, error) {
+	jsonData, err := json.Marshal(CompatibilityPolicy{Compatibility: mode})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/packages/%s/compatibility", c.baseURL, escapePackage(packageName))
+	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to set compatibility: %s - %s", resp.Status, string(body))
+	}
+
+	var policy CompatibilityPolicy
+	if err := json.NewDecoder(resp.Body).Decode(&policy); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &policy, nil
+}
+
+func (c *HTTPClient) GetVersioning(packageName string) (*VersioningPolicy, error) {
+	url := fmt.Sprintf("%s/api/packages/%s/versioning", c.baseURL, escapePackage(packageName))
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to get versioning policy: %s - %s", resp.Status, string(body))
+	}
+
+	var policy VersioningPolicy
+	if err := json.NewDecoder(resp.Body).Decode(&policy); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &policy, nil
+}
+
+func (c *HTTPClient) SetVersioning(packageName string, policy VersioningPolicy) (*VersioningPolicy, error) {
+	jsonData, err := json.Marshal(policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/packages/%s/versioning", c.baseURL, escapePackage(packageName))
+	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to set versioning policy: %s - %s", resp.Status, string(body))
+	}
+
+	var updated VersioningPolicy
+	if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &updated, nil
+}
+
+func (c *HTTPClient) PushVersion(packageName, version string, zipData []byte, opts PushOptions) (*Version, error) {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 
@@ -145,8 +326,26 @@ func (c *HTTPClient) PushVersion(packageName, version string, zipData []byte) (*
 		return nil, err
 	}
 
+	if opts.AllowBreaking {
+		if err := writer.WriteField("allow_breaking", "true"); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Visibility != "" {
+		if err := writer.WriteField("visibility", opts.Visibility); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Draft {
+		if err := writer.WriteField("draft", "true"); err != nil {
+			return nil, err
+		}
+	}
+
 	// Add zip file
-	part, err := writer.CreateFormFile("zip", fmt.Sprintf("%s-%s.zip", packageName, version))
+	part, err := writer.CreateFormFile("zip", fmt.Sprintf("%s-%s.zip", strings.ReplaceAll(packageName, "/", "-"), version))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create form file: %w", err)
 	}
@@ -157,7 +356,7 @@ func (c *HTTPClient) PushVersion(packageName, version string, zipData []byte) (*
 
 	writer.Close()
 
-	url := fmt.Sprintf("%s/api/packages/%s/versions", c.baseURL, packageName)
+	url := fmt.Sprintf("%s/api/packages/%s/versions", c.baseURL, escapePackage(packageName))
 	req, err := http.NewRequest("POST", url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -170,6 +369,43 @@ func (c *HTTPClient) PushVersion(packageName, version string, zipData []byte) (*
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		data, _ := io.ReadAll(resp.Body)
+		var admissionErr AdmissionError
+		if err := json.Unmarshal(data, &admissionErr); err == nil && admissionErr.Hook != "" {
+			return nil, &admissionErr
+		}
+		var bumpErr VersionBumpError
+		if err := json.Unmarshal(data, &bumpErr); err == nil && bumpErr.RequiredBump != "" {
+			return nil, &bumpErr
+		}
+		var breakingErr BreakingChangeError
+		if err := json.Unmarshal(data, &breakingErr); err != nil {
+			return nil, fmt.Errorf("push failed: %s", resp.Status)
+		}
+		return nil, &breakingErr
+	}
+
+	if resp.StatusCode == http.StatusConflict {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil || errResp.Error == "" {
+			return nil, ErrVersionExists
+		}
+		return nil, fmt.Errorf("%w: %s", ErrVersionExists, errResp.Error)
+	}
+
+	if resp.StatusCode == http.StatusForbidden {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil || errResp.Error == "" {
+			return nil, ErrForbidden
+		}
+		return nil, fmt.Errorf("%w: %s", ErrForbidden, errResp.Error)
+	}
+
 	if resp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("push failed: %s - %s", resp.Status, string(body))
@@ -183,44 +419,54 @@ func (c *HTTPClient) PushVersion(packageName, version string, zipData []byte) (*
 	return &ver, nil
 }
 
-func (c *HTTPClient) PullVersion(packageName, version, outputDir string) error {
-	url := fmt.Sprintf("%s/api/packages/%s/versions/%s/files", c.baseURL, packageName, version)
+func (c *HTTPClient) PullVersion(packageName, version, outputDir string) (*PullResult, error) {
+	url := fmt.Sprintf("%s/api/packages/%s/versions/%s/files", c.baseURL, escapePackage(packageName), escapeVersion(version))
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	resp, err := c.do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("pull failed: %s - %s", resp.Status, string(body))
+		return nil, fmt.Errorf("pull failed: %s - %s", resp.Status, string(body))
+	}
+
+	result := &PullResult{
+		Version:    resolvedVersion(resp, version),
+		OutputDir:  outputDir,
+		Yanked:     resp.Header.Get(YankedHeader) == "true",
+		YankReason: resp.Header.Get(YankReasonHeader),
 	}
 
 	// Create output directory
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
 	}
 
 	// Read response content
 	content, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	// Check if response is a ZIP archive
 	contentType := resp.Header.Get("Content-Type")
 	if contentType == "application/zip" || strings.HasSuffix(resp.Header.Get("Content-Disposition"), ".zip") {
 		// Extract ZIP archive
-		return c.extractZipArchive(content, outputDir)
+		if err := c.extractZipArchive(content, outputDir); err != nil {
+			return nil, err
+		}
+		return result, nil
 	}
 
 	// Handle single file response (fallback)
-	filename := fmt.Sprintf("%s-%s.proto", packageName, version)
+	filename := fmt.Sprintf("%s-%s.proto", strings.ReplaceAll(packageName, "/", "-"), result.Version)
 	if disposition := resp.Header.Get("Content-Disposition"); disposition != "" {
 		if parts := strings.Split(disposition, "filename="); len(parts) > 1 {
 			filename = strings.Trim(parts[1], "\"")
@@ -229,10 +475,55 @@ func (c *HTTPClient) PullVersion(packageName, version, outputDir string) error {
 
 	outputFile := filepath.Join(outputDir, filename)
 	if err := os.WriteFile(outputFile, content, 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+		return nil, fmt.Errorf("failed to write file: %w", err)
 	}
 
-	return nil
+	return result, nil
+}
+
+// ResolveVersion returns the concrete version a version query such as latest or ^1.2 refers to
+func (c *HTTPClient) ResolveVersion(packageName, version string) (*Version, error) {
+	url := fmt.Sprintf("%s/api/packages/%s/versions/%s", c.baseURL, escapePackage(packageName), escapeVersion(version))
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to resolve version: %s - %s", resp.Status, string(body))
+	}
+
+	var ver Version
+	if err := json.NewDecoder(resp.Body).Decode(&ver); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &ver, nil
+}
+
+// escapePackage escapes a package name for use as a path segment, scoped names contain a slash
+func escapePackage(name string) string {
+	return url.PathEscape(name)
+}
+
+// escapeVersion escapes a version query for use as a path segment, ranges may contain spaces
+func escapeVersion(version string) string {
+	return url.PathEscape(version)
+}
+
+// resolvedVersion returns the concrete version reported by the registry, falling back to the requested one
+func resolvedVersion(resp *http.Response, requested string) string {
+	if version := resp.Header.Get(VersionHeader); version != "" {
+		return version
+	}
+	return requested
 }
 
 func (c *HTTPClient) extractZipArchive(content []byte, outputDir string) error {
@@ -276,7 +567,7 @@ func (c *HTTPClient) extractZipArchive(content []byte, outputDir string) error {
 }
 
 func (c *HTTPClient) ListVersions(packageName string) ([]*Version, error) {
-	url := fmt.Sprintf("%s/api/packages/%s/versions", c.baseURL, packageName)
+	url := fmt.Sprintf("%s/api/packages/%s/versions", c.baseURL, escapePackage(packageName))
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -300,8 +591,75 @@ func (c *HTTPClient) ListVersions(packageName string) ([]*Version, error) {
 	return versions, nil
 }
 
+// YankVersion marks a version as yanked, it stays downloadable by exact version but is no longer
+// selected by latest or version ranges
+func (c *HTTPClient) YankVersion(packageName, version, reason string) (*Version, error) {
+	query := url.Values{}
+	if reason != "" {
+		query.Set("reason", reason)
+	}
+	reqURL := fmt.Sprintf("%s/api/packages/%s/versions/%s", c.baseURL, escapePackage(packageName), escapeVersion(version))
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+	return c.updateYanked("DELETE", reqURL, "yank")
+}
+
+// UnyankVersion restores a yanked version
+func (c *HTTPClient) UnyankVersion(packageName, version string) (*Version, error) {
+	reqURL := fmt.Sprintf("%s/api/packages/%s/versions/%s/unyank", c.baseURL, escapePackage(packageName), escapeVersion(version))
+	return c.updateYanked("PUT", reqURL, "unyank")
+}
+
+func (c *HTTPClient) updateYanked(method, reqURL, action string) (*Version, error) {
+	req, err := http.NewRequest(method, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("%s failed: %s - %s", action, resp.Status, string(body))
+	}
+
+	var ver Version
+	if err := json.NewDecoder(resp.Body).Decode(&ver); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &ver, nil
+}
+
+// DeleteVersion permanently deletes a version and its files from the registry
+func (c *HTTPClient) DeleteVersion(packageName, version string) error {
+	url := fmt.Sprintf("%s/api/packages/%s/versions/%s?purge=true", c.baseURL, escapePackage(packageName), escapeVersion(version))
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("delete failed: %s - %s", resp.Status, string(body))
+	}
+
+	return nil
+}
+
 func (c *HTTPClient) ViewSchema(packageName, version string) (*SchemaView, error) {
-	url := fmt.Sprintf("%s/api/packages/%s/versions/%s/schema", c.baseURL, packageName, version)
+	url := fmt.Sprintf("%s/api/packages/%s/versions/%s/schema", c.baseURL, escapePackage(packageName), escapeVersion(version))
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -339,7 +697,7 @@ func (c *HTTPClient) GenerateCode(packageName, version, language, outputDir stri
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/api/packages/%s/versions/%s/generate", c.baseURL, packageName, version)
+	url := fmt.Sprintf("%s/api/packages/%s/versions/%s/generate", c.baseURL, escapePackage(packageName), escapeVersion(version))
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -361,6 +719,7 @@ func (c *HTTPClient) GenerateCode(packageName, version, language, outputDir stri
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	result.Version = resolvedVersion(resp, version)
 
 	return &result, nil
 }
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// ListSessions lists the active login sessions of the current user
+func (c *HTTPClient) ListSessions() ([]*Session, error) {
+	url := fmt.Sprintf("%s/api/auth/sessions", c.baseURL)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to list sessions: %s - %s", resp.Status, string(body))
+	}
+
+	var sessions []*Session
+	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (c *HTTPClient) RevokeSession(id string) error {
+	reqURL := fmt.Sprintf("%s/api/auth/sessions/%s", c.baseURL, url.PathEscape(id))
+	req, err := http.NewRequest("DELETE", reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to revoke session: %s - %s", resp.Status, string(body))
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions ends every session of the current user except the one making the request
+// and returns how many were ended
+func (c *HTTPClient) RevokeOtherSessions() (int64, error) {
+	url := fmt.Sprintf("%s/api/auth/sessions", c.baseURL)
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("failed to revoke sessions: %s - %s", resp.Status, string(body))
+	}
+
+	var revoked RevokeSessionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&revoked); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return revoked.Revoked, nil
+}
+
+// endSession ends the session of token on the registry. A token the registry no longer accepts
+// has nothing left to end.
+func (c *HTTPClient) endSession(token string) error {
+	url := fmt.Sprintf("%s/api/auth/logout", c.baseURL)
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusUnauthorized {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to end session: %s - %s", resp.Status, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var (
+	// ErrLoginPending is returned by PollSSOLogin until the user finishes logging in
+	ErrLoginPending = errors.New("login is not finished yet")
+	// ErrSlowDown is returned by PollSSOLogin when the CLI polls too often
+	ErrSlowDown = errors.New("polling too often")
+)
+
+// StartSSOLogin starts a single sign-on login for a device without a browser, such as the CLI
+func (c *HTTPClient) StartSSOLogin() (*DeviceLogin, error) {
+	url := fmt.Sprintf("%s/api/auth/sso/device", c.baseURL)
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to start login: %s - %s", resp.Status, string(body))
+	}
+
+	var login DeviceLogin
+	if err := json.NewDecoder(resp.Body).Decode(&login); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &login, nil
+}
+
+// PollSSOLogin checks whether the user finished a login started with StartSSOLogin. It returns
+// ErrLoginPending or ErrSlowDown while they have not.
+func (c *HTTPClient) PollSSOLogin(deviceCode string) (*LoginResponse, error) {
+	jsonData, err := json.Marshal(DeviceTokenRequest{DeviceCode: deviceCode})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/auth/sso/device/token", c.baseURL)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(body, &errResp)
+		switch errResp.Error {
+		case "authorization_pending":
+			return nil, ErrLoginPending
+		case "slow_down":
+			return nil, ErrSlowDown
+		}
+		return nil, fmt.Errorf("login failed: %s - %s", resp.Status, string(body))
+	}
+
+	var loginResp LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&loginResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &loginResp, nil
+}
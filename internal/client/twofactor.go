@@ -0,0 +1,135 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// CompleteTwoFactorLogin finishes a login that returned a two-factor challenge. code is a code from
+// the authenticator app or a recovery code.
+func (c *HTTPClient) CompleteTwoFactorLogin(challenge, code string) (*LoginResponse, error) {
+	var loginResp LoginResponse
+	if err := c.postTwoFactor("/api/auth/login/2fa", TwoFactorLoginRequest{Challenge: challenge, Code: code}, &loginResp); err != nil {
+		return nil, fmt.Errorf("login failed: %w", err)
+	}
+	return &loginResp, nil
+}
+
+// GetTwoFactorStatus reports whether the current user has two-factor authentication enabled
+func (c *HTTPClient) GetTwoFactorStatus() (*TwoFactorStatus, error) {
+	url := fmt.Sprintf("%s/api/auth/2fa", c.baseURL)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to get two-factor status: %s - %s", resp.Status, string(body))
+	}
+
+	var status TwoFactorStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &status, nil
+}
+
+// SetupTOTP generates a new authenticator secret. It is used once EnableTOTP confirms it.
+func (c *HTTPClient) SetupTOTP() (*TOTPSetup, error) {
+	var setup TOTPSetup
+	if err := c.postTwoFactor("/api/auth/2fa/setup", nil, &setup); err != nil {
+		return nil, fmt.Errorf("failed to set up two-factor authentication: %w", err)
+	}
+	return &setup, nil
+}
+
+// EnableTOTP confirms the secret from SetupTOTP with a code and returns the recovery codes
+func (c *HTTPClient) EnableTOTP(code string) ([]string, error) {
+	var codes RecoveryCodesResponse
+	if err := c.postTwoFactor("/api/auth/2fa/enable", TwoFactorCodeRequest{Code: code}, &codes); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	return codes.RecoveryCodes, nil
+}
+
+// DisableTOTP turns off two-factor authentication after checking a code or recovery code
+func (c *HTTPClient) DisableTOTP(code string) error {
+	if err := c.postTwoFactor("/api/auth/2fa/disable", TwoFactorCodeRequest{Code: code}, nil); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the current user
+func (c *HTTPClient) RegenerateRecoveryCodes(code string) ([]string, error) {
+	var codes RecoveryCodesResponse
+	if err := c.postTwoFactor("/api/auth/2fa/recovery-codes", TwoFactorCodeRequest{Code: code}, &codes); err != nil {
+		return nil, fmt.Errorf("failed to regenerate recovery codes: %w", err)
+	}
+	return codes.RecoveryCodes, nil
+}
+
+// ResetTwoFactor turns off two-factor authentication of a user who lost their authenticator.
+// Only administrators can reset it.
+func (c *HTTPClient) ResetTwoFactor(username string) error {
+	url := fmt.Sprintf("%s/api/admin/users/%s/2fa", c.baseURL, url.PathEscape(username))
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to reset two-factor authentication: %s - %s", resp.Status, string(body))
+	}
+	return nil
+}
+
+// postTwoFactor posts body to one of the two-factor endpoints and decodes the response into out
+func (c *HTTPClient) postTwoFactor(path string, body, out any) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", c.baseURL+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s - %s", resp.Status, string(respBody))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
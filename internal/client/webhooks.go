@@ -0,0 +1,155 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// webhooksURL is where the webhooks of a package are managed, or the registry-wide webhooks when
+// packageName is empty
+func (c *HTTPClient) webhooksURL(packageName string) string {
+	if packageName == "" {
+		return fmt.Sprintf("%s/api/admin/webhooks", c.baseURL)
+	}
+	return fmt.Sprintf("%s/api/packages/%s/webhooks", c.baseURL, escapePackage(packageName))
+}
+
+// ListWebhooks lists the webhooks of a package, or the registry-wide webhooks when packageName is empty
+func (c *HTTPClient) ListWebhooks(packageName string) ([]*Webhook, error) {
+	req, err := http.NewRequest("GET", c.webhooksURL(packageName), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to list webhooks: %s - %s", resp.Status, string(body))
+	}
+
+	var webhooks []*Webhook
+	if err := json.NewDecoder(resp.Body).Decode(&webhooks); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// CreateWebhook registers a webhook for a package, or a registry-wide webhook when packageName is empty
+func (c *HTTPClient) CreateWebhook(packageName string, createReq CreateWebhookRequest) (*CreateWebhookResponse, error) {
+	jsonData, err := json.Marshal(createReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", c.webhooksURL(packageName), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to create webhook: %s - %s", resp.Status, string(body))
+	}
+
+	var created CreateWebhookResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (c *HTTPClient) DeleteWebhook(packageName, id string) error {
+	req, err := http.NewRequest("DELETE", c.webhooksURL(packageName)+"/"+url.PathEscape(id), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete webhook: %s - %s", resp.Status, string(body))
+	}
+
+	return nil
+}
+
+// ListWebhookDeliveries lists the recent deliveries of a webhook, newest first. With failedOnly it
+// only lists the deliveries whose last attempt failed.
+func (c *HTTPClient) ListWebhookDeliveries(packageName, id string, failedOnly bool) ([]*WebhookDelivery, error) {
+	reqURL := c.webhooksURL(packageName) + "/" + url.PathEscape(id) + "/deliveries"
+	if failedOnly {
+		reqURL += "?failed=true"
+	}
+	req, err := http.NewRequest("GET", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to list deliveries: %s - %s", resp.Status, string(body))
+	}
+
+	var deliveries []*WebhookDelivery
+	if err := json.NewDecoder(resp.Body).Decode(&deliveries); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver sends the payload of an earlier delivery to its webhook again, as a new delivery
+func (c *HTTPClient) Redeliver(packageName, id, deliveryID string) (*WebhookDelivery, error) {
+	reqURL := c.webhooksURL(packageName) + "/" + url.PathEscape(id) + "/deliveries/" + url.PathEscape(deliveryID) + "/redeliver"
+	req, err := http.NewRequest("POST", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to redeliver: %s - %s", resp.Status, string(body))
+	}
+
+	var delivery WebhookDelivery
+	if err := json.NewDecoder(resp.Body).Decode(&delivery); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &delivery, nil
+}
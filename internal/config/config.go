@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -28,12 +29,135 @@ type ProtocConfig struct {
 	Version string `yaml:"version"`
 }
 
+// AuthConfig controls how the registry server accepts passwords and handles failed logins
+type AuthConfig struct {
+	PasswordMinLength int `yaml:"password_min_length"`
+	// BreachedPasswordsFile lists the SHA-1 hashes of refused passwords sorted by hash, one per line,
+	// such as the Have I Been Pwned download ordered by hash
+	BreachedPasswordsFile string `yaml:"breached_passwords_file"`
+	// MaxFailedLogins is the number of failed logins for a username, and MaxFailedLoginsPerIP for a
+	// client address, after which further attempts are locked out for LockoutDuration
+	MaxFailedLogins      int           `yaml:"max_failed_logins"`
+	MaxFailedLoginsPerIP int           `yaml:"max_failed_logins_per_ip"`
+	LockoutDuration      time.Duration `yaml:"lockout_duration"`
+	// RequireTwoFactor only lets users with two-factor authentication publish packages
+	RequireTwoFactor bool `yaml:"require_two_factor"`
+	// Provider checks passwords: local (the registry database), htpasswd or ldap
+	Provider     string     `yaml:"provider"`
+	HtpasswdFile string     `yaml:"htpasswd_file"`
+	LDAP         LDAPConfig `yaml:"ldap"`
+}
+
+// LDAPConfig connects the registry server to a directory that checks passwords
+type LDAPConfig struct {
+	// URL of the directory server, for example ldaps://ldap.example.com
+	URL                string `yaml:"url"`
+	StartTLS           bool   `yaml:"start_tls"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+	// BindDN and BindPassword are used to search for users. Without them the search is anonymous.
+	BindDN       string `yaml:"bind_dn"`
+	BindPassword string `yaml:"bind_password"`
+	BaseDN       string `yaml:"base_dn"`
+	// UserFilter finds the entry of a user, with %s replaced by the username
+	UserFilter        string        `yaml:"user_filter"`
+	UsernameAttribute string        `yaml:"username_attribute"`
+	EmailAttribute    string        `yaml:"email_attribute"`
+	Timeout           time.Duration `yaml:"timeout"`
+}
+
+// Registration modes of a registry server
+const (
+	RegistrationOpen   = "open"
+	RegistrationInvite = "invite"
+	RegistrationClosed = "closed"
+)
+
+// RegistrationConfig controls who can create an account on the registry server
+type RegistrationConfig struct {
+	// Mode is open (anyone can register), invite (registering needs an invite from an administrator)
+	// or closed (only administrators create accounts)
+	Mode string `yaml:"mode"`
+	// AllowedEmailDomains, when set, restricts registration to email addresses in these domains
+	AllowedEmailDomains []string `yaml:"allowed_email_domains"`
+}
+
+// SSOConfig connects the registry server to an OpenID Connect identity provider
+type SSOConfig struct {
+	// Name is shown on the login button of the web UI
+	Name         string `yaml:"name"`
+	Issuer       string `yaml:"issuer"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	// RedirectURL is the callback registered with the provider, for example
+	// https://registry.example.com/api/auth/sso/callback. It is derived from the request when empty.
+	RedirectURL   string   `yaml:"redirect_url"`
+	Scopes        []string `yaml:"scopes"`
+	UsernameClaim string   `yaml:"username_claim"`
+	GroupsClaim   string   `yaml:"groups_claim"`
+	// Organizations adds users to organizations based on the groups the provider reports
+	Organizations []SSOOrganization `yaml:"organizations"`
+}
+
+// SSOOrganization makes members of an identity provider group members of an organization
+type SSOOrganization struct {
+	Group        string `yaml:"group"`
+	Organization string `yaml:"organization"`
+	Role         string `yaml:"role"`
+}
+
+// Enabled reports whether single sign-on is configured
+func (c SSOConfig) Enabled() bool {
+	return c.Issuer != "" && c.ClientID != ""
+}
+
+// WebhooksConfig holds the settings of package and registry-wide webhooks
+type WebhooksConfig struct {
+	// AllowPrivateNetworks lets webhooks point at loopback, private and link-local addresses. Any
+	// package owner can add webhooks, so by default they can only reach public addresses.
+	AllowPrivateNetworks bool `yaml:"allow_private_networks"`
+}
+
+// AdmissionHook is called synchronously before a version is published and can reject it
+type AdmissionHook struct {
+	// Name identifies the hook in logs and in the messages shown to the pusher
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Secret signs the requests like webhook payloads, they are not signed when it is empty
+	Secret string `yaml:"secret"`
+	// Packages limits the hook to packages matching these patterns, such as acme/*. The hook is
+	// called for every package when it is empty.
+	Packages []string      `yaml:"packages"`
+	Timeout  time.Duration `yaml:"timeout"`
+	// FailOpen accepts pushes when the hook cannot be reached or answers with an error. By default
+	// such pushes are refused.
+	FailOpen bool `yaml:"fail_open"`
+}
+
+// ServerConfig holds the settings of protodex serve
+type ServerConfig struct {
+	Auth         AuthConfig         `yaml:"auth"`
+	Registration RegistrationConfig `yaml:"registration"`
+	SSO          SSOConfig          `yaml:"sso"`
+	Webhooks     WebhooksConfig     `yaml:"webhooks"`
+	// TrustedProxies are the addresses or CIDR ranges of the reverse proxies in front of the
+	// registry. Only their X-Forwarded-For and X-Real-IP headers are believed, other callers are
+	// identified by the address they connect from.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	// AdmissionHooks are called in order for every push, the first one to reject it wins
+	AdmissionHooks []AdmissionHook `yaml:"admission_hooks"`
+	// AdminUsername is the administrator created on first run when the registry has none.
+	// AdminPassword is only read from the PROTODEX_ADMIN_PASSWORD environment variable.
+	AdminUsername string `yaml:"admin_username"`
+	AdminPassword string `yaml:"-"`
+}
+
 type Config struct {
 	Protoc      ProtocConfig   `yaml:"protoc"`
 	LogLevel    string         `yaml:"log_level"`
 	Registry    string         `yaml:"registry"`
 	HashedToken string         `yaml:"hashed_token"`
 	Database    DatabaseConfig `yaml:"database"`
+	Server      ServerConfig   `yaml:"server"`
 	ConfigPath  string         `yaml:"config_path"`
 }
 
@@ -63,6 +187,7 @@ func Get() *Config {
 				Type: DatabaseTypeSQLite,
 				DSN:  "protodex.db",
 			},
+			Server: DefaultServerConfig(),
 		}
 		_configPath := getConfigPath(configPath)
 		if _, err := os.Stat(_configPath); !os.IsNotExist(err) {
@@ -78,6 +203,34 @@ func Get() *Config {
 	return globalConfig
 }
 
+// DefaultServerConfig returns the server settings used when the config file does not set them
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		Auth: AuthConfig{
+			PasswordMinLength:    8,
+			MaxFailedLogins:      5,
+			MaxFailedLoginsPerIP: 20,
+			LockoutDuration:      15 * time.Minute,
+			Provider:             "local",
+			LDAP: LDAPConfig{
+				UserFilter:        "(uid=%s)",
+				UsernameAttribute: "uid",
+				EmailAttribute:    "mail",
+				Timeout:           10 * time.Second,
+			},
+		},
+		Registration: RegistrationConfig{
+			Mode: RegistrationOpen,
+		},
+		SSO: SSOConfig{
+			Name:          "SSO",
+			Scopes:        []string{"openid", "profile", "email"},
+			UsernameClaim: "preferred_username",
+			GroupsClaim:   "groups",
+		},
+	}
+}
+
 func getConfigPath(override string) string {
 	if override != "" {
 		return override
@@ -115,10 +268,15 @@ func (c *Config) GetRegistryURL(flagRegistry string) string {
 	return c.Registry
 }
 
+// GetToken returns the token to authenticate with. PROTODEX_TOKEN takes precedence over the token
+// saved by login, so CI can use an API token without a config file.
 func (c *Config) GetToken(flagToken string) string {
 	if flagToken != "" {
 		return flagToken
 	}
+	if token := os.Getenv("PROTODEX_TOKEN"); token != "" {
+		return token
+	}
 	return c.HashedToken
 }
 
@@ -0,0 +1,170 @@
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// contextLines is how many unchanged lines surround the changes of a hunk
+const contextLines = 3
+
+type operation int
+
+const (
+	opEqual operation = iota
+	opDelete
+	opInsert
+)
+
+type edit struct {
+	op   operation
+	line string
+}
+
+// Unified returns the line changes between two texts in the unified diff format, with the old text
+// labelled oldName and the new one newName. It returns an empty string when the texts are equal.
+func Unified(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+	edits := lineEdits(splitLines(oldText), splitLines(newText))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	// aLines and bLines count the lines of each text before edit i
+	aLines := make([]int, len(edits)+1)
+	bLines := make([]int, len(edits)+1)
+	for i, e := range edits {
+		aLines[i+1], bLines[i+1] = aLines[i], bLines[i]
+		if e.op != opInsert {
+			aLines[i+1]++
+		}
+		if e.op != opDelete {
+			bLines[i+1]++
+		}
+	}
+
+	for i := 0; i < len(edits); {
+		if edits[i].op == opEqual {
+			i++
+			continue
+		}
+
+		// A hunk runs until the next change is further away than twice the context
+		start := max(i-contextLines, 0)
+		end := i
+		for j := i; j < len(edits) && j-end <= 2*contextLines; j++ {
+			if edits[j].op != opEqual {
+				end = j
+			}
+		}
+		end = min(end+contextLines, len(edits)-1)
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(aLines[start], aLines[end+1]-aLines[start]),
+			hunkRange(bLines[start], bLines[end+1]-bLines[start]))
+		for _, e := range edits[start : end+1] {
+			switch e.op {
+			case opEqual:
+				out.WriteString(" ")
+			case opDelete:
+				out.WriteString("-")
+			case opInsert:
+				out.WriteString("+")
+			}
+			out.WriteString(e.line)
+			out.WriteString("\n")
+		}
+		i = end + 1
+	}
+	return out.String()
+}
+
+// hunkRange formats the lines of a hunk in one text, start counts the lines before the hunk
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// lineEdits finds the shortest sequence of line deletions and insertions turning a into b with the
+// Myers algorithm
+func lineEdits(a, b []string) []edit {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	var trace [][]int
+
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, slices.Clone(v))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, offset)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack walks the furthest reaching paths recorded for every edit distance back from the end of
+// both texts to collect the edits
+func backtrack(trace [][]int, a, b []string, offset int) []edit {
+	var edits []edit
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{op: opEqual, line: a[x-1]})
+			x--
+			y--
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			edits = append(edits, edit{op: opInsert, line: b[y-1]})
+			y--
+		} else {
+			edits = append(edits, edit{op: opDelete, line: a[x-1]})
+			x--
+		}
+	}
+	slices.Reverse(edits)
+	return edits
+}
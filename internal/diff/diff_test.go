@@ -0,0 +1,76 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{
+			name:     "equal texts",
+			old:      "a\nb\n",
+			new:      "a\nb\n",
+			expected: "",
+		},
+		{
+			name: "changed line",
+			old:  "syntax = \"proto3\";\n\nmessage User {\n  string id = 1;\n}\n",
+			new:  "syntax = \"proto3\";\n\nmessage User {\n  string id = 1;\n  string email = 2;\n}\n",
+			expected: "--- a/user.proto\n+++ b/user.proto\n@@ -2,4 +2,5 @@\n \n" +
+				" message User {\n   string id = 1;\n+  string email = 2;\n }\n",
+		},
+		{
+			name: "new file",
+			old:  "",
+			new:  "a\nb\n",
+			expected: `--- a/user.proto
++++ b/user.proto
+@@ -0,0 +1,2 @@
++a
++b
+`,
+		},
+		{
+			name: "removed file",
+			old:  "a\n",
+			new:  "",
+			expected: `--- a/user.proto
++++ b/user.proto
+@@ -1 +0,0 @@
+-a
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Unified("a/user.proto", "b/user.proto", tt.old, tt.new))
+		})
+	}
+}
+
+func TestUnifiedSplitsDistantChanges(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 1; i <= 20; i++ {
+		line := strings.Repeat("x", i)
+		oldLines = append(oldLines, line)
+		if i == 2 || i == 18 {
+			line = "changed"
+		}
+		newLines = append(newLines, line)
+	}
+
+	unified := Unified("old", "new", strings.Join(oldLines, "\n"), strings.Join(newLines, "\n"))
+	assert.Equal(t, 2, strings.Count(unified, "@@ -"))
+	assert.Contains(t, unified, "@@ -1,5 +1,5 @@\n x\n-xx\n+changed\n xxx\n")
+	assert.Contains(t, unified, "@@ -15,6 +15,6 @@\n")
+	assert.True(t, strings.HasSuffix(unified, "-"+strings.Repeat("x", 18)+"\n+changed\n "+strings.Repeat("x", 19)+"\n "+strings.Repeat("x", 20)+"\n"))
+}
@@ -2,6 +2,7 @@ package dependency
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -14,6 +15,7 @@ import (
 type Resolver struct {
 	outputPath string
 	client     *http.Client
+	output     io.Writer
 }
 
 func NewResolver() (*Resolver, error) {
@@ -28,6 +30,18 @@ func NewResolver() (*Resolver, error) {
 	}, nil
 }
 
+// SetOutput sets where progress messages go, standard output by default
+func (dc *Resolver) SetOutput(w io.Writer) {
+	dc.output = w
+}
+
+func (dc *Resolver) out() io.Writer {
+	if dc.output == nil {
+		return os.Stdout
+	}
+	return dc.output
+}
+
 func (dc *Resolver) ResolveDependencies(deps []Config) error {
 	for _, dep := range deps {
 		err := dc.resolveDependency(dep)
@@ -54,6 +68,7 @@ func (dc *Resolver) _resolveDependency(dep Config) error {
 	if err != nil {
 		return fmt.Errorf("failed to create fetcher: %w", err)
 	}
+	fch.Output = dc.output
 	return fch.Fetch()
 }
 
@@ -19,7 +19,7 @@ func (dc *Resolver) resolveGoogleWellKnownProtos(dep Config) error {
 		return nil
 	}
 
-	fmt.Printf("Downloading %s from GitHub...\n", dep.Name)
+	fmt.Fprintf(dc.out(), "Downloading %s from GitHub...\n", dep.Name)
 
 	version := dep.Version
 	if version == "" {
@@ -32,7 +32,7 @@ func (dc *Resolver) resolveGoogleWellKnownProtos(dep Config) error {
 		version, versionWithoutPrefix,
 	)
 
-	fmt.Printf("Downloading from %s...\n", url)
+	fmt.Fprintf(dc.out(), "Downloading from %s...\n", url)
 	resp, err := http.Get(url)
 	if err != nil {
 		return fmt.Errorf("failed to download includes: %w", err)
@@ -55,9 +55,11 @@ func (dc *Resolver) resolveGoogleWellKnownProtos(dep Config) error {
 	}
 	tempFile.Close()
 
-	if err := extractIncludes(tempFile.Name(), targetDir); err != nil {
+	extracted, err := extractIncludes(tempFile.Name(), targetDir)
+	if err != nil {
 		return err
 	}
+	fmt.Fprintf(dc.out(), "Extracted %d include files\n", extracted)
 
 	// Create marker file to indicate completion
 	markerFile := filepath.Join(targetDir, ".protodex-complete")
@@ -65,14 +67,15 @@ func (dc *Resolver) resolveGoogleWellKnownProtos(dep Config) error {
 		return err
 	}
 
-	fmt.Printf("Protobuf includes cached to %s\n", targetDir)
+	fmt.Fprintf(dc.out(), "Protobuf includes cached to %s\n", targetDir)
 	return nil
 }
 
-func extractIncludes(zipPath, targetDir string) error {
+// extractIncludes extracts the include files of a protoc release and returns how many there were
+func extractIncludes(zipPath, targetDir string) (int, error) {
 	reader, err := zip.OpenReader(zipPath)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer reader.Close()
 
@@ -95,17 +98,15 @@ func extractIncludes(zipPath, targetDir string) error {
 
 		// Create directory if needed
 		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-			return err
+			return 0, err
 		}
 		if err := extractFile(file, targetPath); err != nil {
-			return err
+			return 0, err
 		}
 
 		extracted++
 	}
-
-	fmt.Printf("Extracted %d include files\n", extracted)
-	return nil
+	return extracted, nil
 }
 
 func extractFile(file *zip.File, targetPath string) error {
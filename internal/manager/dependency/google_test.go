@@ -99,7 +99,7 @@ func TestExtractIncludes(t *testing.T) {
 
 	createTestIncludeZip(t, zipPath)
 
-	err := extractIncludes(zipPath, targetDir)
+	_, err := extractIncludes(zipPath, targetDir)
 	require.NoError(t, err)
 
 	// Check if files were extracted correctly
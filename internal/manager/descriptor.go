@@ -0,0 +1,52 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/sirrobot01/protodex/internal/breaking"
+)
+
+// DescriptorSet compiles all proto files of the project into a FileDescriptorSet
+func (m *Manager) DescriptorSet() (*descriptorpb.FileDescriptorSet, error) {
+	protoFiles, err := m.GetProtoFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proto files: %w", err)
+	}
+	return m.BuildDescriptorSet(protoFiles)
+}
+
+// BuildDescriptorSet compiles the given proto files into a FileDescriptorSet.
+// Only the project files are included, imports are resolved but not emitted.
+func (m *Manager) BuildDescriptorSet(protoFiles []string) (*descriptorpb.FileDescriptorSet, error) {
+	if len(protoFiles) == 0 {
+		return nil, fmt.Errorf("no proto files to compile")
+	}
+	if err := m.ResolveDependencies(); err != nil {
+		return nil, fmt.Errorf("failed to get import paths: %w", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "protodex-descriptor-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func(path string) {
+		if err := os.RemoveAll(path); err != nil {
+			m.logger.Warn().Err(err).Str("path", path).Msg("failed to remove temp directory")
+		}
+	}(tempDir)
+
+	outPath := filepath.Join(tempDir, "descriptor.pb")
+	args := []string{
+		fmt.Sprintf("--descriptor_set_out=%s", outPath),
+		fmt.Sprintf("--proto_path=%s", m.projectPath),
+	}
+	if err := m.executor.Run(protoFiles, args...); err != nil {
+		return nil, fmt.Errorf("failed to compile proto files: %w", err)
+	}
+
+	return breaking.LoadDescriptorSet(outPath)
+}
@@ -23,7 +23,9 @@ type Fetcher struct {
 	Source     string
 	Version    string
 	Dest       string
-	client     *http.Client
+	// Output receives progress messages and warnings, standard output when nil
+	Output io.Writer
+	client *http.Client
 }
 
 func NewFetcherFromURL(sourceURL, dest string) (*Fetcher, error) {
@@ -47,6 +49,13 @@ func NewFetcher(sourceType SourceType, source, version, dest string) (*Fetcher,
 	}, nil
 }
 
+func (f *Fetcher) out() io.Writer {
+	if f.Output == nil {
+		return os.Stdout
+	}
+	return f.Output
+}
+
 func (f *Fetcher) Fetch() error {
 	switch f.SourceType {
 	case SourceLocal:
@@ -98,7 +107,21 @@ func (f *Fetcher) protodexFetch() error {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
-	return c.PullVersion(f.Source, f.Version, f.Dest)
+	result, err := c.PullVersion(f.Source, f.Version, f.Dest)
+	if err != nil {
+		return err
+	}
+	if result.Version != f.Version {
+		fmt.Fprintf(f.out(), "Resolved %s@%s to %s\n", f.Source, f.Version, result.Version)
+	}
+	if result.Yanked {
+		fmt.Fprintf(f.out(), "Warning: %s@%s has been yanked", f.Source, result.Version)
+		if result.YankReason != "" {
+			fmt.Fprintf(f.out(), ": %s", result.YankReason)
+		}
+		fmt.Fprintln(f.out())
+	}
+	return nil
 }
 
 func (f *Fetcher) urlFetch() error {
@@ -112,10 +135,10 @@ func (f *Fetcher) urlFetch() error {
 	}
 	// Let's check if it has been fetched already
 	if isFetched(f.Dest) {
-		fmt.Printf("Source already fetched to %s\n", f.Dest)
+		fmt.Fprintf(f.out(), "Source already fetched to %s\n", f.Dest)
 		return nil
 	}
-	fmt.Printf("Downloading from %s...\n", f.Source)
+	fmt.Fprintf(f.out(), "Downloading from %s...\n", f.Source)
 
 	if err := f.downloadAndExtract(); err != nil {
 		return fmt.Errorf("failed to fetch from URL: %w", err)
@@ -139,7 +162,7 @@ func (f *Fetcher) githubFetch() error {
 	}
 
 	if isFetched(f.Dest) {
-		fmt.Printf("Source already fetched to %s\n", f.Dest)
+		fmt.Fprintf(f.out(), "Source already fetched to %s\n", f.Dest)
 	}
 
 	// Now let's add github.com/ prefix if missing
@@ -187,7 +210,7 @@ func (f *Fetcher) download(url string) (string, error) {
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
 		if err != nil {
-			fmt.Printf("Warning: failed to close response body: %v\n", err)
+			fmt.Fprintf(f.out(), "Warning: failed to close response body: %v\n", err)
 		}
 	}(resp.Body)
 
@@ -218,20 +241,20 @@ func (f *Fetcher) markAsFetched() error {
 	}
 	defer func() {
 		if err := markerFile.Close(); err != nil {
-			fmt.Printf("Warning: failed to close marker file: %v\n", err)
+			fmt.Fprintf(f.out(), "Warning: failed to close marker file: %v\n", err)
 		}
 	}()
 	return nil
 }
 
-func extractZip(zipPath, destPath string) error {
+func (f *Fetcher) extractZip(zipPath, destPath string) error {
 	reader, err := zip.OpenReader(zipPath)
 	if err != nil {
 		return err
 	}
 	defer func(reader *zip.ReadCloser) {
 		if err := reader.Close(); err != nil {
-			fmt.Printf("Warning: failed to close zip reader: %v\n", err)
+			fmt.Fprintf(f.out(), "Warning: failed to close zip reader: %v\n", err)
 		}
 	}(reader)
 
@@ -350,7 +350,7 @@ func TestExtractZip(t *testing.T) {
 
 	// Test extraction
 	extractDir := filepath.Join(tempDir, "extract")
-	err = extractZip(zipPath, extractDir)
+	err = (&Fetcher{Output: io.Discard}).extractZip(zipPath, extractDir)
 	require.NoError(t, err)
 
 	// Check if file was extracted
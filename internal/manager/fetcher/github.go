@@ -10,7 +10,7 @@ import (
 )
 
 func (f *Fetcher) downloadAndExtractGithub(githubURL, branch string) error {
-	fmt.Printf("Downloading %s from GitHub...\n", githubURL)
+	fmt.Fprintf(f.out(), "Downloading %s from GitHub...\n", githubURL)
 
 	// Stip protocol if present
 	githubURL = strings.TrimPrefix(githubURL, "https://")
@@ -61,7 +61,7 @@ func (f *Fetcher) downloadAndExtractGithub(githubURL, branch string) error {
 		url = fmt.Sprintf("https://github.com/%s/%s/archive/refs/heads/%s.zip", owner, repo, downloadVersion)
 	}
 
-	fmt.Printf("Downloading from %s...\n", url)
+	fmt.Fprintf(f.out(), "Downloading from %s...\n", url)
 
 	zipPath, err := f.download(url)
 	if err != nil {
@@ -70,18 +70,18 @@ func (f *Fetcher) downloadAndExtractGithub(githubURL, branch string) error {
 
 	defer func() {
 		if err := os.Remove(zipPath); err != nil {
-			fmt.Printf("Warning: failed to remove temp file: %v\n", err)
+			fmt.Fprintf(f.out(), "Warning: failed to remove temp file: %v\n", err)
 		}
 	}()
 
 	// Extract zip
-	if err := extractGitHubZip(zipPath, f.Dest, subDir); err != nil {
+	if err := f.extractGitHubZip(zipPath, f.Dest, subDir); err != nil {
 		return err
 	}
 	return nil
 }
 
-func extractGitHubZip(zipPath, targetDir, subDir string) error {
+func (f *Fetcher) extractGitHubZip(zipPath, targetDir, subDir string) error {
 	reader, err := zip.OpenReader(zipPath)
 	if err != nil {
 		return err
@@ -89,7 +89,7 @@ func extractGitHubZip(zipPath, targetDir, subDir string) error {
 	defer func(reader *zip.ReadCloser) {
 		err := reader.Close()
 		if err != nil {
-			fmt.Printf("Warning: failed to close zip reader: %v\n", err)
+			fmt.Fprintf(f.out(), "Warning: failed to close zip reader: %v\n", err)
 		}
 	}(reader)
 
@@ -137,17 +137,17 @@ func extractGitHubZip(zipPath, targetDir, subDir string) error {
 		}
 
 		// Extract file
-		if err := extractFile(file, targetPath); err != nil {
+		if err := f.extractFile(file, targetPath); err != nil {
 			return fmt.Errorf("failed to extract %s: %w", relativePath, err)
 		}
 		extracted++
 	}
 
-	fmt.Printf("Extracted %d files from GitHub repository\n", extracted)
+	fmt.Fprintf(f.out(), "Extracted %d files from GitHub repository\n", extracted)
 	return nil
 }
 
-func extractFile(file *zip.File, targetPath string) error {
+func (f *Fetcher) extractFile(file *zip.File, targetPath string) error {
 	rc, err := file.Open()
 	if err != nil {
 		return err
@@ -155,7 +155,7 @@ func extractFile(file *zip.File, targetPath string) error {
 	defer func(rc io.ReadCloser) {
 		err := rc.Close()
 		if err != nil {
-			fmt.Printf("Warning: failed to close file reader: %v\n", err)
+			fmt.Fprintf(f.out(), "Warning: failed to close file reader: %v\n", err)
 		}
 	}(rc)
 
@@ -166,7 +166,7 @@ func extractFile(file *zip.File, targetPath string) error {
 	defer func(outFile *os.File) {
 		err := outFile.Close()
 		if err != nil {
-			fmt.Printf("Warning: failed to close output file: %v\n", err)
+			fmt.Fprintf(f.out(), "Warning: failed to close output file: %v\n", err)
 		}
 	}(outFile)
 
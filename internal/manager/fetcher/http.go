@@ -12,11 +12,11 @@ func (f *Fetcher) downloadAndExtract() error {
 	}
 	defer func() {
 		if err := os.Remove(zipPath); err != nil {
-			fmt.Printf("Warning: failed to remove temp file: %v\n", err)
+			fmt.Fprintf(f.out(), "Warning: failed to remove temp file: %v\n", err)
 		}
 	}()
 	// Extract zip
-	if err := extractZip(zipPath, f.Dest); err != nil {
+	if err := f.extractZip(zipPath, f.Dest); err != nil {
 		return fmt.Errorf("failed to extract zip: %w", err)
 	}
 
@@ -84,6 +84,26 @@ func TestParseSource(t *testing.T) {
 				Raw:     "protodex://my-service@v2.1.0",
 			},
 		},
+		{
+			name:  "protodex with version range",
+			input: "protodex://my-service@^1.2",
+			expected: &SourceInfo{
+				Type:    SourceProtodex,
+				Source:  "my-service",
+				Version: "^1.2",
+				Raw:     "protodex://my-service@^1.2",
+			},
+		},
+		{
+			name:  "protodex with label",
+			input: "protodex://my-service@prod",
+			expected: &SourceInfo{
+				Type:    SourceProtodex,
+				Source:  "my-service",
+				Version: "prod",
+				Raw:     "protodex://my-service@prod",
+			},
+		},
 		{
 			name:  "protodex with default version",
 			input: "protodex://my-service",
@@ -94,6 +114,16 @@ func TestParseSource(t *testing.T) {
 				Raw:     "protodex://my-service",
 			},
 		},
+		{
+			name:  "protodex scoped package",
+			input: "protodex://acme/payments@v1.0.0",
+			expected: &SourceInfo{
+				Type:    SourceProtodex,
+				Source:  "acme/payments",
+				Version: "v1.0.0",
+				Raw:     "protodex://acme/payments@v1.0.0",
+			},
+		},
 		{
 			name:  "http url",
 			input: "https://example.com/schemas.zip",
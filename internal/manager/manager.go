@@ -2,6 +2,7 @@ package manager
 
 import (
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -50,6 +51,13 @@ func NewManager(projectPath string) (*Manager, error) {
 	return m, nil
 }
 
+// SetOutput sets where progress messages of dependency downloads and the output of protoc go,
+// standard output by default
+func (m *Manager) SetOutput(w io.Writer) {
+	m.resolver.SetOutput(w)
+	m.executor.SetOutput(w)
+}
+
 func (m *Manager) Config() *ProjectConfig {
 	return m.config
 }
@@ -164,15 +172,22 @@ func (m *Manager) Generate(protoFiles []string, options LanguageConfig) error {
 }
 
 func (m *Manager) load() (*ProjectConfig, error) {
-	projectName := filepath.Base(m.projectPath)
+	return LoadProjectConfig(m.projectPath)
+}
+
+// LoadProjectConfig reads the protodex.yaml of a project, or returns the default config when the
+// project has none. Unlike NewManager it sets up nothing to resolve dependencies with.
+func LoadProjectConfig(projectPath string) (*ProjectConfig, error) {
+	projectName := filepath.Base(projectPath)
 	projectDescription := fmt.Sprintf("Protodex project %s", projectName)
 	projectCfg := NewDefaultConfig(projectName, projectDescription)
 
-	if _, err := os.Stat(m.configFile); os.IsNotExist(err) {
+	configFile := filepath.Join(projectPath, ProjectConfigFileName)
+	if _, err := os.Stat(configFile); os.IsNotExist(err) {
 		return projectCfg, nil
 	}
 
-	data, err := os.ReadFile(m.configFile)
+	data, err := os.ReadFile(configFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read project config: %w", err)
 	}
@@ -2,6 +2,7 @@ package protoc
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -11,6 +12,7 @@ type Executor struct {
 	version    string
 	protocPath string
 	depsPath   string
+	output     io.Writer
 }
 
 // NewExecutor creates a new Executor instance.
@@ -23,6 +25,19 @@ func NewExecutor(protocPath, version, depsPath string) *Executor {
 	}
 }
 
+// SetOutput sets where the output of protoc and progress messages go, standard output by default.
+// Errors reported by protoc always go to standard error.
+func (e *Executor) SetOutput(w io.Writer) {
+	e.output = w
+}
+
+func (e *Executor) out() io.Writer {
+	if e.output == nil {
+		return os.Stdout
+	}
+	return e.output
+}
+
 func (e *Executor) ensureProtoc() error {
 	if e.protocPath == "" {
 		return fmt.Errorf("protoc binary path is not set")
@@ -31,7 +46,7 @@ func (e *Executor) ensureProtoc() error {
 	// LookPath checks existence AND executability
 	if _, err := exec.LookPath(e.protocPath); err != nil {
 		// Try to download if it doesn't exist or isn't executable
-		fmt.Printf("protoc binary not found in %s, downloading...", filepath.Dir(e.protocPath))
+		fmt.Fprintf(e.out(), "protoc binary not found in %s, downloading...", filepath.Dir(e.protocPath))
 		if downloadErr := e.downloadProtoc(); downloadErr != nil {
 			return fmt.Errorf("protoc not found and download failed: %w", downloadErr)
 		}
@@ -62,7 +77,7 @@ func (e *Executor) Run(protoFiles []string, args ...string) error {
 	protocArgs = append(protocArgs, protoFiles...)
 
 	cmd := exec.Command(e.protocPath, protocArgs...)
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = e.out()
 	cmd.Stderr = os.Stderr
 
 	return cmd.Run()
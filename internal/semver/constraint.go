@@ -0,0 +1,295 @@
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Latest resolves to the version with the highest precedence, including pre-releases
+	Latest = "latest"
+	// LatestStable resolves to the highest release that is not a pre-release
+	LatestStable = "latest-stable"
+)
+
+// ErrNoMatch is returned by Resolve when no version satisfies the query
+var ErrNoMatch = errors.New("no matching version")
+
+// Constraint is a version range such as ^1.2, ~1.4.0, >=2.0 <3 or 1.x || 2.x.
+// Comparators separated by whitespace or commas must all match; ranges separated by || are alternatives.
+type Constraint struct {
+	raw  string
+	sets [][]comparator
+}
+
+type comparator struct {
+	op      string
+	version Version
+}
+
+// ParseConstraint parses a version range
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{raw: s}
+	for _, alternative := range strings.Split(s, "||") {
+		tokens := strings.Fields(strings.ReplaceAll(alternative, ",", " "))
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("invalid version range %q: empty range", s)
+		}
+
+		var set []comparator
+		for i := 0; i < len(tokens); i++ {
+			token := tokens[i]
+			// Allow a space between the operator and the version, as in ">= 2.0"
+			if isOperator(token) && i+1 < len(tokens) {
+				i++
+				token += tokens[i]
+			}
+			comparators, err := expand(token)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version range %q: %w", s, err)
+			}
+			set = append(set, comparators...)
+		}
+		c.sets = append(c.sets, set)
+	}
+	return c, nil
+}
+
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// Check reports whether v satisfies the constraint. Pre-releases only match when a comparator
+// of the same range names a pre-release of the same MAJOR.MINOR.PATCH, so ^1.2 does not select 1.3.0-rc.1.
+func (c *Constraint) Check(v Version) bool {
+	for _, set := range c.sets {
+		if matchSet(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSet(set []comparator, v Version) bool {
+	for _, comp := range set {
+		if !comp.match(v) {
+			return false
+		}
+	}
+	if !v.IsPrerelease() {
+		return true
+	}
+	for _, comp := range set {
+		cv := comp.version
+		if cv.IsPrerelease() && cv.Major == v.Major && cv.Minor == v.Minor && cv.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+func (comp comparator) match(v Version) bool {
+	cmp := Compare(v, comp.version)
+	switch comp.op {
+	case "=":
+		return cmp == 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+var operators = []string{">=", "<=", ">", "<", "=", "^", "~"}
+
+func isOperator(s string) bool {
+	for _, op := range operators {
+		if s == op {
+			return true
+		}
+	}
+	return false
+}
+
+// expand turns a single range term into primitive comparators
+func expand(term string) ([]comparator, error) {
+	op := ""
+	for _, candidate := range operators {
+		if strings.HasPrefix(term, candidate) {
+			op = candidate
+			break
+		}
+	}
+	rest := strings.TrimPrefix(term, op)
+	if rest == "" {
+		return nil, fmt.Errorf("missing version after %q", op)
+	}
+	v, parts, err := parsePartial(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	anyVersion := []comparator{{op: ">=", version: Version{}}}
+	noVersion := []comparator{{op: "<", version: Version{}}}
+	switch op {
+	case "", "=":
+		switch parts {
+		case 0:
+			return anyVersion, nil
+		case 3:
+			return []comparator{{op: "=", version: v}}, nil
+		}
+		return between(v, upperBound(v, parts)), nil
+	case "^":
+		if parts == 0 {
+			return anyVersion, nil
+		}
+		switch {
+		case v.Major > 0 || parts == 1:
+			return between(v, Version{Major: v.Major + 1}), nil
+		case v.Minor > 0 || parts == 2:
+			return between(v, Version{Minor: v.Minor + 1}), nil
+		}
+		return between(v, Version{Patch: v.Patch + 1}), nil
+	case "~":
+		if parts == 0 {
+			return anyVersion, nil
+		}
+		if parts == 1 {
+			return between(v, Version{Major: v.Major + 1}), nil
+		}
+		return between(v, Version{Major: v.Major, Minor: v.Minor + 1}), nil
+	case ">=":
+		return []comparator{{op: ">=", version: v}}, nil
+	case ">":
+		switch parts {
+		case 0:
+			return noVersion, nil
+		case 3:
+			return []comparator{{op: ">", version: v}}, nil
+		}
+		return []comparator{{op: ">=", version: upperBound(v, parts)}}, nil
+	case "<":
+		if parts == 0 {
+			return noVersion, nil
+		}
+		return []comparator{{op: "<", version: v}}, nil
+	case "<=":
+		switch parts {
+		case 0:
+			return anyVersion, nil
+		case 3:
+			return []comparator{{op: "<=", version: v}}, nil
+		}
+		return []comparator{{op: "<", version: upperBound(v, parts)}}, nil
+	}
+	return nil, fmt.Errorf("unknown operator in %q", term)
+}
+
+func between(lower, upper Version) []comparator {
+	return []comparator{{op: ">=", version: lower}, {op: "<", version: upper}}
+}
+
+// upperBound returns the first version outside a partial version such as 1 or 1.2
+func upperBound(v Version, parts int) Version {
+	if parts == 1 {
+		return Version{Major: v.Major + 1}
+	}
+	return Version{Major: v.Major, Minor: v.Minor + 1}
+}
+
+// parsePartial parses versions such as 1, 1.2, v1.2.3-rc.1 or 1.x, returning the number of
+// components given. Missing components are zero.
+func parsePartial(s string) (Version, int, error) {
+	s = strings.TrimPrefix(s, "v")
+	if s == "*" || s == "x" || s == "X" {
+		return Version{}, 0, nil
+	}
+
+	core := s
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		core = s[:i]
+	}
+	fields := strings.Split(core, ".")
+	if len(fields) > 3 {
+		return Version{}, 0, fmt.Errorf("bad version %q", s)
+	}
+
+	var nums [3]uint64
+	parts := 0
+	for _, field := range fields {
+		if field == "x" || field == "X" || field == "*" {
+			break
+		}
+		n, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return Version{}, 0, fmt.Errorf("bad version %q", s)
+		}
+		nums[parts] = n
+		parts++
+	}
+
+	if parts == 3 {
+		v, err := Parse(s)
+		if err != nil {
+			return Version{}, 0, err
+		}
+		v.Original = ""
+		return v, 3, nil
+	}
+	if core != s {
+		return Version{}, 0, fmt.Errorf("bad version %q: pre-release requires MAJOR.MINOR.PATCH", s)
+	}
+	return Version{Major: nums[0], Minor: nums[1], Patch: nums[2]}, parts, nil
+}
+
+// Resolve selects the version matching query from the available versions. The query may be an exact
+// version name, latest, latest-stable or a version range. Ranges select the highest matching version.
+func Resolve(query string, versions []string) (string, error) {
+	for _, v := range versions {
+		if v == query {
+			return v, nil
+		}
+	}
+
+	sorted := append([]string(nil), versions...)
+	Sort(sorted)
+
+	switch query {
+	case Latest:
+		if len(sorted) == 0 {
+			return "", ErrNoMatch
+		}
+		return sorted[0], nil
+	case LatestStable:
+		for _, v := range sorted {
+			if parsed, err := Parse(v); err == nil && !parsed.IsPrerelease() {
+				return v, nil
+			}
+		}
+		return "", ErrNoMatch
+	}
+
+	constraint, err := ParseConstraint(query)
+	if err != nil {
+		return "", err
+	}
+	for _, v := range sorted {
+		parsed, err := Parse(v)
+		if err != nil {
+			// Sorted versions list semantic versions first, nothing further can match
+			break
+		}
+		if constraint.Check(parsed) {
+			return v, nil
+		}
+	}
+	return "", ErrNoMatch
+}
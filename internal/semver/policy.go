@@ -0,0 +1,39 @@
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Policy controls how the registry reacts when a pushed version does not reflect the schema diff
+type Policy string
+
+const (
+	// PolicyOff disables bump checks
+	PolicyOff Policy = "off"
+	// PolicySuggest accepts the push and returns a warning with the suggested version
+	PolicySuggest Policy = "suggest"
+	// PolicyRequire rejects pushes whose version bump is smaller than the schema diff requires
+	PolicyRequire Policy = "require"
+
+	DefaultPolicy = PolicySuggest
+)
+
+var policies = []Policy{PolicyOff, PolicySuggest, PolicyRequire}
+
+func Policies() []Policy {
+	return policies
+}
+
+func ParsePolicy(s string) (Policy, error) {
+	if s == "" {
+		return DefaultPolicy, nil
+	}
+	policy := Policy(strings.ToLower(s))
+	for _, p := range policies {
+		if p == policy {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown bump policy %q", s)
+}
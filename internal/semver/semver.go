@@ -0,0 +1,264 @@
+package semver
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed Semantic Versioning 2.0.0 version. A leading "v" is accepted and
+// preserved in Original so versions round-trip in the form they were pushed.
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+	Build      string
+	Original   string
+}
+
+// Bump is the kind of version increment between two releases
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	}
+	return "none"
+}
+
+// Parse parses a version such as 1.2.3, v1.2.3-rc.1 or 1.2.3+build.5
+func Parse(s string) (Version, error) {
+	v := Version{Original: s}
+	rest := strings.TrimPrefix(s, "v")
+
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		v.Build = rest[i+1:]
+		rest = rest[:i]
+		if !validIdentifiers(v.Build, false) {
+			return Version{}, fmt.Errorf("invalid semantic version %q: bad build metadata", s)
+		}
+	}
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		pre := rest[i+1:]
+		rest = rest[:i]
+		if !validIdentifiers(pre, true) {
+			return Version{}, fmt.Errorf("invalid semantic version %q: bad pre-release", s)
+		}
+		v.Prerelease = strings.Split(pre, ".")
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid semantic version %q: expected MAJOR.MINOR.PATCH", s)
+	}
+	nums := make([]uint64, 3)
+	for i, part := range parts {
+		if !isNumeric(part) || (len(part) > 1 && part[0] == '0') {
+			return Version{}, fmt.Errorf("invalid semantic version %q: bad number %q", s, part)
+		}
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid semantic version %q: %w", s, err)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+// IsValid reports whether s is a semantic version
+func IsValid(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+func (v Version) String() string {
+	if v.Original != "" {
+		return v.Original
+	}
+	return v.format("")
+}
+
+// IsPrerelease reports whether the version carries a pre-release suffix
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Next returns the release that follows v for the given bump, keeping the "v" prefix style of v.
+// Pre-release and build metadata are dropped.
+func (v Version) Next(b Bump) Version {
+	next := Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	switch b {
+	case BumpMajor:
+		next.Major++
+		next.Minor, next.Patch = 0, 0
+	case BumpMinor:
+		next.Minor++
+		next.Patch = 0
+	case BumpPatch:
+		// A pre-release of x.y.z is followed by the x.y.z release itself
+		if !v.IsPrerelease() {
+			next.Patch++
+		}
+	}
+	prefix := ""
+	if strings.HasPrefix(v.Original, "v") {
+		prefix = "v"
+	}
+	next.Original = next.format(prefix)
+	return next
+}
+
+func (v Version) format(prefix string) string {
+	s := fmt.Sprintf("%s%d.%d.%d", prefix, v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 depending on the precedence of a relative to b.
+// Build metadata does not affect precedence.
+func Compare(a, b Version) int {
+	if c := compareInt(a.Major, b.Major); c != 0 {
+		return c
+	}
+	if c := compareInt(a.Minor, b.Minor); c != 0 {
+		return c
+	}
+	if c := compareInt(a.Patch, b.Patch); c != 0 {
+		return c
+	}
+
+	// A release has higher precedence than its pre-releases
+	switch {
+	case len(a.Prerelease) == 0 && len(b.Prerelease) == 0:
+		return 0
+	case len(a.Prerelease) == 0:
+		return 1
+	case len(b.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a.Prerelease) && i < len(b.Prerelease); i++ {
+		if c := compareIdentifier(a.Prerelease[i], b.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInt(uint64(len(a.Prerelease)), uint64(len(b.Prerelease)))
+}
+
+// Change returns the bump that leads from one release to another, or BumpNone if to
+// does not have higher precedence than from.
+func Change(from, to Version) Bump {
+	switch {
+	case Compare(to, from) <= 0:
+		return BumpNone
+	case to.Major != from.Major:
+		return BumpMajor
+	case to.Minor != from.Minor:
+		return BumpMinor
+	}
+	return BumpPatch
+}
+
+// Sort orders version strings from highest to lowest precedence. Strings that are not
+// semantic versions are placed after all semantic versions, keeping their relative order.
+func Sort(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return Less(versions[j], versions[i])
+	})
+}
+
+// Less reports whether version string a has lower precedence than b. Strings that are not
+// semantic versions have lower precedence than any semantic version and are not ordered
+// among themselves.
+func Less(a, b string) bool {
+	va, errA := Parse(a)
+	vb, errB := Parse(b)
+	switch {
+	case errA != nil:
+		return errB == nil
+	case errB != nil:
+		return false
+	}
+	return Compare(va, vb) < 0
+}
+
+func compareInt(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareIdentifier compares pre-release identifiers: numeric identifiers compare numerically
+// and have lower precedence than alphanumeric ones, which compare lexically
+func compareIdentifier(a, b string) int {
+	aNum, bNum := isNumeric(a), isNumeric(b)
+	switch {
+	case aNum && bNum:
+		if len(a) != len(b) {
+			return compareInt(uint64(len(a)), uint64(len(b)))
+		}
+		return strings.Compare(a, b)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func validIdentifiers(s string, prerelease bool) bool {
+	if s == "" {
+		return false
+	}
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for _, r := range id {
+			if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+				return false
+			}
+		}
+		// Numeric pre-release identifiers must not have leading zeros
+		if prerelease && isNumeric(id) && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
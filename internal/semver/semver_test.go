@@ -0,0 +1,183 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    Version
+		expectError bool
+	}{
+		{input: "1.2.3", expected: Version{Major: 1, Minor: 2, Patch: 3, Original: "1.2.3"}},
+		{input: "v1.2.3", expected: Version{Major: 1, Minor: 2, Patch: 3, Original: "v1.2.3"}},
+		{input: "1.0.0-rc.1", expected: Version{Major: 1, Prerelease: []string{"rc", "1"}, Original: "1.0.0-rc.1"}},
+		{input: "1.0.0-alpha+build.5", expected: Version{Major: 1, Prerelease: []string{"alpha"}, Build: "build.5", Original: "1.0.0-alpha+build.5"}},
+		{input: "1.2", expectError: true},
+		{input: "01.2.3", expectError: true},
+		{input: "1.2.3-01", expectError: true},
+		{input: "1.2.3-", expectError: true},
+		{input: "1.2.3-rc..1", expectError: true},
+		{input: "latest", expectError: true},
+		{input: "", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			v, err := Parse(tt.input)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, v)
+		})
+	}
+}
+
+func TestSortPrecedence(t *testing.T) {
+	versions := []string{
+		"1.0.0-alpha.beta", "1.0.0", "latest", "1.0.0-rc.1", "v2.0.0", "1.0.0-alpha",
+		"1.0.0-beta.11", "1.0.0-alpha.1", "1.0.0-beta", "1.0.0-beta.2", "0.9.12", "dev",
+	}
+	Sort(versions)
+	assert.Equal(t, []string{
+		"v2.0.0", "1.0.0", "1.0.0-rc.1", "1.0.0-beta.11", "1.0.0-beta.2", "1.0.0-beta",
+		"1.0.0-alpha.beta", "1.0.0-alpha.1", "1.0.0-alpha", "0.9.12", "latest", "dev",
+	}, versions)
+}
+
+func TestCompareIgnoresBuild(t *testing.T) {
+	a, _ := Parse("1.2.3+one")
+	b, _ := Parse("1.2.3+two")
+	assert.Equal(t, 0, Compare(a, b))
+}
+
+func TestChangeAndNext(t *testing.T) {
+	tests := []struct {
+		from     string
+		to       string
+		bump     Bump
+		expected string
+	}{
+		{from: "v1.2.3", to: "v2.0.0", bump: BumpMajor, expected: "v2.0.0"},
+		{from: "v1.2.3", to: "v1.3.0", bump: BumpMinor, expected: "v1.3.0"},
+		{from: "1.2.3", to: "1.2.4", bump: BumpPatch, expected: "1.2.4"},
+		{from: "1.3.0-rc.1", to: "1.3.0", bump: BumpPatch, expected: "1.3.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			from, err := Parse(tt.from)
+			require.NoError(t, err)
+			to, err := Parse(tt.to)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.bump, Change(from, to))
+			assert.Equal(t, tt.expected, from.Next(tt.bump).String())
+		})
+	}
+
+	older, _ := Parse("1.0.0")
+	newer, _ := Parse("1.1.0")
+	assert.Equal(t, BumpNone, Change(newer, older))
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultPolicy, policy)
+
+	policy, err = ParsePolicy("REQUIRE")
+	require.NoError(t, err)
+	assert.Equal(t, PolicyRequire, policy)
+
+	_, err = ParsePolicy("sometimes")
+	assert.Error(t, err)
+}
+
+func TestConstraintCheck(t *testing.T) {
+	tests := []struct {
+		constraint string
+		matches    []string
+		rejects    []string
+	}{
+		{constraint: "^1.2", matches: []string{"1.2.0", "v1.9.9"}, rejects: []string{"1.1.9", "2.0.0", "1.3.0-rc.1"}},
+		{constraint: "^0.2.3", matches: []string{"0.2.3", "0.2.9"}, rejects: []string{"0.3.0", "0.2.2"}},
+		{constraint: "~1.4.0", matches: []string{"1.4.0", "1.4.7"}, rejects: []string{"1.5.0", "1.3.9"}},
+		{constraint: ">=2.0 <3", matches: []string{"2.0.0", "2.99.1"}, rejects: []string{"1.9.9", "3.0.0"}},
+		{constraint: ">= 2.0, < 3", matches: []string{"2.5.0"}, rejects: []string{"3.0.0"}},
+		{constraint: "1.x || >=3.1", matches: []string{"1.0.0", "3.1.0"}, rejects: []string{"2.0.0", "3.0.9"}},
+		{constraint: ">1.2", matches: []string{"1.3.0"}, rejects: []string{"1.2.9"}},
+		{constraint: "<=1.2", matches: []string{"1.2.9"}, rejects: []string{"1.3.0"}},
+		{constraint: ">=1.3.0-rc.1", matches: []string{"1.3.0-rc.2", "1.3.0", "1.4.0"}, rejects: []string{"1.3.0-beta", "1.4.0-rc.1"}},
+		{constraint: "1.2.3", matches: []string{"v1.2.3", "1.2.3+build"}, rejects: []string{"1.2.4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			c, err := ParseConstraint(tt.constraint)
+			require.NoError(t, err)
+			for _, v := range tt.matches {
+				parsed, err := Parse(v)
+				require.NoError(t, err)
+				assert.True(t, c.Check(parsed), "expected %s to match", v)
+			}
+			for _, v := range tt.rejects {
+				parsed, err := Parse(v)
+				require.NoError(t, err)
+				assert.False(t, c.Check(parsed), "expected %s not to match", v)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"", "^", ">=1.2.3.4", "^1.2-rc.1", "~abc", "1.2 ||"} {
+		_, err := ParseConstraint(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	versions := []string{"dev", "v1.2.0", "v1.10.0", "v2.0.0-rc.1", "v1.9.1", "v0.9.0"}
+
+	tests := []struct {
+		query       string
+		expected    string
+		expectError bool
+	}{
+		{query: "dev", expected: "dev"},
+		{query: "v1.9.1", expected: "v1.9.1"},
+		{query: "1.9.1", expected: "v1.9.1"},
+		{query: Latest, expected: "v2.0.0-rc.1"},
+		{query: LatestStable, expected: "v1.10.0"},
+		{query: "^1.2", expected: "v1.10.0"},
+		{query: "~1.9.0", expected: "v1.9.1"},
+		{query: ">=2.0 <3", expectError: true},
+		{query: ">=2.0.0-rc.1 <3", expected: "v2.0.0-rc.1"},
+		{query: "^3", expectError: true},
+		{query: "not a range", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			resolved, err := Resolve(tt.query, versions)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, resolved)
+		})
+	}
+
+	_, err := Resolve(Latest, nil)
+	assert.ErrorIs(t, err, ErrNoMatch)
+
+	resolved, err := Resolve(Latest, []string{"nightly", "dev"})
+	require.NoError(t, err)
+	assert.Equal(t, "nightly", resolved)
+}
@@ -0,0 +1,271 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/server/auth"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+	orgstore "github.com/sirrobot01/protodex/internal/store/org"
+	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+)
+
+// orgPackageRoles maps an organization role to the role it gives on the organization's packages
+var orgPackageRoles = map[string]string{
+	orgstore.RoleOwner:      pkgstore.RoleOwner,
+	orgstore.RoleMaintainer: pkgstore.RoleMaintainer,
+	orgstore.RoleMember:     pkgstore.RoleReader,
+}
+
+// scopeRoles maps an API token scope to the highest package role a token with that scope can use
+var scopeRoles = map[string]string{
+	authstore.ScopeRead:  pkgstore.RoleReader,
+	authstore.ScopePush:  pkgstore.RoleMaintainer,
+	authstore.ScopeAdmin: pkgstore.RoleOwner,
+}
+
+// userRole returns the role of a user on a package: the highest of ownership, the role granted to
+// them on the package and the role they have in the package's organization. Registry
+// administrators own every package. It returns an empty string when the user has no role at all.
+func (s *Server) userRole(pkg *pkgstore.Package, user *authstore.User) string {
+	userID := user.ID
+	if pkg.OwnerID == userID || user.IsAdmin {
+		return pkgstore.RoleOwner
+	}
+
+	var role string
+	if collaboratorRole, err := s.packageStore.GetCollaboratorRole(pkg.ID, userID); err == nil {
+		role = collaboratorRole
+	} else if !errors.Is(err, pkgstore.ErrNotCollaborator) {
+		s.logger.Error().Err(err).Msg("Failed to get collaborator role")
+	}
+
+	if pkg.OrgID != "" {
+		if orgRole, err := s.orgStore.GetMemberRole(pkg.OrgID, userID); err == nil {
+			role = pkgstore.HigherRole(role, orgPackageRoles[orgRole])
+		}
+	}
+	return role
+}
+
+// packageRole returns the role the caller can use on a package. API tokens are limited to the role
+// their scopes allow and to the package they are restricted to. Anonymous callers have no role.
+func (s *Server) packageRole(pkg *pkgstore.Package, authCtx *auth.Context) string {
+	if authCtx == nil {
+		return ""
+	}
+	role := s.userRole(pkg, authCtx.User)
+	if authCtx.Token == nil {
+		return role
+	}
+
+	if restricted := authCtx.RestrictedTo(); restricted != "" && restricted != pkg.ID {
+		return ""
+	}
+	var allowed string
+	for _, scope := range authCtx.Token.Scopes {
+		allowed = pkgstore.HigherRole(allowed, scopeRoles[scope])
+	}
+	if !pkgstore.RoleAllows(allowed, role) {
+		return allowed
+	}
+	return role
+}
+
+// authorizedPackage loads the package named in the route and checks the caller has at least the
+// required role on it. It writes the error response and returns nil when the caller is not allowed
+// to perform action.
+func (s *Server) authorizedPackage(c *gin.Context, required, action string) *pkgstore.Package {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return nil
+	}
+
+	pkg, err := s.packageStore.GetPackage(c.Param("package"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+		return nil
+	}
+
+	if !s.checkPackageRole(c, pkg, authCtx, required, action) {
+		return nil
+	}
+	return pkg
+}
+
+// checkPackageRole writes an error response and returns false when the caller lacks the required role.
+// Private packages the caller cannot read are reported as missing rather than forbidden.
+func (s *Server) checkPackageRole(c *gin.Context, pkg *pkgstore.Package, authCtx *auth.Context, required, action string) bool {
+	role := s.packageRole(pkg, authCtx)
+	if pkg.Visibility == pkgstore.VisibilityPrivate && role == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+		return false
+	}
+	if !pkgstore.RoleAllows(role, required) {
+		if authCtx.Token != nil && pkgstore.RoleAllows(s.userRole(pkg, authCtx.User), required) {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("this API token is not allowed to %s on %s", action, pkg.Name)})
+			return false
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("you need the %s role on %s to %s", required, pkg.Name, action)})
+		return false
+	}
+	return true
+}
+
+// caller returns the auth context of the request, or nil for anonymous requests
+func (s *Server) caller(c *gin.Context) *auth.Context {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		return nil
+	}
+	return authCtx
+}
+
+// canRead reports whether the caller can see a package. Public packages are readable by everyone,
+// including anonymous callers with a nil authCtx.
+func (s *Server) canRead(pkg *pkgstore.Package, authCtx *auth.Context) bool {
+	return pkg.Visibility != pkgstore.VisibilityPrivate || pkgstore.RoleAllows(s.packageRole(pkg, authCtx), pkgstore.RoleReader)
+}
+
+// readablePackage loads the package named in the route for reading. It writes a 404 response and
+// returns nil when the package does not exist or is private to the caller.
+func (s *Server) readablePackage(c *gin.Context) *pkgstore.Package {
+	pkg, err := s.packageStore.GetPackage(c.Param("package"))
+	if err != nil || !s.canRead(pkg, s.caller(c)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+		return nil
+	}
+	return pkg
+}
+
+// readablePackages filters packages down to the ones the caller can see
+func (s *Server) readablePackages(c *gin.Context, packages []*pkgstore.Package) []*pkgstore.Package {
+	authCtx := s.caller(c)
+	readable := make([]*pkgstore.Package, 0, len(packages))
+	for _, pkg := range packages {
+		if s.canRead(pkg, authCtx) {
+			readable = append(readable, pkg)
+		}
+	}
+	return readable
+}
+
+func (s *Server) setVisibilityHandler(c *gin.Context) {
+	pkg := s.authorizedPackage(c, pkgstore.RoleOwner, "change its visibility")
+	if pkg == nil {
+		return
+	}
+
+	var req client.VisibilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.packageStore.SetVisibility(pkg.ID, req.Visibility); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.audit(c, auditstore.ActionPackageVisibility, auditstore.TargetPackage, pkg.Name, map[string]string{"visibility": req.Visibility})
+	pkg.Visibility = req.Visibility
+	c.JSON(http.StatusOK, toClientPackage(pkg))
+}
+
+// listAccessHandler lists the owner and collaborators of a package
+func (s *Server) listAccessHandler(c *gin.Context) {
+	pkg := s.authorizedPackage(c, pkgstore.RoleReader, "list its collaborators")
+	if pkg == nil {
+		return
+	}
+
+	collaborators, err := s.packageStore.ListCollaborators(pkg.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientCollaborators := make([]client.Collaborator, 0, len(collaborators)+1)
+	if owner, err := s.authService.GetUserByID(pkg.OwnerID); err == nil {
+		clientCollaborators = append(clientCollaborators, client.Collaborator{Username: owner.Username, Role: pkgstore.RoleOwner})
+	}
+	for _, collaborator := range collaborators {
+		clientCollaborators = append(clientCollaborators, client.Collaborator{
+			Username: collaborator.Username,
+			Role:     collaborator.Role,
+		})
+	}
+	c.JSON(http.StatusOK, clientCollaborators)
+}
+
+// grantAccessHandler gives a user a role on a package or changes the role they have
+func (s *Server) grantAccessHandler(c *gin.Context) {
+	pkg := s.authorizedPackage(c, pkgstore.RoleOwner, "manage its collaborators")
+	if pkg == nil {
+		return
+	}
+
+	var req client.Collaborator
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Role == "" {
+		req.Role = pkgstore.RoleReader
+	}
+	if err := pkgstore.ValidateRole(req.Role); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := s.authService.GetUserByUsername(req.Username)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("user %s not found", req.Username)})
+		return
+	}
+	if user.ID == pkg.OwnerID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s already owns %s", req.Username, pkg.Name)})
+		return
+	}
+
+	if err := s.packageStore.SetCollaborator(pkg.ID, user.ID, req.Role); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to set collaborator")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionAccessGrant, auditstore.TargetPackage, pkg.Name, map[string]string{"user": user.Username, "role": req.Role})
+
+	c.JSON(http.StatusOK, req)
+}
+
+func (s *Server) revokeAccessHandler(c *gin.Context) {
+	pkg := s.authorizedPackage(c, pkgstore.RoleOwner, "manage its collaborators")
+	if pkg == nil {
+		return
+	}
+
+	username := c.Param("username")
+	user, err := s.authService.GetUserByUsername(username)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("user %s not found", username)})
+		return
+	}
+
+	if err := s.packageStore.RemoveCollaborator(pkg.ID, user.ID); err != nil {
+		if errors.Is(err, pkgstore.ErrNotCollaborator) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("%s has no role on %s", username, pkg.Name)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionAccessRevoke, auditstore.TargetPackage, pkg.Name, map[string]string{"user": user.Username})
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,282 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+)
+
+func TestPushRequiresMaintainer(t *testing.T) {
+	ts := newTestServer(t)
+	alice, bob := ts.register("alice"), ts.register("bob")
+	status, body := ts.push(alice, "user-service", "v1.0.0")
+	require.Equal(t, http.StatusCreated, status, body)
+
+	status, body = ts.push(bob, "user-service", "v1.1.0")
+	assert.Equal(t, http.StatusForbidden, status, body)
+
+	ts.grant(alice, "user-service", "bob", pkgstore.RoleReader)
+	status, body = ts.push(bob, "user-service", "v1.1.0")
+	assert.Equal(t, http.StatusForbidden, status, body)
+
+	ts.grant(alice, "user-service", "bob", pkgstore.RoleMaintainer)
+	status, body = ts.push(bob, "user-service", "v1.1.0")
+	assert.Equal(t, http.StatusCreated, status, body)
+}
+
+func TestDeleteRequiresOwner(t *testing.T) {
+	ts := newTestServer(t)
+	alice, bob, carol := ts.register("alice"), ts.register("bob"), ts.register("carol")
+	status, body := ts.push(alice, "user-service", "v1.0.0")
+	require.Equal(t, http.StatusCreated, status, body)
+	ts.grant(alice, "user-service", "bob", pkgstore.RoleMaintainer)
+
+	for _, token := range []string{bob, carol} {
+		status, body = ts.do("DELETE", "/api/packages/user-service", token, nil)
+		assert.Equal(t, http.StatusForbidden, status, body)
+		status, body = ts.do("DELETE", "/api/packages/user-service/versions/v1.0.0?purge=true", token, nil)
+		assert.Equal(t, http.StatusForbidden, status, body)
+	}
+
+	// Maintainers yank versions, only owners delete them
+	status, body = ts.do("DELETE", "/api/packages/user-service/versions/v1.0.0", carol, nil)
+	assert.Equal(t, http.StatusForbidden, status, body)
+	status, body = ts.do("DELETE", "/api/packages/user-service/versions/v1.0.0", bob, nil)
+	assert.Equal(t, http.StatusOK, status, body)
+
+	status, body = ts.do("DELETE", "/api/packages/user-service", alice, nil)
+	assert.Equal(t, http.StatusNoContent, status, body)
+	status, body = ts.do("GET", "/api/packages/user-service", alice, nil)
+	assert.Equal(t, http.StatusNotFound, status, body)
+}
+
+func TestRenameRequiresOwner(t *testing.T) {
+	ts := newTestServer(t)
+	alice, bob, carol := ts.register("alice"), ts.register("bob"), ts.register("carol")
+	status, body := ts.push(alice, "user-service", "v1.0.0")
+	require.Equal(t, http.StatusCreated, status, body)
+	ts.grant(alice, "user-service", "bob", pkgstore.RoleMaintainer)
+
+	for _, token := range []string{bob, carol} {
+		status, body = ts.do("POST", "/api/packages/user-service/rename", token, client.RenameRequest{Name: "account-service"})
+		assert.Equal(t, http.StatusForbidden, status, body)
+	}
+
+	status, body = ts.do("POST", "/api/packages/user-service/rename", alice, client.RenameRequest{Name: "account-service"})
+	require.Equal(t, http.StatusOK, status, body)
+
+	// The old name is an alias of the renamed package
+	status, body = ts.do("GET", "/api/packages/user-service", alice, nil)
+	require.Equal(t, http.StatusOK, status, body)
+	var pkg client.Package
+	require.NoError(t, json.Unmarshal([]byte(body), &pkg))
+	assert.Equal(t, "account-service", pkg.Name)
+}
+
+func TestPrivatePackagesAreHiddenFromOutsiders(t *testing.T) {
+	ts := newTestServer(t)
+	alice, bob := ts.register("alice"), ts.register("bob")
+	status, body := ts.push(alice, "user-service", "v1.0.0")
+	require.Equal(t, http.StatusCreated, status, body)
+	status, body = ts.do("PUT", "/api/packages/user-service/visibility", alice, client.VisibilityRequest{Visibility: pkgstore.VisibilityPrivate})
+	require.Equal(t, http.StatusOK, status, body)
+
+	status, body = ts.push(bob, "user-service", "v1.1.0")
+	assert.Equal(t, http.StatusNotFound, status, body)
+	status, body = ts.do("DELETE", "/api/packages/user-service", bob, nil)
+	assert.Equal(t, http.StatusNotFound, status, body)
+	status, body = ts.do("POST", "/api/packages/user-service/rename", bob, client.RenameRequest{Name: "account-service"})
+	assert.Equal(t, http.StatusNotFound, status, body)
+	status, body = ts.do("GET", "/api/packages/user-service", "", nil)
+	assert.Equal(t, http.StatusNotFound, status, body)
+}
+
+func TestTokenScopesCapPackageRoles(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.register("alice")
+	status, body := ts.push(alice, "user-service", "v1.0.0")
+	require.Equal(t, http.StatusCreated, status, body)
+
+	// alice owns the package, but a read token only reads it
+	read := ts.createToken(alice, client.CreateTokenRequest{Name: "read", Scopes: []string{authstore.ScopeRead}})
+	status, body = ts.do("GET", "/api/packages/user-service/versions/v1.0.0", read, nil)
+	assert.Equal(t, http.StatusOK, status, body)
+	status, body = ts.push(read, "user-service", "v1.1.0")
+	assert.Equal(t, http.StatusForbidden, status, body)
+	status, body = ts.push(read, "billing-service", "v1.0.0")
+	assert.Equal(t, http.StatusForbidden, status, body)
+	status, body = ts.do("DELETE", "/api/packages/user-service/versions/v1.0.0", read, nil)
+	assert.Equal(t, http.StatusForbidden, status, body)
+
+	// A push token acts as a maintainer
+	push := ts.createToken(alice, client.CreateTokenRequest{Name: "push", Scopes: []string{authstore.ScopePush}})
+	status, body = ts.push(push, "user-service", "v1.1.0")
+	assert.Equal(t, http.StatusCreated, status, body)
+	status, body = ts.do("POST", "/api/packages/user-service/rename", push, client.RenameRequest{Name: "account-service"})
+	assert.Equal(t, http.StatusForbidden, status, body)
+	status, body = ts.do("DELETE", "/api/packages/user-service/versions/v1.0.0?purge=true", push, nil)
+	assert.Equal(t, http.StatusForbidden, status, body)
+	status, body = ts.do("DELETE", "/api/packages/user-service", push, nil)
+	assert.Equal(t, http.StatusForbidden, status, body)
+
+	// Scopes cap the role of the user, they never raise it
+	bob := ts.register("bob")
+	bobAdmin := ts.createToken(bob, client.CreateTokenRequest{Name: "admin", Scopes: []string{authstore.ScopeAdmin}})
+	status, body = ts.push(bobAdmin, "user-service", "v1.2.0")
+	assert.Equal(t, http.StatusForbidden, status, body)
+	status, body = ts.do("DELETE", "/api/packages/user-service", bobAdmin, nil)
+	assert.Equal(t, http.StatusForbidden, status, body)
+
+	admin := ts.createToken(alice, client.CreateTokenRequest{Name: "admin", Scopes: []string{authstore.ScopeAdmin}})
+	status, body = ts.do("POST", "/api/packages/user-service/rename", admin, client.RenameRequest{Name: "account-service"})
+	assert.Equal(t, http.StatusOK, status, body)
+}
+
+func TestPackageTokensOnlyReachTheirPackage(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.register("alice")
+	for _, name := range []string{"user-service", "billing-service"} {
+		status, body := ts.push(alice, name, "v1.0.0")
+		require.Equal(t, http.StatusCreated, status, body)
+	}
+
+	token := ts.createToken(alice, client.CreateTokenRequest{Name: "ci", Scopes: []string{authstore.ScopePush}, Package: "user-service"})
+	status, body := ts.push(token, "user-service", "v1.1.0")
+	assert.Equal(t, http.StatusCreated, status, body)
+	status, body = ts.push(token, "billing-service", "v1.1.0")
+	assert.Equal(t, http.StatusForbidden, status, body)
+	status, body = ts.push(token, "new-service", "v1.0.0")
+	assert.Equal(t, http.StatusForbidden, status, body)
+	status, body = ts.do("POST", "/api/tokens", token, client.CreateTokenRequest{Name: "escalate", Scopes: []string{authstore.ScopeAdmin}})
+	assert.Equal(t, http.StatusForbidden, status, body)
+
+	// An administrator's admin token restricted to a package cannot administer the registry
+	ts.register("bob")
+	user, err := ts.server.authService.GetUserByUsername("alice")
+	require.NoError(t, err)
+	require.NoError(t, ts.server.authService.SetAdmin(user.ID, true))
+	admin := ts.createToken(alice, client.CreateTokenRequest{Name: "ci-admin", Scopes: []string{authstore.ScopeAdmin}, Package: "user-service"})
+	status, body = ts.do("GET", "/api/admin/users", admin, nil)
+	assert.Equal(t, http.StatusForbidden, status, body)
+	status, body = ts.do("PUT", "/api/admin/users/bob/password", admin, client.ResetPasswordRequest{})
+	assert.Equal(t, http.StatusForbidden, status, body)
+	assert.NotContains(t, body, "password\":")
+	status, body = ts.do("GET", "/api/admin/users", alice, nil)
+	assert.Equal(t, http.StatusOK, status, body)
+}
+
+func TestOrgMembersAreListedToMembersAndAdmins(t *testing.T) {
+	ts := newTestServer(t)
+	alice, bob, carol := ts.register("alice"), ts.register("bob"), ts.register("carol")
+	status, body := ts.do("POST", "/api/orgs", alice, client.CreateOrganizationRequest{Name: "acme"})
+	require.Equal(t, http.StatusCreated, status, body)
+	status, body = ts.do("PUT", "/api/orgs/acme/members", alice, client.Member{Username: "bob"})
+	require.Equal(t, http.StatusOK, status, body)
+
+	members := func(token string) []client.Member {
+		status, body := ts.do("GET", "/api/orgs/acme", token, nil)
+		require.Equal(t, http.StatusOK, status, body)
+		var org client.Organization
+		require.NoError(t, json.Unmarshal([]byte(body), &org))
+		assert.Equal(t, "acme", org.Name)
+		return org.Members
+	}
+	assert.Len(t, members(alice), 2)
+	assert.Len(t, members(bob), 2)
+	assert.Empty(t, members(carol))
+
+	user, err := ts.server.authService.GetUserByUsername("carol")
+	require.NoError(t, err)
+	require.NoError(t, ts.server.authService.SetAdmin(user.ID, true))
+	assert.Len(t, members(carol), 2)
+
+	// Administrators list members only with tokens that carry the admin scope
+	read := ts.createToken(carol, client.CreateTokenRequest{Name: "read", Scopes: []string{authstore.ScopeRead}})
+	assert.Empty(t, members(read))
+}
+
+func TestServiceAccountsFollowTheirOwner(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.register("alice")
+	user, err := ts.server.authService.GetUserByUsername("alice")
+	require.NoError(t, err)
+	require.NoError(t, ts.server.authService.SetAdmin(user.ID, true))
+
+	// Disabling a user revokes the tokens of their service accounts
+	bob := ts.register("bob")
+	ci := ts.createToken(bob, client.CreateTokenRequest{Name: "ci", Scopes: []string{authstore.ScopePush}, ServiceAccount: "bob-ci"})
+	status, body := ts.push(ci, "user-service", "v1.0.0")
+	require.Equal(t, http.StatusCreated, status, body)
+	status, body = ts.do("POST", "/api/admin/users/bob/disable", alice, nil)
+	require.Equal(t, http.StatusNoContent, status, body)
+	status, body = ts.push(ci, "user-service", "v1.1.0")
+	assert.Equal(t, http.StatusUnauthorized, status, body)
+
+	// A service account that owns a package keeps its owner from being deleted
+	status, body = ts.do("DELETE", "/api/admin/users/bob", alice, nil)
+	assert.Equal(t, http.StatusConflict, status, body)
+	assert.Contains(t, body, "bob-ci")
+
+	// Deleting a user deletes their service accounts and revokes their tokens
+	carol := ts.register("carol")
+	ci = ts.createToken(carol, client.CreateTokenRequest{Name: "ci", Scopes: []string{authstore.ScopeRead}, ServiceAccount: "carol-ci"})
+	status, body = ts.do("GET", "/api/packages/user-service", ci, nil)
+	require.Equal(t, http.StatusOK, status, body)
+	status, body = ts.do("DELETE", "/api/admin/users/carol", alice, nil)
+	require.Equal(t, http.StatusNoContent, status, body)
+	status, body = ts.do("GET", "/api/packages/user-service", ci, nil)
+	assert.Equal(t, http.StatusUnauthorized, status, body)
+
+	status, body = ts.do("GET", "/api/admin/users", alice, nil)
+	require.Equal(t, http.StatusOK, status, body)
+	var users []client.User
+	require.NoError(t, json.Unmarshal([]byte(body), &users))
+	for _, user := range users {
+		assert.NotEqual(t, "carol-ci", user.Username)
+	}
+	assert.Len(t, users, 3)
+}
+
+func TestPrivateDependenciesAreHiddenFromOutsiders(t *testing.T) {
+	ts := newTestServer(t)
+	alice, bob := ts.register("alice"), ts.register("bob")
+	status, body := ts.pushFiles(alice, "secret-types", "v1.0.0", map[string]string{
+		"protodex.yaml":      "package:\n  name: secret-types\n",
+		"proto/secret.proto": "syntax = \"proto3\";\npackage secret;\nmessage Secret { string value = 1; }\n",
+	})
+	require.Equal(t, http.StatusCreated, status, body)
+	status, body = ts.do("PUT", "/api/packages/secret-types/visibility", alice, client.VisibilityRequest{Visibility: pkgstore.VisibilityPrivate})
+	require.Equal(t, http.StatusOK, status, body)
+
+	consumer := func(dependency string) map[string]string {
+		return map[string]string{
+			"protodex.yaml": "package:\n  name: consumer\ndeps:\n  - name: secret\n    type: protodex\n    source: " + dependency + "\n",
+			"proto/consumer.proto": "syntax = \"proto3\";\npackage consumer;\nimport \"secret/proto/secret.proto\";\n" +
+				"message Consumer { secret.Secret secret = 1; }\n",
+		}
+	}
+	// The first versions have nothing to be compatible with, the next ones are compiled with their
+	// dependencies
+	status, body = ts.pushFiles(bob, "consumer", "v1.0.0", consumer("secret-types"))
+	require.Equal(t, http.StatusCreated, status, body)
+	status, body = ts.pushFiles(bob, "other-consumer", "v1.0.0", consumer("missing-types"))
+	require.Equal(t, http.StatusCreated, status, body)
+
+	status, hidden := ts.pushFiles(bob, "consumer", "v1.1.0", consumer("secret-types"))
+	assert.Equal(t, http.StatusBadRequest, status, hidden)
+	assert.Contains(t, hidden, "package secret-types not found")
+	status, missing := ts.pushFiles(bob, "other-consumer", "v1.1.0", consumer("missing-types"))
+	assert.Equal(t, http.StatusBadRequest, status, missing)
+	assert.Contains(t, missing, "package missing-types not found")
+
+	ts.grant(alice, "secret-types", "bob", pkgstore.RoleReader)
+	status, body = ts.pushFiles(bob, "consumer", "v1.1.0", consumer("secret-types"))
+	assert.Equal(t, http.StatusCreated, status, body)
+}
@@ -0,0 +1,303 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/server/auth"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+	orgstore "github.com/sirrobot01/protodex/internal/store/org"
+)
+
+// requireAdmin rejects callers that are not administrators. API tokens also need the admin scope
+// and cannot be restricted to a package.
+func (s *Server) requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authCtx, err := s.getAuthContext(c)
+		if err != nil || !authCtx.User.IsAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "administrator access required"})
+			c.Abort()
+			return
+		}
+		if !s.checkScope(c, authCtx, authstore.ScopeAdmin) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// isAdmin reports whether the caller acts as an administrator, with the same rules as requireAdmin
+func isAdmin(authCtx *auth.Context) bool {
+	return authCtx != nil && authCtx.User.IsAdmin && authCtx.HasScope(authstore.ScopeAdmin) && authCtx.RestrictedTo() == ""
+}
+
+func toClientUser(user *authstore.User) client.User {
+	return client.User{
+		ID:             user.ID,
+		Username:       user.Username,
+		Email:          user.Email,
+		IsAdmin:        user.IsAdmin,
+		ServiceAccount: user.ServiceAccount,
+		Disabled:       user.Disabled,
+		TwoFactor:      user.TOTPEnabled,
+	}
+}
+
+// changePasswordHandler changes the caller's password and ends their other sessions
+func (s *Server) changePasswordHandler(c *gin.Context) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req client.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var keepSessionID string
+	if authCtx.Session != nil {
+		keepSessionID = authCtx.Session.ID
+	}
+
+	if err := s.authService.ChangePassword(authCtx.UserID, req.CurrentPassword, req.NewPassword, keepSessionID); err != nil {
+		if errors.Is(err, auth.ErrWrongPassword) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "current password is incorrect"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionPasswordChange, auditstore.TargetUser, authCtx.User.Username, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// deleteAccountHandler deletes the caller's account once they confirm it with their password
+func (s *Server) deleteAccountHandler(c *gin.Context) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req client.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.authService.CheckPassword(authCtx.UserID, req.Password); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "password is incorrect"})
+		return
+	}
+
+	s.deleteUser(c, authCtx.User)
+}
+
+func (s *Server) listUsersHandler(c *gin.Context) {
+	users, err := s.authService.ListUsers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientUsers := make([]client.User, 0, len(users))
+	for _, user := range users {
+		clientUsers = append(clientUsers, toClientUser(user))
+	}
+	c.JSON(http.StatusOK, clientUsers)
+}
+
+// resetPasswordHandler sets a new password for a user who forgot theirs. A password is generated
+// when the request does not carry one.
+func (s *Server) resetPasswordHandler(c *gin.Context) {
+	user := s.routeUser(c)
+	if user == nil {
+		return
+	}
+	if user.ServiceAccount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "service accounts do not have a password"})
+		return
+	}
+
+	var req client.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var response client.ResetPasswordResponse
+	if req.Password == "" {
+		password, err := auth.GeneratePassword()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate password"})
+			return
+		}
+		req.Password = password
+		response.Password = password
+	}
+
+	if err := s.authService.ResetPassword(user.ID, req.Password); errors.Is(err, auth.ErrWeakPassword) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to reset password")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionPasswordReset, auditstore.TargetUser, user.Username, nil)
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) disableUserHandler(c *gin.Context) {
+	s.setUserDisabled(c, true)
+}
+
+func (s *Server) enableUserHandler(c *gin.Context) {
+	s.setUserDisabled(c, false)
+}
+
+func (s *Server) setUserDisabled(c *gin.Context, disabled bool) {
+	user := s.routeUser(c)
+	if user == nil {
+		return
+	}
+	if disabled && user.ID == s.caller(c).UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "you cannot disable your own account"})
+		return
+	}
+
+	if err := s.authService.SetDisabled(user.ID, disabled); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to update user")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	action := auditstore.ActionUserEnable
+	if disabled {
+		action = auditstore.ActionUserDisable
+	}
+	s.audit(c, action, auditstore.TargetUser, user.Username, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) deleteUserHandler(c *gin.Context) {
+	user := s.routeUser(c)
+	if user == nil {
+		return
+	}
+	if user.ID == s.caller(c).UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "delete your own account with protodex account delete"})
+		return
+	}
+
+	s.deleteUser(c, user)
+}
+
+// routeUser loads the user named in the route. It writes a 404 response and returns nil when there
+// is no such user.
+func (s *Server) routeUser(c *gin.Context) *authstore.User {
+	username := c.Param("username")
+	user, err := s.authService.GetUserByUsername(username)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("user %s not found", username)})
+		return nil
+	}
+	return user
+}
+
+// deleteUser deletes an account unless something would be left without an owner
+func (s *Server) deleteUser(c *gin.Context, user *authstore.User) {
+	if reason, err := s.accountInUse(user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	} else if reason != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s cannot be deleted: %s", user.Username, reason)})
+		return
+	}
+
+	if err := s.authService.DeleteUser(user.ID); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to delete user")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionUserDelete, auditstore.TargetUser, user.Username, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// accountInUse returns why a user cannot be deleted yet, or an empty string. Packages they own,
+// organizations they are the only owner of and the registry itself when they are its only
+// administrator would otherwise be left without an owner. Their service accounts are deleted with
+// them, so the same applies to those.
+func (s *Server) accountInUse(user *authstore.User) (string, error) {
+	if user.IsAdmin {
+		if only, err := s.onlyAdmin(user); err != nil {
+			return "", err
+		} else if only {
+			return "they are the only administrator of the registry", nil
+		}
+	}
+
+	users, err := s.authService.ListUsers()
+	if err != nil {
+		return "", err
+	}
+	for _, account := range users {
+		if !account.ServiceAccount || account.CreatedBy != user.ID {
+			continue
+		}
+		reason, err := s.ownsSomething(account)
+		if err != nil {
+			return "", err
+		}
+		if reason != "" {
+			return fmt.Sprintf("their service account %s is in use, %s", account.Username, reason), nil
+		}
+	}
+	return s.ownsSomething(user)
+}
+
+// ownsSomething returns the package or organization a user is the owner of, or an empty string
+func (s *Server) ownsSomething(user *authstore.User) (string, error) {
+	packages, err := s.packageStore.ListPackages()
+	if err != nil {
+		return "", err
+	}
+	for _, pkg := range packages {
+		if pkg.OwnerID == user.ID {
+			return fmt.Sprintf("they own %s, transfer or delete it first", pkg.Name), nil
+		}
+	}
+
+	orgs, err := s.orgStore.ListUserOrganizations(user.ID)
+	if err != nil {
+		return "", err
+	}
+	for _, org := range orgs {
+		members, err := s.orgStore.ListMembers(org.ID)
+		if err != nil {
+			return "", err
+		}
+		owners := 0
+		for _, member := range members {
+			if member.Role == orgstore.RoleOwner {
+				owners++
+			}
+		}
+		if role, _ := s.orgStore.GetMemberRole(org.ID, user.ID); role == orgstore.RoleOwner && owners == 1 {
+			return fmt.Sprintf("they are the only owner of %s, add another owner first", org.Name), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/server/admission"
+	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+)
+
+// admitVersion asks the admission hooks whether the version staged in schemaDir may be published.
+// It writes the error response and returns false when the push is rejected.
+func (s *Server) admitVersion(c *gin.Context, pkg *pkgstore.Package, version, schemaDir string, bump *bumpCheck) bool {
+	files, err := admission.ListFiles(schemaDir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	previous, err := s.admissionBaseline(pkg, version, bump)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+
+	var previousFiles []admission.File
+	if previous != "" {
+		previousDir, err := s.packageStore.GetSchemaPath(pkg.Name, previous)
+		if err == nil {
+			previousFiles, err = admission.ListFiles(previousDir)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return false
+		}
+	}
+	diff := admission.Compare(previousFiles, files)
+	diff.PreviousVersion = previous
+	if bump != nil {
+		diff.Bump = bump.Actual.String()
+		diff.RequiredBump = bump.Required.String()
+	}
+
+	req := &admission.Request{
+		Package:       admission.Package{Name: pkg.Name, Visibility: pkg.Visibility},
+		Version:       version,
+		AllowBreaking: c.PostForm("allow_breaking") == "true",
+		Files:         files,
+		Diff:          diff,
+	}
+	if authCtx := s.caller(c); authCtx != nil {
+		req.Actor = authCtx.User.Username
+	}
+
+	decision, err := s.admission.Admit(c.Request.Context(), req)
+	if err != nil {
+		s.logger.Error().Err(err).Str("package", pkg.Name).Str("version", version).Msg("Admission hook failed")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": fmt.Sprintf("push could not be admitted: %v", err)})
+		return false
+	}
+	if !decision.Allowed {
+		s.logger.Info().Str("hook", decision.Hook).Str("package", pkg.Name).Str("version", version).
+			Str("actor", req.Actor).Msg("Admission hook rejected push")
+		c.JSON(http.StatusUnprocessableEntity, client.AdmissionError{
+			Message: fmt.Sprintf("version %s of %s was rejected by admission hook %s", version, pkg.Name, decision.Hook),
+			Hook:    decision.Hook,
+			Reason:  decision.Message,
+		})
+		return false
+	}
+	return true
+}
+
+// admissionBaseline returns the version a push is compared with for the admission hooks: the
+// version its bump is checked against, otherwise the latest version that has not been yanked. It
+// is empty for the first version of a package.
+func (s *Server) admissionBaseline(pkg *pkgstore.Package, version string, bump *bumpCheck) (string, error) {
+	if bump != nil {
+		return bump.Previous, nil
+	}
+
+	versions, err := s.publishedVersions(pkg)
+	if err != nil {
+		return "", err
+	}
+	for _, v := range versions {
+		if v.Version != version && !v.Yanked {
+			return v.Version, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,252 @@
+package admission
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/sirrobot01/protodex/internal/config"
+	"github.com/sirrobot01/protodex/internal/server/webhook"
+)
+
+const (
+	// EventHeader is set to Event on every admission request
+	EventHeader = webhook.EventHeader
+	Event       = "version.admission"
+
+	defaultTimeout  = 10 * time.Second
+	maxResponseBody = 64 * 1024
+)
+
+// Request is the JSON body sent to admission hooks
+type Request struct {
+	Package Package `json:"package"`
+	Version string  `json:"version"`
+	// Actor is the user pushing the version
+	Actor         string `json:"actor"`
+	AllowBreaking bool   `json:"allow_breaking"`
+	Files         []File `json:"files"`
+	Diff          Diff   `json:"diff"`
+}
+
+type Package struct {
+	Name       string `json:"name"`
+	Visibility string `json:"visibility"`
+}
+
+// File is a file of the pushed version, Path is relative to the package root
+type File struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Diff summarizes the changes since the previous version, it is empty for the first version
+type Diff struct {
+	PreviousVersion string   `json:"previous_version,omitempty"`
+	Added           []string `json:"added"`
+	Removed         []string `json:"removed"`
+	Changed         []string `json:"changed"`
+	// Bump is the version bump of the push and RequiredBump the one its schema changes require,
+	// both are empty when the bump is not checked
+	Bump         string `json:"bump,omitempty"`
+	RequiredBump string `json:"required_bump,omitempty"`
+}
+
+// Response is the JSON body admission hooks answer with
+type Response struct {
+	Allowed bool   `json:"allowed"`
+	Message string `json:"message"`
+}
+
+// Decision is the outcome of the admission hooks for a push
+type Decision struct {
+	Allowed bool
+	// Hook names the hook that rejected the push
+	Hook    string
+	Message string
+}
+
+// Controller calls the admission hooks configured for the registry
+type Controller struct {
+	hooks  []config.AdmissionHook
+	client *http.Client
+	logger zerolog.Logger
+}
+
+// NewController checks the configured hooks and returns a controller that calls them
+func NewController(hooks []config.AdmissionHook, logger zerolog.Logger) (*Controller, error) {
+	for i, hook := range hooks {
+		if hook.Name == "" {
+			return nil, fmt.Errorf("admission hook %d has no name", i+1)
+		}
+		if err := webhook.ValidateURL(hook.URL); err != nil {
+			return nil, fmt.Errorf("admission hook %s: %w", hook.Name, err)
+		}
+		for _, pattern := range hook.Packages {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("admission hook %s: invalid package pattern %q", hook.Name, pattern)
+			}
+		}
+	}
+
+	return &Controller{
+		hooks: hooks,
+		client: &http.Client{
+			// Redirects are not followed, a hook has to point at its final URL
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		logger: logger,
+	}, nil
+}
+
+// Admit calls the hooks that apply to the package in order and stops at the first one that rejects
+// the push. A hook that cannot be reached or answers with an error rejects the push with an error,
+// unless it fails open.
+func (c *Controller) Admit(ctx context.Context, req *Request) (*Decision, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal admission request: %w", err)
+	}
+
+	for _, hook := range c.hooks {
+		if !appliesTo(hook, req.Package.Name) {
+			continue
+		}
+
+		resp, err := c.call(ctx, hook, body)
+		if err != nil {
+			if hook.FailOpen {
+				c.logger.Warn().Err(err).Str("hook", hook.Name).Str("package", req.Package.Name).
+					Msg("Admission hook failed, accepting the push because it fails open")
+				continue
+			}
+			return nil, fmt.Errorf("admission hook %s failed: %w", hook.Name, err)
+		}
+		if !resp.Allowed {
+			return &Decision{Hook: hook.Name, Message: resp.Message}, nil
+		}
+	}
+	return &Decision{Allowed: true}, nil
+}
+
+func (c *Controller) call(ctx context.Context, hook config.AdmissionHook, body []byte) (*Response, error) {
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "protodex-admission")
+	req.Header.Set(EventHeader, Event)
+	if hook.Secret != "" {
+		req.Header.Set(webhook.SignatureHeader, webhook.Sign(hook.Secret, body))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("hook answered with status %d", resp.StatusCode)
+	}
+
+	var decision Response
+	if err := json.Unmarshal(data, &decision); err != nil {
+		return nil, fmt.Errorf("failed to decode hook response: %w", err)
+	}
+	return &decision, nil
+}
+
+// appliesTo reports whether a hook is called for pushes to the package
+func appliesTo(hook config.AdmissionHook, packageName string) bool {
+	if len(hook.Packages) == 0 {
+		return true
+	}
+	for _, pattern := range hook.Packages {
+		if matched, _ := path.Match(pattern, packageName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ListFiles describes the files of a schema stored in dir, sorted by path
+func ListFiles(dir string) ([]File, error) {
+	var files []File
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", filePath, err)
+		}
+		relPath, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(content)
+		files = append(files, File{
+			Path:   filepath.ToSlash(relPath),
+			Size:   int64(len(content)),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schema files: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// Compare lists the files added, removed and changed between two versions
+func Compare(previous, current []File) Diff {
+	diff := Diff{Added: []string{}, Removed: []string{}, Changed: []string{}}
+
+	checksums := make(map[string]string, len(previous))
+	for _, file := range previous {
+		checksums[file.Path] = file.SHA256
+	}
+	for _, file := range current {
+		checksum, ok := checksums[file.Path]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, file.Path)
+		case checksum != file.SHA256:
+			diff.Changed = append(diff.Changed, file.Path)
+		}
+		delete(checksums, file.Path)
+	}
+	for filePath := range checksums {
+		diff.Removed = append(diff.Removed, filePath)
+	}
+	sort.Strings(diff.Removed)
+	return diff
+}
@@ -0,0 +1,152 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sirrobot01/protodex/internal/config"
+	"github.com/sirrobot01/protodex/internal/server/webhook"
+)
+
+// hookServer answers admission requests with response and records the last request
+func hookServer(t *testing.T, response Response, calls *int, received *Request) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, Event, r.Header.Get(EventHeader))
+		assert.Equal(t, webhook.Sign("admission-secret", body), r.Header.Get(webhook.SignatureHeader))
+		if received != nil {
+			require.NoError(t, json.Unmarshal(body, received))
+		}
+		*calls++
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestControllerAdmit(t *testing.T) {
+	var allowCalls, rejectCalls, otherCalls int
+	var received Request
+	allow := hookServer(t, Response{Allowed: true}, &allowCalls, &received)
+	reject := hookServer(t, Response{Allowed: false, Message: "acme packages need a CODEOWNERS sign-off"}, &rejectCalls, nil)
+	other := hookServer(t, Response{Allowed: false, Message: "never called"}, &otherCalls, nil)
+
+	controller, err := NewController([]config.AdmissionHook{
+		{Name: "naming", URL: allow.URL, Secret: "admission-secret"},
+		{Name: "ownership", URL: reject.URL, Secret: "admission-secret", Packages: []string{"acme/*"}},
+		{Name: "other", URL: other.URL, Secret: "admission-secret", Packages: []string{"other/*"}},
+	}, zerolog.Nop())
+	require.NoError(t, err)
+
+	req := &Request{
+		Package: Package{Name: "acme/payments", Visibility: "private"},
+		Version: "v1.1.0",
+		Actor:   "jane",
+		Files:   []File{{Path: "payments.proto", Size: 10, SHA256: "abc"}},
+	}
+	decision, err := controller.Admit(context.Background(), req)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, "ownership", decision.Hook)
+	assert.Equal(t, "acme packages need a CODEOWNERS sign-off", decision.Message)
+	assert.Equal(t, "acme/payments", received.Package.Name)
+	assert.Equal(t, "v1.1.0", received.Version)
+	assert.Equal(t, "jane", received.Actor)
+	require.Len(t, received.Files, 1)
+
+	// Hooks limited to other packages are skipped
+	req.Package.Name = "payments"
+	decision, err = controller.Admit(context.Background(), req)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, 2, allowCalls)
+	assert.Equal(t, 1, rejectCalls)
+	assert.Equal(t, 0, otherCalls)
+}
+
+func TestControllerFailures(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_ = json.NewEncoder(w).Encode(Response{Allowed: true})
+	}))
+	defer slow.Close()
+
+	req := &Request{Package: Package{Name: "payments"}, Version: "v1.0.0"}
+
+	controller, err := NewController([]config.AdmissionHook{{Name: "policy", URL: failing.URL}}, zerolog.Nop())
+	require.NoError(t, err)
+	_, err = controller.Admit(context.Background(), req)
+	assert.ErrorContains(t, err, "admission hook policy failed: hook answered with status 500")
+
+	controller, err = NewController([]config.AdmissionHook{{Name: "policy", URL: slow.URL, Timeout: 50 * time.Millisecond}}, zerolog.Nop())
+	require.NoError(t, err)
+	_, err = controller.Admit(context.Background(), req)
+	assert.Error(t, err)
+
+	// Hooks that fail open accept the push
+	controller, err = NewController([]config.AdmissionHook{
+		{Name: "policy", URL: failing.URL, FailOpen: true},
+		{Name: "slow", URL: slow.URL, Timeout: 50 * time.Millisecond, FailOpen: true},
+	}, zerolog.Nop())
+	require.NoError(t, err)
+	decision, err := controller.Admit(context.Background(), req)
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+}
+
+func TestNewControllerValidatesHooks(t *testing.T) {
+	_, err := NewController([]config.AdmissionHook{{URL: "https://policy.example.com"}}, zerolog.Nop())
+	assert.Error(t, err)
+	_, err = NewController([]config.AdmissionHook{{Name: "policy", URL: "policy.example.com"}}, zerolog.Nop())
+	assert.Error(t, err)
+	_, err = NewController([]config.AdmissionHook{{Name: "policy", URL: "https://policy.example.com", Packages: []string{"acme/["}}}, zerolog.Nop())
+	assert.Error(t, err)
+}
+
+func TestListFilesAndCompare(t *testing.T) {
+	previousDir, currentDir := t.TempDir(), t.TempDir()
+	write := func(dir, name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write(previousDir, "protodex.yaml", "name: payments")
+	write(previousDir, "payments/v1/payments.proto", "syntax = \"proto3\";")
+	write(previousDir, "payments/v1/legacy.proto", "syntax = \"proto3\";")
+	write(currentDir, "protodex.yaml", "name: payments")
+	write(currentDir, "payments/v1/payments.proto", "syntax = \"proto3\"; package payments.v1;")
+	write(currentDir, "payments/v1/refunds.proto", "syntax = \"proto3\";")
+
+	current, err := ListFiles(currentDir)
+	require.NoError(t, err)
+	require.Len(t, current, 3)
+	assert.Equal(t, "payments/v1/payments.proto", current[0].Path)
+	assert.Equal(t, int64(len("syntax = \"proto3\"; package payments.v1;")), current[0].Size)
+	assert.Len(t, current[0].SHA256, 64)
+
+	previous, err := ListFiles(previousDir)
+	require.NoError(t, err)
+
+	diff := Compare(previous, current)
+	assert.Equal(t, []string{"payments/v1/refunds.proto"}, diff.Added)
+	assert.Equal(t, []string{"payments/v1/legacy.proto"}, diff.Removed)
+	assert.Equal(t, []string{"payments/v1/payments.proto"}, diff.Changed)
+
+	diff = Compare(nil, current)
+	assert.Len(t, diff.Added, 3)
+	assert.Empty(t, diff.Removed)
+}
@@ -5,18 +5,26 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/sirrobot01/protodex/internal/client"
 	"github.com/sirrobot01/protodex/internal/manager"
+	"github.com/sirrobot01/protodex/internal/semver"
 	"github.com/sirrobot01/protodex/internal/server/auth"
+	"github.com/sirrobot01/protodex/internal/server/webhook"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+	webhookstore "github.com/sirrobot01/protodex/internal/store/webhook"
 )
 
 const AuthContextKey = "auth_context"
@@ -47,6 +55,28 @@ func (s *Server) authMiddleware() gin.HandlerFunc {
 	}
 }
 
+// optionalAuthMiddleware authenticates requests that carry a token and lets anonymous ones through,
+// handlers decide what anonymous callers can see
+func (s *Server) optionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := auth.ExtractTokenFromHeader(c.GetHeader("Authorization"))
+		if token == "" {
+			c.Next()
+			return
+		}
+
+		authCtx, err := s.authService.ValidateToken(token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Set(AuthContextKey, authCtx)
+		c.Next()
+	}
+}
+
 func (s *Server) getAuthContext(c *gin.Context) (*auth.Context, error) {
 	if ctx, exists := c.Get(AuthContextKey); exists {
 		if authCtx, ok := ctx.(*auth.Context); ok {
@@ -64,22 +94,35 @@ func (s *Server) loginHandler(c *gin.Context) {
 		return
 	}
 	userAgent := c.GetHeader("User-Agent")
+	ip := c.ClientIP()
+
+	if wait := s.loginLimiter.LockedFor(req.Username, ip); wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed logins, try again later"})
+		return
+	}
 
 	loginResp, err := s.authService.Login(userAgent, req.Username, req.Password)
 	if err != nil {
+		locked := s.loginLimiter.Fail(req.Username, ip)
+		s.logger.Warn().Err(err).
+			Str("event", "login_failed").
+			Str("username", req.Username).
+			Str("ip", ip).
+			Str("user_agent", userAgent).
+			Bool("locked_out", locked).
+			Msg("Failed login")
+		s.auditAs(c, nil, auditstore.ActionLoginFailed, auditstore.TargetUser, req.Username, nil)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
 		return
 	}
-
-	response := client.LoginResponse{
-		Token: loginResp.Token,
-		User: client.User{
-			ID:       loginResp.User.ID,
-			Username: loginResp.User.Username,
-		},
+	// A password only starts a two-factor login, failed codes keep counting until a session is issued
+	if loginResp.Token != "" {
+		s.loginLimiter.Succeed(req.Username)
+		s.auditAs(c, loginResp.User, auditstore.ActionLogin, auditstore.TargetUser, loginResp.User.Username, map[string]string{"method": "password"})
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, toClientLogin(loginResp))
 }
 
 func (s *Server) registerHandler(c *gin.Context) {
@@ -89,6 +132,11 @@ func (s *Server) registerHandler(c *gin.Context) {
 		return
 	}
 
+	invite, ok := s.checkRegistration(c, &req)
+	if !ok {
+		return
+	}
+
 	// Check if user already exists
 	_, err := s.authService.GetUserByUsername(req.Username)
 	if err == nil {
@@ -98,11 +146,33 @@ func (s *Server) registerHandler(c *gin.Context) {
 
 	// Create user
 	user, err := s.authService.CreateUser(req.Username, req.Password)
+	if errors.Is(err, auth.ErrWeakPassword) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		s.logger.Error().Err(err).Msg("Failed to create user")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
+	if invite != nil {
+		// Redeeming the invite only now keeps it usable when creating the user fails
+		if err := s.authService.UseInvite(invite.ID, user.ID); err != nil {
+			_ = s.authService.DeleteUser(user.ID)
+			c.JSON(http.StatusForbidden, gin.H{"error": "invite is invalid or has expired"})
+			return
+		}
+	}
+	if req.Email != "" {
+		if err := s.authService.SetEmail(user.ID, req.Email); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to save email address")
+		}
+	}
+	var details map[string]string
+	if invite != nil {
+		details = map[string]string{"invite": invite.ID}
+	}
+	s.auditAs(c, user, auditstore.ActionRegister, auditstore.TargetUser, user.Username, details)
 	userAgent := c.GetHeader("User-Agent")
 
 	// Create login session
@@ -131,12 +201,20 @@ func (s *Server) getCurrentUserHandler(c *gin.Context) {
 		return
 	}
 
-	user := client.User{
-		ID:       authCtx.User.ID,
-		Username: authCtx.User.Username,
-	}
+	c.JSON(http.StatusOK, toClientUser(authCtx.User))
+}
 
-	c.JSON(http.StatusOK, user)
+func toClientPackage(pkg *pkgstore.Package) *client.Package {
+	return &client.Package{
+		ID:            pkg.ID,
+		Name:          pkg.Name,
+		Description:   pkg.Description,
+		Tags:          pkg.Tags,
+		CreatedAt:     pkg.CreatedAt,
+		OwnerID:       pkg.OwnerID,
+		Compatibility: pkg.Compatibility,
+		Visibility:    pkg.Visibility,
+	}
 }
 
 // Package handlers
@@ -149,38 +227,19 @@ func (s *Server) listPackagesHandler(c *gin.Context) {
 	}
 
 	var clientPackages []*client.Package
-	for _, pkg := range packages {
-		clpkg := &client.Package{
-			ID:          pkg.ID,
-			Name:        pkg.Name,
-			Description: pkg.Description,
-			Tags:        pkg.Tags,
-			CreatedAt:   pkg.CreatedAt,
-			OwnerID:     pkg.OwnerID,
-		}
-		clientPackages = append(clientPackages, clpkg)
+	for _, pkg := range s.readablePackages(c, packages) {
+		clientPackages = append(clientPackages, toClientPackage(pkg))
 	}
 
 	c.JSON(http.StatusOK, clientPackages)
 }
 
 func (s *Server) getPackageHandler(c *gin.Context) {
-	packageName := c.Param("package")
-	pkg, err := s.packageStore.GetPackage(packageName)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+	pkg := s.readablePackage(c)
+	if pkg == nil {
 		return
 	}
-	clientPackage := &client.Package{
-		ID:          pkg.ID,
-		Name:        pkg.Name,
-		Description: pkg.Description,
-		Tags:        pkg.Tags,
-		CreatedAt:   pkg.CreatedAt,
-		OwnerID:     pkg.OwnerID,
-	}
-
-	c.JSON(http.StatusOK, clientPackage)
+	c.JSON(http.StatusOK, toClientPackage(pkg))
 }
 
 func (s *Server) createPackageHandler(c *gin.Context) {
@@ -194,6 +253,7 @@ func (s *Server) createPackageHandler(c *gin.Context) {
 		Name        string   `json:"name" binding:"required"`
 		Description string   `json:"description"`
 		Tags        []string `json:"tags"`
+		Visibility  string   `json:"visibility"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -201,22 +261,57 @@ func (s *Server) createPackageHandler(c *gin.Context) {
 		return
 	}
 
-	pkg, err := s.packageStore.CreatePackage(req.Name, req.Description, authCtx.UserID, req.Tags)
-	if err != nil {
-		s.logger.Error().Err(err).Msg("Failed to create package")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if !s.checkPackageName(c, authCtx, req.Name) {
 		return
 	}
 
-	clientPackage := &client.Package{
-		ID:          pkg.ID,
-		Name:        pkg.Name,
-		Description: pkg.Description,
-		Tags:        pkg.Tags,
-		CreatedAt:   pkg.CreatedAt,
+	pkg := s.createPackage(c, req.Name, req.Description, authCtx.UserID, req.Tags, req.Visibility)
+	if pkg == nil {
+		return
+	}
+
+	c.JSON(http.StatusCreated, toClientPackage(pkg))
+}
+
+// createPackage creates a package with the given visibility, public when empty. It writes the error
+// response and returns nil when the package cannot be created.
+func (s *Server) createPackage(c *gin.Context, name, description, ownerID string, tags []string, visibility string) *pkgstore.Package {
+	pkg := s.storePackage(c, name, description, ownerID, tags, visibility)
+	if pkg == nil {
+		return nil
+	}
+	s.announcePackage(c, pkg)
+	return pkg
+}
+
+// storePackage creates a package without recording or announcing it, see announcePackage. It writes
+// the error response and returns nil when the package cannot be created.
+func (s *Server) storePackage(c *gin.Context, name, description, ownerID string, tags []string, visibility string) *pkgstore.Package {
+	if visibility == "" {
+		visibility = pkgstore.VisibilityPublic
+	}
+	if err := pkgstore.ValidateVisibility(visibility); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil
 	}
 
-	c.JSON(http.StatusCreated, clientPackage)
+	pkg, err := s.packageStore.CreatePackage(name, description, ownerID, tags)
+	if err == nil && visibility != pkgstore.VisibilityPublic {
+		err = s.packageStore.SetVisibility(pkg.ID, visibility)
+		pkg.Visibility = visibility
+	}
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to create package")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil
+	}
+	return pkg
+}
+
+// announcePackage records the creation of a package in the audit log and sends the package.created event
+func (s *Server) announcePackage(c *gin.Context, pkg *pkgstore.Package) {
+	s.audit(c, auditstore.ActionPackageCreate, auditstore.TargetPackage, pkg.Name, map[string]string{"visibility": pkg.Visibility})
+	s.publishEvent(c, webhookstore.EventPackageCreated, pkg, nil)
 }
 
 func (s *Server) searchPackagesHandler(c *gin.Context) {
@@ -231,20 +326,15 @@ func (s *Server) searchPackagesHandler(c *gin.Context) {
 	}
 
 	var clientPackages []*client.Package
-	for _, pkg := range packages {
-		clientPackages = append(clientPackages, &client.Package{
-			ID:          pkg.ID,
-			Name:        pkg.Name,
-			Description: pkg.Description,
-			Tags:        pkg.Tags,
-			CreatedAt:   pkg.CreatedAt,
-		})
+	for _, pkg := range s.readablePackages(c, packages) {
+		clientPackages = append(clientPackages, toClientPackage(pkg))
 	}
 
 	c.JSON(http.StatusOK, clientPackages)
 }
 
-// pushVersionZipHandler handles uploading a ZIP file containing schema files for a specific package version
+// pushVersionHandler handles uploading a ZIP file containing schema files for a specific package version.
+// The upload is extracted to a staging directory and only published once every check has passed.
 func (s *Server) pushVersionHandler(c *gin.Context) {
 	authCtx, err := s.getAuthContext(c)
 	if err != nil {
@@ -255,8 +345,8 @@ func (s *Server) pushVersionHandler(c *gin.Context) {
 	packageName := c.Param("package")
 	version := c.PostForm("version")
 
-	if version == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "version is required"})
+	if err := pkgstore.ValidateVersion(version); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -280,15 +370,42 @@ func (s *Server) pushVersionHandler(c *gin.Context) {
 		return
 	}
 
-	// Get or create package
+	// A new package is only created once the push has passed every check, so a rejected first push
+	// leaves nothing behind. Until then the checks see a package with default settings and no versions.
 	pkg, err := s.packageStore.GetPackage(packageName)
-	if err != nil {
-		pkg, err = s.packageStore.CreatePackage(packageName, "", authCtx.UserID, []string{})
-		if err != nil {
-			s.logger.Error().Err(err).Msg("Failed to create package")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	newPackage := err != nil
+	if newPackage {
+		if !s.checkScope(c, authCtx, authstore.ScopePush) || !s.checkPackageName(c, authCtx, packageName) {
 			return
 		}
+		visibility := c.PostForm("visibility")
+		if visibility == "" {
+			visibility = pkgstore.VisibilityPublic
+		}
+		if err := pkgstore.ValidateVisibility(visibility); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		pkg = &pkgstore.Package{Name: packageName, OwnerID: authCtx.UserID, Visibility: visibility}
+	} else if !s.checkPackageRole(c, pkg, authCtx, pkgstore.RoleMaintainer, "push versions") {
+		return
+	}
+
+	if pkg.StrictSemver && !semver.IsValid(version) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("package %s requires semantic versions, got %q", pkg.Name, version)})
+		return
+	}
+
+	// Published versions are immutable, drafts are replaced by pushing them again
+	if existing, err := s.packageStore.GetSchemaVersion(pkg.ID, version); err == nil && existing.Published() {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("version %s of %s already exists, published versions cannot be overwritten", version, pkg.Name)})
+		return
+	}
+
+	// Packages that require approvals only accept drafts
+	status := pkgstore.StatusPublished
+	if c.PostForm("draft") == "true" || pkg.RequiredApprovals > 0 {
+		status = pkgstore.StatusDraft
 	}
 
 	// Extract and validate zip contents
@@ -298,14 +415,16 @@ func (s *Server) pushVersionHandler(c *gin.Context) {
 		return
 	}
 
-	// Create schema directory
-	schemaDir := s.packageStore.GetSchemaPath(packageName, version)
-	if err := os.MkdirAll(schemaDir, 0755); err != nil {
+	// Stage the upload, it is removed unless it gets published
+	schemaDir, err := s.packageStore.StagingDir()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to create staging directory")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create schema directory"})
 		return
 	}
+	defer os.RemoveAll(schemaDir)
 
-	var filePaths []string
+	var fileCount int
 	var allContent []byte
 	var hasProtodexYaml, hasProtoFiles bool
 
@@ -316,6 +435,13 @@ func (s *Server) pushVersionHandler(c *gin.Context) {
 			continue
 		}
 
+		// Reject entries that would be written outside the schema directory
+		filePath := filepath.Join(schemaDir, zipFile.Name)
+		if !strings.HasPrefix(filePath, schemaDir+string(os.PathSeparator)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid path in zip: %s", zipFile.Name)})
+			return
+		}
+
 		rc, err := zipFile.Open()
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to open zip entry %s", zipFile.Name)})
@@ -340,7 +466,6 @@ func (s *Server) pushVersionHandler(c *gin.Context) {
 		allContent = append(allContent, content...)
 
 		// Write file to schema directory maintaining structure
-		filePath := filepath.Join(schemaDir, zipFile.Name)
 		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create file directory"})
 			return
@@ -351,34 +476,64 @@ func (s *Server) pushVersionHandler(c *gin.Context) {
 			return
 		}
 
-		filePaths = append(filePaths, filePath)
+		fileCount++
 	}
 
 	// Validate package structure
 	if !hasProtodexYaml {
-		// Clean up created files
-		if err := os.RemoveAll(schemaDir); err != nil {
-			return
-		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "zip must contain protodex.yaml file"})
 		return
 	}
 
 	if !hasProtoFiles {
-		// Clean up created files
-
-		if err := os.RemoveAll(schemaDir); err != nil {
-			return
-		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": "zip must contain at least one .proto file"})
 		return
 	}
 
-	if len(filePaths) == 0 {
+	if fileCount == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "no files provided in zip"})
 		return
 	}
 
+	// Enforce the package compatibility policy unless the caller explicitly overrides the check
+	if c.PostForm("allow_breaking") != "true" {
+		mode, previousVersion, violations, err := s.checkCompatibility(pkg, version, schemaDir, s.caller(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("compatibility check failed: %v", err)})
+			return
+		}
+		if len(violations) > 0 {
+			c.JSON(http.StatusUnprocessableEntity, breakingChange(version, mode, previousVersion, violations))
+			return
+		}
+	}
+
+	// Check the version number reflects the schema diff
+	bump, err := s.checkVersionBump(pkg, version, schemaDir, s.caller(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("version check failed: %v", err)})
+		return
+	}
+	var warnings []string
+	if bump != nil && !bump.sufficient() {
+		message := fmt.Sprintf("version %s is a %s bump from %s but the schema changes require a %s bump, suggested version %s",
+			version, bump.Actual, bump.Previous, bump.Required, bump.Suggested)
+		if pkg.BumpPolicy == string(semver.PolicyRequire) {
+			c.JSON(http.StatusUnprocessableEntity, client.VersionBumpError{
+				Message:          message,
+				PreviousVersion:  bump.Previous,
+				RequiredBump:     bump.Required.String(),
+				SuggestedVersion: bump.Suggested,
+			})
+			return
+		}
+		warnings = append(warnings, message)
+	}
+
+	if !s.admitVersion(c, pkg, version, schemaDir, bump) {
+		return
+	}
+
 	// Calculate checksum
 	hasher := sha256.New()
 	hasher.Write(allContent)
@@ -386,11 +541,24 @@ func (s *Server) pushVersionHandler(c *gin.Context) {
 
 	// Don't save ZIP archive - generate on-demand for pulls
 
-	// Store schema files in database
-	schemaVersion, err := s.packageStore.SaveSchemaFiles(pkg.ID, version, filePaths, authCtx.UserID)
+	if newPackage {
+		if pkg = s.storePackage(c, pkg.Name, "", authCtx.UserID, []string{}, pkg.Visibility); pkg == nil {
+			return
+		}
+	}
+
+	// Move the staged files into place and store them in the database as one unit
+	schemaVersion, err := s.packageStore.PublishSchema(pkg.ID, pkg.Name, version, schemaDir, authCtx.UserID, status)
 	if err != nil {
-		// Clean up created files
-		os.RemoveAll(schemaDir)
+		if newPackage {
+			if err := s.packageStore.DeletePackage(pkg.ID); err != nil {
+				s.logger.Error().Err(err).Str("package", pkg.Name).Msg("Failed to remove package of a failed first push")
+			}
+		}
+		if errors.Is(err, pkgstore.ErrVersionExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("version %s of %s already exists, published versions cannot be overwritten", version, pkg.Name)})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -398,27 +566,43 @@ func (s *Server) pushVersionHandler(c *gin.Context) {
 	// Update version with checksum
 	schemaVersion.Checksum = checksum
 
+	if newPackage {
+		s.announcePackage(c, pkg)
+	}
+
+	details := map[string]string{"checksum": checksum}
+	if c.PostForm("allow_breaking") == "true" {
+		details["allow_breaking"] = "true"
+	}
+	// A draft is announced once it is approved and published
+	if status == pkgstore.StatusDraft {
+		details["status"] = status
+	}
+	s.audit(c, auditstore.ActionVersionPush, auditstore.TargetVersion, versionTarget(pkg.Name, version), details)
+	if status == pkgstore.StatusPublished {
+		s.publishEvent(c, webhookstore.EventVersionPushed, pkg, &webhook.Version{Version: version, Checksum: checksum})
+	}
+
 	clientVersion := &client.Version{
 		ID:        schemaVersion.ID,
 		Version:   schemaVersion.Version,
 		CreatedAt: schemaVersion.CreatedAt,
 		CreatedBy: schemaVersion.CreatedBy,
 		Checksum:  checksum,
+		Status:    status,
+		Warnings:  warnings,
 	}
 
 	c.JSON(http.StatusCreated, clientVersion)
 }
 
 func (s *Server) listVersionsHandler(c *gin.Context) {
-	packageName := c.Param("package")
-
-	pkg, err := s.packageStore.GetPackage(packageName)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+	pkg := s.readablePackage(c)
+	if pkg == nil {
 		return
 	}
 
-	versions, err := s.packageStore.ListVersions(pkg.ID)
+	versions, err := s.publishedVersions(pkg)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -426,32 +610,27 @@ func (s *Server) listVersionsHandler(c *gin.Context) {
 
 	clientVersions := make([]client.Version, 0, len(versions))
 	for _, ver := range versions {
-		clientVersions = append(clientVersions, client.Version{
-			ID:        ver.ID,
-			Version:   ver.Version,
-			CreatedAt: ver.CreatedAt,
-			CreatedBy: ver.CreatedBy,
-		})
+		clientVersions = append(clientVersions, toClientVersion(ver))
 	}
 
 	c.JSON(http.StatusOK, clientVersions)
 }
 
 func (s *Server) pullVersionHandler(c *gin.Context) {
-	packageName := c.Param("package")
 	version := c.Param("version")
 
-	pkg, err := s.packageStore.GetPackage(packageName)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+	pkg := s.readablePackage(c)
+	if pkg == nil {
 		return
 	}
 
-	schemaVersion, err := s.packageStore.GetSchemaVersion(pkg.ID, version)
+	schemaVersion, err := s.resolveVersion(pkg, version)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "version not found"})
+		writeResolveError(c, version, err)
 		return
 	}
+	version = schemaVersion.Version
+	setVersionHeaders(c, schemaVersion)
 
 	if len(schemaVersion.Files) == 0 {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "no files found in schema version"})
@@ -476,16 +655,7 @@ func (s *Server) pullVersionHandler(c *gin.Context) {
 		}
 
 		// Use the original zip structure (relative to schema root)
-		zipEntryPath := file.Filename
-		if strings.Contains(file.FilePath, "schemas/") {
-			parts := strings.Split(file.FilePath, "schemas/")
-			if len(parts) > 1 {
-				schemaParts := strings.SplitN(parts[1], "/", 3)
-				if len(schemaParts) >= 3 {
-					zipEntryPath = schemaParts[2]
-				}
-			}
-		}
+		zipEntryPath := s.schemaRelativePath(pkg.Name, version, filePath, file.Filename)
 
 		f, err := zipWriter.Create(zipEntryPath)
 		if err != nil {
@@ -504,27 +674,47 @@ func (s *Server) pullVersionHandler(c *gin.Context) {
 		return
 	}
 
-	filename := fmt.Sprintf("%s-%s.zip", packageName, version)
+	filename := fmt.Sprintf("%s-%s.zip", archiveName(pkg.Name), version)
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
 	c.Data(http.StatusOK, "application/zip", zipBuffer.Bytes())
 }
 
+// schemaRelativePath returns the path of a stored file within its version directory, which is the
+// path it had in the pushed archive. fallback is used for files stored outside the version directory.
+func (s *Server) schemaRelativePath(packageName, version, filePath, fallback string) string {
+	schemaDir, err := s.packageStore.GetSchemaPath(packageName, version)
+	if err != nil {
+		return fallback
+	}
+	rel, err := filepath.Rel(schemaDir, filePath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return fallback
+	}
+	return filepath.ToSlash(rel)
+}
+
 // viewSchemaHandler returns schema information and file contents for viewing
 func (s *Server) viewSchemaHandler(c *gin.Context) {
 	packageName := c.Param("package")
 	version := c.Param("version")
 
-	pkg, err := s.packageStore.GetPackage(packageName)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+	pkg := s.readablePackage(c)
+	if pkg == nil {
 		return
 	}
 
-	schemaVersion, err := s.packageStore.GetSchemaVersion(pkg.ID, version)
+	schemaVersion, err := s.resolveVersion(pkg, version)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "version not found"})
-		return
+		// Drafts are not resolved but can be viewed by their exact version for review
+		draft, draftErr := s.packageStore.GetSchemaVersion(pkg.ID, version)
+		if draftErr != nil {
+			writeResolveError(c, version, err)
+			return
+		}
+		schemaVersion = draft
 	}
+	version = schemaVersion.Version
+	setVersionHeaders(c, schemaVersion)
 
 	if len(schemaVersion.Files) == 0 {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "no files found in schema version"})
@@ -563,22 +753,7 @@ func (s *Server) viewSchemaHandler(c *gin.Context) {
 		}
 
 		// Extract file path relative to schema directory for hierarchy display
-		displayPath := file.Filename // default to filename
-
-		// If FilePath is stored as relative from data dir, extract the schema-relative part
-		if strings.Contains(file.FilePath, "schemas/") {
-			parts := strings.Split(file.FilePath, "schemas/")
-			if len(parts) > 1 {
-				// Further split to get just the file path within the schema
-				schemaParts := strings.SplitN(parts[1], "/", 3) // package/version/filepath
-				if len(schemaParts) >= 3 {
-					displayPath = schemaParts[2]
-				}
-			}
-		} else if !filepath.IsAbs(file.FilePath) {
-			// If already relative, use as-is
-			displayPath = file.FilePath
-		}
+		displayPath := s.schemaRelativePath(pkg.Name, version, filePath, file.Filename)
 
 		files = append(files, FileContent{
 			Name:    file.Filename,
@@ -602,7 +777,6 @@ func (s *Server) viewSchemaHandler(c *gin.Context) {
 }
 
 func (s *Server) generateCodeHandler(c *gin.Context) {
-	packageName := c.Param("package")
 	version := c.Param("version")
 
 	var req client.GenerateOptions
@@ -618,17 +792,18 @@ func (s *Server) generateCodeHandler(c *gin.Context) {
 	}
 
 	// Get package and version from registry
-	pkg, err := s.packageStore.GetPackage(packageName)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+	pkg := s.readablePackage(c)
+	if pkg == nil {
 		return
 	}
 
-	schemaVersion, err := s.packageStore.GetSchemaVersion(pkg.ID, version)
+	schemaVersion, err := s.resolveVersion(pkg, version)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "version not found"})
+		writeResolveError(c, version, err)
 		return
 	}
+	version = schemaVersion.Version
+	setVersionHeaders(c, schemaVersion)
 
 	if len(schemaVersion.Files) == 0 {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "no files found in schema version"})
@@ -756,7 +931,7 @@ func (s *Server) generateCodeHandler(c *gin.Context) {
 	}
 
 	// Return ZIP file
-	filename := fmt.Sprintf("%s-%s-%s-generated.zip", packageName, version, language)
+	filename := fmt.Sprintf("%s-%s-%s-generated.zip", archiveName(pkg.Name), version, language)
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
 	c.Data(http.StatusOK, "application/zip", zipBuffer.Bytes())
 }
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+)
+
+// audit records a change made by the caller of the request. The change has already happened, so
+// failing to record it is logged rather than failing the request.
+func (s *Server) audit(c *gin.Context, action, targetType, target string, details map[string]string) {
+	var actor *authstore.User
+	if authCtx := s.caller(c); authCtx != nil {
+		actor = authCtx.User
+		if authCtx.Token != nil {
+			if details == nil {
+				details = map[string]string{}
+			}
+			details["token"] = authCtx.Token.Name
+		}
+	}
+	s.auditAs(c, actor, action, targetType, target, details)
+}
+
+// auditAs records a change made by actor, for requests that authenticate the user themselves such
+// as logins. actor is nil when the user is unknown.
+func (s *Server) auditAs(c *gin.Context, actor *authstore.User, action, targetType, target string, details map[string]string) {
+	// ClientIP only follows forwarding headers sent by trusted proxies, the proxy itself is kept too
+	if proxy := c.RemoteIP(); proxy != c.ClientIP() {
+		if details == nil {
+			details = map[string]string{}
+		}
+		details["proxy"] = proxy
+	}
+	entry := &auditstore.Entry{
+		Action:     action,
+		IP:         c.ClientIP(),
+		UserAgent:  c.GetHeader("User-Agent"),
+		TargetType: targetType,
+		Target:     target,
+		Details:    details,
+	}
+	if actor != nil {
+		entry.ActorID = actor.ID
+		entry.Actor = actor.Username
+	}
+
+	if err := s.auditStore.Record(entry); err != nil {
+		s.logger.Error().Err(err).Str("action", action).Str("target", target).Msg("Failed to record audit entry")
+	}
+}
+
+// versionTarget names a version in audit entries
+func versionTarget(packageName, version string) string {
+	return packageName + "@" + version
+}
+
+// listAuditHandler returns audit entries, newest first. Older entries are paged through with the
+// before parameter set to the ID of the last entry returned.
+func (s *Server) listAuditHandler(c *gin.Context) {
+	filter := auditstore.Filter{
+		Actor:      c.Query("actor"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		Target:     c.Query("target"),
+	}
+
+	var err error
+	for param, value := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if raw := c.Query(param); raw != "" {
+			if *value, err = time.Parse(time.RFC3339, raw); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param + " time, use RFC 3339 such as 2024-01-02T15:04:05Z"})
+				return
+			}
+		}
+	}
+	if raw := c.Query("before"); raw != "" {
+		if filter.Before, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid before entry ID"})
+			return
+		}
+	}
+	if raw := c.Query("limit"); raw != "" {
+		if filter.Limit, err = strconv.Atoi(raw); err != nil || filter.Limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+
+	entries, err := s.auditStore.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientEntries := make([]client.AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		clientEntries = append(clientEntries, client.AuditEntry{
+			ID:         entry.ID,
+			Action:     entry.Action,
+			ActorID:    entry.ActorID,
+			Actor:      entry.Actor,
+			IP:         entry.IP,
+			UserAgent:  entry.UserAgent,
+			TargetType: entry.TargetType,
+			Target:     entry.Target,
+			Details:    entry.Details,
+			CreatedAt:  entry.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, clientEntries)
+}
@@ -3,7 +3,9 @@ package auth
 import (
 	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -11,10 +13,31 @@ import (
 	authstore "github.com/sirrobot01/protodex/internal/store/auth"
 )
 
+// apiTokenPrefix tells API tokens apart from session tokens
+const apiTokenPrefix = "pdx_"
+
+// ErrWrongPassword is returned when a password given to confirm an account change does not match
+var ErrWrongPassword = errors.New("wrong password")
+
 type Context struct {
 	Session *authstore.Session `json:"session"`
-	UserID  string             `json:"user_id"`
-	User    *authstore.User    `json:"user,omitempty"`
+	// Token is set instead of Session when the request was made with an API token
+	Token  *authstore.APIToken `json:"token,omitempty"`
+	UserID string              `json:"user_id"`
+	User   *authstore.User     `json:"user,omitempty"`
+}
+
+// HasScope reports whether the credential allows scope. Sessions allow everything.
+func (c *Context) HasScope(scope string) bool {
+	return c.Token == nil || c.Token.HasScope(scope)
+}
+
+// RestrictedTo returns the package an API token is limited to, or an empty string
+func (c *Context) RestrictedTo() string {
+	if c.Token == nil {
+		return ""
+	}
+	return c.Token.PackageID
 }
 
 type LoginRequest struct {
@@ -27,26 +50,69 @@ type LoginResponse struct {
 	User    *authstore.User `json:"user"`
 	Token   string          `json:"token"`
 	Expires *time.Time      `json:"expires_at,omitempty"`
+	// Challenge is set instead of Token when the user still has to enter a two-factor code
+	Challenge string `json:"challenge,omitempty"`
 }
 
 type Service struct {
-	store authstore.Store
+	store      authstore.Store
+	passwords  PasswordPolicy
+	provider   Provider
+	challenges challenges
 }
 
 func NewAuthService(store authstore.Store) *Service {
 	return &Service{
-		store: store,
+		store:      store,
+		provider:   NewLocalProvider(store),
+		challenges: challenges{pending: make(map[string]*challenge)},
 	}
 }
 
+// SetProvider sets where passwords given to Login are checked
+func (s *Service) SetProvider(provider Provider) {
+	s.provider = provider
+}
+
+// SetPasswordPolicy sets the policy new passwords are checked against
+func (s *Service) SetPasswordPolicy(policy PasswordPolicy) {
+	s.passwords = policy
+}
+
 func (s *Service) Login(client, username, password string) (*LoginResponse, error) {
-	user, err := s.store.Authenticate(username, password)
+	user, err := s.authenticate(username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.FinishLogin(client, user)
+}
+
+// authenticate checks a password with the provider and returns the user it belongs to. Users of an
+// external provider get an account on their first login.
+func (s *Service) authenticate(username, password string) (*authstore.User, error) {
+	identity, err := s.provider.Authenticate(username, password)
 	if err != nil {
+		// Accounts that only exist in the registry database, such as the bootstrap administrator,
+		// keep logging in with their own password
+		if s.provider.Name() != ProviderLocal {
+			if user, localErr := s.store.Authenticate(username, password); localErr == nil && user.SSOSubject == "" {
+				return user, nil
+			}
+		}
 		return nil, err
 	}
 
+	if identity.Subject == "" {
+		return s.store.GetUserByUsername(identity.Username)
+	}
+	return s.ExternalUser(*identity)
+}
+
+// StartSession creates a session for a user who has already proven who they are
+func (s *Service) StartSession(client string, user *authstore.User) (*LoginResponse, error) {
 	// Create a session token
-	token, err := generateToken()
+	token, err := generateToken("pg_")
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate session token: %w", err)
 	}
@@ -69,6 +135,10 @@ func (s *Service) ValidateToken(token string) (*Context, error) {
 		return nil, fmt.Errorf("token is required")
 	}
 
+	if strings.HasPrefix(token, apiTokenPrefix) {
+		return s.validateAPIToken(token)
+	}
+
 	tokenHash := hashToken(token)
 
 	session, err := s.store.GetSessionByHash(tokenHash)
@@ -89,6 +159,9 @@ func (s *Service) ValidateToken(token string) (*Context, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
+	if user.Disabled {
+		return nil, fmt.Errorf("account is disabled")
+	}
 
 	return &Context{
 		Session: session,
@@ -97,6 +170,164 @@ func (s *Service) ValidateToken(token string) (*Context, error) {
 	}, nil
 }
 
+func (s *Service) validateAPIToken(token string) (*Context, error) {
+	apiToken, err := s.store.GetAPITokenByHash(hashToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	if apiToken.IsExpired() {
+		return nil, fmt.Errorf("token expired")
+	}
+
+	if err := s.store.UpdateAPITokenLastUsed(apiToken.ID); err != nil {
+		return nil, fmt.Errorf("failed to update token: %w", err)
+	}
+
+	user, err := s.store.GetUserByID(apiToken.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Disabled {
+		return nil, fmt.Errorf("account is disabled")
+	}
+
+	return &Context{
+		Token:  apiToken,
+		UserID: apiToken.UserID,
+		User:   user,
+	}, nil
+}
+
+// CreateAPIToken generates a token for the user set in apiToken. The plain token is only returned
+// here, the store keeps its hash.
+func (s *Service) CreateAPIToken(apiToken *authstore.APIToken) (string, *authstore.APIToken, error) {
+	token, err := generateToken(apiTokenPrefix)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate API token: %w", err)
+	}
+
+	apiToken.TokenHash = hashToken(token)
+	created, err := s.store.CreateAPIToken(apiToken)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, created, nil
+}
+
+func (s *Service) GetAPIToken(id string) (*authstore.APIToken, error) {
+	return s.store.GetAPIToken(id)
+}
+
+func (s *Service) ListAPITokens(createdBy string) ([]*authstore.APIToken, error) {
+	return s.store.ListAPITokens(createdBy)
+}
+
+func (s *Service) RevokeAPIToken(id string) error {
+	return s.store.DeleteAPIToken(id)
+}
+
+func (s *Service) CreateServiceAccount(name, ownerID string) (*authstore.User, error) {
+	return s.store.CreateServiceAccount(name, ownerID)
+}
+
+// Logout ends a session so its token can no longer be used
+func (s *Service) Logout(sessionID string) error {
+	return s.store.DeleteSession(sessionID)
+}
+
+func (s *Service) ListSessions(userID string) ([]*authstore.Session, error) {
+	return s.store.ListSessions(userID)
+}
+
+// RevokeSession ends one of the sessions of a user. Sessions of other users are reported as not found.
+func (s *Service) RevokeSession(userID, sessionID string) error {
+	session, err := s.store.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return fmt.Errorf("session not found")
+	}
+	return s.store.DeleteSession(sessionID)
+}
+
+// RevokeOtherSessions ends every session of a user except keepID and returns how many were ended
+func (s *Service) RevokeOtherSessions(userID, keepID string) (int64, error) {
+	return s.store.DeleteUserSessions(userID, keepID)
+}
+
+// ChangePassword replaces the password of a user after checking their current one. Every session
+// but keepSessionID is ended, so a leaked password stops working everywhere else.
+func (s *Service) ChangePassword(userID, currentPassword, newPassword, keepSessionID string) error {
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.ServiceAccount {
+		return fmt.Errorf("service accounts do not have a password")
+	}
+	if user.PasswordHash == "" {
+		return fmt.Errorf("this account logs in through an identity provider and has no password")
+	}
+	if err := authstore.CheckPasswordHash(currentPassword, user.PasswordHash); err != nil {
+		return ErrWrongPassword
+	}
+	if err := s.passwords.Check(newPassword); err != nil {
+		return err
+	}
+
+	if err := s.store.UpdatePassword(userID, newPassword); err != nil {
+		return err
+	}
+	_, err = s.store.DeleteUserSessions(userID, keepSessionID)
+	return err
+}
+
+// CheckPassword returns ErrWrongPassword when password is not the password of the user
+func (s *Service) CheckPassword(userID, password string) error {
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := authstore.CheckPasswordHash(password, user.PasswordHash); err != nil {
+		return ErrWrongPassword
+	}
+	return nil
+}
+
+// ResetPassword sets a new password for a user without knowing the old one and ends all their sessions
+func (s *Service) ResetPassword(userID, password string) error {
+	if err := s.passwords.Check(password); err != nil {
+		return err
+	}
+	if err := s.store.UpdatePassword(userID, password); err != nil {
+		return err
+	}
+	_, err := s.store.DeleteUserSessions(userID, "")
+	return err
+}
+
+func (s *Service) ListUsers() ([]*authstore.User, error) {
+	return s.store.ListUsers()
+}
+
+func (s *Service) SetDisabled(userID string, disabled bool) error {
+	return s.store.SetDisabled(userID, disabled)
+}
+
+func (s *Service) SetEmail(userID, email string) error {
+	return s.store.SetEmail(userID, strings.ToLower(email))
+}
+
+func (s *Service) SetAdmin(userID string, admin bool) error {
+	return s.store.SetAdmin(userID, admin)
+}
+
+func (s *Service) DeleteUser(userID string) error {
+	return s.store.DeleteUser(userID)
+}
+
 func (s *Service) GetUserByID(userID string) (*authstore.User, error) {
 	return s.store.GetUserByID(userID)
 }
@@ -105,17 +336,31 @@ func (s *Service) GetUserByUsername(username string) (*authstore.User, error) {
 	return s.store.GetUserByUsername(username)
 }
 
+// CreateUser creates a user after checking their password against the password policy
 func (s *Service) CreateUser(username, password string) (*authstore.User, error) {
+	if err := s.passwords.Check(password); err != nil {
+		return nil, err
+	}
 	return s.store.CreateUser(username, password)
 }
 
-func generateToken() (string, error) {
+func generateToken(prefix string) (string, error) {
 	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
 		return "", err
 	}
 
-	return "pg_" + hex.EncodeToString(bytes), nil
+	return prefix + hex.EncodeToString(bytes), nil
+}
+
+// GeneratePassword returns a random password for accounts reset by an administrator
+func GeneratePassword() (string, error) {
+	bytes := make([]byte, 12)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
 }
 
 func hashToken(token string) string {
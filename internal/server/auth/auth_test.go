@@ -2,9 +2,13 @@ package auth
 
 import (
 	"database/sql"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirrobot01/protodex/internal/store/auth"
 	"github.com/stretchr/testify/assert"
@@ -65,6 +69,524 @@ func TestValidateToken(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestLogout(t *testing.T) {
+	service := setupTestAuthService(t)
+
+	_, err := service.CreateUser("testuser", "password123")
+	require.NoError(t, err)
+
+	loginResp, err := service.Login("test-user-agent", "testuser", "password123")
+	require.NoError(t, err)
+
+	authCtx, err := service.ValidateToken(loginResp.Token)
+	require.NoError(t, err)
+
+	require.NoError(t, service.Logout(authCtx.Session.ID))
+
+	_, err = service.ValidateToken(loginResp.Token)
+	assert.Error(t, err)
+}
+
+func TestRevokeSessions(t *testing.T) {
+	service := setupTestAuthService(t)
+
+	user, err := service.CreateUser("testuser", "password123")
+	require.NoError(t, err)
+	other, err := service.CreateUser("otheruser", "password123")
+	require.NoError(t, err)
+
+	laptop, err := service.Login("laptop", "testuser", "password123")
+	require.NoError(t, err)
+	_, err = service.Login("desktop", "testuser", "password123")
+	require.NoError(t, err)
+	phone, err := service.Login("phone", "testuser", "password123")
+	require.NoError(t, err)
+
+	sessions, err := service.ListSessions(user.ID)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 3)
+
+	phoneCtx, err := service.ValidateToken(phone.Token)
+	require.NoError(t, err)
+
+	// Sessions of other users cannot be revoked
+	assert.Error(t, service.RevokeSession(other.ID, phoneCtx.Session.ID))
+
+	require.NoError(t, service.RevokeSession(user.ID, phoneCtx.Session.ID))
+	_, err = service.ValidateToken(phone.Token)
+	assert.Error(t, err)
+
+	laptopCtx, err := service.ValidateToken(laptop.Token)
+	require.NoError(t, err)
+
+	revoked, err := service.RevokeOtherSessions(user.ID, laptopCtx.Session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), revoked)
+
+	sessions, err = service.ListSessions(user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "laptop", sessions[0].UserAgent)
+}
+
+func TestChangePassword(t *testing.T) {
+	service := setupTestAuthService(t)
+
+	user, err := service.CreateUser("testuser", "password123")
+	require.NoError(t, err)
+
+	laptop, err := service.Login("laptop", "testuser", "password123")
+	require.NoError(t, err)
+	phone, err := service.Login("phone", "testuser", "password123")
+	require.NoError(t, err)
+
+	laptopCtx, err := service.ValidateToken(laptop.Token)
+	require.NoError(t, err)
+
+	err = service.ChangePassword(user.ID, "wrongpassword", "newpassword", laptopCtx.Session.ID)
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	require.NoError(t, service.ChangePassword(user.ID, "password123", "newpassword", laptopCtx.Session.ID))
+
+	// The session that changed the password stays valid, the others are ended
+	_, err = service.ValidateToken(laptop.Token)
+	assert.NoError(t, err)
+	_, err = service.ValidateToken(phone.Token)
+	assert.Error(t, err)
+
+	_, err = service.Login("laptop", "testuser", "password123")
+	assert.Error(t, err)
+	_, err = service.Login("laptop", "testuser", "newpassword")
+	assert.NoError(t, err)
+}
+
+func TestDisableUser(t *testing.T) {
+	service := setupTestAuthService(t)
+
+	user, err := service.CreateUser("testuser", "password123")
+	require.NoError(t, err)
+
+	loginResp, err := service.Login("test-user-agent", "testuser", "password123")
+	require.NoError(t, err)
+	apiToken, _, err := service.CreateAPIToken(&auth.APIToken{
+		Name:      "ci",
+		UserID:    user.ID,
+		Scopes:    []string{auth.ScopeRead},
+		CreatedBy: user.ID,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, service.SetDisabled(user.ID, true))
+
+	_, err = service.ValidateToken(loginResp.Token)
+	assert.Error(t, err)
+	_, err = service.ValidateToken(apiToken)
+	assert.Error(t, err)
+	_, err = service.Login("test-user-agent", "testuser", "password123")
+	assert.Error(t, err)
+
+	require.NoError(t, service.SetDisabled(user.ID, false))
+	_, err = service.Login("test-user-agent", "testuser", "password123")
+	assert.NoError(t, err)
+}
+
+func TestInvite(t *testing.T) {
+	service := setupTestAuthService(t)
+
+	admin, err := service.CreateUser("admin", "password123")
+	require.NoError(t, err)
+
+	code, invite, err := service.CreateInvite(admin.ID, "Jane@Example.com", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", invite.Email)
+
+	_, err = service.CheckInvite("pdi_unknown", "jane@example.com")
+	assert.Error(t, err)
+	_, err = service.CheckInvite(code, "john@example.com")
+	assert.Error(t, err)
+
+	checked, err := service.CheckInvite(code, "jane@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, invite.ID, checked.ID)
+
+	jane, err := service.CreateUser("jane", "password123")
+	require.NoError(t, err)
+	require.NoError(t, service.UseInvite(invite.ID, jane.ID))
+
+	// An invite can only be used once
+	assert.Error(t, service.UseInvite(invite.ID, jane.ID))
+	_, err = service.CheckInvite(code, "jane@example.com")
+	assert.Error(t, err)
+
+	invites, err := service.ListInvites()
+	require.NoError(t, err)
+	assert.Empty(t, invites)
+
+	expiredCode, _, err := service.CreateInvite(admin.ID, "", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	_, err = service.CheckInvite(expiredCode, "")
+	assert.Error(t, err)
+}
+
+func TestExternalUser(t *testing.T) {
+	service := setupTestAuthService(t)
+
+	jane, err := service.ExternalUser(Identity{Subject: "sub-jane", Username: "jane", Email: "Jane@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "jane", jane.Username)
+	assert.Equal(t, "jane@example.com", jane.Email)
+
+	// SSO users have no password to log in with
+	_, err = service.Login("test-user-agent", "jane", "")
+	assert.Error(t, err)
+
+	again, err := service.ExternalUser(Identity{Subject: "sub-jane", Username: "jane-renamed"})
+	require.NoError(t, err)
+	assert.Equal(t, jane.ID, again.ID)
+
+	bob, err := service.CreateUser("bob", "password123")
+	require.NoError(t, err)
+	require.NoError(t, service.SetEmail(bob.ID, "bob@example.com"))
+
+	// Providers that are not authoritative cannot claim existing accounts, even with the same email
+	_, err = service.ExternalUser(Identity{Subject: "sub-bob", Username: "bob", Email: "bob@example.com"})
+	assert.ErrorIs(t, err, ErrUsernameTaken)
+
+	// Owners link them while logged in, after which the identity logs in to the account
+	linked, err := service.LinkExternalUser(bob.ID, Identity{Subject: "sub-bob", Username: "bob"})
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, linked.ID)
+	again, err = service.ExternalUser(Identity{Subject: "sub-bob", Username: "bob"})
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, again.ID)
+
+	// An identity belongs to a single account, and accounts to a single identity
+	_, err = service.LinkExternalUser(jane.ID, Identity{Subject: "sub-bob", Username: "bob"})
+	assert.ErrorIs(t, err, ErrIdentityLinked)
+	_, err = service.LinkExternalUser(bob.ID, Identity{Subject: "sub-other", Username: "bob"})
+	assert.Error(t, err)
+
+	// Authoritative providers own their usernames
+	carol, err := service.CreateUser("carol", "password123")
+	require.NoError(t, err)
+	linked, err = service.ExternalUser(Identity{Subject: "ldap:carol", Username: "carol", Authoritative: true})
+	require.NoError(t, err)
+	assert.Equal(t, carol.ID, linked.ID)
+
+	require.NoError(t, service.SetDisabled(jane.ID, true))
+	_, err = service.ExternalUser(Identity{Subject: "sub-jane", Username: "jane"})
+	assert.Error(t, err)
+}
+
+func TestHtpasswdProvider(t *testing.T) {
+	service := setupTestAuthService(t)
+
+	// Hashes made with openssl passwd -apr1, the SHA-1 variant of htpasswd and bcrypt
+	bcryptHash, err := auth.HashPassword("carol-secret")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	require.NoError(t, os.WriteFile(path, []byte("# users\n"+
+		"alice:$apr1$Xy7z$rbvSFV/g1m.nKgJXYzMAB1\n"+
+		"bob:{SHA}AMr9EmGC6KnnwBuy8N/QBJa+ck8=\n"+
+		"carol:"+bcryptHash+"\n"), 0600))
+
+	provider, err := NewHtpasswdProvider(path)
+	require.NoError(t, err)
+	service.SetProvider(provider)
+
+	alice, err := service.Login("test-user-agent", "alice", "secret1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", alice.User.Username)
+	_, err = service.Login("test-user-agent", "bob", "secret1")
+	require.NoError(t, err)
+	_, err = service.Login("test-user-agent", "carol", "carol-secret")
+	require.NoError(t, err)
+	_, err = service.Login("test-user-agent", "alice", "wrong")
+	assert.Error(t, err)
+
+	// Sessions work like those of local accounts, and later logins reuse the account
+	authCtx, err := service.ValidateToken(alice.Token)
+	require.NoError(t, err)
+	assert.Equal(t, alice.User.ID, authCtx.UserID)
+	again, err := service.Login("test-user-agent", "alice", "secret1")
+	require.NoError(t, err)
+	assert.Equal(t, alice.User.ID, again.User.ID)
+
+	// Accounts that only exist in the registry keep their own password
+	admin, err := service.CreateUser("admin", "password123")
+	require.NoError(t, err)
+	adminLogin, err := service.Login("test-user-agent", "admin", "password123")
+	require.NoError(t, err)
+	assert.Equal(t, admin.ID, adminLogin.User.ID)
+
+	// The provider owns its usernames, so existing accounts are linked and stop using their old password
+	dave, err := service.CreateUser("dave", "password123")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte("dave:{SHA}AMr9EmGC6KnnwBuy8N/QBJa+ck8=\n"), 0600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	daveLogin, err := service.Login("test-user-agent", "dave", "secret1")
+	require.NoError(t, err)
+	assert.Equal(t, dave.ID, daveLogin.User.ID)
+	_, err = service.Login("test-user-agent", "dave", "password123")
+	assert.Error(t, err)
+
+	// Users removed from the file can no longer log in
+	_, err = service.Login("test-user-agent", "alice", "secret1")
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("eve:plaintext\n"), 0600))
+	_, err = NewHtpasswdProvider(path)
+	assert.Error(t, err)
+}
+
+func TestTwoFactor(t *testing.T) {
+	service := setupTestAuthService(t)
+
+	user, err := service.CreateUser("testuser", "password123")
+	require.NoError(t, err)
+
+	enrollment, err := service.StartTOTPEnrollment(user)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/protodex:testuser?")
+	secret, err := base32NoPadding.DecodeString(enrollment.Secret)
+	require.NoError(t, err)
+	step := time.Now().Unix() / totpPeriod
+
+	// Logins skip the second factor until the enrollment is confirmed
+	loginResp, err := service.Login("test-user-agent", "testuser", "password123")
+	require.NoError(t, err)
+	assert.NotEmpty(t, loginResp.Token)
+
+	_, err = service.EnableTOTP(user.ID, "000000")
+	assert.ErrorIs(t, err, ErrInvalidCode)
+	recoveryCodes, err := service.EnableTOTP(user.ID, totpCode(secret, step))
+	require.NoError(t, err)
+	assert.Len(t, recoveryCodes, RecoveryCodeCount)
+
+	// The password alone now returns a challenge instead of a session
+	loginResp, err = service.Login("test-user-agent", "testuser", "password123")
+	require.NoError(t, err)
+	assert.Empty(t, loginResp.Token)
+	require.NotEmpty(t, loginResp.Challenge)
+	_, err = service.ValidateToken(loginResp.Challenge)
+	assert.Error(t, err)
+
+	// A code cannot be replayed
+	_, err = service.CompleteChallenge(loginResp.Challenge, totpCode(secret, step))
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	session, err := service.CompleteChallenge(loginResp.Challenge, totpCode(secret, step+1))
+	require.NoError(t, err)
+	assert.NotEmpty(t, session.Token)
+	assert.Equal(t, "testuser", session.User.Username)
+
+	// Challenges are single use
+	_, err = service.CompleteChallenge(loginResp.Challenge, totpCode(secret, step-1))
+	assert.ErrorIs(t, err, ErrChallengeExpired)
+
+	// Recovery codes work once, typed in any case
+	loginResp, err = service.Login("test-user-agent", "testuser", "password123")
+	require.NoError(t, err)
+	_, err = service.CompleteChallenge(loginResp.Challenge, strings.ToUpper(recoveryCodes[0]))
+	require.NoError(t, err)
+	count, err := service.CountRecoveryCodes(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RecoveryCodeCount-1, count)
+
+	loginResp, err = service.Login("test-user-agent", "testuser", "password123")
+	require.NoError(t, err)
+	_, err = service.CompleteChallenge(loginResp.Challenge, recoveryCodes[0])
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	// Too many wrong codes end the challenge
+	for range maxChallengeAttempts - 1 {
+		_, err = service.CompleteChallenge(loginResp.Challenge, "000000")
+		assert.ErrorIs(t, err, ErrInvalidCode)
+	}
+	_, err = service.CompleteChallenge(loginResp.Challenge, recoveryCodes[1])
+	assert.ErrorIs(t, err, ErrChallengeExpired)
+
+	require.NoError(t, service.DisableTOTP(user.ID, recoveryCodes[1]))
+	loginResp, err = service.Login("test-user-agent", "testuser", "password123")
+	require.NoError(t, err)
+	assert.NotEmpty(t, loginResp.Token)
+}
+
+func TestResetTwoFactor(t *testing.T) {
+	service := setupTestAuthService(t)
+
+	user, err := service.CreateUser("testuser", "password123")
+	require.NoError(t, err)
+	enrollment, err := service.StartTOTPEnrollment(user)
+	require.NoError(t, err)
+	secret, err := base32NoPadding.DecodeString(enrollment.Secret)
+	require.NoError(t, err)
+	_, err = service.EnableTOTP(user.ID, totpCode(secret, time.Now().Unix()/totpPeriod))
+	require.NoError(t, err)
+
+	session, err := service.StartSession("test-user-agent", user)
+	require.NoError(t, err)
+
+	require.NoError(t, service.ResetTOTP(user.ID))
+	_, err = service.ValidateToken(session.Token)
+	assert.Error(t, err)
+
+	loginResp, err := service.Login("test-user-agent", "testuser", "password123")
+	require.NoError(t, err)
+	assert.NotEmpty(t, loginResp.Token)
+	count, err := service.CountRecoveryCodes(user.ID)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestAPIToken(t *testing.T) {
+	service := setupTestAuthService(t)
+
+	user, err := service.CreateUser("testuser", "password123")
+	require.NoError(t, err)
+
+	token, created, err := service.CreateAPIToken(&auth.APIToken{
+		Name:      "ci",
+		UserID:    user.ID,
+		Scopes:    []string{auth.ScopePush},
+		PackageID: "pkg1",
+		CreatedBy: user.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "testuser", created.Username)
+
+	authCtx, err := service.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, authCtx.UserID)
+	assert.Nil(t, authCtx.Session)
+	assert.True(t, authCtx.HasScope(auth.ScopeRead))
+	assert.True(t, authCtx.HasScope(auth.ScopePush))
+	assert.False(t, authCtx.HasScope(auth.ScopeAdmin))
+	assert.Equal(t, "pkg1", authCtx.RestrictedTo())
+
+	require.NoError(t, service.RevokeAPIToken(created.ID))
+	_, err = service.ValidateToken(token)
+	assert.Error(t, err)
+}
+
+func TestExpiredAPIToken(t *testing.T) {
+	service := setupTestAuthService(t)
+
+	user, err := service.CreateUser("testuser", "password123")
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(-time.Hour)
+	token, _, err := service.CreateAPIToken(&auth.APIToken{
+		Name:      "old",
+		UserID:    user.ID,
+		Scopes:    []string{auth.ScopeRead},
+		ExpiresAt: &expiresAt,
+		CreatedBy: user.ID,
+	})
+	require.NoError(t, err)
+
+	_, err = service.ValidateToken(token)
+	assert.ErrorContains(t, err, "expired")
+}
+
+func TestServiceAccountCannotLogin(t *testing.T) {
+	service := setupTestAuthService(t)
+
+	owner, err := service.CreateUser("testuser", "password123")
+	require.NoError(t, err)
+
+	account, err := service.CreateServiceAccount("ci-bot", owner.ID)
+	require.NoError(t, err)
+	assert.True(t, account.ServiceAccount)
+	assert.Equal(t, owner.ID, account.CreatedBy)
+
+	_, err = service.Login("test-user-agent", "ci-bot", "")
+	assert.Error(t, err)
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	listPath := filepath.Join(t.TempDir(), "breached.txt")
+	// Sorted SHA-1 hashes in the Have I Been Pwned format, including those of "password123" and
+	// "letmein123", with Windows line endings like the downloads
+	hashes := []string{
+		"0000000A0E3B9F25FF41DE4B5AC238C2D545C7A8:15",
+		sha1Hex("letmein123") + ":7",
+		"CBFDAC6008F9CAB4083784CBD1874F76618D2A97:2412",
+		"FFFFFFF8A0382AA9C8D9536EFBA77F261815334D:3",
+	}
+	sort.Strings(hashes)
+	require.NoError(t, os.WriteFile(listPath, []byte(strings.Join(hashes, "\r\n")), 0644))
+
+	policy := PasswordPolicy{MinLength: 8}
+	require.NoError(t, policy.LoadBreachedPasswords(listPath))
+
+	assert.ErrorIs(t, policy.Check("short"), ErrWeakPassword)
+	assert.ErrorIs(t, policy.Check("letmein123"), ErrWeakPassword)
+	assert.ErrorIs(t, policy.Check("password123"), ErrWeakPassword)
+	assert.NoError(t, policy.Check("correct horse battery"))
+
+	// Every line of the list is found, including the first and the last
+	for _, line := range hashes {
+		hash, _, _ := strings.Cut(line, ":")
+		found, err := policy.breached.contains(hash)
+		require.NoError(t, err)
+		assert.True(t, found, hash)
+	}
+
+	// Larger lists with lines of varying length
+	var many []string
+	for i := range 1000 {
+		many = append(many, fmt.Sprintf("%s:%d", sha1Hex(fmt.Sprintf("password-%d", i)), i*i))
+	}
+	sort.Strings(many)
+	manyPath := filepath.Join(t.TempDir(), "many.txt")
+	require.NoError(t, os.WriteFile(manyPath, []byte(strings.Join(many, "\n")+"\n"), 0644))
+	large := PasswordPolicy{}
+	require.NoError(t, large.LoadBreachedPasswords(manyPath))
+	for i := range 1000 {
+		assert.ErrorIs(t, large.Check(fmt.Sprintf("password-%d", i)), ErrWeakPassword)
+		assert.NoError(t, large.Check(fmt.Sprintf("passphrase-%d", i)))
+	}
+
+	// Lists of plain passwords cannot be searched
+	plainPath := filepath.Join(t.TempDir(), "plain.txt")
+	require.NoError(t, os.WriteFile(plainPath, []byte("letmein123\n"), 0644))
+	assert.Error(t, (&PasswordPolicy{}).LoadBreachedPasswords(plainPath))
+
+	service := setupTestAuthService(t)
+	service.SetPasswordPolicy(policy)
+
+	_, err := service.CreateUser("testuser", "short")
+	assert.ErrorIs(t, err, ErrWeakPassword)
+}
+
+func TestLoginLimiter(t *testing.T) {
+	limiter := NewLoginLimiter(3, 5, 15*time.Minute)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	// A successful login forgets earlier failures of the user
+	limiter.Fail("alice", "10.0.0.1")
+	limiter.Fail("alice", "10.0.0.1")
+	limiter.Succeed("alice")
+	assert.False(t, limiter.Fail("alice", "10.0.0.1"))
+	assert.Zero(t, limiter.LockedFor("alice", "10.0.0.2"))
+
+	assert.False(t, limiter.Fail("alice", "10.0.0.2"))
+	assert.True(t, limiter.Fail("alice", "10.0.0.2"))
+	assert.Equal(t, 15*time.Minute, limiter.LockedFor("alice", "10.0.0.3"))
+	assert.Zero(t, limiter.LockedFor("bob", "10.0.0.3"))
+
+	// Failures across usernames lock out the address
+	for _, username := range []string{"bob", "carol", "dave"} {
+		limiter.Fail(username, "10.0.0.1")
+	}
+	assert.Positive(t, limiter.LockedFor("erin", "10.0.0.1"))
+
+	now = now.Add(16 * time.Minute)
+	assert.Zero(t, limiter.LockedFor("alice", "10.0.0.1"))
+}
+
 func TestTokenExtraction(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -111,6 +633,15 @@ func setupTestAuthService(t *testing.T) *Service {
 			id TEXT PRIMARY KEY,
 			username TEXT UNIQUE NOT NULL,
 			password_hash TEXT NOT NULL,
+			service_account INTEGER NOT NULL DEFAULT 0,
+			created_by TEXT,
+			email TEXT NOT NULL DEFAULT '',
+			is_admin INTEGER NOT NULL DEFAULT 0,
+			disabled INTEGER NOT NULL DEFAULT 0,
+			sso_subject TEXT UNIQUE,
+			totp_secret TEXT NOT NULL DEFAULT '',
+			totp_enabled INTEGER NOT NULL DEFAULT 0,
+			totp_last_step INTEGER NOT NULL DEFAULT 0,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)
 	`)
@@ -131,6 +662,46 @@ func setupTestAuthService(t *testing.T) *Service {
 	`)
 	require.NoError(t, err)
 
+	_, err = db.Exec(`
+		CREATE TABLE api_tokens (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			token_hash TEXT UNIQUE NOT NULL,
+			scopes TEXT NOT NULL,
+			package_id TEXT,
+			expires_at DATETIME,
+			created_by TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_used TIMESTAMP
+		)
+	`)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+		CREATE TABLE invites (
+			id TEXT PRIMARY KEY,
+			code_hash TEXT UNIQUE NOT NULL,
+			email TEXT NOT NULL DEFAULT '',
+			created_by TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			expires_at DATETIME NOT NULL,
+			used_by TEXT,
+			used_at DATETIME
+		)
+	`)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+		CREATE TABLE recovery_codes (
+			user_id TEXT NOT NULL,
+			code_hash TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, code_hash)
+		)
+	`)
+	require.NoError(t, err)
+
 	authStore := auth.NewStore(db)
 	service := NewAuthService(authStore)
 
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+)
+
+const (
+	// challengeTTL is how long a user has to enter their two-factor code after their password
+	challengeTTL = 5 * time.Minute
+	// maxChallengeAttempts is how many wrong codes end a challenge
+	maxChallengeAttempts = 5
+)
+
+// ErrChallengeExpired is returned for unknown, expired or exhausted two-factor challenges
+var ErrChallengeExpired = errors.New("login expired, log in again")
+
+// challenges holds the logins waiting for a two-factor code. Only hashes of the challenge tokens
+// are kept.
+type challenges struct {
+	mu      sync.Mutex
+	pending map[string]*challenge
+}
+
+type challenge struct {
+	userID   string
+	client   string
+	expires  time.Time
+	attempts int
+}
+
+// FinishLogin starts a session for a user who proved who they are. Users who enrolled in two-factor
+// authentication get a challenge instead, which CompleteChallenge turns into a session.
+func (s *Service) FinishLogin(client string, user *authstore.User) (*LoginResponse, error) {
+	if !user.TOTPEnabled {
+		return s.StartSession(client, user)
+	}
+
+	token, err := generateToken("pc_")
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+
+	s.challenges.mu.Lock()
+	defer s.challenges.mu.Unlock()
+	for key, pending := range s.challenges.pending {
+		if time.Now().After(pending.expires) {
+			delete(s.challenges.pending, key)
+		}
+	}
+	s.challenges.pending[hashToken(token)] = &challenge{
+		userID:  user.ID,
+		client:  client,
+		expires: time.Now().Add(challengeTTL),
+	}
+
+	return &LoginResponse{User: user, Challenge: token}, nil
+}
+
+// ChallengeUser returns the user a pending challenge belongs to
+func (s *Service) ChallengeUser(token string) (*authstore.User, error) {
+	s.challenges.mu.Lock()
+	pending, ok := s.challenges.pending[hashToken(token)]
+	s.challenges.mu.Unlock()
+	if !ok || time.Now().After(pending.expires) {
+		return nil, ErrChallengeExpired
+	}
+	return s.store.GetUserByID(pending.userID)
+}
+
+// CompleteChallenge checks the two-factor code of a challenge and starts the session
+func (s *Service) CompleteChallenge(token, code string) (*LoginResponse, error) {
+	key := hashToken(token)
+
+	s.challenges.mu.Lock()
+	pending, ok := s.challenges.pending[key]
+	s.challenges.mu.Unlock()
+	if !ok || time.Now().After(pending.expires) {
+		return nil, ErrChallengeExpired
+	}
+
+	user, err := s.store.GetUserByID(pending.userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, fmt.Errorf("account is disabled")
+	}
+
+	if err := s.CheckSecondFactor(user, code); err != nil {
+		s.challenges.mu.Lock()
+		pending.attempts++
+		if pending.attempts >= maxChallengeAttempts {
+			delete(s.challenges.pending, key)
+		}
+		s.challenges.mu.Unlock()
+		return nil, err
+	}
+
+	s.challenges.mu.Lock()
+	_, stillPending := s.challenges.pending[key]
+	delete(s.challenges.pending, key)
+	s.challenges.mu.Unlock()
+	if !stillPending {
+		return nil, ErrChallengeExpired
+	}
+
+	return s.StartSession(pending.client, user)
+}
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// HtpasswdProvider checks passwords against an Apache htpasswd file. The file is read again when it
+// changes, so users can be added without restarting the registry. Supported hashes are bcrypt,
+// MD5 ($apr1$) and SHA-1 ({SHA}).
+type HtpasswdProvider struct {
+	path string
+
+	mu       sync.Mutex
+	modified time.Time
+	hashes   map[string]string
+}
+
+// NewHtpasswdProvider reads the htpasswd file at path
+func NewHtpasswdProvider(path string) (*HtpasswdProvider, error) {
+	p := &HtpasswdProvider{path: path}
+	if err := p.reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *HtpasswdProvider) Name() string {
+	return ProviderHtpasswd
+}
+
+func (p *HtpasswdProvider) Authenticate(username, password string) (*Identity, error) {
+	p.mu.Lock()
+	err := p.reload()
+	hash, ok := p.hashes[username]
+	p.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed: %w", err)
+	}
+
+	if !ok || !checkHtpasswdHash(password, hash) {
+		return nil, fmt.Errorf("authentication failed: invalid username or password")
+	}
+	return &Identity{
+		Subject:       ProviderHtpasswd + ":" + username,
+		Username:      username,
+		Authoritative: true,
+	}, nil
+}
+
+// reload reads the file when it changed since it was last read. Logins fail while the file is
+// unreadable or invalid.
+func (p *HtpasswdProvider) reload() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to read htpasswd file: %w", err)
+	}
+	if p.hashes != nil && info.ModTime().Equal(p.modified) {
+		return nil
+	}
+	p.hashes = nil
+
+	file, err := os.Open(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to read htpasswd file: %w", err)
+	}
+	defer file.Close()
+
+	hashes := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		username, hash, ok := strings.Cut(entry, ":")
+		if !ok || username == "" {
+			return fmt.Errorf("htpasswd file %s: line %d is not username:hash", p.path, line)
+		}
+		if !supportedHtpasswdHash(hash) {
+			return fmt.Errorf("htpasswd file %s: user %s has an unsupported hash, use bcrypt (htpasswd -B)", p.path, username)
+		}
+		hashes[username] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read htpasswd file: %w", err)
+	}
+
+	p.hashes = hashes
+	p.modified = info.ModTime()
+	return nil
+}
+
+func supportedHtpasswdHash(hash string) bool {
+	return strings.HasPrefix(hash, "$2") || strings.HasPrefix(hash, "$apr1$") || strings.HasPrefix(hash, "{SHA}")
+}
+
+func checkHtpasswdHash(password, hash string) bool {
+	switch {
+	case strings.HasPrefix(hash, "$2"):
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case strings.HasPrefix(hash, "$apr1$"):
+		salt, _, _ := strings.Cut(strings.TrimPrefix(hash, "$apr1$"), "$")
+		return subtle.ConstantTimeCompare([]byte(apr1(password, salt)), []byte(hash)) == 1
+	case strings.HasPrefix(hash, "{SHA}"):
+		sum := sha1.Sum([]byte(password))
+		expected := "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(expected), []byte(hash)) == 1
+	}
+	return false
+}
+
+// apr1 computes the Apache variant of the MD5-based crypt hash
+func apr1(password, salt string) string {
+	const magic = "$apr1$"
+	if len(salt) > 8 {
+		salt = salt[:8]
+	}
+
+	alternate := md5.Sum([]byte(password + salt + password))
+
+	hash := md5.New()
+	hash.Write([]byte(password + magic + salt))
+	for i := len(password); i > 0; i -= 16 {
+		hash.Write(alternate[:min(i, 16)])
+	}
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			hash.Write([]byte{0})
+		} else {
+			hash.Write([]byte{password[0]})
+		}
+	}
+	final := hash.Sum(nil)
+
+	for i := range 1000 {
+		round := md5.New()
+		if i&1 != 0 {
+			round.Write([]byte(password))
+		} else {
+			round.Write(final)
+		}
+		if i%3 != 0 {
+			round.Write([]byte(salt))
+		}
+		if i%7 != 0 {
+			round.Write([]byte(password))
+		}
+		if i&1 != 0 {
+			round.Write(final)
+		} else {
+			round.Write([]byte(password))
+		}
+		final = round.Sum(nil)
+	}
+
+	const alphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	var encoded strings.Builder
+	encode := func(value uint32, chars int) {
+		for range chars {
+			encoded.WriteByte(alphabet[value&0x3f])
+			value >>= 6
+		}
+	}
+	for _, group := range [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}} {
+		encode(uint32(final[group[0]])<<16|uint32(final[group[1]])<<8|uint32(final[group[2]]), 4)
+	}
+	encode(uint32(final[11]), 2)
+
+	return magic + salt + "$" + encoded.String()
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+)
+
+// ErrUsernameTaken is returned when an external identity would get the username of an account that
+// cannot be linked to it
+var ErrUsernameTaken = errors.New("username is already taken")
+
+// ErrIdentityLinked is returned when an external identity already belongs to another account
+var ErrIdentityLinked = errors.New("identity is linked to another account")
+
+// Identity is who a provider outside the registry database says a user is
+type Identity struct {
+	// Subject identifies the user at the provider. It is empty for accounts of the registry database.
+	Subject  string
+	Username string
+	Email    string
+	// Authoritative providers are configured for the whole registry and own their usernames, so an
+	// existing account with the same username is the same person
+	Authoritative bool
+}
+
+// ExternalUser returns the user that logs in as identity. On the first login an existing account
+// with the same username is linked when the provider is authoritative, otherwise a new user without
+// a password is created. Other providers cannot claim existing accounts, their owners link them
+// with LinkExternalUser while logged in.
+func (s *Service) ExternalUser(identity Identity) (*authstore.User, error) {
+	if identity.Subject == "" {
+		return nil, fmt.Errorf("identity has no subject")
+	}
+
+	user, err := s.store.GetUserBySSOSubject(identity.Subject)
+	if err == nil {
+		if user.Disabled {
+			return nil, fmt.Errorf("account is disabled")
+		}
+		return user, nil
+	}
+
+	if identity.Username == "" {
+		return nil, fmt.Errorf("identity provider did not return a username")
+	}
+
+	existing, err := s.store.GetUserByUsername(identity.Username)
+	if err != nil {
+		return s.store.CreateSSOUser(identity.Username, strings.ToLower(identity.Email), identity.Subject)
+	}
+
+	linkable := existing.SSOSubject == "" && !existing.ServiceAccount && identity.Authoritative
+	if !linkable {
+		return nil, fmt.Errorf("%w: %s", ErrUsernameTaken, identity.Username)
+	}
+	if existing.Disabled {
+		return nil, fmt.Errorf("account is disabled")
+	}
+	if err := s.store.SetSSOSubject(existing.ID, identity.Subject); err != nil {
+		return nil, err
+	}
+	existing.SSOSubject = identity.Subject
+	return existing, nil
+}
+
+// LinkExternalUser links identity to the account of a logged-in user, so they can log in with it
+// from then on
+func (s *Service) LinkExternalUser(userID string, identity Identity) (*authstore.User, error) {
+	if identity.Subject == "" {
+		return nil, fmt.Errorf("identity has no subject")
+	}
+
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if linked, err := s.store.GetUserBySSOSubject(identity.Subject); err == nil {
+		if linked.ID != user.ID {
+			return nil, ErrIdentityLinked
+		}
+		return user, nil
+	}
+
+	if user.Disabled {
+		return nil, fmt.Errorf("account is disabled")
+	}
+	if user.ServiceAccount {
+		return nil, fmt.Errorf("service accounts cannot be linked to an identity")
+	}
+	if user.SSOSubject != "" {
+		return nil, fmt.Errorf("account is already linked to another identity")
+	}
+	if err := s.store.SetSSOSubject(user.ID, identity.Subject); err != nil {
+		return nil, err
+	}
+	user.SSOSubject = identity.Subject
+	return user, nil
+}
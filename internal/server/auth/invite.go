@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+)
+
+// inviteCodePrefix tells invite codes apart from other tokens
+const inviteCodePrefix = "pdi_"
+
+// DefaultInviteTTL is how long an invite can be used when no expiry is given
+const DefaultInviteTTL = 7 * 24 * time.Hour
+
+// CreateInvite generates an invite code valid until expiresAt. When email is set, only that address
+// can register with it. The plain code is only returned here, the store keeps its hash.
+func (s *Service) CreateInvite(createdBy, email string, expiresAt time.Time) (string, *authstore.Invite, error) {
+	code, err := generateToken(inviteCodePrefix)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate invite code: %w", err)
+	}
+
+	invite, err := s.store.CreateInvite(&authstore.Invite{
+		CodeHash:  hashToken(code),
+		Email:     strings.ToLower(email),
+		CreatedBy: createdBy,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return code, invite, nil
+}
+
+// CheckInvite returns the invite for code if it can still be used to register with email
+func (s *Service) CheckInvite(code, email string) (*authstore.Invite, error) {
+	invite, err := s.store.GetInviteByHash(hashToken(code))
+	if err != nil || invite.UsedBy != "" || invite.IsExpired() {
+		return nil, fmt.Errorf("invite is invalid or has expired")
+	}
+	if invite.Email != "" && !strings.EqualFold(invite.Email, email) {
+		return nil, fmt.Errorf("invite was issued for a different email address")
+	}
+	return invite, nil
+}
+
+func (s *Service) UseInvite(inviteID, userID string) error {
+	return s.store.UseInvite(inviteID, userID)
+}
+
+func (s *Service) ListInvites() ([]*authstore.Invite, error) {
+	return s.store.ListInvites()
+}
+
+func (s *Service) RevokeInvite(id string) error {
+	return s.store.DeleteInvite(id)
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// LoginLimiter counts failed logins per username and per client address and locks either out for a
+// while once it reaches its limit. Counts are kept in memory and start over when the server restarts.
+type LoginLimiter struct {
+	maxPerUser int
+	maxPerIP   int
+	lockout    time.Duration
+	now        func() time.Time
+
+	mu        sync.Mutex
+	attempts  map[string]*loginAttempts
+	lastPrune time.Time
+}
+
+type loginAttempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// NewLoginLimiter creates a limiter. A limit of zero disables that limit.
+func NewLoginLimiter(maxPerUser, maxPerIP int, lockout time.Duration) *LoginLimiter {
+	return &LoginLimiter{
+		maxPerUser: maxPerUser,
+		maxPerIP:   maxPerIP,
+		lockout:    lockout,
+		now:        time.Now,
+		attempts:   make(map[string]*loginAttempts),
+	}
+}
+
+// LockedFor returns how long logins for username or from ip are still locked out, or zero
+func (l *LoginLimiter) LockedFor(username, ip string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var remaining time.Duration
+	for _, key := range []string{userKey(username), ipKey(ip)} {
+		if attempts, ok := l.attempts[key]; ok && attempts.lockedUntil.After(now) {
+			remaining = max(remaining, attempts.lockedUntil.Sub(now))
+		}
+	}
+	return remaining
+}
+
+// Fail records a failed login and reports whether it locked out the username or the address
+func (l *LoginLimiter) Fail(username, ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	lockedUser := l.fail(userKey(username), l.maxPerUser, now)
+	lockedIP := l.fail(ipKey(ip), l.maxPerIP, now)
+	return lockedUser || lockedIP
+}
+
+// Succeed forgets the failed logins of username. Failures from the address are kept, so guessing
+// across many accounts is still limited.
+func (l *LoginLimiter) Succeed(username string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.attempts, userKey(username))
+}
+
+func (l *LoginLimiter) fail(key string, limit int, now time.Time) bool {
+	if limit <= 0 {
+		return false
+	}
+
+	attempts, ok := l.attempts[key]
+	if !ok {
+		attempts = &loginAttempts{}
+		l.attempts[key] = attempts
+	}
+	if now.Sub(attempts.lastFailure) > l.lockout {
+		attempts.failures = 0
+	}
+	attempts.failures++
+	attempts.lastFailure = now
+
+	if attempts.failures >= limit {
+		attempts.failures = 0
+		attempts.lockedUntil = now.Add(l.lockout)
+		return true
+	}
+	return false
+}
+
+// prune forgets failures that are older than the lockout duration, so occasional typos never add
+// up to a lockout. It runs at most once a minute.
+func (l *LoginLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < time.Minute {
+		return
+	}
+	l.lastPrune = now
+
+	for key, attempts := range l.attempts {
+		if attempts.lockedUntil.Before(now) && now.Sub(attempts.lastFailure) > l.lockout {
+			delete(l.attempts, key)
+		}
+	}
+}
+
+func userKey(username string) string {
+	return "user:" + username
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
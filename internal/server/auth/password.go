@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrWeakPassword is returned when a password does not satisfy the password policy
+var ErrWeakPassword = errors.New("password is too weak")
+
+// PasswordPolicy decides which passwords users can choose
+type PasswordPolicy struct {
+	MinLength int
+	breached  *breachedList
+}
+
+// LoadBreachedPasswords opens a list of refused passwords: SHA-1 hashes sorted by hash, one per
+// line and optionally followed by ":count", like the Have I Been Pwned download ordered by hash.
+// The list is searched on disk, so lists of any size only cost an open file.
+func (p *PasswordPolicy) LoadBreachedPasswords(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to read breached password list: %w", err)
+	}
+
+	list := &breachedList{file: file, size: info.Size()}
+	if list.size > 0 {
+		first, _, err := list.readLine(0)
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("failed to read breached password list: %w", err)
+		}
+		if !isSHA1Hex(lineHash(first)) {
+			file.Close()
+			return fmt.Errorf("breached password list %s must hold SHA-1 hashes sorted by hash", path)
+		}
+	}
+	p.breached = list
+	return nil
+}
+
+// Check returns an error describing why password is not allowed
+func (p *PasswordPolicy) Check(password string) error {
+	if len(password) < p.MinLength {
+		return fmt.Errorf("%w: it must be at least %d characters long", ErrWeakPassword, p.MinLength)
+	}
+	if p.breached != nil {
+		found, err := p.breached.contains(sha1Hex(password))
+		if err != nil {
+			return fmt.Errorf("failed to check breached password list: %w", err)
+		}
+		if found {
+			return fmt.Errorf("%w: it appears in a list of breached passwords", ErrWeakPassword)
+		}
+	}
+	return nil
+}
+
+// breachedList is a sorted file of SHA-1 hashes searched by bisecting byte offsets
+type breachedList struct {
+	file *os.File
+	size int64
+}
+
+// contains reports whether the list holds hash, an upper-case hex SHA-1 hash
+func (l *breachedList) contains(hash string) (bool, error) {
+	// Search for the line holding hash among the lines starting in [low, high)
+	low, high := int64(0), l.size
+	for low < high {
+		mid := low + (high-low)/2
+		start, err := l.lineStart(mid)
+		if err != nil {
+			return false, err
+		}
+		if start >= high {
+			high = mid
+			continue
+		}
+		line, next, err := l.readLine(start)
+		if err != nil {
+			return false, err
+		}
+		switch candidate := lineHash(line); {
+		case candidate == hash:
+			return true, nil
+		case candidate < hash:
+			low = next
+		default:
+			high = mid
+		}
+	}
+	return false, nil
+}
+
+// lineStart returns the offset of the first line starting at or after offset
+func (l *breachedList) lineStart(offset int64) (int64, error) {
+	if offset == 0 {
+		return 0, nil
+	}
+	_, next, err := l.readLine(offset - 1)
+	return next, err
+}
+
+// readLine returns the rest of the line at offset, without its line ending, and the offset of the
+// next line
+func (l *breachedList) readLine(offset int64) ([]byte, int64, error) {
+	var line []byte
+	buf := make([]byte, 128)
+	for pos := offset; pos < l.size; {
+		n, err := l.file.ReadAt(buf, pos)
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			line = append(line, buf[:i]...)
+			return line, pos + int64(i) + 1, nil
+		}
+		line = append(line, buf[:n]...)
+		pos += int64(n)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, 0, err
+		}
+	}
+	return line, l.size, nil
+}
+
+// lineHash returns the upper-case hash of a line of the list
+func lineHash(line []byte) string {
+	hash, _, _ := strings.Cut(strings.TrimSpace(string(line)), ":")
+	return strings.ToUpper(hash)
+}
+
+func sha1Hex(value string) string {
+	hash := sha1.Sum([]byte(value))
+	return strings.ToUpper(hex.EncodeToString(hash[:]))
+}
+
+func isSHA1Hex(value string) bool {
+	if len(value) != sha1.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
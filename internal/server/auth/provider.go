@@ -0,0 +1,43 @@
+package auth
+
+import (
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+)
+
+// Names of the authentication providers
+const (
+	ProviderLocal    = "local"
+	ProviderHtpasswd = "htpasswd"
+	ProviderLDAP     = "ldap"
+)
+
+// Provider checks the username and password of a login. Sessions and API tokens are always issued
+// by the registry, whichever provider checked the password.
+type Provider interface {
+	// Name identifies the provider in the configuration
+	Name() string
+	// Authenticate returns who the credentials belong to, or an error when they are not valid.
+	// Identities without a subject are accounts of the registry database.
+	Authenticate(username, password string) (*Identity, error)
+}
+
+// LocalProvider checks passwords against the hashes in the registry database
+type LocalProvider struct {
+	store authstore.Store
+}
+
+func NewLocalProvider(store authstore.Store) *LocalProvider {
+	return &LocalProvider{store: store}
+}
+
+func (p *LocalProvider) Name() string {
+	return ProviderLocal
+}
+
+func (p *LocalProvider) Authenticate(username, password string) (*Identity, error) {
+	user, err := p.store.Authenticate(username, password)
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{Username: user.Username, Email: user.Email}, nil
+}
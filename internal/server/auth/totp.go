@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+)
+
+const (
+	totpIssuer = "protodex"
+	totpPeriod = 30
+	totpDigits = 6
+	// totpSkew is how many time steps before and after the current one are accepted, so codes keep
+	// working when the clocks of the registry and the authenticator drift apart
+	totpSkew = 1
+
+	// RecoveryCodeCount is how many recovery codes a user gets
+	RecoveryCodeCount = 10
+)
+
+// ErrInvalidCode is returned when a two-factor code or recovery code is wrong or was used before
+var ErrInvalidCode = errors.New("invalid two-factor code")
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTPEnrollment is what a user adds to their authenticator app
+type TOTPEnrollment struct {
+	Secret string
+	// URI is the otpauth:// URI authenticator apps read from QR codes
+	URI string
+}
+
+// StartTOTPEnrollment generates a new secret for a user. Two-factor authentication is enabled once
+// the user confirms the secret with EnableTOTP.
+func (s *Service) StartTOTPEnrollment(user *authstore.User) (*TOTPEnrollment, error) {
+	if user.ServiceAccount {
+		return nil, fmt.Errorf("service accounts cannot use two-factor authentication")
+	}
+	if user.TOTPEnabled {
+		return nil, fmt.Errorf("two-factor authentication is already enabled")
+	}
+
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	encoded := base32NoPadding.EncodeToString(secret)
+	if err := s.store.SetTOTPSecret(user.ID, encoded); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{
+		"secret":    {encoded},
+		"issuer":    {totpIssuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(totpDigits)},
+		"period":    {fmt.Sprint(totpPeriod)},
+	}
+	label := url.PathEscape(totpIssuer + ":" + user.Username)
+	return &TOTPEnrollment{
+		Secret: encoded,
+		URI:    "otpauth://totp/" + label + "?" + params.Encode(),
+	}, nil
+}
+
+// EnableTOTP confirms an enrollment with a code from the authenticator and returns the recovery
+// codes of the user, which are only shown this once
+func (s *Service) EnableTOTP(userID, code string) ([]string, error) {
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, fmt.Errorf("two-factor authentication is already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, fmt.Errorf("start the enrollment before enabling two-factor authentication")
+	}
+	if err := s.checkTOTP(user, code); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.EnableTOTP(userID); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(userID)
+}
+
+// DisableTOTP turns off two-factor authentication after checking a code or recovery code
+func (s *Service) DisableTOTP(userID, code string) error {
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return fmt.Errorf("two-factor authentication is not enabled")
+	}
+	if err := s.CheckSecondFactor(user, code); err != nil {
+		return err
+	}
+	return s.store.DisableTOTP(userID)
+}
+
+// ResetTOTP turns off two-factor authentication without a code, for users who lost their
+// authenticator and recovery codes. Their sessions are ended.
+func (s *Service) ResetTOTP(userID string) error {
+	if err := s.store.DisableTOTP(userID); err != nil {
+		return err
+	}
+	_, err := s.store.DeleteUserSessions(userID, "")
+	return err
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user after checking a code
+func (s *Service) RegenerateRecoveryCodes(userID, code string) ([]string, error) {
+	user, err := s.store.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, fmt.Errorf("two-factor authentication is not enabled")
+	}
+	if err := s.CheckSecondFactor(user, code); err != nil {
+		return nil, err
+	}
+	return s.newRecoveryCodes(userID)
+}
+
+// CountRecoveryCodes returns how many unused recovery codes a user has left
+func (s *Service) CountRecoveryCodes(userID string) (int, error) {
+	return s.store.CountRecoveryCodes(userID)
+}
+
+// CheckSecondFactor checks a code from the user's authenticator or one of their recovery codes.
+// Every code works only once.
+func (s *Service) CheckSecondFactor(user *authstore.User, code string) error {
+	code = normalizeCode(code)
+	if len(code) == totpDigits {
+		return s.checkTOTP(user, code)
+	}
+
+	used, err := s.store.UseRecoveryCode(user.ID, hashToken(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// checkTOTP checks a code against the user's secret and records its time step, so it cannot be
+// replayed
+func (s *Service) checkTOTP(user *authstore.User, code string) error {
+	secret, err := base32NoPadding.DecodeString(user.TOTPSecret)
+	if err != nil || len(secret) == 0 {
+		return ErrInvalidCode
+	}
+
+	current := time.Now().Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(secret, step)), []byte(normalizeCode(code))) != 1 {
+			continue
+		}
+		fresh, err := s.store.UseTOTPStep(user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidCode
+		}
+		return nil
+	}
+	return ErrInvalidCode
+}
+
+func (s *Service) newRecoveryCodes(userID string) ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		bytes := make([]byte, 5)
+		if _, err := rand.Read(bytes); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		code := strings.ToLower(base32NoPadding.EncodeToString(bytes))
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = hashToken(code)
+	}
+
+	if err := s.store.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// totpCode computes the code of a time step as defined by RFC 6238
+func totpCode(secret []byte, step int64) string {
+	mac := hmac.New(sha1.New, secret)
+	_ = binary.Write(mac, binary.BigEndian, step)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// normalizeCode removes the spaces and dashes people type into codes
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/breaking"
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/semver"
+	"github.com/sirrobot01/protodex/internal/server/auth"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+)
+
+// checkCompatibility validates the schema extracted in schemaDir against the package's
+// published versions according to its compatibility mode, resolving dependencies as authCtx.
+// Semantic versions are only checked against the versions below them, so a fix of an older release
+// line is compared with that line and not with newer major versions. It returns the mode, the
+// closest previous version (empty if there is none) and any violations.
+func (s *Server) checkCompatibility(pkg *pkgstore.Package, version, schemaDir string, authCtx *auth.Context) (breaking.Mode, string, []breaking.Violation, error) {
+	mode, err := breaking.ParseMode(pkg.Compatibility)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if mode == breaking.ModeNone {
+		return mode, "", nil, nil
+	}
+
+	versions, err := s.publishedVersions(pkg)
+	if err != nil {
+		return mode, "", nil, err
+	}
+
+	// Versions are sorted by precedence, so the first lower one is the closest previous release
+	ordered := semver.IsValid(version)
+	var previous []*pkgstore.SchemaVersion
+	for _, v := range versions {
+		// Yanked versions were withdrawn, a fix does not have to stay compatible with them
+		if v.Version == version || v.Yanked {
+			continue
+		}
+		if ordered && !semver.Less(v.Version, version) {
+			continue
+		}
+		previous = append(previous, v)
+		if !mode.Transitive() {
+			break
+		}
+	}
+	if len(previous) == 0 {
+		return mode, "", nil, nil
+	}
+
+	current, err := s.compileSchema(schemaDir, authCtx)
+	if err != nil {
+		return mode, "", nil, err
+	}
+
+	history := make([]breaking.Revision, 0, len(previous))
+	for _, v := range previous {
+		dir, err := s.packageStore.GetSchemaPath(pkg.Name, v.Version)
+		if err != nil {
+			return mode, "", nil, err
+		}
+		set, err := s.compileSchema(dir, authCtx)
+		if err != nil {
+			return mode, "", nil, fmt.Errorf("failed to compile previous version %s: %w", v.Version, err)
+		}
+		history = append(history, breaking.Revision{Version: v.Version, Set: set})
+	}
+
+	return mode, previous[0].Version, breaking.Check(mode, current, history), nil
+}
+
+// breakingChange describes a schema rejected for breaking the compatibility policy of its package
+func breakingChange(version string, mode breaking.Mode, previousVersion string, violations []breaking.Violation) client.BreakingChangeError {
+	return client.BreakingChangeError{
+		Message:         fmt.Sprintf("version %s violates %s compatibility with %d change(s)", version, mode, len(violations)),
+		Compatibility:   string(mode),
+		PreviousVersion: previousVersion,
+		Violations:      toClientViolations(violations),
+	}
+}
+
+func toClientViolations(violations []breaking.Violation) []client.Violation {
+	clientViolations := make([]client.Violation, 0, len(violations))
+	for _, v := range violations {
+		clientViolations = append(clientViolations, client.Violation{
+			Rule:      string(v.Rule),
+			File:      v.File,
+			Subject:   v.Subject,
+			Message:   v.Message,
+			Against:   v.Against,
+			Direction: v.Direction,
+		})
+	}
+	return clientViolations
+}
+
+func (s *Server) getCompatibilityHandler(c *gin.Context) {
+	pkg := s.readablePackage(c)
+	if pkg == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, client.CompatibilityPolicy{
+		Package:       pkg.Name,
+		Compatibility: pkg.Compatibility,
+	})
+}
+
+func (s *Server) setCompatibilityHandler(c *gin.Context) {
+	pkg := s.authorizedPackage(c, pkgstore.RoleOwner, "change its compatibility policy")
+	if pkg == nil {
+		return
+	}
+
+	var req client.CompatibilityPolicy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mode, err := breaking.ParseMode(req.Compatibility)
+	if err != nil || req.Compatibility == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid compatibility mode, expected one of %v", breaking.Modes())})
+		return
+	}
+
+	if err := s.packageStore.SetCompatibility(pkg.ID, string(mode)); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to update compatibility")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionPackageCompatibility, auditstore.TargetPackage, pkg.Name, map[string]string{"compatibility": string(mode)})
+
+	c.JSON(http.StatusOK, client.CompatibilityPolicy{
+		Package:       pkg.Name,
+		Compatibility: string(mode),
+	})
+}
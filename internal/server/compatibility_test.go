@@ -0,0 +1,43 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sirrobot01/protodex/internal/breaking"
+	"github.com/sirrobot01/protodex/internal/client"
+)
+
+func TestHotfixesAreCheckedAgainstTheirReleaseLine(t *testing.T) {
+	const (
+		v1 = "message A { string name = 1; }"
+		v2 = "message A { string name = 1; string email = 2; }"
+	)
+	for _, mode := range []breaking.Mode{breaking.ModeBackward, breaking.ModeBackwardTransitive, breaking.ModeFullTransitive} {
+		t.Run(string(mode), func(t *testing.T) {
+			ts := newTestServer(t)
+			alice := ts.register("alice")
+			status, body := ts.pushProto(alice, "user-service", "v1.5.0", v1)
+			require.Equal(t, http.StatusCreated, status, body)
+			status, body = ts.do("PUT", "/api/packages/user-service/compatibility", alice, client.CompatibilityPolicy{Compatibility: string(mode)})
+			require.Equal(t, http.StatusOK, status, body)
+			status, body = ts.pushProto(alice, "user-service", "v2.0.0", v2)
+			require.Equal(t, http.StatusCreated, status, body)
+
+			// The hotfix drops email compared with v2.0.0, but v1.5.0 never had it
+			status, body = ts.pushProto(alice, "user-service", "v1.5.1", v1)
+			assert.Equal(t, http.StatusCreated, status, body)
+
+			// It still has to stay compatible with its own release line
+			status, body = ts.pushProto(alice, "user-service", "v1.5.2", "message A { int64 name = 1; }")
+			require.Equal(t, http.StatusUnprocessableEntity, status, body)
+			var rejected client.BreakingChangeError
+			require.NoError(t, json.Unmarshal([]byte(body), &rejected))
+			assert.Equal(t, "v1.5.1", rejected.PreviousVersion)
+		})
+	}
+}
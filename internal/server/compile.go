@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/sirrobot01/protodex/internal/manager"
+	"github.com/sirrobot01/protodex/internal/manager/fetcher"
+	"github.com/sirrobot01/protodex/internal/semver"
+	"github.com/sirrobot01/protodex/internal/server/auth"
+)
+
+// compileSchema builds a descriptor set for a schema stored in dir. It runs in process and never
+// fetches anything: imports resolve against the files of the schema, the well-known types and the
+// registry versions of the protodex dependencies declared in its protodex.yaml that authCtx can
+// read. Other dependencies, such as GitHub or HTTP sources and local paths, are not resolved, so the
+// schema cannot be compiled when it imports them.
+func (s *Server) compileSchema(dir string, authCtx *auth.Context) (*descriptorpb.FileDescriptorSet, error) {
+	projectConfig, err := manager.LoadProjectConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	project, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open schema: %w", err)
+	}
+	defer project.Close()
+
+	protoFiles, err := schemaProtoFiles(project.FS(), projectConfig)
+	if err != nil {
+		return nil, err
+	}
+	if len(protoFiles) == 0 {
+		return nil, fmt.Errorf("no proto files to compile")
+	}
+
+	dependencies, err := s.schemaDependencies(projectConfig, authCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, root := range dependencies {
+			root.Close()
+		}
+	}()
+
+	// Roots refuse paths that leave them, including through symlinks, so imports cannot read
+	// anything outside the schema and its dependencies
+	accessor := func(name string) (io.ReadCloser, error) {
+		if file, err := project.Open(filepath.FromSlash(name)); err == nil {
+			return file, nil
+		}
+		if dependency, rest, ok := strings.Cut(name, "/"); ok {
+			if root, ok := dependencies[dependency]; ok {
+				return root.Open(filepath.FromSlash(rest))
+			}
+		}
+		return nil, fs.ErrNotExist
+	}
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{Accessor: accessor}),
+	}
+	files, err := compiler.Compile(context.Background(), protoFiles...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile proto files: %w", err)
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range files {
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(file))
+	}
+	return set, nil
+}
+
+// schemaProtoFiles lists the proto files of a schema like the manager does, as paths relative to
+// the schema. The base directory has to be inside the schema.
+func schemaProtoFiles(schema fs.FS, projectConfig *manager.ProjectConfig) ([]string, error) {
+	baseDir := path.Clean(filepath.ToSlash(projectConfig.Files.BaseDir))
+	if !fs.ValidPath(baseDir) {
+		return nil, fmt.Errorf("invalid base_dir %q, it must be a relative path inside the schema", projectConfig.Files.BaseDir)
+	}
+
+	var protoFiles []string
+	err := fs.WalkDir(schema, baseDir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(name, ".proto") {
+			return nil
+		}
+		for _, pattern := range projectConfig.Files.Exclude {
+			if matched, _ := path.Match(path.Join(baseDir, filepath.ToSlash(pattern)), name); matched {
+				return nil
+			}
+		}
+		protoFiles = append(protoFiles, name)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list proto files: %w", err)
+	}
+	return protoFiles, nil
+}
+
+// schemaDependencies opens the registry versions of the protodex dependencies of a schema, keyed by
+// the dependency name its imports start with
+func (s *Server) schemaDependencies(projectConfig *manager.ProjectConfig, authCtx *auth.Context) (map[string]*os.Root, error) {
+	dependencies := make(map[string]*os.Root)
+	for _, dependency := range projectConfig.Dependencies {
+		if dependency.Type != fetcher.SourceProtodex {
+			continue
+		}
+		root, err := s.openDependency(dependency.Source, dependency.Version, authCtx)
+		if err != nil {
+			for _, root := range dependencies {
+				root.Close()
+			}
+			return nil, fmt.Errorf("failed to resolve dependency %s: %w", dependency.Name, err)
+		}
+		dependencies[dependency.Name] = root
+	}
+	return dependencies, nil
+}
+
+// openDependency opens the version of a dependency that query resolves to. Private packages the
+// caller cannot read are reported as not found, like the package routes do.
+func (s *Server) openDependency(packageName, query string, authCtx *auth.Context) (*os.Root, error) {
+	pkg, err := s.packageStore.GetPackage(packageName)
+	if err != nil || !s.canRead(pkg, authCtx) {
+		return nil, fmt.Errorf("package %s not found", packageName)
+	}
+	if query == "" {
+		query = semver.Latest
+	}
+	version, err := s.resolveVersion(pkg, query)
+	if err != nil {
+		return nil, fmt.Errorf("no version of %s matches %s", packageName, query)
+	}
+	dir, err := s.packageStore.GetSchemaPath(pkg.Name, version.Version)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenRoot(dir)
+}
@@ -0,0 +1,381 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/breaking"
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/diff"
+	"github.com/sirrobot01/protodex/internal/server/admission"
+	"github.com/sirrobot01/protodex/internal/server/webhook"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+	webhookstore "github.com/sirrobot01/protodex/internal/store/webhook"
+)
+
+// Changes of a file in a draft diff
+const (
+	fileAdded    = "added"
+	fileRemoved  = "removed"
+	fileModified = "modified"
+)
+
+// listDraftsHandler lists the versions of a package that have not been published
+func (s *Server) listDraftsHandler(c *gin.Context) {
+	pkg := s.readablePackage(c)
+	if pkg == nil {
+		return
+	}
+
+	versions, err := s.packageStore.ListVersions(pkg.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	drafts := make([]*client.Draft, 0)
+	for _, version := range versions {
+		if version.Published() {
+			continue
+		}
+		draft, err := s.toClientDraft(pkg, version)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		drafts = append(drafts, draft)
+	}
+
+	c.JSON(http.StatusOK, drafts)
+}
+
+func (s *Server) getDraftHandler(c *gin.Context) {
+	pkg := s.readablePackage(c)
+	if pkg == nil {
+		return
+	}
+	version := s.routeDraft(c, pkg)
+	if version == nil {
+		return
+	}
+
+	s.writeDraft(c, pkg, version)
+}
+
+// draftDiffHandler compares the files of a draft with the latest release of its package and, when
+// both compile, lists the breaking changes of the draft
+func (s *Server) draftDiffHandler(c *gin.Context) {
+	pkg := s.readablePackage(c)
+	if pkg == nil {
+		return
+	}
+	version := s.routeDraft(c, pkg)
+	if version == nil {
+		return
+	}
+
+	result := client.DraftDiff{Package: pkg.Name, Version: version.Version, Files: []client.FileDiff{}}
+	versions, err := s.publishedVersions(pkg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, v := range versions {
+		if !v.Yanked {
+			result.BaseVersion = v.Version
+			break
+		}
+	}
+
+	draftDir, err := s.packageStore.GetSchemaPath(pkg.Name, version.Version)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var baseDir string
+	var baseFiles []admission.File
+	if result.BaseVersion != "" {
+		baseDir, err = s.packageStore.GetSchemaPath(pkg.Name, result.BaseVersion)
+		if err == nil {
+			baseFiles, err = admission.ListFiles(baseDir)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	draftFiles, err := admission.ListFiles(draftDir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	changes := admission.Compare(baseFiles, draftFiles)
+	for change, paths := range map[string][]string{fileAdded: changes.Added, fileRemoved: changes.Removed, fileModified: changes.Changed} {
+		for _, path := range paths {
+			var oldText, newText string
+			if change != fileAdded {
+				oldText = readSchemaFile(baseDir, path)
+			}
+			if change != fileRemoved {
+				newText = readSchemaFile(draftDir, path)
+			}
+			result.Files = append(result.Files, client.FileDiff{
+				Path:   path,
+				Change: change,
+				Diff:   diff.Unified("a/"+path, "b/"+path, oldText, newText),
+			})
+		}
+	}
+	slices.SortFunc(result.Files, func(a, b client.FileDiff) int { return strings.Compare(a.Path, b.Path) })
+
+	if result.BaseVersion != "" {
+		// A schema that does not compile can still be reviewed from its file changes
+		current, err := s.compileSchema(draftDir, s.caller(c))
+		if err == nil {
+			baseline, baseErr := s.compileSchema(baseDir, s.caller(c))
+			if baseErr != nil {
+				err = fmt.Errorf("failed to compile %s: %w", result.BaseVersion, baseErr)
+			} else {
+				result.Violations = toClientViolations(breaking.Compare(baseline, current))
+				result.RequiredBump = breaking.RequiredBump(baseline, current).String()
+			}
+		}
+		if err != nil {
+			result.CompileError = err.Error()
+		}
+	}
+	if result.Violations == nil {
+		result.Violations = []client.Violation{}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// approveDraftHandler records the approval of a maintainer and publishes the draft once it has
+// enough approvals. On packages that require approvals, authors cannot approve their own drafts.
+func (s *Server) approveDraftHandler(c *gin.Context) {
+	pkg := s.authorizedPackage(c, pkgstore.RoleMaintainer, "review drafts")
+	if pkg == nil {
+		return
+	}
+	version := s.routeDraft(c, pkg)
+	if version == nil {
+		return
+	}
+	req, ok := bindReview(c)
+	if !ok {
+		return
+	}
+
+	if version.Status == pkgstore.StatusRejected {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("draft %s of %s was rejected, push it again to restart the review", version.Version, pkg.Name)})
+		return
+	}
+	authCtx := s.caller(c)
+	if pkg.RequiredApprovals > 0 && version.CreatedBy == authCtx.UserID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you cannot approve your own draft"})
+		return
+	}
+
+	if err := s.packageStore.ReviewVersion(version.ID, authCtx.UserID, pkgstore.DecisionApprove, req.Comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	target := versionTarget(pkg.Name, version.Version)
+	s.audit(c, auditstore.ActionVersionApprove, auditstore.TargetVersion, target, reviewDetails(req))
+
+	reviews, err := s.packageStore.ListReviews(version.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if countApprovals(pkg, version, reviews) >= requiredApprovals(pkg) {
+		// Versions published since the push may have made the draft incompatible, so the policy is
+		// checked again unless the approver explicitly overrides it
+		if !req.AllowBreaking && !s.draftCompatible(c, pkg, version) {
+			return
+		}
+		err := s.packageStore.SetVersionStatus(version.ID, pkgstore.StatusDraft, pkgstore.StatusPublished)
+		switch {
+		case err == nil:
+			s.audit(c, auditstore.ActionVersionPublish, auditstore.TargetVersion, target, nil)
+			s.publishEvent(c, webhookstore.EventVersionPushed, pkg, &webhook.Version{Version: version.Version, Checksum: version.Checksum})
+		// A concurrent review already published or rejected the draft, the response shows its status
+		case !errors.Is(err, pkgstore.ErrStatusChanged):
+			s.logger.Error().Err(err).Str("package", pkg.Name).Str("version", version.Version).Msg("Failed to publish draft")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	s.reloadDraft(c, pkg, version)
+}
+
+// draftCompatible checks a draft against the compatibility policy of its package before it is
+// published. It writes the error response and returns false when the draft breaks the policy.
+func (s *Server) draftCompatible(c *gin.Context, pkg *pkgstore.Package, version *pkgstore.SchemaVersion) bool {
+	schemaDir, err := s.packageStore.GetSchemaPath(pkg.Name, version.Version)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	mode, previousVersion, violations, err := s.checkCompatibility(pkg, version.Version, schemaDir, s.caller(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("compatibility check failed: %v", err)})
+		return false
+	}
+	if len(violations) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, breakingChange(version.Version, mode, previousVersion, violations))
+		return false
+	}
+	return true
+}
+
+// rejectDraftHandler rejects a draft, it is kept for reference until it is pushed again or deleted
+func (s *Server) rejectDraftHandler(c *gin.Context) {
+	pkg := s.authorizedPackage(c, pkgstore.RoleMaintainer, "review drafts")
+	if pkg == nil {
+		return
+	}
+	version := s.routeDraft(c, pkg)
+	if version == nil {
+		return
+	}
+	req, ok := bindReview(c)
+	if !ok {
+		return
+	}
+
+	authCtx := s.caller(c)
+	if err := s.packageStore.ReviewVersion(version.ID, authCtx.UserID, pkgstore.DecisionReject, req.Comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	err := s.packageStore.SetVersionStatus(version.ID, pkgstore.StatusDraft, pkgstore.StatusRejected)
+	if err != nil && !errors.Is(err, pkgstore.ErrStatusChanged) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionVersionReject, auditstore.TargetVersion, versionTarget(pkg.Name, version.Version), reviewDetails(req))
+
+	s.reloadDraft(c, pkg, version)
+}
+
+// routeDraft loads the draft named in the route, published versions are reported as missing. It
+// writes the error response and returns nil when there is no such draft.
+func (s *Server) routeDraft(c *gin.Context, pkg *pkgstore.Package) *pkgstore.SchemaVersion {
+	version, err := s.packageStore.GetSchemaVersion(pkg.ID, c.Param("version"))
+	if err != nil || version.Published() {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("draft %s of %s not found", c.Param("version"), pkg.Name)})
+		return nil
+	}
+	return version
+}
+
+// reloadDraft responds with the current state of a version after a review changed it
+func (s *Server) reloadDraft(c *gin.Context, pkg *pkgstore.Package, version *pkgstore.SchemaVersion) {
+	version, err := s.packageStore.GetSchemaVersion(pkg.ID, version.Version)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.writeDraft(c, pkg, version)
+}
+
+func (s *Server) writeDraft(c *gin.Context, pkg *pkgstore.Package, version *pkgstore.SchemaVersion) {
+	draft, err := s.toClientDraft(pkg, version)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, draft)
+}
+
+func (s *Server) toClientDraft(pkg *pkgstore.Package, version *pkgstore.SchemaVersion) (*client.Draft, error) {
+	reviews, err := s.packageStore.ListReviews(version.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	draft := &client.Draft{
+		Version:           toClientVersion(version),
+		Approvals:         countApprovals(pkg, version, reviews),
+		RequiredApprovals: requiredApprovals(pkg),
+		Reviews:           make([]client.DraftReview, 0, len(reviews)),
+	}
+	for _, review := range reviews {
+		draft.Reviews = append(draft.Reviews, client.DraftReview{
+			Username:  review.Username,
+			Decision:  review.Decision,
+			Comment:   review.Comment,
+			CreatedAt: review.CreatedAt,
+		})
+	}
+	return draft, nil
+}
+
+// requiredApprovals is how many approvals publish a draft, drafts pushed to packages without a
+// requirement need one
+func requiredApprovals(pkg *pkgstore.Package) int {
+	return max(pkg.RequiredApprovals, 1)
+}
+
+// countApprovals counts the approvals of a draft, leaving out the author on packages that require
+// approvals
+func countApprovals(pkg *pkgstore.Package, version *pkgstore.SchemaVersion, reviews []*pkgstore.Review) int {
+	var approvals int
+	for _, review := range reviews {
+		if review.Decision != pkgstore.DecisionApprove {
+			continue
+		}
+		if pkg.RequiredApprovals > 0 && review.UserID == version.CreatedBy {
+			continue
+		}
+		approvals++
+	}
+	return approvals
+}
+
+// bindReview reads the optional comment of a review. It writes the error response and returns
+// false when the body is invalid.
+func bindReview(c *gin.Context) (client.ReviewRequest, bool) {
+	var req client.ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, false
+	}
+	return req, true
+}
+
+func reviewDetails(req client.ReviewRequest) map[string]string {
+	details := make(map[string]string)
+	if req.Comment != "" {
+		details["comment"] = req.Comment
+	}
+	if req.AllowBreaking {
+		details["allow_breaking"] = "true"
+	}
+	if len(details) == 0 {
+		return nil
+	}
+	return details
+}
+
+// readSchemaFile returns the content of a file of a stored schema, empty when it cannot be read
+func readSchemaFile(dir, path string) string {
+	content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+	if err != nil {
+		return ""
+	}
+	return string(content)
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/server/auth"
+	"github.com/sirrobot01/protodex/internal/server/events"
+	webhookstore "github.com/sirrobot01/protodex/internal/store/webhook"
+)
+
+// eventKeepAlive is how often an idle event stream sends a comment so proxies keep it open
+const eventKeepAlive = 30 * time.Second
+
+// eventReauthorize is how often an event stream checks that its caller is still allowed to read it
+const eventReauthorize = 15 * time.Second
+
+// eventFilter selects the events a stream sends, empty fields match everything
+type eventFilter struct {
+	packages []string
+	org      string
+	events   []string
+}
+
+func (f eventFilter) matches(event *events.Event) bool {
+	name := event.Payload.Package.Name
+	if len(f.packages) > 0 && !slices.Contains(f.packages, name) {
+		return false
+	}
+	if f.org != "" && !strings.HasPrefix(name, f.org+"/") {
+		return false
+	}
+	return len(f.events) == 0 || slices.Contains(f.events, event.Payload.Event)
+}
+
+// eventsHandler streams the activity of the registry as Server-Sent Events, limited to the
+// packages the caller can see. The package, org and event query parameters filter the stream.
+// Clients that reconnect with the Last-Event-ID header receive the recent events they missed.
+//
+// Streams outlive the checks of the request that opened them. Package access is checked again for
+// every event, and the caller is authenticated again every eventReauthorize: the stream ends once
+// their session or token stops being valid, their account is disabled or they can no longer read
+// a package they asked for.
+func (s *Server) eventsHandler(c *gin.Context) {
+	filter := eventFilter{
+		packages: c.QueryArray("package"),
+		org:      c.Query("org"),
+		events:   c.QueryArray("event"),
+	}
+	for _, event := range filter.events {
+		if !slices.Contains(webhookstore.Events, event) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown event %q, expected one of %s", event, strings.Join(webhookstore.Events, ", "))})
+			return
+		}
+	}
+
+	var lastID uint64
+	if header := c.GetHeader("Last-Event-ID"); header != "" {
+		parsed, err := strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid Last-Event-ID"})
+			return
+		}
+		lastID = parsed
+	}
+
+	token := auth.ExtractTokenFromHeader(c.GetHeader("Authorization"))
+	authCtx := s.caller(c)
+	if !s.canStreamPackages(filter, authCtx) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "package not found"})
+		return
+	}
+
+	sub := s.events.Subscribe(lastID)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Stop nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	reauthorize := time.NewTicker(eventReauthorize)
+	defer reauthorize.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case <-reauthorize.C:
+			var ok bool
+			authCtx, ok = s.reauthorizeStream(token, filter)
+			return ok
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case event, ok := <-sub.C:
+			// The subscription ends when the client falls behind, it can resume with Last-Event-ID
+			if !ok {
+				return false
+			}
+			if filter.matches(event) && s.canRead(event.Package, authCtx) {
+				c.Render(-1, sse.Event{
+					Id:    strconv.FormatUint(event.ID, 10),
+					Event: event.Payload.Event,
+					Data:  event.Payload,
+				})
+			}
+			return true
+		}
+	})
+}
+
+// reauthorizeStream validates the token of an event stream again and checks it can still read the
+// packages it asked for. Anonymous streams only have their packages checked.
+func (s *Server) reauthorizeStream(token string, filter eventFilter) (*auth.Context, bool) {
+	var authCtx *auth.Context
+	if token != "" {
+		var err error
+		if authCtx, err = s.authService.ValidateToken(token); err != nil {
+			return nil, false
+		}
+	}
+	return authCtx, s.canStreamPackages(filter, authCtx)
+}
+
+// canStreamPackages reports whether the caller can read the packages an event stream asked for.
+// Packages that do not exist yet are allowed, their events start once they are created.
+func (s *Server) canStreamPackages(filter eventFilter, authCtx *auth.Context) bool {
+	for _, name := range filter.packages {
+		pkg, err := s.packageStore.GetPackage(name)
+		if err == nil && !s.canRead(pkg, authCtx) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/sirrobot01/protodex/internal/server/webhook"
+	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+)
+
+const (
+	// historySize is how many recent events are kept for subscribers that reconnect
+	historySize = 256
+	// bufferSize is how many events a subscriber can fall behind before it is dropped
+	bufferSize = 64
+)
+
+// Event is an activity of the registry sent to the subscribers of the live stream
+type Event struct {
+	// ID increases with every event. IDs start over when the registry restarts.
+	ID      uint64
+	Payload *webhook.Payload
+	// Package is the package as it was when the event happened, used to check who can see the event
+	Package *pkgstore.Package
+}
+
+// Subscription receives the events published after it was created. C is closed when the
+// subscription ends, either by Close or because the subscriber fell too far behind.
+type Subscription struct {
+	C      <-chan *Event
+	ch     chan *Event
+	broker *Broker
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+// Broker fans the events of the registry out to the subscribers of the live stream. It keeps the
+// recent events in memory so that subscribers can resume where they left off.
+type Broker struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     []*Event
+	subscribers map[*Subscription]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[*Subscription]struct{})}
+}
+
+// Publish sends an event to every subscriber. Subscribers whose buffer is full are dropped rather
+// than slowing down the request that caused the event.
+func (b *Broker) Publish(payload *webhook.Payload, pkg *pkgstore.Package) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	snapshot := *pkg
+	event := &Event{ID: b.lastID, Payload: payload, Package: &snapshot}
+
+	b.history = append(b.history, event)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+
+	for sub := range b.subscribers {
+		select {
+		case sub.ch <- event:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe starts a subscription. When lastID is the ID of an event still in the history, the
+// events that followed it are sent first.
+func (b *Broker) Subscribe(lastID uint64) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var missed []*Event
+	// An ID above the last one was issued before a restart, nothing can be replayed for it
+	if lastID > 0 && lastID <= b.lastID {
+		for _, event := range b.history {
+			if event.ID > lastID {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	ch := make(chan *Event, bufferSize+len(missed))
+	for _, event := range missed {
+		ch <- event
+	}
+	sub := &Subscription{C: ch, ch: ch, broker: b}
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// remove ends a subscription, b.mu must be held
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.ch)
+	}
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sirrobot01/protodex/internal/server/webhook"
+	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+	webhookstore "github.com/sirrobot01/protodex/internal/store/webhook"
+)
+
+func publish(b *Broker, event string) {
+	b.Publish(&webhook.Payload{Event: event}, &pkgstore.Package{Name: "payments"})
+}
+
+func TestBrokerPublish(t *testing.T) {
+	broker := NewBroker()
+	publish(broker, webhookstore.EventPackageCreated)
+
+	// Subscribers only receive the events published after they subscribed
+	first, second := broker.Subscribe(0), broker.Subscribe(0)
+	publish(broker, webhookstore.EventVersionPushed)
+
+	for _, sub := range []*Subscription{first, second} {
+		event := <-sub.C
+		assert.Equal(t, uint64(2), event.ID)
+		assert.Equal(t, webhookstore.EventVersionPushed, event.Payload.Event)
+		assert.Equal(t, "payments", event.Package.Name)
+	}
+
+	first.Close()
+	_, ok := <-first.C
+	assert.False(t, ok)
+	first.Close()
+
+	publish(broker, webhookstore.EventVersionYanked)
+	event := <-second.C
+	assert.Equal(t, uint64(3), event.ID)
+}
+
+func TestBrokerReplaysMissedEvents(t *testing.T) {
+	broker := NewBroker()
+	for i := 0; i < 5; i++ {
+		publish(broker, webhookstore.EventVersionPushed)
+	}
+
+	sub := broker.Subscribe(3)
+	require.Len(t, sub.C, 2)
+	assert.Equal(t, uint64(4), (<-sub.C).ID)
+	assert.Equal(t, uint64(5), (<-sub.C).ID)
+
+	// IDs from before a restart are not replayed
+	assert.Len(t, broker.Subscribe(42).C, 0)
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	broker := NewBroker()
+	sub := broker.Subscribe(0)
+	for i := 0; i < bufferSize+1; i++ {
+		publish(broker, webhookstore.EventVersionPushed)
+	}
+
+	var received int
+	for range sub.C {
+		received++
+	}
+	assert.Equal(t, bufferSize, received)
+	assert.Empty(t, broker.subscribers)
+}
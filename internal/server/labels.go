@@ -0,0 +1,160 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+)
+
+func (s *Server) listLabelsHandler(c *gin.Context) {
+	pkg := s.readablePackage(c)
+	if pkg == nil {
+		return
+	}
+
+	labels, err := s.packageStore.ListLabels(pkg.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientLabels := make([]client.Label, 0, len(labels))
+	for _, label := range labels {
+		clientLabels = append(clientLabels, toClientLabel(label))
+	}
+	c.JSON(http.StatusOK, clientLabels)
+}
+
+// labelHistoryHandler lists every move of a label, including the moves of a label since removed
+func (s *Server) labelHistoryHandler(c *gin.Context) {
+	pkg := s.readablePackage(c)
+	if pkg == nil {
+		return
+	}
+
+	moves, err := s.packageStore.LabelHistory(pkg.ID, c.Param("label"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(moves) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("label %s of %s not found", c.Param("label"), pkg.Name)})
+		return
+	}
+
+	clientMoves := make([]client.LabelMove, 0, len(moves))
+	for _, move := range moves {
+		clientMoves = append(clientMoves, client.LabelMove{
+			Label:     move.Label,
+			From:      move.From,
+			To:        move.To,
+			MovedBy:   move.MovedBy,
+			CreatedAt: move.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, clientMoves)
+}
+
+// setLabelHandler points a label at the version a query resolves to. The query may be another
+// label, so promoting what stable points at to prod is a single request.
+func (s *Server) setLabelHandler(c *gin.Context) {
+	pkg := s.authorizedPackage(c, pkgstore.RoleMaintainer, "move its labels")
+	if pkg == nil {
+		return
+	}
+
+	name := c.Param("label")
+	if err := pkgstore.ValidateLabel(name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req client.SetLabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schemaVersion, err := s.resolveVersion(pkg, req.Version)
+	if err != nil {
+		writeResolveError(c, req.Version, err)
+		return
+	}
+	if schemaVersion.Yanked {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("version %s of %s has been yanked and cannot be labelled", schemaVersion.Version, pkg.Name)})
+		return
+	}
+
+	authCtx := s.caller(c)
+	previous, err := s.packageStore.SetLabel(pkg.ID, name, schemaVersion.ID, authCtx.UserID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("package", pkg.Name).Str("label", name).Msg("Failed to set label")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if previous != schemaVersion.Version {
+		details := map[string]string{"to": schemaVersion.Version}
+		if previous != "" {
+			details["from"] = previous
+		}
+		s.audit(c, auditstore.ActionLabelMove, auditstore.TargetLabel, versionTarget(pkg.Name, name), details)
+	}
+
+	label, err := s.packageStore.GetLabel(pkg.ID, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toClientLabel(label))
+}
+
+func (s *Server) deleteLabelHandler(c *gin.Context) {
+	pkg := s.authorizedPackage(c, pkgstore.RoleMaintainer, "remove its labels")
+	if pkg == nil {
+		return
+	}
+
+	name := c.Param("label")
+	authCtx := s.caller(c)
+	if err := s.packageStore.DeleteLabel(pkg.ID, name, authCtx.UserID); err != nil {
+		if errors.Is(err, pkgstore.ErrLabelNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("label %s of %s not found", name, pkg.Name)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionLabelDelete, auditstore.TargetLabel, versionTarget(pkg.Name, name), nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// versionLabels returns the names of the labels pointing at a version
+func (s *Server) versionLabels(pkg *pkgstore.Package, version string) ([]string, error) {
+	labels, err := s.packageStore.ListLabels(pkg.ID)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, label := range labels {
+		if label.Version == version {
+			names = append(names, label.Name)
+		}
+	}
+	return names, nil
+}
+
+func toClientLabel(label *pkgstore.Label) client.Label {
+	return client.Label{
+		Name:      label.Name,
+		Version:   label.Version,
+		UpdatedBy: label.UpdatedBy,
+		UpdatedAt: label.UpdatedAt,
+	}
+}
@@ -0,0 +1,309 @@
+package ldap
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockEntry is an entry of the mock directory
+type mockEntry struct {
+	dn         string
+	attributes map[string][]string
+}
+
+// mockDirectory is an in-process LDAP server. It answers binds with the passwords in passwords and
+// searches with the matching entries.
+type mockDirectory struct {
+	t        *testing.T
+	listener net.Listener
+
+	mu        sync.Mutex
+	entries   []mockEntry
+	passwords map[string]string
+	binds     []string
+	filters   []string
+}
+
+func newMockDirectory(t *testing.T) *mockDirectory {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	d := &mockDirectory{
+		t:        t,
+		listener: listener,
+		entries: []mockEntry{
+			{dn: "uid=jane,ou=people,dc=example,dc=com", attributes: map[string][]string{
+				"uid": {"jane"}, "mail": {"jane@example.com"}, "objectClass": {"person"},
+			}},
+			{dn: "uid=bob,ou=people,dc=example,dc=com", attributes: map[string][]string{
+				"uid": {"bob"}, "objectClass": {"person"},
+			}},
+			{dn: "cn=printer,ou=devices,dc=example,dc=com", attributes: map[string][]string{
+				"cn": {"printer"}, "objectClass": {"device"},
+			}},
+		},
+		passwords: map[string]string{
+			"cn=protodex,dc=example,dc=com":        "service-secret",
+			"uid=jane,ou=people,dc=example,dc=com": "jane-secret",
+			"uid=bob,ou=people,dc=example,dc=com":  "bob-secret",
+		},
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go d.serve(conn)
+		}
+	}()
+	return d
+}
+
+func (d *mockDirectory) url() string {
+	return "ldap://" + d.listener.Addr().String()
+}
+
+func (d *mockDirectory) serve(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		message, err := ber.ReadPacket(conn)
+		if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if !assert.NoError(d.t, err) {
+			return
+		}
+		id, op := message.Children[0].Value.(int64), message.Children[1]
+
+		switch op.Tag {
+		case goldap.ApplicationBindRequest:
+			dn, password := op.Children[1].Value.(string), op.Children[2].Data.String()
+			d.mu.Lock()
+			d.binds = append(d.binds, dn)
+			expected, ok := d.passwords[dn]
+			d.mu.Unlock()
+			code := goldap.LDAPResultSuccess
+			if !ok || expected != password {
+				code = goldap.LDAPResultInvalidCredentials
+			}
+			d.respond(conn, id, goldap.ApplicationBindResponse, code)
+		case goldap.ApplicationSearchRequest:
+			baseDN, sizeLimit, filter := op.Children[0].Value.(string), op.Children[3].Value.(int64), op.Children[6]
+			description, err := goldap.DecompileFilter(filter)
+			assert.NoError(d.t, err)
+			d.mu.Lock()
+			d.filters = append(d.filters, description)
+			entries := d.entries
+			d.mu.Unlock()
+
+			code, sent := goldap.LDAPResultSuccess, int64(0)
+			for _, entry := range entries {
+				if !strings.HasSuffix(entry.dn, baseDN) || !matches(filter, entry) {
+					continue
+				}
+				if sizeLimit > 0 && sent == sizeLimit {
+					code = goldap.LDAPResultSizeLimitExceeded
+					break
+				}
+				d.write(conn, id, searchResultEntry(entry))
+				sent++
+			}
+			d.respond(conn, id, goldap.ApplicationSearchResultDone, code)
+		case goldap.ApplicationUnbindRequest:
+			return
+		default:
+			d.respond(conn, id, goldap.ApplicationExtendedResponse, goldap.LDAPResultProtocolError)
+		}
+	}
+}
+
+func (d *mockDirectory) respond(conn net.Conn, id int64, op ber.Tag, code int) {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, op, nil, "")
+	response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	d.write(conn, id, response)
+}
+
+func (d *mockDirectory) write(conn net.Conn, id int64, op *ber.Packet) {
+	message := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	message.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+	message.AppendChild(op)
+	_, err := conn.Write(message.Bytes())
+	assert.NoError(d.t, err)
+}
+
+func searchResultEntry(entry mockEntry) *ber.Packet {
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	for name, values := range entry.attributes {
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, ""))
+		}
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "")
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, ""))
+	result.AppendChild(attributes)
+	return result
+}
+
+// matches evaluates the filters the provider sends
+func matches(filter *ber.Packet, entry mockEntry) bool {
+	switch filter.Tag {
+	case goldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !matches(child, entry) {
+				return false
+			}
+		}
+		return true
+	case goldap.FilterOr:
+		for _, child := range filter.Children {
+			if matches(child, entry) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterNot:
+		return !matches(filter.Children[0], entry)
+	case goldap.FilterEqualityMatch:
+		for _, value := range attribute(entry, filter.Children[0].Data.String()) {
+			if strings.EqualFold(value, filter.Children[1].Data.String()) {
+				return true
+			}
+		}
+		return false
+	case goldap.FilterPresent:
+		return len(attribute(entry, filter.Data.String())) > 0
+	}
+	return false
+}
+
+func attribute(entry mockEntry, name string) []string {
+	for attribute, values := range entry.attributes {
+		if strings.EqualFold(attribute, name) {
+			return values
+		}
+	}
+	return nil
+}
+
+func newTestProvider(t *testing.T, directory *mockDirectory) *Provider {
+	provider, err := NewProvider(Config{
+		URL:          directory.url(),
+		BindDN:       "cn=protodex,dc=example,dc=com",
+		BindPassword: "service-secret",
+		BaseDN:       "ou=people,dc=example,dc=com",
+		UserFilter:   "(&(objectClass=person)(uid=%s))",
+		Timeout:      5 * time.Second,
+	})
+	require.NoError(t, err)
+	return provider
+}
+
+func TestProviderAuthenticate(t *testing.T) {
+	directory := newMockDirectory(t)
+	provider := newTestProvider(t, directory)
+
+	identity, err := provider.Authenticate("JANE", "jane-secret")
+	require.NoError(t, err)
+	assert.Equal(t, "jane", identity.Username)
+	assert.Equal(t, "ldap:jane", identity.Subject)
+	assert.Equal(t, "jane@example.com", identity.Email)
+	assert.True(t, identity.Authoritative)
+
+	// The provider searched as its service account, then bound as the user
+	assert.Equal(t, []string{"cn=protodex,dc=example,dc=com", "uid=jane,ou=people,dc=example,dc=com"}, directory.binds)
+	assert.Equal(t, []string{"(&(objectClass=person)(uid=JANE))"}, directory.filters)
+
+	identity, err = provider.Authenticate("bob", "bob-secret")
+	require.NoError(t, err)
+	assert.Empty(t, identity.Email)
+}
+
+func TestProviderRejectsInvalidCredentials(t *testing.T) {
+	directory := newMockDirectory(t)
+	provider := newTestProvider(t, directory)
+
+	_, err := provider.Authenticate("jane", "wrong")
+	assert.ErrorContains(t, err, "invalid username or password")
+
+	_, err = provider.Authenticate("nobody", "jane-secret")
+	assert.ErrorContains(t, err, "0 directory entries")
+
+	// An empty password would be an anonymous bind that servers accept
+	_, err = provider.Authenticate("jane", "")
+	assert.Error(t, err)
+
+	// Filter characters in usernames are escaped, so they cannot widen the search
+	_, err = provider.Authenticate("*", "jane-secret")
+	assert.Error(t, err)
+	assert.Equal(t, "(&(objectClass=person)(uid=\\2a))", directory.filters[len(directory.filters)-1])
+
+	// Entries outside the base DN cannot log in
+	_, err = provider.Authenticate("printer", "anything")
+	assert.Error(t, err)
+
+	wrongService, err := NewProvider(Config{
+		URL:          directory.url(),
+		BindDN:       "cn=protodex,dc=example,dc=com",
+		BindPassword: "wrong",
+		BaseDN:       "dc=example,dc=com",
+	})
+	require.NoError(t, err)
+	_, err = wrongService.Authenticate("jane", "jane-secret")
+	assert.ErrorContains(t, err, "failed to bind as cn=protodex")
+}
+
+func TestProviderRejectsAmbiguousUsers(t *testing.T) {
+	directory := newMockDirectory(t)
+	provider, err := NewProvider(Config{
+		URL:          directory.url(),
+		BindDN:       "cn=protodex,dc=example,dc=com",
+		BindPassword: "service-secret",
+		BaseDN:       "ou=people,dc=example,dc=com",
+		UserFilter:   "(|(uid=%s)(objectClass=person))",
+	})
+	require.NoError(t, err)
+
+	_, err = provider.Authenticate("jane", "jane-secret")
+	assert.ErrorContains(t, err, "2 directory entries match")
+
+	// Searches stop at two entries, directories answer that more match
+	directory.mu.Lock()
+	directory.entries = append(directory.entries, mockEntry{dn: "uid=eve,ou=people,dc=example,dc=com", attributes: map[string][]string{
+		"uid": {"eve"}, "objectClass": {"person"},
+	}})
+	directory.mu.Unlock()
+	_, err = provider.Authenticate("jane", "jane-secret")
+	assert.ErrorContains(t, err, "several directory entries match")
+}
+
+func TestNewProviderChecksFilter(t *testing.T) {
+	for _, filter := range []string{"(uid=jane)", "uid=%s", "(uid=%s", "(uid=%s))", "(cn=\\2%s)"} {
+		_, err := NewProvider(Config{URL: "ldap://localhost", BaseDN: "dc=example,dc=com", UserFilter: filter})
+		assert.Error(t, err, filter)
+	}
+
+	_, err := NewProvider(Config{URL: "ldap://localhost", BaseDN: "dc=example,dc=com", UserFilter: "(&(objectClass=person)(|(uid=%s)(mail=%s)))"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,151 @@
+// Package ldap checks passwords against a directory with simple binds, on top of go-ldap.
+package ldap
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	goldap "github.com/go-ldap/ldap/v3"
+
+	"github.com/sirrobot01/protodex/internal/server/auth"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Config tells the provider how to find users in a directory
+type Config struct {
+	// URL of the directory server, ldap:// or ldaps://
+	URL string
+	// StartTLS upgrades ldap:// connections to TLS before credentials are sent
+	StartTLS           bool
+	InsecureSkipVerify bool
+	// BindDN and BindPassword are used to search for users. Without them the search is anonymous.
+	BindDN       string
+	BindPassword string
+	// BaseDN is where users are searched
+	BaseDN string
+	// UserFilter finds the entry of a user, with %s replaced by the escaped username
+	UserFilter        string
+	UsernameAttribute string
+	EmailAttribute    string
+	Timeout           time.Duration
+}
+
+// Provider checks passwords by binding to a directory as the user. The user's entry is found with
+// a search first, so users can log in with their username rather than their DN.
+type Provider struct {
+	config Config
+}
+
+// NewProvider checks config and returns a provider for it. The directory is only contacted when
+// users log in.
+func NewProvider(config Config) (*Provider, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("LDAP URL is required")
+	}
+	if _, err := url.Parse(config.URL); err != nil {
+		return nil, fmt.Errorf("invalid LDAP URL: %w", err)
+	}
+	if config.BaseDN == "" {
+		return nil, fmt.Errorf("LDAP base DN is required")
+	}
+	if config.UserFilter == "" {
+		config.UserFilter = "(uid=%s)"
+	}
+	if !strings.Contains(config.UserFilter, "%s") {
+		return nil, fmt.Errorf("LDAP user filter must contain %%s")
+	}
+	if _, err := goldap.CompileFilter(strings.ReplaceAll(config.UserFilter, "%s", "user")); err != nil {
+		return nil, fmt.Errorf("invalid LDAP user filter: %w", err)
+	}
+	if config.UsernameAttribute == "" {
+		config.UsernameAttribute = "uid"
+	}
+	if config.EmailAttribute == "" {
+		config.EmailAttribute = "mail"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+	return &Provider{config: config}, nil
+}
+
+func (p *Provider) Name() string {
+	return auth.ProviderLDAP
+}
+
+// Authenticate finds the entry of username and binds as it with password
+func (p *Provider) Authenticate(username, password string) (*auth.Identity, error) {
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("authentication failed: username and password are required")
+	}
+
+	conn, err := p.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if p.config.BindDN != "" {
+		if err := conn.Bind(p.config.BindDN, p.config.BindPassword); err != nil {
+			return nil, fmt.Errorf("failed to bind as %s: %w", p.config.BindDN, err)
+		}
+	}
+
+	filter := strings.ReplaceAll(p.config.UserFilter, "%s", goldap.EscapeFilter(username))
+	result, err := conn.Search(goldap.NewSearchRequest(p.config.BaseDN, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases,
+		2, int(p.config.Timeout.Seconds()), false, filter, []string{p.config.UsernameAttribute, p.config.EmailAttribute}, nil))
+	if goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("authentication failed: several directory entries match %s", username)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to search for user: %w", err)
+	}
+	if len(result.Entries) != 1 {
+		return nil, fmt.Errorf("authentication failed: %d directory entries match %s", len(result.Entries), username)
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return nil, fmt.Errorf("authentication failed: invalid username or password")
+		}
+		return nil, fmt.Errorf("failed to bind as %s: %w", entry.DN, err)
+	}
+
+	// The directory spells the username the way the account is known, whatever case was typed
+	name := entry.GetEqualFoldAttributeValue(p.config.UsernameAttribute)
+	if name == "" {
+		name = username
+	}
+	return &auth.Identity{
+		Subject:       auth.ProviderLDAP + ":" + strings.ToLower(name),
+		Username:      name,
+		Email:         entry.GetEqualFoldAttributeValue(p.config.EmailAttribute),
+		Authoritative: true,
+	}, nil
+}
+
+func (p *Provider) connect() (*goldap.Conn, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: p.config.InsecureSkipVerify}
+	conn, err := goldap.DialURL(p.config.URL,
+		goldap.DialWithDialer(&net.Dialer{Timeout: p.config.Timeout}),
+		goldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", p.config.URL, err)
+	}
+	conn.SetTimeout(p.config.Timeout)
+
+	if p.config.StartTLS && strings.HasPrefix(p.config.URL, "ldap://") {
+		parsed, _ := url.Parse(p.config.URL)
+		tlsConfig.ServerName = parsed.Hostname()
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	return conn, nil
+}
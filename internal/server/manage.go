@@ -0,0 +1,99 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+	webhookstore "github.com/sirrobot01/protodex/internal/store/webhook"
+)
+
+func (s *Server) deletePackageHandler(c *gin.Context) {
+	pkg := s.authorizedPackage(c, pkgstore.RoleOwner, "delete the package")
+	if pkg == nil {
+		return
+	}
+
+	if err := s.packageStore.DeletePackage(pkg.ID); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to delete package")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionPackageDelete, auditstore.TargetPackage, pkg.Name, nil)
+
+	// The webhooks of the package are removed once the deletion has been queued for them
+	s.publishEvent(c, webhookstore.EventPackageDeleted, pkg, nil)
+	if err := s.webhookStore.DeletePackageWebhooks(pkg.ID); err != nil {
+		s.logger.Error().Err(err).Str("package", pkg.Name).Msg("Failed to delete package webhooks")
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// renamePackageHandler renames a package, the old name keeps resolving to it as an alias
+func (s *Server) renamePackageHandler(c *gin.Context) {
+	pkg := s.authorizedPackage(c, pkgstore.RoleOwner, "rename the package")
+	if pkg == nil {
+		return
+	}
+
+	var req client.RenameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authCtx, _ := s.getAuthContext(c)
+	if !s.checkPackageName(c, authCtx, req.Name) {
+		return
+	}
+
+	renamed, err := s.packageStore.RenamePackage(pkg.ID, req.Name)
+	if err != nil {
+		if errors.Is(err, pkgstore.ErrNameTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("package name %s is already taken", req.Name)})
+			return
+		}
+		s.logger.Error().Err(err).Msg("Failed to rename package")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionPackageRename, auditstore.TargetPackage, pkg.Name, map[string]string{"name": renamed.Name})
+
+	c.JSON(http.StatusOK, toClientPackage(renamed))
+}
+
+// transferPackageHandler hands a package over to another user
+func (s *Server) transferPackageHandler(c *gin.Context) {
+	pkg := s.authorizedPackage(c, pkgstore.RoleOwner, "transfer the package")
+	if pkg == nil {
+		return
+	}
+
+	var req client.TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	owner, err := s.authService.GetUserByUsername(req.Owner)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("user %s not found", req.Owner)})
+		return
+	}
+
+	if err := s.packageStore.TransferOwnership(pkg.ID, owner.ID); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to transfer package")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionPackageTransfer, auditstore.TargetPackage, pkg.Name, map[string]string{"owner": owner.Username})
+
+	pkg.OwnerID = owner.ID
+	c.JSON(http.StatusOK, toClientPackage(pkg))
+}
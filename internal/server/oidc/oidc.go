@@ -0,0 +1,275 @@
+// Package oidc implements the parts of OpenID Connect the registry needs to log users in through an
+// identity provider: discovery, the authorization code flow with PKCE, the device authorization
+// flow and ID token verification, which is left to go-oidc.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+)
+
+// Errors an identity provider returns while a device login is not finished
+const (
+	ErrorAuthorizationPending = "authorization_pending"
+	ErrorSlowDown             = "slow_down"
+	ErrorExpiredToken         = "expired_token"
+	ErrorAccessDenied         = "access_denied"
+)
+
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// Config identifies the registry to an identity provider
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// Scopes requested in addition to openid
+	Scopes []string
+}
+
+// Provider talks to one identity provider
+type Provider struct {
+	config     Config
+	metadata   metadata
+	httpClient *http.Client
+	verifier   *gooidc.IDTokenVerifier
+}
+
+// metadata is the subset of the provider's discovery document the registry uses
+type metadata struct {
+	Issuer                      string `json:"issuer"`
+	AuthorizationEndpoint       string `json:"authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+	JWKSURI                     string `json:"jwks_uri"`
+}
+
+// Tokens is a successful token response
+type Tokens struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+}
+
+// TokenError is an error response of the token or device authorization endpoint
+type TokenError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *TokenError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Description)
+	}
+	return e.Code
+}
+
+// DeviceAuth is the start of a device login. The user opens VerificationURI and enters UserCode
+// while the device polls with DeviceCode.
+type DeviceAuth struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval,omitempty"`
+}
+
+// NewProvider reads the discovery document of the issuer in config
+func NewProvider(ctx context.Context, config Config, httpClient *http.Client) (*Provider, error) {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	p := &Provider{
+		config:     config,
+		httpClient: httpClient,
+	}
+
+	discoveryURL := strings.TrimSuffix(config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, discoveryURL, &p.metadata); err != nil {
+		return nil, fmt.Errorf("failed to read discovery document: %w", err)
+	}
+	if p.metadata.Issuer != config.Issuer {
+		return nil, fmt.Errorf("discovery document is for issuer %q, expected %q", p.metadata.Issuer, config.Issuer)
+	}
+	if p.metadata.AuthorizationEndpoint == "" || p.metadata.TokenEndpoint == "" || p.metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document of %s is missing endpoints", config.Issuer)
+	}
+	p.verifier = p.newVerifier()
+	return p, nil
+}
+
+// SupportsDeviceFlow reports whether the provider implements the device authorization flow
+func (p *Provider) SupportsDeviceFlow() bool {
+	return p.metadata.DeviceAuthorizationEndpoint != ""
+}
+
+// AuthCodeURL returns the URL that starts a browser login. The provider sends the user back to
+// redirectURL with a code and state.
+func (p *Provider) AuthCodeURL(redirectURL, state, nonce, codeVerifier string) string {
+	challenge := sha256.Sum256([]byte(codeVerifier))
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {redirectURL},
+		"scope":                 {p.scope()},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(p.metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return p.metadata.AuthorizationEndpoint + separator + params.Encode()
+}
+
+// Exchange trades the code of a browser login for tokens
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, redirectURL string) (*Tokens, error) {
+	return p.token(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"code_verifier": {codeVerifier},
+		"redirect_uri":  {redirectURL},
+	})
+}
+
+// StartDeviceAuth starts a device login
+func (p *Provider) StartDeviceAuth(ctx context.Context) (*DeviceAuth, error) {
+	if !p.SupportsDeviceFlow() {
+		return nil, fmt.Errorf("identity provider does not support device logins")
+	}
+
+	resp, err := p.post(ctx, p.metadata.DeviceAuthorizationEndpoint, url.Values{"scope": {p.scope()}})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readTokenError(resp)
+	}
+
+	var auth DeviceAuth
+	if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil {
+		return nil, fmt.Errorf("failed to decode device authorization: %w", err)
+	}
+	if auth.Interval == 0 {
+		auth.Interval = 5
+	}
+	return &auth, nil
+}
+
+// PollDeviceToken asks once whether the user finished a device login. Until they do it returns a
+// *TokenError with the code ErrorAuthorizationPending or ErrorSlowDown.
+func (p *Provider) PollDeviceToken(ctx context.Context, deviceCode string) (*Tokens, error) {
+	return p.token(ctx, url.Values{
+		"grant_type":  {deviceCodeGrantType},
+		"device_code": {deviceCode},
+	})
+}
+
+func (p *Provider) token(ctx context.Context, params url.Values) (*Tokens, error) {
+	resp, err := p.post(ctx, p.metadata.TokenEndpoint, params)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readTokenError(resp)
+	}
+
+	var tokens Tokens
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("token response has no ID token")
+	}
+	return &tokens, nil
+}
+
+// post sends a form to the provider, authenticating with the client secret when there is one
+func (p *Provider) post(ctx context.Context, endpoint string, params url.Values) (*http.Response, error) {
+	if p.config.ClientSecret == "" {
+		params.Set("client_id", p.config.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to identity provider failed: %w", err)
+	}
+	return resp, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (p *Provider) scope() string {
+	scopes := []string{"openid"}
+	for _, scope := range p.config.Scopes {
+		if scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return strings.Join(scopes, " ")
+}
+
+func readTokenError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	tokenErr := &TokenError{}
+	if err := json.Unmarshal(body, tokenErr); err != nil || tokenErr.Code == "" {
+		return fmt.Errorf("identity provider returned %s: %s", resp.Status, string(body))
+	}
+	return tokenErr
+}
+
+// RandomString returns a random URL-safe string for states, nonces and PKCE code verifiers
+func RandomString() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
@@ -0,0 +1,288 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockIssuer is an in-process identity provider. It issues ID tokens for codes handed out by
+// authorize and for device logins approved with approve.
+type mockIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu       sync.Mutex
+	codes    map[string]mockLogin
+	devices  map[string]bool
+	subject  string
+	audience string
+}
+
+type mockLogin struct {
+	challenge string
+	nonce     string
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	m := &mockIssuer{
+		t:        t,
+		key:      key,
+		codes:    make(map[string]mockLogin),
+		devices:  make(map[string]bool),
+		subject:  "user-1",
+		audience: "protodex",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                        m.server.URL,
+			"authorization_endpoint":        m.server.URL + "/authorize",
+			"token_endpoint":                m.server.URL + "/token",
+			"device_authorization_endpoint": m.server.URL + "/device",
+			"jwks_uri":                      m.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		m.devices["device-code"] = false
+		m.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]any{
+			"device_code":      "device-code",
+			"user_code":        "ABCD-EFGH",
+			"verification_uri": m.server.URL + "/activate",
+			"expires_in":       600,
+		})
+	})
+	mux.HandleFunc("/token", m.token)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != "protodex" || clientSecret != "secret" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	require.NoError(m.t, r.ParseForm())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch r.Form.Get("grant_type") {
+	case "authorization_code":
+		login, ok := m.codes[r.Form.Get("code")]
+		challenge := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(challenge[:]) != login.challenge {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		delete(m.codes, r.Form.Get("code"))
+		writeJSON(w, http.StatusOK, map[string]string{"id_token": m.idToken(login.nonce), "token_type": "Bearer"})
+	case deviceCodeGrantType:
+		approved, ok := m.devices[r.Form.Get("device_code")]
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": ErrorExpiredToken})
+			return
+		}
+		if !approved {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": ErrorAuthorizationPending})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"id_token": m.idToken(""), "token_type": "Bearer"})
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+	}
+}
+
+// authorize plays the user logging in at the authorization URL and returns the code the provider
+// redirects back with
+func (m *mockIssuer) authorize(authURL string) (code, state string) {
+	parsed, err := url.Parse(authURL)
+	require.NoError(m.t, err)
+	query := parsed.Query()
+	require.Equal(m.t, "S256", query.Get("code_challenge_method"))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.codes["auth-code"] = mockLogin{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	return "auth-code", query.Get("state")
+}
+
+func (m *mockIssuer) approve(deviceCode string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.devices[deviceCode] = true
+}
+
+func (m *mockIssuer) idToken(nonce string) string {
+	claims := map[string]any{
+		"iss":                m.server.URL,
+		"sub":                m.subject,
+		"aud":                m.audience,
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"iat":                time.Now().Unix(),
+		"preferred_username": "jane",
+		"email":              "jane@example.com",
+		"email_verified":     true,
+		"groups":             []string{"platform", "payments"},
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	return m.sign(map[string]string{"alg": "RS256", "kid": "key-1", "typ": "JWT"}, claims)
+}
+
+func (m *mockIssuer) sign(header map[string]string, claims map[string]any) string {
+	headerJSON, err := json.Marshal(header)
+	require.NoError(m.t, err)
+	claimsJSON, err := json.Marshal(claims)
+	require.NoError(m.t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, digest[:])
+	require.NoError(m.t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func newTestProvider(t *testing.T, issuer *mockIssuer) *Provider {
+	provider, err := NewProvider(context.Background(), Config{
+		Issuer:       issuer.server.URL,
+		ClientID:     "protodex",
+		ClientSecret: "secret",
+		Scopes:       []string{"profile", "email"},
+	}, nil)
+	require.NoError(t, err)
+	return provider
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider := newTestProvider(t, issuer)
+	ctx := context.Background()
+
+	verifier, err := RandomString()
+	require.NoError(t, err)
+	authURL := provider.AuthCodeURL("https://registry.example.com/callback", "state-1", "nonce-1", verifier)
+	code, state := issuer.authorize(authURL)
+	assert.Equal(t, "state-1", state)
+
+	// A wrong code verifier is refused by the provider
+	_, err = provider.Exchange(ctx, code, "wrong-verifier", "https://registry.example.com/callback")
+	var tokenErr *TokenError
+	require.ErrorAs(t, err, &tokenErr)
+	assert.Equal(t, "invalid_grant", tokenErr.Code)
+
+	issuer.authorize(authURL)
+	tokens, err := provider.Exchange(ctx, code, verifier, "https://registry.example.com/callback")
+	require.NoError(t, err)
+
+	_, err = provider.Verify(ctx, tokens.IDToken, "other-nonce")
+	assert.Error(t, err)
+
+	idToken, err := provider.Verify(ctx, tokens.IDToken, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", idToken.Subject)
+	assert.Equal(t, "jane", idToken.String("preferred_username"))
+	assert.True(t, idToken.Bool("email_verified"))
+	assert.Equal(t, []string{"platform", "payments"}, idToken.Strings("groups"))
+}
+
+func TestDeviceFlow(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider := newTestProvider(t, issuer)
+	ctx := context.Background()
+
+	require.True(t, provider.SupportsDeviceFlow())
+	auth, err := provider.StartDeviceAuth(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "ABCD-EFGH", auth.UserCode)
+	assert.Equal(t, 5, auth.Interval)
+
+	_, err = provider.PollDeviceToken(ctx, auth.DeviceCode)
+	var tokenErr *TokenError
+	require.ErrorAs(t, err, &tokenErr)
+	assert.Equal(t, ErrorAuthorizationPending, tokenErr.Code)
+
+	issuer.approve(auth.DeviceCode)
+	tokens, err := provider.PollDeviceToken(ctx, auth.DeviceCode)
+	require.NoError(t, err)
+
+	idToken, err := provider.Verify(ctx, tokens.IDToken, "")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", idToken.Subject)
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	issuer := newMockIssuer(t)
+	provider := newTestProvider(t, issuer)
+	ctx := context.Background()
+
+	valid := issuer.idToken("")
+	_, err := provider.Verify(ctx, valid, "")
+	require.NoError(t, err)
+
+	// Tampered claims no longer match the signature
+	_, err = provider.Verify(ctx, valid[:len(valid)-4]+"AAAA", "")
+	assert.Error(t, err)
+
+	issuer.audience = "another-client"
+	_, err = provider.Verify(ctx, issuer.idToken(""), "")
+	assert.Error(t, err)
+	issuer.audience = "protodex"
+
+	expired := issuer.sign(map[string]string{"alg": "RS256", "kid": "key-1"}, map[string]any{
+		"iss": issuer.server.URL,
+		"sub": "user-1",
+		"aud": "protodex",
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	})
+	_, err = provider.Verify(ctx, expired, "")
+	assert.Error(t, err)
+
+	unsigned := issuer.sign(map[string]string{"alg": "none", "kid": "key-1"}, map[string]any{
+		"iss": issuer.server.URL,
+		"sub": "user-1",
+		"aud": "protodex",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	_, err = provider.Verify(ctx, unsigned, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+)
+
+// signingAlgorithms are the ID token signature algorithms the registry accepts
+var signingAlgorithms = []string{
+	gooidc.RS256, gooidc.RS384, gooidc.RS512,
+	gooidc.ES256, gooidc.ES384, gooidc.ES512,
+}
+
+// IDToken holds the verified claims of an ID token
+type IDToken struct {
+	Subject string
+	Claims  map[string]any
+}
+
+// String returns a string claim, or an empty string
+func (t *IDToken) String(name string) string {
+	value, _ := t.Claims[name].(string)
+	return value
+}
+
+// Bool returns a boolean claim. Some providers send booleans as strings.
+func (t *IDToken) Bool(name string) bool {
+	switch value := t.Claims[name].(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	}
+	return false
+}
+
+// Strings returns a claim holding a list of strings, such as groups
+func (t *IDToken) Strings(name string) []string {
+	switch value := t.Claims[name].(type) {
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	case string:
+		return []string{value}
+	}
+	return nil
+}
+
+// newVerifier checks ID tokens against the key set of the provider. The key set is fetched on
+// first use and again when a token is signed with an unknown key, because providers rotate them.
+func (p *Provider) newVerifier() *gooidc.IDTokenVerifier {
+	// The key set outlives the request that read the discovery document
+	keySet := gooidc.NewRemoteKeySet(gooidc.ClientContext(context.Background(), p.httpClient), p.metadata.JWKSURI)
+	return gooidc.NewVerifier(p.metadata.Issuer, keySet, &gooidc.Config{
+		ClientID:             p.config.ClientID,
+		SupportedSigningAlgs: signingAlgorithms,
+	})
+}
+
+// Verify checks the signature and claims of an ID token issued to the registry. When nonce is set
+// the token must carry it, which ties a browser login to the request that started it.
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*IDToken, error) {
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	var claims struct {
+		AuthorizedParty string `json:"azp"`
+	}
+	token := &IDToken{Subject: idToken.Subject}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("malformed ID token claims: %w", err)
+	}
+	if err := idToken.Claims(&token.Claims); err != nil {
+		return nil, fmt.Errorf("malformed ID token claims: %w", err)
+	}
+
+	if claims.AuthorizedParty != "" && claims.AuthorizedParty != p.config.ClientID {
+		return nil, fmt.Errorf("ID token was issued to another client")
+	}
+	if nonce != "" && idToken.Nonce != nonce {
+		return nil, fmt.Errorf("ID token nonce does not match")
+	}
+	if token.Subject == "" {
+		return nil, fmt.Errorf("ID token has no subject")
+	}
+	return token, nil
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/server/auth"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	orgstore "github.com/sirrobot01/protodex/internal/store/org"
+	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+)
+
+// packagesPrefix is the path every package route lives under
+const packagesPrefix = "/api/packages/"
+
+// scopedPackagePath turns /api/packages/acme/payments/... into /api/packages/acme%2Fpayments/..., the
+// form the package routes match scoped names in. It reports false for paths that cannot name a
+// scoped package or are already escaped.
+func scopedPackagePath(escapedPath string) (string, bool) {
+	rest, ok := strings.CutPrefix(escapedPath, packagesPrefix)
+	if !ok {
+		return "", false
+	}
+	parts := strings.SplitN(rest, "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" || strings.Contains(strings.ToLower(parts[0]), "%2f") {
+		return "", false
+	}
+	path := packagesPrefix + parts[0] + "%2F" + parts[1]
+	if len(parts) == 3 {
+		path += "/" + parts[2]
+	}
+	return path, true
+}
+
+// routeScopedPackage retries an unmatched package request with the scope and name joined into
+// the package segment. It returns false when the request is not for a scoped package.
+func (s *Server) routeScopedPackage(c *gin.Context) bool {
+	escaped, ok := scopedPackagePath(c.Request.URL.EscapedPath())
+	if !ok {
+		return false
+	}
+	path, err := url.PathUnescape(escaped)
+	if err != nil {
+		return false
+	}
+	c.Request.URL.Path = path
+	c.Request.URL.RawPath = escaped
+	s.router.HandleContext(c)
+	// HandleContext restores the handler index of this chain, stop it from running the new chain again
+	c.Abort()
+	return true
+}
+
+// archiveName is a package name usable in a file name
+func archiveName(packageName string) string {
+	return strings.ReplaceAll(packageName, "/", "-")
+}
+
+// checkPackageName validates a package name the caller wants to create or rename to. Scoped
+// names need an existing organization the caller maintains or owns. It writes the error response
+// and returns false when the name cannot be used.
+func (s *Server) checkPackageName(c *gin.Context, authCtx *auth.Context, name string) bool {
+	if err := pkgstore.ValidateName(name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	scope, _ := pkgstore.SplitName(name)
+	if scope == "" {
+		return true
+	}
+
+	org, err := s.orgStore.GetOrganization(scope)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("organization %s not found", scope)})
+		return false
+	}
+	role, err := s.orgStore.GetMemberRole(org.ID, authCtx.UserID)
+	if err != nil || role == orgstore.RoleMember {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("you need the %s or %s role in %s to create packages in it", orgstore.RoleMaintainer, orgstore.RoleOwner, scope)})
+		return false
+	}
+	return true
+}
+
+func (s *Server) createOrgHandler(c *gin.Context) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req client.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := orgstore.ValidateName(req.Name); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := s.orgStore.GetOrganization(req.Name); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("organization %s already exists", req.Name)})
+		return
+	}
+
+	org, err := s.orgStore.CreateOrganization(req.Name, authCtx.UserID)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to create organization")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionOrgCreate, auditstore.TargetOrg, org.Name, nil)
+
+	c.JSON(http.StatusCreated, toClientOrganization(org))
+}
+
+func (s *Server) listOrgsHandler(c *gin.Context) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	orgs, err := s.orgStore.ListUserOrganizations(authCtx.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientOrgs := make([]client.Organization, 0, len(orgs))
+	for _, org := range orgs {
+		clientOrgs = append(clientOrgs, toClientOrganization(org))
+	}
+	c.JSON(http.StatusOK, clientOrgs)
+}
+
+// getOrgHandler describes an organization. Its members are only listed to members and
+// administrators, other users see the organization alone.
+func (s *Server) getOrgHandler(c *gin.Context) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	org, err := s.orgStore.GetOrganization(c.Param("org"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+		return
+	}
+
+	if _, err := s.orgStore.GetMemberRole(org.ID, authCtx.UserID); err != nil && !isAdmin(authCtx) {
+		c.JSON(http.StatusOK, toClientOrganization(org))
+		return
+	}
+
+	members, err := s.orgStore.ListMembers(org.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientOrg := toClientOrganization(org)
+	for _, member := range members {
+		clientOrg.Members = append(clientOrg.Members, client.Member{
+			Username: member.Username,
+			Role:     member.Role,
+		})
+	}
+	c.JSON(http.StatusOK, clientOrg)
+}
+
+// setOrgMemberHandler adds a user to an organization or changes their role
+func (s *Server) setOrgMemberHandler(c *gin.Context) {
+	org := s.ownedOrganization(c)
+	if org == nil {
+		return
+	}
+
+	var req client.Member
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Role == "" {
+		req.Role = orgstore.RoleMember
+	}
+	if err := orgstore.ValidateRole(req.Role); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := s.authService.GetUserByUsername(req.Username)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("user %s not found", req.Username)})
+		return
+	}
+
+	if err := s.orgStore.SetMember(org.ID, user.ID, req.Role); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to set organization member")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionOrgMemberSet, auditstore.TargetOrg, org.Name, map[string]string{"user": user.Username, "role": req.Role})
+
+	c.JSON(http.StatusOK, req)
+}
+
+func (s *Server) removeOrgMemberHandler(c *gin.Context) {
+	org := s.ownedOrganization(c)
+	if org == nil {
+		return
+	}
+
+	username := c.Param("username")
+	user, err := s.authService.GetUserByUsername(username)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("user %s not found", username)})
+		return
+	}
+
+	if err := s.orgStore.RemoveMember(org.ID, user.ID); err != nil {
+		if errors.Is(err, orgstore.ErrNotMember) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("%s is not a member of %s", username, org.Name)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionOrgMemberRemove, auditstore.TargetOrg, org.Name, map[string]string{"user": user.Username})
+
+	c.Status(http.StatusNoContent)
+}
+
+// ownedOrganization loads the organization named in the route and checks the caller is one of its
+// owners. It writes the error response and returns nil otherwise.
+func (s *Server) ownedOrganization(c *gin.Context) *orgstore.Organization {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return nil
+	}
+
+	org, err := s.orgStore.GetOrganization(c.Param("org"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "organization not found"})
+		return nil
+	}
+
+	role, err := s.orgStore.GetMemberRole(org.ID, authCtx.UserID)
+	if err != nil || role != orgstore.RoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only organization owners can manage members"})
+		return nil
+	}
+	return org
+}
+
+func toClientOrganization(org *orgstore.Organization) client.Organization {
+	return client.Organization{
+		ID:        org.ID,
+		Name:      org.Name,
+		CreatedAt: org.CreatedAt,
+	}
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/sirrobot01/protodex/internal/config"
+	"github.com/sirrobot01/protodex/internal/server/auth"
+	"github.com/sirrobot01/protodex/internal/server/ldap"
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+)
+
+// newAuthProvider returns the provider selected by server.auth.provider
+func newAuthProvider(cfg config.AuthConfig, store authstore.Store) (auth.Provider, error) {
+	switch cfg.Provider {
+	case "", auth.ProviderLocal:
+		return auth.NewLocalProvider(store), nil
+	case auth.ProviderHtpasswd:
+		if cfg.HtpasswdFile == "" {
+			return nil, fmt.Errorf("the htpasswd provider needs server.auth.htpasswd_file")
+		}
+		return auth.NewHtpasswdProvider(cfg.HtpasswdFile)
+	case auth.ProviderLDAP:
+		return ldap.NewProvider(ldap.Config{
+			URL:                cfg.LDAP.URL,
+			StartTLS:           cfg.LDAP.StartTLS,
+			InsecureSkipVerify: cfg.LDAP.InsecureSkipVerify,
+			BindDN:             cfg.LDAP.BindDN,
+			BindPassword:       cfg.LDAP.BindPassword,
+			BaseDN:             cfg.LDAP.BaseDN,
+			UserFilter:         cfg.LDAP.UserFilter,
+			UsernameAttribute:  cfg.LDAP.UsernameAttribute,
+			EmailAttribute:     cfg.LDAP.EmailAttribute,
+			Timeout:            cfg.LDAP.Timeout,
+		})
+	}
+	return nil, fmt.Errorf("unknown auth provider %q, use local, htpasswd or ldap", cfg.Provider)
+}
@@ -0,0 +1,317 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/config"
+	"github.com/sirrobot01/protodex/internal/server/auth"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+)
+
+// registrationInfoHandler tells the web UI which fields the registration form needs
+func (s *Server) registrationInfoHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, client.RegistrationInfo{
+		Mode:                s.registration.Mode,
+		AllowedEmailDomains: s.registration.AllowedEmailDomains,
+	})
+}
+
+// checkRegistration applies the registration settings to a self-registration. It returns the invite
+// the registration uses, if any. It writes the error response and returns false when the
+// registration is not allowed.
+func (s *Server) checkRegistration(c *gin.Context, req *client.RegisterRequest) (*authstore.Invite, bool) {
+	if s.registration.Mode == config.RegistrationClosed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Registration is closed, ask an administrator for an account"})
+		return nil, false
+	}
+
+	if req.Email != "" {
+		if _, err := mail.ParseAddress(req.Email); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+			return nil, false
+		}
+	}
+	if len(s.registration.AllowedEmailDomains) > 0 && !s.allowedEmail(req.Email) {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Registration requires an email address in %s",
+			strings.Join(s.registration.AllowedEmailDomains, ", "))})
+		return nil, false
+	}
+
+	if s.registration.Mode != config.RegistrationInvite {
+		return nil, true
+	}
+	if req.Invite == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "An invite is required to register"})
+		return nil, false
+	}
+	invite, err := s.authService.CheckInvite(req.Invite, req.Email)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return invite, true
+}
+
+func (s *Server) allowedEmail(email string) bool {
+	_, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return false
+	}
+	return slices.ContainsFunc(s.registration.AllowedEmailDomains, func(allowed string) bool {
+		return strings.EqualFold(domain, allowed)
+	})
+}
+
+// bootstrapAdmin makes sure the registry has an administrator. When it has none, the configured
+// admin user is promoted, or created with the configured password or a generated one.
+func (s *Server) bootstrapAdmin(cfg config.ServerConfig) error {
+	users, err := s.authService.ListUsers()
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(users, func(user *authstore.User) bool { return user.IsAdmin }) {
+		return nil
+	}
+
+	if cfg.AdminUsername == "" {
+		s.logger.Warn().Msg("The registry has no administrator, restart it with --admin-username to create one")
+		return nil
+	}
+
+	if user, err := s.authService.GetUserByUsername(cfg.AdminUsername); err == nil {
+		if user.ServiceAccount {
+			return fmt.Errorf("%s is a service account and cannot be an administrator", user.Username)
+		}
+		if err := s.authService.SetAdmin(user.ID, true); err != nil {
+			return err
+		}
+		s.logger.Info().Str("username", user.Username).Msg("Made existing user the registry administrator")
+		return nil
+	}
+
+	password := cfg.AdminPassword
+	if password == "" {
+		if password, err = auth.GeneratePassword(); err != nil {
+			return fmt.Errorf("failed to generate password: %w", err)
+		}
+		s.logger.Warn().Str("username", cfg.AdminUsername).Str("password", password).
+			Msg("Created the registry administrator with a generated password, change it after logging in")
+	}
+
+	user, err := s.authService.CreateUser(cfg.AdminUsername, password)
+	if err != nil {
+		return err
+	}
+	if err := s.authService.SetAdmin(user.ID, true); err != nil {
+		return err
+	}
+	s.logger.Info().Str("username", user.Username).Msg("Created the registry administrator")
+	return nil
+}
+
+// createUserHandler creates an account on behalf of someone, which is how users join a registry
+// with closed registration. A password is generated when the request does not carry one.
+func (s *Server) createUserHandler(c *gin.Context) {
+	var req client.CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Email != "" {
+		if _, err := mail.ParseAddress(req.Email); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email address"})
+			return
+		}
+	}
+	if _, err := s.authService.GetUserByUsername(req.Username); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
+		return
+	}
+
+	var response client.CreateUserResponse
+	if req.Password == "" {
+		password, err := auth.GeneratePassword()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate password"})
+			return
+		}
+		req.Password = password
+		response.Password = password
+	}
+
+	user, err := s.authService.CreateUser(req.Username, req.Password)
+	if errors.Is(err, auth.ErrWeakPassword) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to create user")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
+
+	if req.Email != "" {
+		if err := s.authService.SetEmail(user.ID, req.Email); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.Admin {
+		if err := s.authService.SetAdmin(user.ID, true); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if user, err = s.authService.GetUserByID(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionUserCreate, auditstore.TargetUser, user.Username, map[string]string{"admin": strconv.FormatBool(user.IsAdmin)})
+	response.User = toClientUser(user)
+	c.JSON(http.StatusCreated, response)
+}
+
+// setAdminHandler promotes a user to administrator or demotes them. The last administrator cannot
+// be demoted, so the registry always keeps one.
+func (s *Server) setAdminHandler(c *gin.Context) {
+	user := s.routeUser(c)
+	if user == nil {
+		return
+	}
+
+	var req client.SetAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Admin && user.ServiceAccount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "service accounts cannot be administrators"})
+		return
+	}
+	if !req.Admin && user.IsAdmin {
+		if only, err := s.onlyAdmin(user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		} else if only {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s is the only administrator of the registry", user.Username)})
+			return
+		}
+	}
+
+	if err := s.authService.SetAdmin(user.ID, req.Admin); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to update user")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	action := auditstore.ActionDemote
+	if req.Admin {
+		action = auditstore.ActionPromote
+	}
+	s.audit(c, action, auditstore.TargetUser, user.Username, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// onlyAdmin reports whether user is the only enabled administrator of the registry
+func (s *Server) onlyAdmin(user *authstore.User) (bool, error) {
+	users, err := s.authService.ListUsers()
+	if err != nil {
+		return false, err
+	}
+	for _, other := range users {
+		if other.ID != user.ID && other.IsAdmin && !other.Disabled {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (s *Server) createInviteHandler(c *gin.Context) {
+	var req client.CreateInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Email != "" {
+		if _, err := mail.ParseAddress(req.Email); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid email address"})
+			return
+		}
+	}
+
+	expiresAt := time.Now().Add(auth.DefaultInviteTTL)
+	if req.ExpiresAt != nil {
+		if req.ExpiresAt.Before(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expiry must be in the future"})
+			return
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	code, invite, err := s.authService.CreateInvite(s.caller(c).UserID, req.Email, expiresAt)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to create invite")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var details map[string]string
+	if invite.Email != "" {
+		details = map[string]string{"email": invite.Email}
+	}
+	s.audit(c, auditstore.ActionInviteCreate, auditstore.TargetInvite, invite.ID, details)
+
+	c.JSON(http.StatusCreated, client.CreateInviteResponse{
+		Code:   code,
+		Invite: s.toClientInvite(invite),
+	})
+}
+
+func (s *Server) listInvitesHandler(c *gin.Context) {
+	invites, err := s.authService.ListInvites()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientInvites := make([]client.Invite, 0, len(invites))
+	for _, invite := range invites {
+		clientInvites = append(clientInvites, s.toClientInvite(invite))
+	}
+	c.JSON(http.StatusOK, clientInvites)
+}
+
+func (s *Server) revokeInviteHandler(c *gin.Context) {
+	if err := s.authService.RevokeInvite(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invite not found"})
+		return
+	}
+	s.audit(c, auditstore.ActionInviteRevoke, auditstore.TargetInvite, c.Param("id"), nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) toClientInvite(invite *authstore.Invite) client.Invite {
+	clientInvite := client.Invite{
+		ID:        invite.ID,
+		Email:     invite.Email,
+		CreatedBy: invite.CreatedBy,
+		CreatedAt: invite.CreatedAt,
+		ExpiresAt: invite.ExpiresAt,
+	}
+	if creator, err := s.authService.GetUserByID(invite.CreatedBy); err == nil {
+		clientInvite.CreatedBy = creator.Username
+	}
+	return clientInvite
+}
@@ -1,17 +1,27 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
+	"github.com/sirrobot01/protodex/internal/server/admission"
+	"github.com/sirrobot01/protodex/internal/server/events"
 	"github.com/sirrobot01/protodex/internal/server/web"
+	"github.com/sirrobot01/protodex/internal/server/webhook"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+	orgstore "github.com/sirrobot01/protodex/internal/store/org"
 	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+	webhookstore "github.com/sirrobot01/protodex/internal/store/webhook"
 
+	"github.com/sirrobot01/protodex/internal/config"
 	"github.com/sirrobot01/protodex/internal/logger"
 	"github.com/sirrobot01/protodex/internal/server/auth"
 	"github.com/sirrobot01/protodex/internal/store"
@@ -19,12 +29,23 @@ import (
 
 type Server struct {
 	packageStore pkgstore.Store
+	orgStore     orgstore.Store
+	auditStore   auditstore.Store
+	webhookStore webhookstore.Store
+	webhooks     *webhook.Dispatcher
+	admission    *admission.Controller
+	events       *events.Broker
 	authService  *auth.Service
-	logger       zerolog.Logger
-	router       *gin.Engine
+	loginLimiter *auth.LoginLimiter
+	registration config.RegistrationConfig
+	sso          *ssoState
+	// requireTwoFactor only lets users with two-factor authentication publish
+	requireTwoFactor bool
+	logger           zerolog.Logger
+	router           *gin.Engine
 }
 
-func New(dataDir string, port int) *Server {
+func New(dataDir string, port int, cfg config.ServerConfig) *Server {
 	// Get or create folder
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
 		panic(fmt.Sprintf("Failed to create data directory: %v", err))
@@ -40,15 +61,69 @@ func New(dataDir string, port int) *Server {
 	}
 
 	authService := auth.NewAuthService(_store.Auth())
+	passwords := auth.PasswordPolicy{MinLength: cfg.Auth.PasswordMinLength}
+	if cfg.Auth.BreachedPasswordsFile != "" {
+		if err := passwords.LoadBreachedPasswords(cfg.Auth.BreachedPasswordsFile); err != nil {
+			panic(fmt.Sprintf("Failed to load password policy: %v", err))
+		}
+	}
+	authService.SetPasswordPolicy(passwords)
+	provider, err := newAuthProvider(cfg.Auth, _store.Auth())
+	if err != nil {
+		panic(fmt.Sprintf("Failed to set up auth provider: %v", err))
+	}
+	authService.SetProvider(provider)
+	admissionController, err := admission.NewController(cfg.AdmissionHooks, logger.Get())
+	if err != nil {
+		panic(fmt.Sprintf("Failed to set up admission hooks: %v", err))
+	}
 	gin.SetMode(gin.ReleaseMode)
 
 	server := &Server{
-		packageStore: _store.Package(),
-		authService:  authService,
-		router:       gin.Default(),
-		logger:       logger.Get(),
+		packageStore:     _store.Package(),
+		orgStore:         _store.Org(),
+		auditStore:       _store.Audit(),
+		webhookStore:     _store.Webhook(),
+		admission:        admissionController,
+		events:           events.NewBroker(),
+		authService:      authService,
+		loginLimiter:     auth.NewLoginLimiter(cfg.Auth.MaxFailedLogins, cfg.Auth.MaxFailedLoginsPerIP, cfg.Auth.LockoutDuration),
+		registration:     cfg.Registration,
+		sso:              newSSOState(cfg.SSO),
+		requireTwoFactor: cfg.Auth.RequireTwoFactor,
+		router:           gin.Default(),
+		logger:           logger.Get(),
+	}
+
+	// Scoped package names such as acme/payments are sent with an escaped slash in the package segment
+	server.router.UseRawPath = true
+
+	// Client addresses feed login limits, two-factor lockouts and the audit log, so forwarding
+	// headers are only believed from the configured proxies
+	if err := server.router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		panic(fmt.Sprintf("Invalid trusted proxies: %v", err))
+	}
+
+	// Users of an external provider get their account on first login. A self-registered account
+	// could take the username of a provider user and be linked to them, so registration is closed.
+	if provider.Name() != auth.ProviderLocal && server.registration.Mode != config.RegistrationClosed {
+		server.logger.Warn().Str("provider", provider.Name()).Msg("Closing self-registration because users come from an external auth provider")
+		server.registration.Mode = config.RegistrationClosed
+	}
+
+	if err := server.bootstrapAdmin(cfg); err != nil {
+		panic(fmt.Sprintf("Failed to create administrator: %v", err))
+	}
+
+	// Remove uploads left behind by pushes that were interrupted
+	if err := server.packageStore.CleanStaging(time.Hour); err != nil {
+		server.logger.Warn().Err(err).Msg("Failed to clean staging directories")
 	}
 
+	// Send webhook deliveries in the background, including the ones left pending by the last run
+	server.webhooks = webhook.NewDispatcher(server.webhookStore, cfg.Webhooks.AllowPrivateNetworks, server.logger)
+	go server.webhooks.Run(context.Background())
+
 	// Setup routes
 
 	server.setupWebRoutes()
@@ -73,29 +148,161 @@ func (s *Server) setupAPIRoutes() {
 		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 	})
 
+	// Live stream of registry activity, private packages are only streamed to callers who can read them
+	api.GET("/events", s.optionalAuthMiddleware(), s.eventsHandler)
+
 	// Auth routes
 	authGroup := api.Group("/auth")
 	{
 		authGroup.POST("/login", s.loginHandler)
+		authGroup.POST("/login/2fa", s.twoFactorLoginHandler)
 		authGroup.POST("/register", s.registerHandler)
+		authGroup.GET("/registration", s.registrationInfoHandler)
+		authGroup.GET("/sso", s.ssoInfoHandler)
+		authGroup.GET("/sso/login", s.ssoLoginHandler)
+		authGroup.GET("/sso/callback", s.ssoCallbackHandler)
+		authGroup.POST("/sso/link", s.authMiddleware(), s.requireScope(authstore.ScopeAdmin), s.ssoLinkHandler)
+		authGroup.POST("/sso/device", s.ssoDeviceHandler)
+		authGroup.POST("/sso/device/token", s.ssoDeviceTokenHandler)
 		authGroup.GET("/me", s.authMiddleware(), s.getCurrentUserHandler)
+		authGroup.POST("/logout", s.authMiddleware(), s.logoutHandler)
+		authGroup.PUT("/password", s.authMiddleware(), s.requireScope(authstore.ScopeAdmin), s.changePasswordHandler)
+		authGroup.DELETE("/me", s.authMiddleware(), s.requireScope(authstore.ScopeAdmin), s.deleteAccountHandler)
+	}
+
+	// Session routes, tokens can only manage sessions when they have the admin scope
+	sessions := authGroup.Group("/sessions")
+	sessions.Use(s.authMiddleware(), s.requireScope(authstore.ScopeAdmin))
+	{
+		sessions.GET("", s.listSessionsHandler)
+		sessions.DELETE("", s.revokeOtherSessionsHandler)
+		sessions.DELETE("/:id", s.revokeSessionHandler)
+	}
+
+	// Two-factor routes, tokens can only manage two-factor authentication when they have the admin scope
+	twoFactor := authGroup.Group("/2fa")
+	twoFactor.Use(s.authMiddleware(), s.requireScope(authstore.ScopeAdmin))
+	{
+		twoFactor.GET("", s.twoFactorStatusHandler)
+		twoFactor.POST("/setup", s.setupTOTPHandler)
+		twoFactor.POST("/enable", s.enableTOTPHandler)
+		twoFactor.POST("/disable", s.disableTOTPHandler)
+		twoFactor.POST("/recovery-codes", s.regenerateRecoveryCodesHandler)
+	}
+
+	// API token routes, tokens can only manage tokens when they have the admin scope
+	tokens := api.Group("/tokens")
+	tokens.Use(s.authMiddleware(), s.requireScope(authstore.ScopeAdmin))
+	{
+		tokens.GET("", s.listTokensHandler)
+		tokens.POST("", s.createTokenHandler)
+		tokens.DELETE("/:id", s.revokeTokenHandler)
+	}
+
+	// User administration routes
+	users := api.Group("/admin/users")
+	users.Use(s.authMiddleware(), s.requireAdmin())
+	{
+		users.GET("", s.listUsersHandler)
+		users.POST("", s.createUserHandler)
+		users.PUT("/:username/admin", s.setAdminHandler)
+		users.PUT("/:username/password", s.resetPasswordHandler)
+		users.DELETE("/:username/2fa", s.resetTwoFactorHandler)
+		users.POST("/:username/disable", s.disableUserHandler)
+		users.POST("/:username/enable", s.enableUserHandler)
+		users.DELETE("/:username", s.deleteUserHandler)
+	}
+
+	// Invite routes
+	invites := api.Group("/admin/invites")
+	invites.Use(s.authMiddleware(), s.requireAdmin())
+	{
+		invites.GET("", s.listInvitesHandler)
+		invites.POST("", s.createInviteHandler)
+		invites.DELETE("/:id", s.revokeInviteHandler)
+	}
+
+	// Audit log routes
+	auditGroup := api.Group("/audit")
+	auditGroup.Use(s.authMiddleware(), s.requireAdmin())
+	{
+		auditGroup.GET("", s.listAuditHandler)
+	}
+
+	// Registry-wide webhook routes
+	webhooks := api.Group("/admin/webhooks")
+	webhooks.Use(s.authMiddleware(), s.requireAdmin())
+	{
+		webhooks.GET("", s.listWebhooksHandler)
+		webhooks.POST("", s.createWebhookHandler)
+		webhooks.DELETE("/:id", s.deleteWebhookHandler)
+		webhooks.GET("/:id/deliveries", s.listDeliveriesHandler)
+		webhooks.POST("/:id/deliveries/:delivery/redeliver", s.redeliverHandler)
+	}
+
+	// Organization routes
+	orgs := api.Group("/orgs")
+	orgs.Use(s.authMiddleware())
+	{
+		orgs.GET("", s.listOrgsHandler)
+		orgs.POST("", s.requireScope(authstore.ScopeAdmin), s.createOrgHandler)
+		orgs.GET("/:org", s.getOrgHandler)
+		orgs.PUT("/:org/members", s.requireScope(authstore.ScopeAdmin), s.setOrgMemberHandler)
+		orgs.DELETE("/:org/members/:username", s.requireScope(authstore.ScopeAdmin), s.removeOrgMemberHandler)
+	}
+
+	// Package read routes, public packages are readable without a token
+	public := api.Group("/packages")
+	public.Use(s.optionalAuthMiddleware())
+	{
+		public.GET("", s.listPackagesHandler)
+		public.GET("/search", s.searchPackagesHandler)
+		public.GET("/:package", s.getPackageHandler)
+		public.GET("/:package/compatibility", s.getCompatibilityHandler)
+		public.GET("/:package/versioning", s.getVersioningHandler)
+		public.GET("/:package/versions", s.listVersionsHandler)
+		public.GET("/:package/versions/:version", s.getVersionHandler)
+		public.GET("/:package/versions/:version/files", s.pullVersionHandler)
+		public.GET("/:package/versions/:version/schema", s.viewSchemaHandler)
+		public.POST("/:package/versions/:version/generate", s.generateCodeHandler)
+		public.GET("/:package/drafts", s.listDraftsHandler)
+		public.GET("/:package/drafts/:version", s.getDraftHandler)
+		public.GET("/:package/drafts/:version/diff", s.draftDiffHandler)
+		public.GET("/:package/labels", s.listLabelsHandler)
+		public.GET("/:package/labels/:label/history", s.labelHistoryHandler)
 	}
 
-	// Protected package routes
+	// Protected package routes, publishing may require two-factor authentication
 	packages := api.Group("/packages")
-	packages.GET("/search", s.searchPackagesHandler)
 	packages.Use(s.authMiddleware())
+	publish := s.requireTwoFactorToPublish()
 	{
-		packages.GET("", s.listPackagesHandler)
-		packages.POST("", s.createPackageHandler)
-		packages.GET("/:package", s.getPackageHandler)
+		packages.POST("", s.requireScope(authstore.ScopePush), publish, s.createPackageHandler)
+		packages.DELETE("/:package", publish, s.deletePackageHandler)
+		packages.POST("/:package/rename", publish, s.renamePackageHandler)
+		packages.POST("/:package/transfer", publish, s.transferPackageHandler)
+		packages.GET("/:package/access", s.listAccessHandler)
+		packages.PUT("/:package/access", s.grantAccessHandler)
+		packages.DELETE("/:package/access/:username", s.revokeAccessHandler)
+		packages.PUT("/:package/compatibility", s.setCompatibilityHandler)
+		packages.PUT("/:package/versioning", s.setVersioningHandler)
+		packages.PUT("/:package/visibility", s.setVisibilityHandler)
+		packages.GET("/:package/webhooks", s.listWebhooksHandler)
+		packages.POST("/:package/webhooks", s.createWebhookHandler)
+		packages.DELETE("/:package/webhooks/:id", s.deleteWebhookHandler)
+		packages.GET("/:package/webhooks/:id/deliveries", s.listDeliveriesHandler)
+		packages.POST("/:package/webhooks/:id/deliveries/:delivery/redeliver", s.redeliverHandler)
 
 		// Version routes
-		packages.POST("/:package/versions", s.pushVersionHandler)
-		packages.GET("/:package/versions", s.listVersionsHandler)
-		packages.GET("/:package/versions/:version/files", s.pullVersionHandler)
-		packages.GET("/:package/versions/:version/schema", s.viewSchemaHandler)
-		packages.POST("/:package/versions/:version/generate", s.generateCodeHandler)
+		packages.POST("/:package/versions", publish, s.pushVersionHandler)
+		packages.DELETE("/:package/versions/:version", publish, s.deleteVersionHandler)
+		packages.PUT("/:package/versions/:version/unyank", publish, s.unyankVersionHandler)
+		packages.POST("/:package/drafts/:version/approve", publish, s.approveDraftHandler)
+		packages.POST("/:package/drafts/:version/reject", publish, s.rejectDraftHandler)
+
+		// Label routes
+		packages.PUT("/:package/labels/:label", publish, s.setLabelHandler)
+		packages.DELETE("/:package/labels/:label", publish, s.deleteLabelHandler)
 	}
 }
 
@@ -118,6 +325,11 @@ func (s *Server) serveReactApp() {
 	s.router.NoRoute(func(c *gin.Context) {
 		path := c.Request.URL.Path
 
+		// Scoped package names may also be sent with a literal slash
+		if strings.HasPrefix(path, packagesPrefix) && s.routeScopedPackage(c) {
+			return
+		}
+
 		// Return 404 JSON for API routes
 		if strings.HasPrefix(path, "/api/") {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/config"
+)
+
+// testServer is a registry served over HTTP with a fresh data directory
+type testServer struct {
+	t       *testing.T
+	dataDir string
+	server  *Server
+	http    *httptest.Server
+}
+
+func newTestServer(t *testing.T) *testServer {
+	dataDir := t.TempDir()
+	s := New(dataDir, 0, config.DefaultServerConfig())
+	ts := httptest.NewServer(s.Router())
+	t.Cleanup(ts.Close)
+	return &testServer{t: t, dataDir: dataDir, server: s, http: ts}
+}
+
+// do sends a request with an optional JSON body and returns the status and response body. The path
+// is sent as is, so escaped segments such as %2E%2E reach the router unchanged.
+func (ts *testServer) do(method, path, token string, body any) (int, string) {
+	ts.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(ts.t, err)
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, ts.http.URL+path, reader)
+	require.NoError(ts.t, err)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return ts.send(req, token)
+}
+
+func (ts *testServer) send(req *http.Request, token string) (int, string) {
+	ts.t.Helper()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := ts.http.Client().Do(req)
+	require.NoError(ts.t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(ts.t, err)
+	return resp.StatusCode, string(data)
+}
+
+// register creates a user and returns a session token for it
+func (ts *testServer) register(username string) string {
+	ts.t.Helper()
+	status, body := ts.do("POST", "/api/auth/register", "", client.RegisterRequest{Username: username, Password: "correct-horse-battery"})
+	require.Equal(ts.t, http.StatusCreated, status, body)
+	var resp client.RegisterResponse
+	require.NoError(ts.t, json.Unmarshal([]byte(body), &resp))
+	return resp.Token
+}
+
+// createToken creates an API token for the user of token and returns its secret
+func (ts *testServer) createToken(token string, req client.CreateTokenRequest) string {
+	ts.t.Helper()
+	status, body := ts.do("POST", "/api/tokens", token, req)
+	require.Equal(ts.t, http.StatusCreated, status, body)
+	var resp client.CreateTokenResponse
+	require.NoError(ts.t, json.Unmarshal([]byte(body), &resp))
+	return resp.Token
+}
+
+// grant gives a user a role on a package
+func (ts *testServer) grant(token, pkg, username, role string) {
+	ts.t.Helper()
+	status, body := ts.do("PUT", "/api/packages/"+pkg+"/access", token, client.Collaborator{Username: username, Role: role})
+	require.Equal(ts.t, http.StatusOK, status, body)
+}
+
+// push uploads a version of a small valid schema. pkg is used as the path segment of the package,
+// so callers escape it.
+func (ts *testServer) push(token, pkg, version string) (int, string) {
+	ts.t.Helper()
+	return ts.pushProto(token, pkg, version, "message A { string name = 1; }")
+}
+
+// pushProto uploads a version whose only proto file declares messages in package test
+func (ts *testServer) pushProto(token, pkg, version, messages string) (int, string) {
+	ts.t.Helper()
+	return ts.pushFiles(token, pkg, version, map[string]string{
+		"protodex.yaml": "package:\n  name: test\n",
+		"proto/a.proto": "syntax = \"proto3\";\npackage test;\n" + messages + "\n",
+	})
+}
+
+// pushFiles uploads a version made of files, keyed by their path in the schema
+func (ts *testServer) pushFiles(token, pkg, version string, files map[string]string) (int, string) {
+	ts.t.Helper()
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	require.NoError(ts.t, form.WriteField("version", version))
+	part, err := form.CreateFormFile("zip", "schema.zip")
+	require.NoError(ts.t, err)
+	_, err = part.Write(schemaZip(ts.t, files))
+	require.NoError(ts.t, err)
+	require.NoError(ts.t, form.Close())
+
+	req, err := http.NewRequest("POST", ts.http.URL+"/api/packages/"+pkg+"/versions", body)
+	require.NoError(ts.t, err)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return ts.send(req, token)
+}
+
+func schemaZip(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	archive := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
+
+// dataEntries lists the data directory and the directory around it, a request that escapes the
+// schemas root would add or remove entries there
+func (ts *testServer) dataEntries() []string {
+	ts.t.Helper()
+	var entries []string
+	for _, dir := range []string{filepath.Dir(ts.dataDir), ts.dataDir} {
+		dirEntries, err := os.ReadDir(dir)
+		require.NoError(ts.t, err)
+		for _, entry := range dirEntries {
+			entries = append(entries, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return entries
+}
+
+func TestPushRejectsTraversalInVersions(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.register("alice")
+	status, body := ts.push(alice, "user-service", "v1.0.0")
+	require.Equal(t, http.StatusCreated, status, body)
+
+	for _, version := range []string{"..", "../v1.0.0", "v1/../..", "v1\\..\\.."} {
+		status, body := ts.push(alice, "user-service", version)
+		assert.Equal(t, http.StatusBadRequest, status, "version %q: %s", version, body)
+	}
+
+	status, body = ts.do("GET", "/api/packages/user-service/versions", alice, nil)
+	require.Equal(t, http.StatusOK, status, body)
+	var versions []client.Version
+	require.NoError(t, json.Unmarshal([]byte(body), &versions))
+	require.Len(t, versions, 1)
+	assert.Equal(t, "v1.0.0", versions[0].Version)
+}
+
+func TestPushRejectsTraversalInPackageNames(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.register("alice")
+	before := ts.dataEntries()
+
+	for _, name := range []string{"%2E%2E", "acme%2F%2E%2E", "%2E%2E%2Fescape", "acme%2F%2E%2E%2F%2E%2E"} {
+		status, body := ts.push(alice, name, "v1.0.0")
+		assert.Equal(t, http.StatusBadRequest, status, "package %q: %s", name, body)
+	}
+
+	assert.Equal(t, before, ts.dataEntries())
+	status, body := ts.do("GET", "/api/packages", alice, nil)
+	require.Equal(t, http.StatusOK, status, body)
+	var packages []client.Package
+	require.NoError(t, json.Unmarshal([]byte(body), &packages))
+	assert.Empty(t, packages)
+}
+
+func TestDeleteRejectsTraversal(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.register("alice")
+	status, body := ts.push(alice, "user-service", "v1.0.0")
+	require.Equal(t, http.StatusCreated, status, body)
+
+	// The handler looks the name up, the router does not clean it into another route
+	status, body = ts.do("DELETE", "/api/packages/%2E%2E", alice, nil)
+	assert.Equal(t, http.StatusNotFound, status, body)
+	assert.Contains(t, body, "package not found")
+	status, body = ts.do("DELETE", "/api/packages/user-service/versions/%2E%2E?purge=true", alice, nil)
+	assert.Equal(t, http.StatusBadRequest, status, body)
+	status, body = ts.do("DELETE", "/api/packages/user-service/versions/..%2Fv1.0.0?purge=true", alice, nil)
+	assert.Equal(t, http.StatusBadRequest, status, body)
+
+	status, body = ts.do("GET", "/api/packages/user-service/versions/v1.0.0/files", alice, nil)
+	assert.Equal(t, http.StatusOK, status, body)
+}
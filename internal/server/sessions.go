@@ -0,0 +1,106 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+)
+
+// logoutHandler ends the session the request was made with
+func (s *Server) logoutHandler(c *gin.Context) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+	if authCtx.Session == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "API tokens are not sessions, revoke them with protodex token revoke"})
+		return
+	}
+
+	if err := s.authService.Logout(authCtx.Session.ID); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to end session")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionLogout, auditstore.TargetSession, authCtx.Session.ID, nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) listSessionsHandler(c *gin.Context) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	sessions, err := s.authService.ListSessions(authCtx.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientSessions := make([]client.Session, 0, len(sessions))
+	for _, session := range sessions {
+		clientSessions = append(clientSessions, toClientSession(session, authCtx.Session))
+	}
+	c.JSON(http.StatusOK, clientSessions)
+}
+
+func (s *Server) revokeSessionHandler(c *gin.Context) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	if err := s.authService.RevokeSession(authCtx.UserID, c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+		return
+	}
+	s.audit(c, auditstore.ActionSessionRevoke, auditstore.TargetSession, c.Param("id"), nil)
+
+	c.Status(http.StatusNoContent)
+}
+
+// revokeOtherSessionsHandler ends every session of the caller except the current one. Requests
+// made with an API token end all sessions.
+func (s *Server) revokeOtherSessionsHandler(c *gin.Context) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var keepID string
+	if authCtx.Session != nil {
+		keepID = authCtx.Session.ID
+	}
+
+	revoked, err := s.authService.RevokeOtherSessions(authCtx.UserID, keepID)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to revoke sessions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionSessionRevoke, auditstore.TargetUser, authCtx.User.Username, map[string]string{"revoked": strconv.FormatInt(revoked, 10)})
+
+	c.JSON(http.StatusOK, client.RevokeSessionsResponse{Revoked: revoked})
+}
+
+func toClientSession(session, current *authstore.Session) client.Session {
+	return client.Session{
+		ID:        session.ID,
+		UserAgent: session.UserAgent,
+		CreatedAt: session.CreatedAt,
+		LastUsed:  session.LastUsed,
+		ExpiresAt: session.ExpiresAt,
+		Current:   current != nil && current.ID == session.ID,
+	}
+}
@@ -0,0 +1,411 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/config"
+	"github.com/sirrobot01/protodex/internal/server/auth"
+	"github.com/sirrobot01/protodex/internal/server/oidc"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+	orgstore "github.com/sirrobot01/protodex/internal/store/org"
+)
+
+// ssoLoginTTL is how long a user has to finish a browser login at the identity provider
+const ssoLoginTTL = 10 * time.Minute
+
+// maxPendingSSOLogins bounds the memory anonymous callers can make the registry hold
+const maxPendingSSOLogins = 10000
+
+// ssoState holds the identity provider and the browser logins in progress
+type ssoState struct {
+	config config.SSOConfig
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+	logins   map[string]ssoLogin
+}
+
+// ssoLogin is a browser login waiting for the identity provider to redirect back
+type ssoLogin struct {
+	nonce        string
+	codeVerifier string
+	redirectURL  string
+	returnTo     string
+	// linkUserID is the logged-in user who links the identity to their account, empty for logins
+	linkUserID string
+	expires    time.Time
+}
+
+func newSSOState(cfg config.SSOConfig) *ssoState {
+	if !cfg.Enabled() {
+		return nil
+	}
+	return &ssoState{
+		config: cfg,
+		logins: make(map[string]ssoLogin),
+	}
+}
+
+// ssoProvider returns the identity provider, reading its discovery document on first use so the
+// registry starts even while the provider is unreachable
+func (s *Server) ssoProvider(ctx context.Context) (*oidc.Provider, error) {
+	if s.sso == nil {
+		return nil, errors.New("single sign-on is not configured")
+	}
+
+	s.sso.mu.Lock()
+	defer s.sso.mu.Unlock()
+
+	if s.sso.provider == nil {
+		provider, err := oidc.NewProvider(ctx, oidc.Config{
+			Issuer:       s.sso.config.Issuer,
+			ClientID:     s.sso.config.ClientID,
+			ClientSecret: s.sso.config.ClientSecret,
+			Scopes:       s.sso.config.Scopes,
+		}, nil)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to reach identity provider")
+			return nil, errors.New("identity provider is unavailable")
+		}
+		s.sso.provider = provider
+	}
+	return s.sso.provider, nil
+}
+
+// ssoInfoHandler tells the web UI whether to offer single sign-on
+func (s *Server) ssoInfoHandler(c *gin.Context) {
+	if s.sso == nil {
+		c.JSON(http.StatusOK, client.SSOInfo{})
+		return
+	}
+	c.JSON(http.StatusOK, client.SSOInfo{Enabled: true, Name: s.sso.config.Name})
+}
+
+// ssoLoginHandler starts a browser login by sending the user to the identity provider
+func (s *Server) ssoLoginHandler(c *gin.Context) {
+	authURL, err := s.startSSOLogin(c, "")
+	if err != nil {
+		s.ssoFailed(c, err.Error())
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// ssoLinkHandler starts a browser login that links the identity to the caller's account instead of
+// logging in. It answers with the URL of the identity provider, since browsers do not send the
+// session token when they follow a link.
+func (s *Server) ssoLinkHandler(c *gin.Context) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	authURL, err := s.startSSOLogin(c, authCtx.UserID)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, client.SSOLink{URL: authURL})
+}
+
+// startSSOLogin records a browser login in progress and returns the URL of the identity provider
+// to send the user to
+func (s *Server) startSSOLogin(c *gin.Context, linkUserID string) (string, error) {
+	provider, err := s.ssoProvider(c.Request.Context())
+	if err != nil {
+		return "", err
+	}
+
+	state, err1 := oidc.RandomString()
+	nonce, err2 := oidc.RandomString()
+	verifier, err3 := oidc.RandomString()
+	if err := errors.Join(err1, err2, err3); err != nil {
+		return "", errors.New("failed to start login")
+	}
+
+	login := ssoLogin{
+		nonce:        nonce,
+		codeVerifier: verifier,
+		redirectURL:  s.ssoRedirectURL(c),
+		returnTo:     localPath(c.Query("redirect")),
+		linkUserID:   linkUserID,
+		expires:      time.Now().Add(ssoLoginTTL),
+	}
+
+	s.sso.mu.Lock()
+	for key, pending := range s.sso.logins {
+		if time.Now().After(pending.expires) {
+			delete(s.sso.logins, key)
+		}
+	}
+	full := len(s.sso.logins) >= maxPendingSSOLogins
+	if !full {
+		s.sso.logins[state] = login
+	}
+	s.sso.mu.Unlock()
+	if full {
+		return "", errors.New("too many logins in progress, try again later")
+	}
+
+	return provider.AuthCodeURL(login.redirectURL, state, nonce, verifier), nil
+}
+
+// ssoCallbackHandler finishes a browser login. The session token, or the two-factor challenge, is
+// handed to the web UI in the URL fragment, which browsers do not send to servers or in Referer
+// headers.
+func (s *Server) ssoCallbackHandler(c *gin.Context) {
+	if s.sso == nil {
+		s.ssoFailed(c, "single sign-on is not configured")
+		return
+	}
+	if errCode := c.Query("error"); errCode != "" {
+		s.ssoFailed(c, strings.TrimSpace("login failed at the identity provider: "+errCode+" "+c.Query("error_description")))
+		return
+	}
+
+	s.sso.mu.Lock()
+	login, ok := s.sso.logins[c.Query("state")]
+	delete(s.sso.logins, c.Query("state"))
+	s.sso.mu.Unlock()
+	if !ok || time.Now().After(login.expires) {
+		s.ssoFailed(c, "login expired, try again")
+		return
+	}
+
+	provider, err := s.ssoProvider(c.Request.Context())
+	if err != nil {
+		s.ssoFailed(c, err.Error())
+		return
+	}
+	tokens, err := provider.Exchange(c.Request.Context(), c.Query("code"), login.codeVerifier, login.redirectURL)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to exchange login code")
+		s.ssoFailed(c, "login failed at the identity provider")
+		return
+	}
+	idToken, err := provider.Verify(c.Request.Context(), tokens.IDToken, login.nonce)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Rejected ID token")
+		s.ssoFailed(c, "login failed at the identity provider")
+		return
+	}
+
+	if login.linkUserID != "" {
+		if err := s.ssoLink(c, login.linkUserID, idToken); err != nil {
+			s.ssoFailed(c, err.Error())
+			return
+		}
+		c.Redirect(http.StatusFound, login.returnTo)
+		return
+	}
+
+	loginResp, err := s.ssoSession(c, idToken)
+	if err != nil {
+		s.ssoFailed(c, err.Error())
+		return
+	}
+
+	fragment := url.Values{"token": {loginResp.Token}, "redirect": {login.returnTo}}
+	if loginResp.Challenge != "" {
+		fragment = url.Values{"challenge": {loginResp.Challenge}, "redirect": {login.returnTo}}
+	}
+	c.Redirect(http.StatusFound, "/sso#"+fragment.Encode())
+}
+
+// ssoDeviceHandler starts a device login for the CLI
+func (s *Server) ssoDeviceHandler(c *gin.Context) {
+	provider, err := s.ssoProvider(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	deviceAuth, err := provider.StartDeviceAuth(c.Request.Context())
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to start device login")
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, client.DeviceLogin{
+		DeviceCode:              deviceAuth.DeviceCode,
+		UserCode:                deviceAuth.UserCode,
+		VerificationURI:         deviceAuth.VerificationURI,
+		VerificationURIComplete: deviceAuth.VerificationURIComplete,
+		ExpiresIn:               deviceAuth.ExpiresIn,
+		Interval:                deviceAuth.Interval,
+	})
+}
+
+// ssoDeviceTokenHandler checks whether the user finished a device login. Until they do it answers
+// with the error code of the identity provider, such as authorization_pending.
+func (s *Server) ssoDeviceTokenHandler(c *gin.Context) {
+	var req client.DeviceTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	provider, err := s.ssoProvider(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := provider.PollDeviceToken(c.Request.Context(), req.DeviceCode)
+	var tokenErr *oidc.TokenError
+	if errors.As(err, &tokenErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": tokenErr.Code})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	idToken, err := provider.Verify(c.Request.Context(), tokens.IDToken, "")
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Rejected ID token")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login failed at the identity provider"})
+		return
+	}
+
+	loginResp, err := s.ssoSession(c, idToken)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, toClientLogin(loginResp))
+}
+
+// ssoSession maps a verified ID token to a user, creating it on first login, and starts a session
+// or a two-factor challenge
+func (s *Server) ssoSession(c *gin.Context, idToken *oidc.IDToken) (*auth.LoginResponse, error) {
+	identity := s.ssoIdentity(idToken)
+	user, err := s.authService.ExternalUser(identity)
+	if err != nil {
+		s.logger.Warn().Err(err).
+			Str("event", "login_failed").
+			Str("subject", identity.Subject).
+			Str("username", identity.Username).
+			Str("ip", c.ClientIP()).
+			Msg("Failed single sign-on login")
+		s.auditAs(c, nil, auditstore.ActionLoginFailed, auditstore.TargetUser, identity.Username, map[string]string{"method": "sso"})
+		if errors.Is(err, auth.ErrUsernameTaken) {
+			return nil, fmt.Errorf("an account named %s already exists, log in to it and link your identity from your account settings", identity.Username)
+		}
+		return nil, errors.New("login failed")
+	}
+
+	s.syncSSOOrganizations(c, user, idToken.Strings(s.sso.config.GroupsClaim))
+
+	loginResp, err := s.authService.FinishLogin(c.GetHeader("User-Agent"), user)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to create session")
+		return nil, errors.New("login failed")
+	}
+	if loginResp.Token != "" {
+		s.auditAs(c, user, auditstore.ActionLogin, auditstore.TargetUser, user.Username, map[string]string{"method": "sso"})
+	}
+	return loginResp, nil
+}
+
+// ssoIdentity maps the claims of a verified ID token to an identity
+func (s *Server) ssoIdentity(idToken *oidc.IDToken) auth.Identity {
+	identity := auth.Identity{
+		Subject:  idToken.Subject,
+		Username: idToken.String(s.sso.config.UsernameClaim),
+		Email:    idToken.String("email"),
+	}
+	if identity.Username == "" {
+		identity.Username, _, _ = strings.Cut(identity.Email, "@")
+	}
+	return identity
+}
+
+// ssoLink links the identity of a verified ID token to the account that started the login
+func (s *Server) ssoLink(c *gin.Context, userID string, idToken *oidc.IDToken) error {
+	user, err := s.authService.LinkExternalUser(userID, s.ssoIdentity(idToken))
+	if err != nil {
+		s.logger.Warn().Err(err).Str("subject", idToken.Subject).Str("user_id", userID).Msg("Failed to link identity")
+		if errors.Is(err, auth.ErrIdentityLinked) {
+			return errors.New("your identity is already linked to another account")
+		}
+		return errors.New("failed to link your identity")
+	}
+	s.auditAs(c, user, auditstore.ActionSSOLink, auditstore.TargetUser, user.Username, map[string]string{"subject": idToken.Subject})
+	return nil
+}
+
+// syncSSOOrganizations adds a user to the organizations mapped to their identity provider groups.
+// Memberships are only added, so roles changed in protodex are kept.
+func (s *Server) syncSSOOrganizations(c *gin.Context, user *authstore.User, groups []string) {
+	for _, mapping := range s.sso.config.Organizations {
+		if !slices.Contains(groups, mapping.Group) {
+			continue
+		}
+
+		org, err := s.orgStore.GetOrganization(mapping.Organization)
+		if err != nil {
+			s.logger.Warn().Err(err).Str("organization", mapping.Organization).Msg("Organization of SSO group mapping not found")
+			continue
+		}
+		if _, err := s.orgStore.GetMemberRole(org.ID, user.ID); err == nil {
+			continue
+		}
+
+		role := mapping.Role
+		if role == "" {
+			role = orgstore.RoleMember
+		}
+		if err := s.orgStore.SetMember(org.ID, user.ID, role); err != nil {
+			s.logger.Warn().Err(err).Str("organization", org.Name).Msg("Failed to add SSO user to organization")
+			continue
+		}
+		s.auditAs(c, user, auditstore.ActionOrgMemberSet, auditstore.TargetOrg, org.Name, map[string]string{
+			"user": user.Username, "role": role, "group": mapping.Group,
+		})
+	}
+}
+
+// ssoFailed sends the browser back to the login page with an error message
+func (s *Server) ssoFailed(c *gin.Context, message string) {
+	c.Redirect(http.StatusFound, "/login?sso_error="+url.QueryEscape(message))
+}
+
+// ssoRedirectURL returns the callback URL the identity provider sends users back to
+func (s *Server) ssoRedirectURL(c *gin.Context) string {
+	if s.sso.config.RedirectURL != "" {
+		return s.sso.config.RedirectURL
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host + "/api/auth/sso/callback"
+}
+
+// localPath returns path when it stays on the registry, so logins cannot redirect to other sites
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/dashboard"
+	}
+	return path
+}
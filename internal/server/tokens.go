@@ -0,0 +1,198 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/server/auth"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+)
+
+// requireScope rejects API tokens that lack scope or are restricted to a single package. It guards
+// routes that do not act on one package, package routes are limited through packageRole.
+func (s *Server) requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authCtx := s.caller(c); authCtx != nil && !s.checkScope(c, authCtx, scope) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// checkScope writes a 403 response and returns false when an API token cannot be used for scope
+// outside of the package it is restricted to
+func (s *Server) checkScope(c *gin.Context, authCtx *auth.Context, scope string) bool {
+	if !authCtx.HasScope(scope) {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("this API token does not have the %s scope", scope)})
+		return false
+	}
+	if authCtx.RestrictedTo() != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "this API token is restricted to a single package"})
+		return false
+	}
+	return true
+}
+
+// createTokenHandler creates an API token for the caller, or for one of their service accounts.
+// A service account named in the request is created on first use.
+func (s *Server) createTokenHandler(c *gin.Context) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req client.CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Scopes) == 0 {
+		req.Scopes = []string{authstore.ScopeRead}
+	}
+	if err := authstore.ValidateScopes(req.Scopes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiry must be in the future"})
+		return
+	}
+
+	apiToken := &authstore.APIToken{
+		Name:      req.Name,
+		UserID:    authCtx.UserID,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: authCtx.UserID,
+	}
+
+	if req.Package != "" {
+		pkg, err := s.packageStore.GetPackage(req.Package)
+		if err != nil || !s.canRead(pkg, authCtx) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("package %s not found", req.Package)})
+			return
+		}
+		apiToken.PackageID = pkg.ID
+	}
+
+	owner := authCtx.User.Username
+	if req.ServiceAccount != "" {
+		account := s.serviceAccount(c, req.ServiceAccount, authCtx.UserID)
+		if account == nil {
+			return
+		}
+		apiToken.UserID = account.ID
+		owner = account.Username
+	}
+
+	token, created, err := s.authService.CreateAPIToken(apiToken)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to create API token")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	details := map[string]string{"name": created.Name, "user": owner, "scopes": strings.Join(created.Scopes, ",")}
+	if req.Package != "" {
+		details["package"] = req.Package
+	}
+	if created.ExpiresAt != nil {
+		details["expires_at"] = created.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	s.audit(c, auditstore.ActionTokenCreate, auditstore.TargetToken, created.ID, details)
+
+	c.JSON(http.StatusCreated, client.CreateTokenResponse{
+		Token:    token,
+		APIToken: s.toClientToken(created),
+	})
+}
+
+// serviceAccount returns the service account called name, creating it for ownerID when no user has
+// that name. It writes the error response and returns nil when the name belongs to someone else.
+func (s *Server) serviceAccount(c *gin.Context, name, ownerID string) *authstore.User {
+	user, err := s.authService.GetUserByUsername(name)
+	if err != nil {
+		user, err = s.authService.CreateServiceAccount(name, ownerID)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to create service account")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return nil
+		}
+		s.audit(c, auditstore.ActionUserCreate, auditstore.TargetUser, user.Username, map[string]string{"service_account": "true"})
+		return user
+	}
+
+	if !user.ServiceAccount || user.CreatedBy != ownerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("%s is not a service account you manage", name)})
+		return nil
+	}
+	return user
+}
+
+// listTokensHandler lists the tokens the caller created, for themselves and their service accounts
+func (s *Server) listTokensHandler(c *gin.Context) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	tokens, err := s.authService.ListAPITokens(authCtx.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientTokens := make([]client.APIToken, 0, len(tokens))
+	for _, token := range tokens {
+		clientTokens = append(clientTokens, s.toClientToken(token))
+	}
+	c.JSON(http.StatusOK, clientTokens)
+}
+
+func (s *Server) revokeTokenHandler(c *gin.Context) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	token, err := s.authService.GetAPIToken(c.Param("id"))
+	if err != nil || (token.CreatedBy != authCtx.UserID && token.UserID != authCtx.UserID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "token not found"})
+		return
+	}
+
+	if err := s.authService.RevokeAPIToken(token.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionTokenRevoke, auditstore.TargetToken, token.ID, map[string]string{"name": token.Name})
+
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) toClientToken(token *authstore.APIToken) client.APIToken {
+	clientToken := client.APIToken{
+		ID:        token.ID,
+		Name:      token.Name,
+		Username:  token.Username,
+		Scopes:    token.Scopes,
+		ExpiresAt: token.ExpiresAt,
+		CreatedAt: token.CreatedAt,
+		LastUsed:  token.LastUsed,
+	}
+	if token.PackageID != "" {
+		if pkg, err := s.packageStore.GetPackageByID(token.PackageID); err == nil {
+			clientToken.Package = pkg.Name
+		}
+	}
+	return clientToken
+}
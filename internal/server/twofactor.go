@@ -0,0 +1,237 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/server/auth"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+)
+
+// toClientLogin converts a login of the auth service, which is either a session or a two-factor
+// challenge
+func toClientLogin(loginResp *auth.LoginResponse) client.LoginResponse {
+	return client.LoginResponse{
+		Token:              loginResp.Token,
+		User:               client.User{ID: loginResp.User.ID, Username: loginResp.User.Username},
+		TwoFactorChallenge: loginResp.Challenge,
+	}
+}
+
+// twoFactorLoginHandler finishes a login with the two-factor code for its challenge. Wrong codes
+// count as failed logins of the user.
+func (s *Server) twoFactorLoginHandler(c *gin.Context) {
+	var req client.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ip := c.ClientIP()
+
+	user, err := s.authService.ChallengeUser(req.Challenge)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": auth.ErrChallengeExpired.Error()})
+		return
+	}
+	if wait := s.loginLimiter.LockedFor(user.Username, ip); wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed logins, try again later"})
+		return
+	}
+
+	loginResp, err := s.authService.CompleteChallenge(req.Challenge, req.Code)
+	if err != nil {
+		locked := s.loginLimiter.Fail(user.Username, ip)
+		s.logger.Warn().Err(err).
+			Str("event", "login_failed").
+			Str("username", user.Username).
+			Str("ip", ip).
+			Str("user_agent", c.GetHeader("User-Agent")).
+			Bool("locked_out", locked).
+			Msg("Failed two-factor login")
+		s.auditAs(c, nil, auditstore.ActionLoginFailed, auditstore.TargetUser, user.Username, map[string]string{"method": "two_factor"})
+		if errors.Is(err, auth.ErrChallengeExpired) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		return
+	}
+	s.loginLimiter.Succeed(user.Username)
+	s.auditAs(c, loginResp.User, auditstore.ActionLogin, auditstore.TargetUser, user.Username, map[string]string{"method": "two_factor"})
+
+	c.JSON(http.StatusOK, toClientLogin(loginResp))
+}
+
+// twoFactorStatusHandler tells the caller whether they have two-factor authentication enabled
+func (s *Server) twoFactorStatusHandler(c *gin.Context) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	status := client.TwoFactorStatus{
+		Enabled:  authCtx.User.TOTPEnabled,
+		Required: s.requireTwoFactor,
+	}
+	if status.Enabled {
+		if status.RecoveryCodesLeft, err = s.authService.CountRecoveryCodes(authCtx.UserID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// setupTOTPHandler starts a two-factor enrollment with a new authenticator secret
+func (s *Server) setupTOTPHandler(c *gin.Context) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	enrollment, err := s.authService.StartTOTPEnrollment(authCtx.User)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, client.TOTPSetup{Secret: enrollment.Secret, URI: enrollment.URI})
+}
+
+// enableTOTPHandler finishes an enrollment with a code from the authenticator and returns the
+// recovery codes
+func (s *Server) enableTOTPHandler(c *gin.Context) {
+	authCtx, req, ok := s.twoFactorCodeRequest(c)
+	if !ok {
+		return
+	}
+
+	codes, err := s.authService.EnableTOTP(authCtx.UserID, req.Code)
+	if err != nil {
+		s.twoFactorError(c, authCtx, err)
+		return
+	}
+	s.logger.Info().Str("username", authCtx.User.Username).Msg("Enabled two-factor authentication")
+	s.audit(c, auditstore.ActionTwoFactorEnable, auditstore.TargetUser, authCtx.User.Username, nil)
+	c.JSON(http.StatusOK, client.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// disableTOTPHandler turns off two-factor authentication after checking a code or recovery code
+func (s *Server) disableTOTPHandler(c *gin.Context) {
+	authCtx, req, ok := s.twoFactorCodeRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := s.authService.DisableTOTP(authCtx.UserID, req.Code); err != nil {
+		s.twoFactorError(c, authCtx, err)
+		return
+	}
+	s.logger.Info().Str("username", authCtx.User.Username).Msg("Disabled two-factor authentication")
+	s.audit(c, auditstore.ActionTwoFactorDisable, auditstore.TargetUser, authCtx.User.Username, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// regenerateRecoveryCodesHandler replaces the caller's recovery codes
+func (s *Server) regenerateRecoveryCodesHandler(c *gin.Context) {
+	authCtx, req, ok := s.twoFactorCodeRequest(c)
+	if !ok {
+		return
+	}
+
+	codes, err := s.authService.RegenerateRecoveryCodes(authCtx.UserID, req.Code)
+	if err != nil {
+		s.twoFactorError(c, authCtx, err)
+		return
+	}
+	s.audit(c, auditstore.ActionRecoveryCodes, auditstore.TargetUser, authCtx.User.Username, nil)
+	c.JSON(http.StatusOK, client.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// resetTwoFactorHandler lets administrators turn off two-factor authentication of a user who lost
+// their authenticator and recovery codes
+func (s *Server) resetTwoFactorHandler(c *gin.Context) {
+	user := s.routeUser(c)
+	if user == nil {
+		return
+	}
+	if !user.TOTPEnabled && user.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication is not enabled"})
+		return
+	}
+
+	if err := s.authService.ResetTOTP(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.logger.Info().Str("username", user.Username).Msg("Reset two-factor authentication")
+	s.audit(c, auditstore.ActionTwoFactorReset, auditstore.TargetUser, user.Username, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// requireTwoFactorToPublish rejects publishing requests of users without two-factor authentication
+// when the registry requires it. Service accounts cannot enroll, so they publish when the user who
+// owns them has two-factor authentication enabled.
+func (s *Server) requireTwoFactorToPublish() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authCtx := s.caller(c)
+		if !s.requireTwoFactor || authCtx == nil {
+			c.Next()
+			return
+		}
+
+		user := authCtx.User
+		if user.ServiceAccount {
+			owner, err := s.authService.GetUserByID(user.CreatedBy)
+			if err != nil {
+				owner = &authstore.User{}
+			}
+			user = owner
+		}
+		if !user.TOTPEnabled {
+			c.JSON(http.StatusForbidden, gin.H{"error": "this registry requires two-factor authentication to publish, enable it with protodex account 2fa enable"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// twoFactorCodeRequest reads the code of a two-factor request. Callers who entered too many wrong
+// codes are locked out like failed logins, so codes cannot be guessed with a stolen session. It
+// writes the error response and returns false when the request is invalid.
+func (s *Server) twoFactorCodeRequest(c *gin.Context) (*auth.Context, *client.TwoFactorCodeRequest, bool) {
+	authCtx, err := s.getAuthContext(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return nil, nil, false
+	}
+	if wait := s.loginLimiter.LockedFor(authCtx.User.Username, c.ClientIP()); wait > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many wrong codes, try again later"})
+		return nil, nil, false
+	}
+
+	var req client.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	return authCtx, &req, true
+}
+
+func (s *Server) twoFactorError(c *gin.Context, authCtx *auth.Context, err error) {
+	if errors.Is(err, auth.ErrInvalidCode) {
+		s.loginLimiter.Fail(authCtx.User.Username, c.ClientIP())
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/config"
+)
+
+// totpCode returns the current code of an authenticator with secret
+func totpCode(t *testing.T, secret string) string {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	require.NoError(t, err)
+	mac := hmac.New(sha1.New, key)
+	require.NoError(t, binary.Write(mac, binary.BigEndian, time.Now().Unix()/30))
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+func TestTwoFactorCodesCountAcrossChallenges(t *testing.T) {
+	ts := newTestServer(t)
+	alice := ts.register("alice")
+	status, body := ts.do("POST", "/api/auth/2fa/setup", alice, nil)
+	require.Equal(t, http.StatusOK, status, body)
+	var setup client.TOTPSetup
+	require.NoError(t, json.Unmarshal([]byte(body), &setup))
+	code := totpCode(t, setup.Secret)
+	status, body = ts.do("POST", "/api/auth/2fa/enable", alice, client.TwoFactorCodeRequest{Code: code})
+	require.Equal(t, http.StatusOK, status, body)
+
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	login := client.LoginRequest{Username: "alice", Password: "correct-horse-battery"}
+
+	// Every correct password starts a new challenge, it does not forget the wrong codes
+	limit := config.DefaultServerConfig().Auth.MaxFailedLogins
+	for i := 0; i < limit; i++ {
+		status, body = ts.do("POST", "/api/auth/login", "", login)
+		require.Equal(t, http.StatusOK, status, body)
+		var resp client.LoginResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &resp))
+		require.Empty(t, resp.Token)
+		require.NotEmpty(t, resp.TwoFactorChallenge)
+
+		status, body = ts.do("POST", "/api/auth/login/2fa", "", client.TwoFactorLoginRequest{Challenge: resp.TwoFactorChallenge, Code: wrong})
+		assert.Equal(t, http.StatusUnauthorized, status, body)
+	}
+
+	status, body = ts.do("POST", "/api/auth/login", "", login)
+	assert.Equal(t, http.StatusTooManyRequests, status, body)
+}
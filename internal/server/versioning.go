@@ -0,0 +1,224 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/breaking"
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/semver"
+	"github.com/sirrobot01/protodex/internal/server/auth"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+)
+
+// bumpCheck is the result of comparing a pushed version number with the schema diff it introduces
+type bumpCheck struct {
+	Previous  string
+	Required  semver.Bump
+	Actual    semver.Bump
+	Suggested string
+}
+
+func (b *bumpCheck) sufficient() bool {
+	return b.Actual >= b.Required
+}
+
+// checkVersionBump compares the schema in schemaDir with the closest lower semantic version of the
+// package, resolving dependencies as authCtx. It returns nil when bump checks are disabled, the
+// version is not a semantic version or there is no earlier semantic version to compare with.
+func (s *Server) checkVersionBump(pkg *pkgstore.Package, version, schemaDir string, authCtx *auth.Context) (*bumpCheck, error) {
+	policy, err := semver.ParsePolicy(pkg.BumpPolicy)
+	if err != nil {
+		return nil, err
+	}
+	if policy == semver.PolicyOff {
+		return nil, nil
+	}
+
+	pushed, err := semver.Parse(version)
+	if err != nil {
+		return nil, nil
+	}
+
+	versions, err := s.publishedVersions(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Versions are sorted by precedence, so the first lower one is the release being bumped
+	var previous *semver.Version
+	for _, v := range versions {
+		parsed, err := semver.Parse(v.Version)
+		if err != nil || semver.Compare(parsed, pushed) >= 0 {
+			continue
+		}
+		previous = &parsed
+		break
+	}
+	if previous == nil {
+		return nil, nil
+	}
+
+	current, err := s.compileSchema(schemaDir, authCtx)
+	if err != nil {
+		return nil, err
+	}
+	previousDir, err := s.packageStore.GetSchemaPath(pkg.Name, previous.String())
+	if err != nil {
+		return nil, err
+	}
+	baseline, err := s.compileSchema(previousDir, authCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile previous version %s: %w", previous, err)
+	}
+
+	required := breaking.RequiredBump(baseline, current)
+	suggested := required
+	if suggested == semver.BumpNone {
+		suggested = semver.BumpPatch
+	}
+
+	return &bumpCheck{
+		Previous:  previous.String(),
+		Required:  required,
+		Actual:    semver.Change(*previous, pushed),
+		Suggested: previous.Next(suggested).String(),
+	}, nil
+}
+
+// publishedVersions returns the versions of a package that consumers can resolve, leaving out
+// drafts, sorted by precedence
+func (s *Server) publishedVersions(pkg *pkgstore.Package) ([]*pkgstore.SchemaVersion, error) {
+	versions, err := s.packageStore.ListVersions(pkg.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions: %w", err)
+	}
+	return slices.DeleteFunc(versions, func(v *pkgstore.SchemaVersion) bool {
+		return !v.Published()
+	}), nil
+}
+
+// resolveVersion finds the schema version a query refers to. Exact version names take priority,
+// then labels such as stable or prod, then latest, latest-stable and version ranges such as ^1.2 or
+// >=2.0 <3 are resolved against the published versions that have not been yanked. Drafts are never
+// resolved.
+func (s *Server) resolveVersion(pkg *pkgstore.Package, query string) (*pkgstore.SchemaVersion, error) {
+	if schemaVersion, err := s.packageStore.GetSchemaVersion(pkg.ID, query); err == nil && schemaVersion.Published() {
+		return schemaVersion, nil
+	}
+	// A label points at the version it was set to even when that version has been yanked since
+	if label, err := s.packageStore.GetLabel(pkg.ID, query); err == nil {
+		return s.packageStore.GetSchemaVersion(pkg.ID, label.Version)
+	}
+
+	versions, err := s.publishedVersions(pkg)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(versions))
+	for _, v := range versions {
+		if v.Yanked {
+			continue
+		}
+		names = append(names, v.Version)
+	}
+
+	resolved, err := semver.Resolve(query, names)
+	if err != nil {
+		return nil, err
+	}
+	return s.packageStore.GetSchemaVersion(pkg.ID, resolved)
+}
+
+// writeResolveError responds to a version query that could not be resolved
+func writeResolveError(c *gin.Context, query string, err error) {
+	if errors.Is(err, semver.ErrNoMatch) {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("no version matches %s", query)})
+		return
+	}
+	// Anything that is neither a published version nor a valid range is simply unknown
+	c.JSON(http.StatusNotFound, gin.H{"error": "version not found"})
+}
+
+// getVersionHandler reports the concrete version a version query resolves to
+func (s *Server) getVersionHandler(c *gin.Context) {
+	version := c.Param("version")
+
+	pkg := s.readablePackage(c)
+	if pkg == nil {
+		return
+	}
+
+	schemaVersion, err := s.resolveVersion(pkg, version)
+	if err != nil {
+		writeResolveError(c, version, err)
+		return
+	}
+
+	setVersionHeaders(c, schemaVersion)
+	c.JSON(http.StatusOK, toClientVersion(schemaVersion))
+}
+
+func (s *Server) getVersioningHandler(c *gin.Context) {
+	pkg := s.readablePackage(c)
+	if pkg == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, toClientVersioning(pkg))
+}
+
+func (s *Server) setVersioningHandler(c *gin.Context) {
+	pkg := s.authorizedPackage(c, pkgstore.RoleOwner, "change its versioning policy")
+	if pkg == nil {
+		return
+	}
+
+	req := toClientVersioning(pkg)
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := semver.ParsePolicy(req.BumpPolicy)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid bump policy, expected one of %v", semver.Policies())})
+		return
+	}
+
+	if req.RequiredApprovals < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "required approvals cannot be negative"})
+		return
+	}
+
+	if err := s.packageStore.SetVersioning(pkg.ID, req.StrictSemver, string(policy), req.RequiredApprovals); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to update versioning")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionPackageVersioning, auditstore.TargetPackage, pkg.Name, map[string]string{
+		"strict_semver":      strconv.FormatBool(req.StrictSemver),
+		"bump_policy":        string(policy),
+		"required_approvals": strconv.Itoa(req.RequiredApprovals),
+	})
+
+	pkg.StrictSemver = req.StrictSemver
+	pkg.BumpPolicy = string(policy)
+	pkg.RequiredApprovals = req.RequiredApprovals
+	c.JSON(http.StatusOK, toClientVersioning(pkg))
+}
+
+func toClientVersioning(pkg *pkgstore.Package) *client.VersioningPolicy {
+	return &client.VersioningPolicy{
+		Package:           pkg.Name,
+		StrictSemver:      pkg.StrictSemver,
+		BumpPolicy:        pkg.BumpPolicy,
+		RequiredApprovals: pkg.RequiredApprovals,
+	}
+}
@@ -0,0 +1,371 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	webhookstore "github.com/sirrobot01/protodex/internal/store/webhook"
+)
+
+// Headers sent with every delivery
+const (
+	EventHeader     = "X-Protodex-Event"
+	DeliveryHeader  = "X-Protodex-Delivery"
+	SignatureHeader = "X-Protodex-Signature-256"
+)
+
+const (
+	// MaxAttempts is how many times a delivery is attempted before it fails
+	MaxAttempts = 8
+	// retryBase is the delay before the first retry, it doubles with every attempt up to retryMax
+	retryBase = 30 * time.Second
+	retryMax  = time.Hour
+
+	requestTimeout  = 10 * time.Second
+	pollInterval    = 5 * time.Second
+	batchSize       = 20
+	maxResponseBody = 1024
+	// keepDeliveries is how long finished deliveries stay in the history
+	keepDeliveries = 30 * 24 * time.Hour
+)
+
+// ErrPrivateAddress is returned when a webhook points at an address that is not publicly routable
+var ErrPrivateAddress = errors.New("webhooks cannot be delivered to loopback, private or link-local addresses")
+
+// reservedPrefixes are ranges that are not publicly routable besides the loopback, private,
+// link-local and multicast ranges netip classifies itself
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// Payload is the JSON body sent to webhooks
+type Payload struct {
+	Event     string    `json:"event"`
+	Timestamp time.Time `json:"timestamp"`
+	// Actor is the user who caused the event
+	Actor   string   `json:"actor,omitempty"`
+	Package Package  `json:"package"`
+	Version *Version `json:"version,omitempty"`
+}
+
+type Package struct {
+	Name       string `json:"name"`
+	Visibility string `json:"visibility"`
+}
+
+type Version struct {
+	Version    string `json:"version"`
+	Checksum   string `json:"checksum,omitempty"`
+	YankReason string `json:"yank_reason,omitempty"`
+}
+
+// Sign returns the signature of a payload, the hex encoded HMAC-SHA256 of the body with the
+// webhook secret prefixed by sha256=
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret generates a secret for signing payloads
+func NewSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// ValidateURL checks a webhook URL is an absolute http or https URL
+func ValidateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("webhook URL must be an http or https URL")
+	}
+	return nil
+}
+
+// PublicAddress reports whether deliveries may be sent to addr
+func PublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// refusePrivate is the dialer control of deliveries. It runs for the address actually connected to,
+// after name resolution, so a host cannot resolve to a public address when the webhook is created
+// and to an internal one when it is called.
+func refusePrivate(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, address)
+	}
+	if !PublicAddress(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// Dispatcher delivers events to webhooks. Deliveries are stored before they are sent, so the ones
+// pending when the registry stops are sent after it starts again.
+type Dispatcher struct {
+	store        webhookstore.Store
+	client       *http.Client
+	allowPrivate bool
+	logger       zerolog.Logger
+	wake         chan struct{}
+}
+
+// NewDispatcher creates a dispatcher. Unless allowPrivate is set, deliveries are only sent to
+// publicly routable addresses, so webhooks cannot be used to reach services inside the network of
+// the registry. Such deliveries also bypass any HTTP proxy configured in the environment, whose own
+// address would otherwise be the only one checked.
+func NewDispatcher(store webhookstore.Store, allowPrivate bool, logger zerolog.Logger) *Dispatcher {
+	dialer := &net.Dialer{Timeout: requestTimeout}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer.Control = refusePrivate
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+
+	return &Dispatcher{
+		store: store,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   requestTimeout,
+			// Redirects are not followed, a webhook has to point at its final URL
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		allowPrivate: allowPrivate,
+		logger:       logger,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// CheckURL resolves the host of a webhook URL and fails when it has an address deliveries would be
+// refused for, so a webhook that can never be delivered is rejected when it is created. Deliveries
+// are checked again when they connect.
+func (d *Dispatcher) CheckURL(ctx context.Context, rawURL string) error {
+	if d.allowPrivate {
+		return nil
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", parsed.Hostname())
+	if err != nil {
+		return fmt.Errorf("failed to resolve webhook host %s: %w", parsed.Hostname(), err)
+	}
+	for _, addr := range addrs {
+		if !PublicAddress(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrPrivateAddress, parsed.Hostname(), addr.Unmap())
+		}
+	}
+	return nil
+}
+
+// Publish queues a delivery of payload to every webhook of the package and every registry-wide
+// webhook subscribed to the event
+func (d *Dispatcher) Publish(packageID string, payload *Payload) error {
+	webhooks, err := d.store.ListSubscribers(packageID, payload.Event)
+	if err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+	for _, webhook := range webhooks {
+		if _, err := d.queue(webhook, payload.Event, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Redeliver queues a new delivery of the payload of an earlier delivery to its webhook
+func (d *Dispatcher) Redeliver(webhook *webhookstore.Webhook, delivery *webhookstore.Delivery) (*webhookstore.Delivery, error) {
+	return d.queue(webhook, delivery.Event, []byte(delivery.Payload))
+}
+
+func (d *Dispatcher) queue(webhook *webhookstore.Webhook, event string, body []byte) (*webhookstore.Delivery, error) {
+	delivery := &webhookstore.Delivery{
+		WebhookID: webhook.ID,
+		Event:     event,
+		URL:       webhook.URL,
+		Payload:   string(body),
+		Signature: Sign(webhook.Secret, body),
+	}
+	if err := d.store.CreateDelivery(delivery); err != nil {
+		return nil, err
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return delivery, nil
+}
+
+// Run sends due deliveries until ctx is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	lastPrune := time.Time{}
+	for {
+		now := time.Now()
+		d.deliverDue(ctx, now)
+
+		if now.Sub(lastPrune) > time.Hour {
+			if pruned, err := d.store.PruneDeliveries(now.Add(-keepDeliveries)); err != nil {
+				d.logger.Error().Err(err).Msg("Failed to prune webhook deliveries")
+			} else if pruned > 0 {
+				d.logger.Debug().Int64("deliveries", pruned).Msg("Pruned webhook deliveries")
+			}
+			lastPrune = now
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// deliverDue sends every delivery due at now, at most batchSize at a time
+func (d *Dispatcher) deliverDue(ctx context.Context, now time.Time) {
+	for ctx.Err() == nil {
+		deliveries, err := d.store.DueDeliveries(now, batchSize)
+		if err != nil {
+			d.logger.Error().Err(err).Msg("Failed to load webhook deliveries")
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.attempt(ctx, delivery)
+			}()
+		}
+		wg.Wait()
+
+		if len(deliveries) < batchSize {
+			return
+		}
+	}
+}
+
+// attempt sends a delivery once and stores the outcome, scheduling a retry when it failed
+func (d *Dispatcher) attempt(ctx context.Context, delivery *webhookstore.Delivery) {
+	status, body, err := d.send(ctx, delivery)
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+	delivery.Error = ""
+	delivery.NextAttemptAt = nil
+
+	if err == nil && status >= 200 && status < 300 {
+		delivery.Status = webhookstore.StatusSucceeded
+	} else {
+		if err != nil {
+			delivery.Error = err.Error()
+		} else {
+			delivery.Error = fmt.Sprintf("webhook responded with status %d", status)
+		}
+		if delivery.Attempts >= MaxAttempts {
+			delivery.Status = webhookstore.StatusFailed
+		} else {
+			next := now.Add(retryDelay(delivery.Attempts))
+			delivery.NextAttemptAt = &next
+		}
+		d.logger.Warn().
+			Str("delivery", delivery.ID).
+			Str("event", delivery.Event).
+			Str("url", delivery.URL).
+			Int("attempt", delivery.Attempts).
+			Str("error", delivery.Error).
+			Msg("Webhook delivery failed")
+	}
+
+	if err := d.store.UpdateDelivery(delivery); err != nil {
+		d.logger.Error().Err(err).Str("delivery", delivery.ID).Msg("Failed to store webhook delivery")
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery *webhookstore.Delivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "protodex-webhook")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, delivery.Signature)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	return resp.StatusCode, string(body), nil
+}
+
+// retryDelay returns how long to wait after a failed attempt before the next one
+func retryDelay(attempts int) time.Duration {
+	delay := retryBase
+	for i := 1; i < attempts && delay < retryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, retryMax)
+}
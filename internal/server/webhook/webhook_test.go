@@ -0,0 +1,237 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sirrobot01/protodex/internal/store"
+	webhookstore "github.com/sirrobot01/protodex/internal/store/webhook"
+)
+
+// receiver records the requests sent to a webhook and answers with the next status
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte("received"))
+}
+
+func setupDispatcher(t *testing.T) (*Dispatcher, webhookstore.Store) {
+	storage, err := store.New(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, storage.Init())
+	t.Cleanup(func() { _ = storage.Close() })
+
+	return NewDispatcher(storage.Webhook(), true, zerolog.Nop()), storage.Webhook()
+}
+
+func TestDispatcherDeliversSignedPayloads(t *testing.T) {
+	dispatcher, webhookStore := setupDispatcher(t)
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	hook, err := webhookStore.CreateWebhook(&webhookstore.Webhook{
+		PackageID: "pkg-1",
+		URL:       server.URL,
+		Secret:    "top-secret",
+		Events:    []string{webhookstore.EventVersionPushed},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, dispatcher.Publish("pkg-1", &Payload{
+		Event:   webhookstore.EventVersionPushed,
+		Actor:   "jane",
+		Package: Package{Name: "payments", Visibility: "public"},
+		Version: &Version{Version: "v1.2.0", Checksum: "abc"},
+	}))
+	// Events the webhook is not subscribed to and events of other packages are not delivered
+	require.NoError(t, dispatcher.Publish("pkg-1", &Payload{Event: webhookstore.EventVersionYanked}))
+	require.NoError(t, dispatcher.Publish("pkg-2", &Payload{Event: webhookstore.EventVersionPushed}))
+
+	dispatcher.deliverDue(context.Background(), time.Now())
+
+	require.Len(t, recv.requests, 1)
+	req, body := recv.requests[0], recv.bodies[0]
+	assert.Equal(t, webhookstore.EventVersionPushed, req.Header.Get(EventHeader))
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.Equal(t, Sign("top-secret", body), req.Header.Get(SignatureHeader))
+	assert.NotEqual(t, Sign("other-secret", body), req.Header.Get(SignatureHeader))
+
+	var payload Payload
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "payments", payload.Package.Name)
+	assert.Equal(t, "v1.2.0", payload.Version.Version)
+	assert.Equal(t, "jane", payload.Actor)
+
+	deliveries, err := webhookStore.ListDeliveries(hook.ID, false, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, req.Header.Get(DeliveryHeader), deliveries[0].ID)
+	assert.Equal(t, webhookstore.StatusSucceeded, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, "received", deliveries[0].ResponseBody)
+	assert.Nil(t, deliveries[0].NextAttemptAt)
+}
+
+func TestDispatcherRetriesFailedDeliveries(t *testing.T) {
+	dispatcher, webhookStore := setupDispatcher(t)
+	recv := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway}}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	hook, err := webhookStore.CreateWebhook(&webhookstore.Webhook{
+		URL:    server.URL,
+		Secret: "top-secret",
+		Events: []string{webhookstore.EventPackageCreated},
+	})
+	require.NoError(t, err)
+	require.NoError(t, dispatcher.Publish("pkg-1", &Payload{Event: webhookstore.EventPackageCreated}))
+
+	now := time.Now()
+	dispatcher.deliverDue(context.Background(), now)
+	deliveries, err := webhookStore.ListDeliveries(hook.ID, true, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	delivery := deliveries[0]
+	assert.Equal(t, webhookstore.StatusPending, delivery.Status)
+	assert.Equal(t, http.StatusInternalServerError, delivery.ResponseStatus)
+	assert.Contains(t, delivery.Error, "status 500")
+	require.NotNil(t, delivery.NextAttemptAt)
+	assert.WithinDuration(t, now.Add(retryBase), *delivery.NextAttemptAt, 5*time.Second)
+
+	// Nothing is sent before the retry is due
+	dispatcher.deliverDue(context.Background(), now)
+	assert.Len(t, recv.requests, 1)
+
+	dispatcher.deliverDue(context.Background(), now.Add(retryBase+5*time.Second))
+	dispatcher.deliverDue(context.Background(), now.Add(time.Hour))
+	require.Len(t, recv.requests, 3)
+	assert.Equal(t, recv.bodies[0], recv.bodies[2])
+
+	delivery, err = webhookStore.GetDelivery(delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, webhookstore.StatusSucceeded, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Empty(t, delivery.Error)
+
+	// Redelivering sends the same payload as a new delivery
+	redelivery, err := dispatcher.Redeliver(hook, delivery)
+	require.NoError(t, err)
+	assert.NotEqual(t, delivery.ID, redelivery.ID)
+	dispatcher.deliverDue(context.Background(), time.Now())
+	require.Len(t, recv.requests, 4)
+	assert.Equal(t, recv.bodies[0], recv.bodies[3])
+	assert.Equal(t, redelivery.ID, recv.requests[3].Header.Get(DeliveryHeader))
+}
+
+func TestDispatcherGivesUp(t *testing.T) {
+	dispatcher, webhookStore := setupDispatcher(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	hook, err := webhookStore.CreateWebhook(&webhookstore.Webhook{
+		URL:    server.URL,
+		Secret: "top-secret",
+		Events: []string{webhookstore.EventPackageDeleted},
+	})
+	require.NoError(t, err)
+	require.NoError(t, dispatcher.Publish("pkg-1", &Payload{Event: webhookstore.EventPackageDeleted}))
+
+	// Each attempt is made once the previous retry is due
+	for i := 1; i <= MaxAttempts+1; i++ {
+		dispatcher.deliverDue(context.Background(), time.Now().Add(time.Duration(i)*retryMax))
+	}
+
+	deliveries, err := webhookStore.ListDeliveries(hook.ID, false, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, webhookstore.StatusFailed, deliveries[0].Status)
+	assert.Equal(t, MaxAttempts, deliveries[0].Attempts)
+	assert.Nil(t, deliveries[0].NextAttemptAt)
+}
+
+func TestDispatcherRefusesPrivateAddresses(t *testing.T) {
+	storage, err := store.New(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, storage.Init())
+	t.Cleanup(func() { _ = storage.Close() })
+	dispatcher, webhookStore := NewDispatcher(storage.Webhook(), false, zerolog.Nop()), storage.Webhook()
+
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	assert.ErrorIs(t, dispatcher.CheckURL(context.Background(), server.URL), ErrPrivateAddress)
+	assert.ErrorIs(t, dispatcher.CheckURL(context.Background(), "http://169.254.169.254/latest/meta-data"), ErrPrivateAddress)
+
+	// A webhook stored anyway, or whose host resolves differently later, is refused when it connects
+	hook, err := webhookStore.CreateWebhook(&webhookstore.Webhook{
+		URL:    server.URL,
+		Secret: "top-secret",
+		Events: []string{webhookstore.EventVersionPushed},
+	})
+	require.NoError(t, err)
+	require.NoError(t, dispatcher.Publish("pkg-1", &Payload{Event: webhookstore.EventVersionPushed}))
+	dispatcher.deliverDue(context.Background(), time.Now())
+
+	assert.Empty(t, recv.requests)
+	deliveries, err := webhookStore.ListDeliveries(hook.ID, false, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Contains(t, deliveries[0].Error, ErrPrivateAddress.Error())
+	assert.Empty(t, deliveries[0].ResponseBody)
+}
+
+func TestPublicAddress(t *testing.T) {
+	for _, addr := range []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"} {
+		assert.True(t, PublicAddress(netip.MustParseAddr(addr)), addr)
+	}
+	for _, addr := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "0.0.0.0",
+		"100.64.0.1", "255.255.255.255", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1", "::ffff:169.254.169.254"} {
+		assert.False(t, PublicAddress(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, retryDelay(1))
+	assert.Equal(t, time.Minute, retryDelay(2))
+	assert.Equal(t, 4*time.Minute, retryDelay(4))
+	assert.Equal(t, retryMax, retryDelay(10))
+}
+
+func TestValidateURL(t *testing.T) {
+	assert.NoError(t, ValidateURL("https://ci.example.com/hooks/protodex"))
+	assert.NoError(t, ValidateURL("http://localhost:8080/hook"))
+	assert.Error(t, ValidateURL("ftp://example.com/hook"))
+	assert.Error(t, ValidateURL("/hook"))
+	assert.Error(t, ValidateURL("https://"))
+}
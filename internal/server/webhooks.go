@@ -0,0 +1,265 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/server/webhook"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+	webhookstore "github.com/sirrobot01/protodex/internal/store/webhook"
+)
+
+const (
+	// minWebhookSecretLength applies to secrets chosen by users, generated ones are longer
+	minWebhookSecretLength = 16
+	// deliveryHistoryLimit is how many deliveries of a webhook are listed
+	deliveryHistoryLimit = 100
+)
+
+// publishEvent queues the deliveries of an event caused by the caller to the webhooks of a package
+// and the registry-wide webhooks, and sends it to the live event stream. The change has already
+// happened, so failing to queue the deliveries is logged rather than failing the request.
+func (s *Server) publishEvent(c *gin.Context, event string, pkg *pkgstore.Package, version *webhook.Version) {
+	payload := &webhook.Payload{
+		Event:     event,
+		Timestamp: time.Now().UTC(),
+		Package:   webhook.Package{Name: pkg.Name, Visibility: pkg.Visibility},
+		Version:   version,
+	}
+	if authCtx := s.caller(c); authCtx != nil {
+		payload.Actor = authCtx.User.Username
+	}
+
+	if err := s.webhooks.Publish(pkg.ID, payload); err != nil {
+		s.logger.Error().Err(err).Str("event", event).Str("package", pkg.Name).Msg("Failed to queue webhook deliveries")
+	}
+	s.events.Publish(payload, pkg)
+}
+
+// webhookPackage returns the package whose webhooks the request manages, or nil on the admin routes
+// of the registry-wide webhooks. It writes the error response and returns false when the caller
+// cannot manage the webhooks.
+func (s *Server) webhookPackage(c *gin.Context) (*pkgstore.Package, bool) {
+	if c.Param("package") == "" {
+		return nil, true
+	}
+	pkg := s.authorizedPackage(c, pkgstore.RoleOwner, "manage its webhooks")
+	return pkg, pkg != nil
+}
+
+// routeWebhook loads the webhook named in the route. Webhooks of other packages are reported as
+// missing. It writes the error response and returns nil when the webhook cannot be used.
+func (s *Server) routeWebhook(c *gin.Context) (*pkgstore.Package, *webhookstore.Webhook) {
+	pkg, ok := s.webhookPackage(c)
+	if !ok {
+		return nil, nil
+	}
+
+	var packageID string
+	if pkg != nil {
+		packageID = pkg.ID
+	}
+	hook, err := s.webhookStore.GetWebhook(c.Param("id"))
+	if err != nil || hook.PackageID != packageID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return nil, nil
+	}
+	return pkg, hook
+}
+
+func (s *Server) listWebhooksHandler(c *gin.Context) {
+	pkg, ok := s.webhookPackage(c)
+	if !ok {
+		return
+	}
+
+	var packageID string
+	if pkg != nil {
+		packageID = pkg.ID
+	}
+	hooks, err := s.webhookStore.ListWebhooks(packageID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientHooks := make([]client.Webhook, 0, len(hooks))
+	for _, hook := range hooks {
+		clientHooks = append(clientHooks, s.toClientWebhook(hook, pkg))
+	}
+	c.JSON(http.StatusOK, clientHooks)
+}
+
+// createWebhookHandler registers a webhook and returns the secret its payloads are signed with
+func (s *Server) createWebhookHandler(c *gin.Context) {
+	pkg, ok := s.webhookPackage(c)
+	if !ok {
+		return
+	}
+	authCtx, _ := s.getAuthContext(c)
+
+	var req client.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := webhook.ValidateURL(req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.webhooks.CheckURL(c.Request.Context(), req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	events, err := webhookstore.ValidateEvents(req.Events)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = webhook.NewSecret(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	} else if len(secret) < minWebhookSecretLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "webhook secret must be at least 16 characters"})
+		return
+	}
+
+	hook := &webhookstore.Webhook{
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    events,
+		CreatedBy: authCtx.UserID,
+	}
+	details := map[string]string{"url": hook.URL, "events": strings.Join(events, ",")}
+	if pkg != nil {
+		hook.PackageID = pkg.ID
+		details["package"] = pkg.Name
+	}
+	if hook, err = s.webhookStore.CreateWebhook(hook); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to create webhook")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, auditstore.ActionWebhookCreate, auditstore.TargetWebhook, hook.ID, details)
+
+	c.JSON(http.StatusCreated, client.CreateWebhookResponse{
+		Secret:  secret,
+		Webhook: s.toClientWebhook(hook, pkg),
+	})
+}
+
+// deleteWebhookHandler removes a webhook, its pending deliveries are not sent
+func (s *Server) deleteWebhookHandler(c *gin.Context) {
+	pkg, hook := s.routeWebhook(c)
+	if hook == nil {
+		return
+	}
+
+	if err := s.webhookStore.DeleteWebhook(hook.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	details := map[string]string{"url": hook.URL}
+	if pkg != nil {
+		details["package"] = pkg.Name
+	}
+	s.audit(c, auditstore.ActionWebhookDelete, auditstore.TargetWebhook, hook.ID, details)
+
+	c.Status(http.StatusNoContent)
+}
+
+// listDeliveriesHandler returns the recent deliveries of a webhook, only the failed ones with
+// failed=true
+func (s *Server) listDeliveriesHandler(c *gin.Context) {
+	_, hook := s.routeWebhook(c)
+	if hook == nil {
+		return
+	}
+
+	deliveries, err := s.webhookStore.ListDeliveries(hook.ID, c.Query("failed") == "true", deliveryHistoryLimit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientDeliveries := make([]client.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		clientDeliveries = append(clientDeliveries, s.toClientDelivery(c, delivery))
+	}
+	c.JSON(http.StatusOK, clientDeliveries)
+}
+
+// redeliverHandler sends the payload of an earlier delivery again, as a new delivery
+func (s *Server) redeliverHandler(c *gin.Context) {
+	_, hook := s.routeWebhook(c)
+	if hook == nil {
+		return
+	}
+
+	delivery, err := s.webhookStore.GetDelivery(c.Param("delivery"))
+	if err != nil || delivery.WebhookID != hook.ID {
+		if err != nil && !errors.Is(err, webhookstore.ErrNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
+		return
+	}
+
+	redelivery, err := s.webhooks.Redeliver(hook, delivery)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, s.toClientDelivery(c, redelivery))
+}
+
+// toClientWebhook converts a webhook of pkg, nil for registry-wide webhooks
+func (s *Server) toClientWebhook(hook *webhookstore.Webhook, pkg *pkgstore.Package) client.Webhook {
+	clientHook := client.Webhook{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Events:    hook.Events,
+		CreatedBy: hook.CreatedBy,
+		CreatedAt: hook.CreatedAt,
+	}
+	if pkg != nil {
+		clientHook.Package = pkg.Name
+	}
+	if creator, err := s.authService.GetUserByID(hook.CreatedBy); err == nil {
+		clientHook.CreatedBy = creator.Username
+	}
+	return clientHook
+}
+
+// toClientDelivery converts a delivery for the caller. The response body is only shown to
+// administrators, it could otherwise be used to read the answers of services the registry can reach.
+func (s *Server) toClientDelivery(c *gin.Context, delivery *webhookstore.Delivery) client.WebhookDelivery {
+	clientDelivery := client.WebhookDelivery{
+		ID:             delivery.ID,
+		Event:          delivery.Event,
+		URL:            delivery.URL,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		Error:          delivery.Error,
+		CreatedAt:      delivery.CreatedAt,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		NextAttemptAt:  delivery.NextAttemptAt,
+	}
+	if isAdmin(s.caller(c)) {
+		clientDelivery.ResponseBody = delivery.ResponseBody
+	}
+	return clientDelivery
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/sirrobot01/protodex/internal/client"
+	"github.com/sirrobot01/protodex/internal/server/webhook"
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+	webhookstore "github.com/sirrobot01/protodex/internal/store/webhook"
+)
+
+// deleteVersionHandler yanks a version, or permanently deletes it when called with purge=true.
+// Yanked versions stay downloadable by exact version but are skipped by latest and range queries.
+func (s *Server) deleteVersionHandler(c *gin.Context) {
+	purge := c.Query("purge") == "true"
+	required, action := pkgstore.RoleMaintainer, "yank versions"
+	if purge {
+		required, action = pkgstore.RoleOwner, "delete versions"
+	}
+	pkg := s.authorizedPackage(c, required, action)
+	if pkg == nil {
+		return
+	}
+
+	version := c.Param("version")
+	if err := pkgstore.ValidateVersion(version); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	schemaVersion, err := s.packageStore.GetSchemaVersion(pkg.ID, version)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "version not found"})
+		return
+	}
+	if !purge && !schemaVersion.Published() {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("version %s of %s is a %s, reject or delete it instead of yanking", version, pkg.Name, schemaVersion.Status)})
+		return
+	}
+
+	if purge {
+		// Consumers resolve labels, deleting the version they point at would break them all at once
+		labels, err := s.versionLabels(pkg, version)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(labels) > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("version %s of %s is labelled %s, move or remove the labels first", version, pkg.Name, strings.Join(labels, ", "))})
+			return
+		}
+
+		if err := s.packageStore.DeleteSchemaVersion(pkg.ID, version, s.caller(c).UserID); err != nil {
+			if errors.Is(err, pkgstore.ErrInvalidPath) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			s.logger.Error().Err(err).Msg("Failed to delete version")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		s.audit(c, auditstore.ActionVersionDelete, auditstore.TargetVersion, versionTarget(pkg.Name, version), nil)
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	s.setYanked(c, pkg, version, true, c.Query("reason"))
+}
+
+func (s *Server) unyankVersionHandler(c *gin.Context) {
+	pkg := s.authorizedPackage(c, pkgstore.RoleMaintainer, "unyank versions")
+	if pkg == nil {
+		return
+	}
+
+	s.setYanked(c, pkg, c.Param("version"), false, "")
+}
+
+func (s *Server) setYanked(c *gin.Context, pkg *pkgstore.Package, version string, yanked bool, reason string) {
+	if err := s.packageStore.SetYanked(pkg.ID, version, yanked, reason); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("version %s of %s not found", version, pkg.Name)})
+		return
+	}
+	if yanked {
+		var details map[string]string
+		if reason != "" {
+			details = map[string]string{"reason": reason}
+		}
+		s.audit(c, auditstore.ActionVersionYank, auditstore.TargetVersion, versionTarget(pkg.Name, version), details)
+		s.publishEvent(c, webhookstore.EventVersionYanked, pkg, &webhook.Version{Version: version, YankReason: reason})
+	} else {
+		s.audit(c, auditstore.ActionVersionUnyank, auditstore.TargetVersion, versionTarget(pkg.Name, version), nil)
+	}
+
+	schemaVersion, err := s.packageStore.GetSchemaVersion(pkg.ID, version)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toClientVersion(schemaVersion))
+}
+
+// setVersionHeaders reports the concrete version a query resolved to and whether it has been yanked
+func setVersionHeaders(c *gin.Context, schemaVersion *pkgstore.SchemaVersion) {
+	c.Header(client.VersionHeader, schemaVersion.Version)
+	if schemaVersion.Yanked {
+		c.Header(client.YankedHeader, "true")
+		if schemaVersion.YankReason != "" {
+			c.Header(client.YankReasonHeader, schemaVersion.YankReason)
+		}
+	}
+}
+
+func toClientVersion(schemaVersion *pkgstore.SchemaVersion) client.Version {
+	return client.Version{
+		ID:         schemaVersion.ID,
+		Version:    schemaVersion.Version,
+		CreatedAt:  schemaVersion.CreatedAt,
+		CreatedBy:  schemaVersion.CreatedBy,
+		Checksum:   schemaVersion.Checksum,
+		Yanked:     schemaVersion.Yanked,
+		YankReason: schemaVersion.YankReason,
+		Status:     schemaVersion.Status,
+	}
+}
@@ -0,0 +1,227 @@
+package audit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Actions recorded in the audit log. Actions are grouped by the kind of target before the dot, so
+// filtering by "version" finds every version action.
+const (
+	ActionLogin            = "user.login"
+	ActionLoginFailed      = "user.login_failed"
+	ActionLogout           = "user.logout"
+	ActionRegister         = "user.register"
+	ActionUserCreate       = "user.create"
+	ActionUserDelete       = "user.delete"
+	ActionUserDisable      = "user.disable"
+	ActionUserEnable       = "user.enable"
+	ActionPromote          = "user.promote"
+	ActionDemote           = "user.demote"
+	ActionPasswordChange   = "user.password_change"
+	ActionPasswordReset    = "user.password_reset"
+	ActionTwoFactorEnable  = "user.2fa_enable"
+	ActionTwoFactorDisable = "user.2fa_disable"
+	ActionTwoFactorReset   = "user.2fa_reset"
+	ActionRecoveryCodes    = "user.recovery_codes"
+	ActionSSOLink          = "user.sso_link"
+
+	ActionSessionRevoke = "session.revoke"
+	ActionTokenCreate   = "token.create"
+	ActionTokenRevoke   = "token.revoke"
+	ActionInviteCreate  = "invite.create"
+	ActionInviteRevoke  = "invite.revoke"
+
+	ActionWebhookCreate = "webhook.create"
+	ActionWebhookDelete = "webhook.delete"
+
+	ActionOrgCreate       = "org.create"
+	ActionOrgMemberSet    = "org.member_set"
+	ActionOrgMemberRemove = "org.member_remove"
+
+	ActionPackageCreate        = "package.create"
+	ActionPackageDelete        = "package.delete"
+	ActionPackageRename        = "package.rename"
+	ActionPackageTransfer      = "package.transfer"
+	ActionPackageVisibility    = "package.visibility"
+	ActionPackageCompatibility = "package.compatibility"
+	ActionPackageVersioning    = "package.versioning"
+	ActionAccessGrant          = "package.access_grant"
+	ActionAccessRevoke         = "package.access_revoke"
+
+	ActionVersionPush    = "version.push"
+	ActionVersionYank    = "version.yank"
+	ActionVersionUnyank  = "version.unyank"
+	ActionVersionDelete  = "version.delete"
+	ActionVersionApprove = "version.approve"
+	ActionVersionReject  = "version.reject"
+	ActionVersionPublish = "version.publish"
+
+	ActionLabelMove   = "label.move"
+	ActionLabelDelete = "label.delete"
+)
+
+// Kinds of targets
+const (
+	TargetUser    = "user"
+	TargetSession = "session"
+	TargetToken   = "token"
+	TargetInvite  = "invite"
+	TargetWebhook = "webhook"
+	TargetOrg     = "org"
+	TargetPackage = "package"
+	TargetVersion = "version"
+	TargetLabel   = "label"
+)
+
+// DefaultLimit and MaxLimit bound how many entries List returns
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Entry records one change to the registry. Actors and targets are stored by name as they were at
+// the time, so entries stay readable after users and packages are renamed or deleted.
+type Entry struct {
+	ID     int64  `json:"id"`
+	Action string `json:"action"`
+	// ActorID and Actor are empty for anonymous requests, such as failed logins of unknown users
+	ActorID    string            `json:"actor_id,omitempty"`
+	Actor      string            `json:"actor,omitempty"`
+	IP         string            `json:"ip"`
+	UserAgent  string            `json:"user_agent,omitempty"`
+	TargetType string            `json:"target_type"`
+	Target     string            `json:"target"`
+	Details    map[string]string `json:"details,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// Filter selects audit entries. Empty fields match everything.
+type Filter struct {
+	Actor string
+	// Action matches an action, or every action of a group such as "version"
+	Action     string
+	TargetType string
+	Target     string
+	Since      time.Time
+	Until      time.Time
+	// Before only returns entries older than the entry with this ID, to page through the log
+	Before int64
+	Limit  int
+}
+
+// Store is append-only: entries cannot be changed or deleted, which the database also enforces
+type Store interface {
+	Record(entry *Entry) error
+	List(filter Filter) ([]*Entry, error)
+}
+
+type dbStore struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) Store {
+	return &dbStore{
+		db: db,
+	}
+}
+
+const entryColumns = `id, action, actor_id, actor, ip, user_agent, target_type, target, details, created_at`
+
+// Record appends an entry and sets its ID and time
+func (ds *dbStore) Record(entry *Entry) error {
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return fmt.Errorf("failed to encode details: %w", err)
+	}
+	entry.CreatedAt = time.Now().UTC()
+
+	query := `INSERT INTO audit_log (action, actor_id, actor, ip, user_agent, target_type, target, details, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := ds.db.Exec(query, entry.Action, entry.ActorID, entry.Actor, entry.IP, entry.UserAgent,
+		entry.TargetType, entry.Target, string(details), entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	if entry.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// List returns the entries matching filter, newest first
+func (ds *dbStore) List(filter Filter) ([]*Entry, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "(action = ? OR action LIKE ? ESCAPE '\\')")
+		args = append(args, filter.Action, escapeLike(filter.Action)+".%")
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, "target_type = ?")
+		args = append(args, filter.TargetType)
+	}
+	if filter.Target != "" {
+		conditions = append(conditions, "target = ?")
+		args = append(args, filter.Target)
+	}
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.Until.UTC())
+	}
+	if filter.Before > 0 {
+		conditions = append(conditions, "id < ?")
+		args = append(args, filter.Before)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	query := `SELECT ` + entryColumns + ` FROM audit_log`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := ds.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*Entry{}
+	for rows.Next() {
+		entry := &Entry{}
+		var details string
+		if err := rows.Scan(&entry.ID, &entry.Action, &entry.ActorID, &entry.Actor, &entry.IP, &entry.UserAgent,
+			&entry.TargetType, &entry.Target, &details, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if err := json.Unmarshal([]byte(details), &entry.Details); err != nil {
+			return nil, fmt.Errorf("failed to decode audit details: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
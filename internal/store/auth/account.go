@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+func (ds *dbStore) ListUsers() ([]*User, error) {
+	rows, err := ds.db.Query(`SELECT ` + userColumns + ` FROM users ORDER BY username`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (ds *dbStore) SetEmail(userID, email string) error {
+	return ds.updateUser(`UPDATE users SET email = ? WHERE id = ?`, email, userID)
+}
+
+func (ds *dbStore) SetAdmin(userID string, admin bool) error {
+	return ds.updateUser(`UPDATE users SET is_admin = ? WHERE id = ?`, admin, userID)
+}
+
+// SetSSOSubject links a user to an external identity
+func (ds *dbStore) SetSSOSubject(userID, subject string) error {
+	return ds.updateUser(`UPDATE users SET sso_subject = ? WHERE id = ?`, subject, userID)
+}
+
+func (ds *dbStore) updateUser(query string, args ...any) error {
+	result, err := ds.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+func (ds *dbStore) UpdatePassword(userID, password string) error {
+	passwordHash, err := HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return ds.updateUser(`UPDATE users SET password_hash = ? WHERE id = ?`, passwordHash, userID)
+}
+
+// SetDisabled disables or re-enables a user. Disabling also deletes the user's sessions and API
+// tokens, including those of the service accounts they own, so it takes effect immediately and
+// re-enabled users have to log in again and create new tokens.
+func (ds *dbStore) SetDisabled(userID string, disabled bool) error {
+	queries := []string{`UPDATE users SET disabled = ? WHERE id = ?`}
+	if disabled {
+		queries = append(queries,
+			`DELETE FROM sessions WHERE user_id = ?`,
+			`DELETE FROM api_tokens WHERE user_id = ?1 OR created_by = ?1
+			 OR user_id IN (SELECT id FROM users WHERE service_account = 1 AND created_by = ?1)`,
+		)
+	}
+
+	tx, err := ds.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec(queries[0], disabled, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+	for _, query := range queries[1:] {
+		if _, err := tx.Exec(query, userID); err != nil {
+			return fmt.Errorf("failed to revoke credentials: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// DeleteUser deletes a user with their sessions, API tokens, package roles and organization
+// memberships, and the service accounts they own. Packages they or their service accounts own have
+// to be transferred or deleted first.
+func (ds *dbStore) DeleteUser(userID string) error {
+	tx, err := ds.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var serviceAccounts []string
+	rows, err := tx.Query(`SELECT id FROM users WHERE service_account = 1 AND created_by = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list service accounts: %w", err)
+	}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan service account: %w", err)
+		}
+		serviceAccounts = append(serviceAccounts, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list service accounts: %w", err)
+	}
+
+	for _, id := range serviceAccounts {
+		if err := deleteUser(tx, id); err != nil {
+			return err
+		}
+	}
+	if err := deleteUser(tx, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM api_tokens WHERE created_by = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func deleteUser(tx *sql.Tx, userID string) error {
+	queries := []string{
+		`DELETE FROM sessions WHERE user_id = ?`,
+		`DELETE FROM api_tokens WHERE user_id = ?`,
+		`DELETE FROM package_collaborators WHERE user_id = ?`,
+		`DELETE FROM org_members WHERE user_id = ?`,
+		`DELETE FROM recovery_codes WHERE user_id = ?`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, userID); err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+	}
+
+	result, err := tx.Exec(`DELETE FROM users WHERE id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
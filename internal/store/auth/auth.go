@@ -25,6 +25,20 @@ type User struct {
 	Username     string `json:"username"`
 	PasswordHash string `json:"-"`
 	CreatedAt    string `json:"created_at"`
+	// ServiceAccount users cannot log in and act only through API tokens created by CreatedBy
+	ServiceAccount bool   `json:"service_account,omitempty"`
+	CreatedBy      string `json:"created_by,omitempty"`
+	Email          string `json:"email,omitempty"`
+	IsAdmin        bool   `json:"is_admin,omitempty"`
+	// Disabled users cannot log in and have no sessions or tokens
+	Disabled bool `json:"disabled,omitempty"`
+	// SSOSubject is the subject of the external identity the user logs in with, from single sign-on
+	// or an authentication provider such as LDAP
+	SSOSubject string `json:"-"`
+	// TOTPSecret is set while the user enrolls in two-factor authentication and after, TOTPEnabled
+	// once they confirmed it with a code
+	TOTPSecret  string `json:"-"`
+	TOTPEnabled bool   `json:"totp_enabled,omitempty"`
 }
 
 type Store interface {
@@ -32,13 +46,46 @@ type Store interface {
 	CreateUser(username, password string) (*User, error)
 	GetUserByUsername(username string) (*User, error)
 	GetUserByID(userID string) (*User, error)
+	CreateServiceAccount(name, ownerID string) (*User, error)
+	CreateSSOUser(username, email, subject string) (*User, error)
+	GetUserBySSOSubject(subject string) (*User, error)
+	SetSSOSubject(userID, subject string) error
+	ListUsers() ([]*User, error)
+	SetEmail(userID, email string) error
+	SetAdmin(userID string, admin bool) error
+	UpdatePassword(userID, password string) error
+	SetDisabled(userID string, disabled bool) error
+	DeleteUser(userID string) error
+
+	SetTOTPSecret(userID, secret string) error
+	EnableTOTP(userID string) error
+	DisableTOTP(userID string) error
+	UseTOTPStep(userID string, step int64) (bool, error)
+	ReplaceRecoveryCodes(userID string, codeHashes []string) error
+	UseRecoveryCode(userID, codeHash string) (bool, error)
+	CountRecoveryCodes(userID string) (int, error)
 
 	CreateSession(userAgent, userID, tokenHash string) (*Session, error)
 	GetSession(id string) (*Session, error)
 	GetSessionByHash(tokenHash string) (*Session, error)
+	ListSessions(userID string) ([]*Session, error)
 	UpdateSessionLastUsed(sessionID string) error
 	DeleteSession(sessionID string) error
+	DeleteUserSessions(userID, keepID string) (int64, error)
 	IsSessionExpired(session *Session) bool
+
+	CreateAPIToken(token *APIToken) (*APIToken, error)
+	GetAPIToken(id string) (*APIToken, error)
+	GetAPITokenByHash(tokenHash string) (*APIToken, error)
+	ListAPITokens(createdBy string) ([]*APIToken, error)
+	UpdateAPITokenLastUsed(id string) error
+	DeleteAPIToken(id string) error
+
+	CreateInvite(invite *Invite) (*Invite, error)
+	GetInviteByHash(codeHash string) (*Invite, error)
+	ListInvites() ([]*Invite, error)
+	UseInvite(id, userID string) error
+	DeleteInvite(id string) error
 }
 
 type dbStore struct {
@@ -57,6 +104,14 @@ func (ds *dbStore) Authenticate(username, password string) (*User, error) {
 		return nil, fmt.Errorf("authentication failed: %w", err)
 	}
 
+	if user.ServiceAccount {
+		return nil, fmt.Errorf("authentication failed: service accounts cannot log in")
+	}
+
+	if user.Disabled {
+		return nil, fmt.Errorf("authentication failed: account is disabled")
+	}
+
 	if err := CheckPasswordHash(password, user.PasswordHash); err != nil {
 		return nil, fmt.Errorf("authentication failed: %w", err)
 	}
@@ -78,19 +133,54 @@ func (ds *dbStore) CreateUser(username, password string) (*User, error) {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	return &User{
-		ID:           id,
-		Username:     username,
-		PasswordHash: passwordHash,
-		CreatedAt:    time.Now().Format(time.RFC3339),
-	}, nil
+	return ds.GetUserByID(id)
 }
 
-func (ds *dbStore) GetUserByUsername(username string) (*User, error) {
+// userColumns is the column list read by scanUser
+const userColumns = `id, username, password_hash, created_at, service_account, created_by, email, is_admin, disabled, sso_subject, totp_secret, totp_enabled`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*User, error) {
 	user := &User{}
+	var createdBy, ssoSubject sql.NullString
+	if err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt, &user.ServiceAccount, &createdBy, &user.Email, &user.IsAdmin, &user.Disabled, &ssoSubject, &user.TOTPSecret, &user.TOTPEnabled); err != nil {
+		return nil, err
+	}
+	user.CreatedBy = createdBy.String
+	user.SSOSubject = ssoSubject.String
+	return user, nil
+}
+
+// CreateServiceAccount creates a user without a password that is managed by ownerID
+func (ds *dbStore) CreateServiceAccount(name, ownerID string) (*User, error) {
+	id := uuid.New().String()
+
+	query := `INSERT INTO users (id, username, password_hash, service_account, created_by) VALUES (?, ?, '', 1, ?)`
+	if _, err := ds.db.Exec(query, id, name, ownerID); err != nil {
+		return nil, fmt.Errorf("failed to create service account: %w", err)
+	}
+
+	return ds.GetUserByID(id)
+}
+
+// CreateSSOUser creates a user without a password who logs in as the external identity subject
+func (ds *dbStore) CreateSSOUser(username, email, subject string) (*User, error) {
+	id := uuid.New().String()
+
+	query := `INSERT INTO users (id, username, password_hash, email, sso_subject) VALUES (?, ?, '', ?, ?)`
+	if _, err := ds.db.Exec(query, id, username, email, subject); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
 
-	query := `SELECT id, username, password_hash, created_at FROM users WHERE username = ?`
-	err := ds.db.QueryRow(query, username).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt)
+	return ds.GetUserByID(id)
+}
+
+func (ds *dbStore) GetUserBySSOSubject(subject string) (*User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE sso_subject = ?`
+	user, err := scanUser(ds.db.QueryRow(query, subject))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("user not found")
@@ -101,11 +191,22 @@ func (ds *dbStore) GetUserByUsername(username string) (*User, error) {
 	return user, nil
 }
 
-func (ds *dbStore) GetUserByID(userID string) (*User, error) {
-	user := &User{}
+func (ds *dbStore) GetUserByUsername(username string) (*User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = ?`
+	user, err := scanUser(ds.db.QueryRow(query, username))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
 
-	query := `SELECT id, username, password_hash, created_at FROM users WHERE id = ?`
-	err := ds.db.QueryRow(query, userID).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt)
+	return user, nil
+}
+
+func (ds *dbStore) GetUserByID(userID string) (*User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = ?`
+	user, err := scanUser(ds.db.QueryRow(query, userID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("user not found")
@@ -116,11 +217,20 @@ func (ds *dbStore) GetUserByID(userID string) (*User, error) {
 	return user, nil
 }
 
+// SessionTTL is how long a session stays valid after it was last used
+const SessionTTL = 30 * 24 * time.Hour
+
+const sessionColumns = `id, user_id, token_hash, user_agent, expires_at, created_at, last_used`
+
+func scanSession(row rowScanner) (*Session, error) {
+	session := &Session{}
+	err := row.Scan(&session.ID, &session.UserID, &session.TokenHash, &session.UserAgent, &session.ExpiresAt, &session.CreatedAt, &session.LastUsed)
+	return session, err
+}
+
 func (ds *dbStore) CreateSession(userAgent, userID, tokenHash string) (*Session, error) {
 	id := uuid.New().String()
-
-	// Sessions expire in 30 days
-	expiresAt := time.Now().Add(30 * 24 * time.Hour)
+	expiresAt := time.Now().Add(SessionTTL)
 
 	query := `INSERT INTO sessions (id, user_id, token_hash, user_agent, expires_at) VALUES (?, ?, ?, ?, ?)`
 	_, err := ds.db.Exec(query, id, userID, tokenHash, userAgent, expiresAt)
@@ -132,10 +242,8 @@ func (ds *dbStore) CreateSession(userAgent, userID, tokenHash string) (*Session,
 }
 
 func (ds *dbStore) GetSession(id string) (*Session, error) {
-	session := &Session{}
-
-	query := `SELECT id, user_id, token_hash, user_agent, expires_at, created_at, last_used FROM sessions WHERE id = ?`
-	err := ds.db.QueryRow(query, id).Scan(&session.ID, &session.UserID, &session.TokenHash, &session.UserAgent, &session.ExpiresAt, &session.CreatedAt, &session.LastUsed)
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = ?`
+	session, err := scanSession(ds.db.QueryRow(query, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("session not found")
@@ -147,10 +255,8 @@ func (ds *dbStore) GetSession(id string) (*Session, error) {
 }
 
 func (ds *dbStore) GetSessionByHash(tokenHash string) (*Session, error) {
-	session := &Session{}
-
-	query := `SELECT id, user_id, token_hash, user_agent, expires_at, created_at, last_used FROM sessions WHERE token_hash = ?`
-	err := ds.db.QueryRow(query, tokenHash).Scan(&session.ID, &session.UserID, &session.TokenHash, &session.UserAgent, &session.ExpiresAt, &session.CreatedAt, &session.LastUsed)
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE token_hash = ?`
+	session, err := scanSession(ds.db.QueryRow(query, tokenHash))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("session not found")
@@ -161,9 +267,32 @@ func (ds *dbStore) GetSessionByHash(tokenHash string) (*Session, error) {
 	return session, nil
 }
 
+// ListSessions returns the sessions of a user that have not expired, most recently used first
+func (ds *dbStore) ListSessions(userID string) ([]*Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE user_id = ? AND (expires_at IS NULL OR expires_at > ?)
+			  ORDER BY COALESCE(last_used, created_at) DESC`
+	rows, err := ds.db.Query(query, userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// UpdateSessionLastUsed records that a session was used and slides its expiry, so sessions only
+// expire after SessionTTL without use
 func (ds *dbStore) UpdateSessionLastUsed(sessionID string) error {
-	query := `UPDATE sessions SET last_used = CURRENT_TIMESTAMP WHERE id = ?`
-	_, err := ds.db.Exec(query, sessionID)
+	query := `UPDATE sessions SET last_used = CURRENT_TIMESTAMP, expires_at = ? WHERE id = ?`
+	_, err := ds.db.Exec(query, time.Now().Add(SessionTTL), sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to update session last used: %w", err)
 	}
@@ -189,6 +318,17 @@ func (ds *dbStore) DeleteSession(sessionID string) error {
 	return nil
 }
 
+// DeleteUserSessions deletes every session of a user except keepID and returns how many were deleted.
+// An empty keepID deletes them all.
+func (ds *dbStore) DeleteUserSessions(userID, keepID string) (int64, error) {
+	query := `DELETE FROM sessions WHERE user_id = ? AND id != ?`
+	result, err := ds.db.Exec(query, userID, keepID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
 func (ds *dbStore) IsSessionExpired(session *Session) bool {
 	if session.ExpiresAt == nil {
 		return false
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invite lets one person register on a registry that only accepts invited users. Only the hash of
+// the invite code is stored.
+type Invite struct {
+	ID       string `json:"id"`
+	CodeHash string `json:"-"`
+	// Email, when set, is the only address the invite can be used with
+	Email     string     `json:"email,omitempty"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedBy    string     `json:"used_by,omitempty"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// IsExpired reports whether the invite can no longer be used
+func (i *Invite) IsExpired() bool {
+	return time.Now().After(i.ExpiresAt)
+}
+
+const inviteColumns = `id, code_hash, email, created_by, created_at, expires_at, used_by, used_at`
+
+func scanInvite(row rowScanner) (*Invite, error) {
+	invite := &Invite{}
+	var usedBy sql.NullString
+	if err := row.Scan(&invite.ID, &invite.CodeHash, &invite.Email, &invite.CreatedBy, &invite.CreatedAt,
+		&invite.ExpiresAt, &usedBy, &invite.UsedAt); err != nil {
+		return nil, err
+	}
+	invite.UsedBy = usedBy.String
+	return invite, nil
+}
+
+// CreateInvite stores a new invite. The ID and creation time are filled in by the store.
+func (ds *dbStore) CreateInvite(invite *Invite) (*Invite, error) {
+	id := uuid.New().String()
+
+	query := `INSERT INTO invites (id, code_hash, email, created_by, expires_at) VALUES (?, ?, ?, ?, ?)`
+	if _, err := ds.db.Exec(query, id, invite.CodeHash, invite.Email, invite.CreatedBy, invite.ExpiresAt); err != nil {
+		return nil, fmt.Errorf("failed to create invite: %w", err)
+	}
+
+	return ds.getInvite(`id = ?`, id)
+}
+
+func (ds *dbStore) GetInviteByHash(codeHash string) (*Invite, error) {
+	return ds.getInvite(`code_hash = ?`, codeHash)
+}
+
+func (ds *dbStore) getInvite(where string, arg string) (*Invite, error) {
+	invite, err := scanInvite(ds.db.QueryRow(`SELECT `+inviteColumns+` FROM invites WHERE `+where, arg))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("invite not found")
+		}
+		return nil, fmt.Errorf("failed to get invite: %w", err)
+	}
+	return invite, nil
+}
+
+// ListInvites lists the invites that have not been used yet, newest first
+func (ds *dbStore) ListInvites() ([]*Invite, error) {
+	rows, err := ds.db.Query(`SELECT ` + inviteColumns + ` FROM invites WHERE used_by IS NULL ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invites: %w", err)
+	}
+	defer rows.Close()
+
+	var invites []*Invite
+	for rows.Next() {
+		invite, err := scanInvite(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invite: %w", err)
+		}
+		invites = append(invites, invite)
+	}
+	return invites, rows.Err()
+}
+
+// UseInvite marks an invite as used by userID. It fails when the invite was already used, so an
+// invite creates at most one account even when it is redeemed twice at the same time.
+func (ds *dbStore) UseInvite(id, userID string) error {
+	query := `UPDATE invites SET used_by = ?, used_at = CURRENT_TIMESTAMP WHERE id = ? AND used_by IS NULL`
+	result, err := ds.db.Exec(query, userID, id)
+	if err != nil {
+		return fmt.Errorf("failed to use invite: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("invite already used")
+	}
+	return nil
+}
+
+func (ds *dbStore) DeleteInvite(id string) error {
+	result, err := ds.db.Exec(`DELETE FROM invites WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete invite: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("invite not found")
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scopes an API token can carry. Each scope includes the ones before it: push implies read and
+// admin implies push.
+const (
+	ScopeRead  = "read"
+	ScopePush  = "push"
+	ScopeAdmin = "admin"
+)
+
+var scopeOrder = []string{ScopeRead, ScopePush, ScopeAdmin}
+
+// APIToken is a long-lived credential for scripts and CI. Only the hash of the token is stored.
+type APIToken struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	UserID    string     `json:"user_id"`
+	Username  string     `json:"username"`
+	TokenHash string     `json:"-"`
+	Scopes    []string   `json:"scopes"`
+	PackageID string     `json:"package_id,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	LastUsed  *time.Time `json:"last_used,omitempty"`
+}
+
+// ValidateScopes checks every scope is known
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(scopeOrder, scope) {
+			return fmt.Errorf("invalid scope %q, expected %s", scope, strings.Join(scopeOrder, ", "))
+		}
+	}
+	return nil
+}
+
+// HasScope reports whether the token grants scope, directly or through a broader scope
+func (t *APIToken) HasScope(scope string) bool {
+	required := slices.Index(scopeOrder, scope)
+	for _, granted := range t.Scopes {
+		if slices.Index(scopeOrder, granted) >= required {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired reports whether the token has an expiry in the past
+func (t *APIToken) IsExpired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
+
+// apiTokenColumns is the column list read by scanAPIToken, t is api_tokens and u the token user
+const apiTokenColumns = `t.id, t.name, t.user_id, u.username, t.token_hash, t.scopes, t.package_id, t.expires_at, t.created_by, t.created_at, t.last_used`
+
+func scanAPIToken(row rowScanner) (*APIToken, error) {
+	token := &APIToken{}
+	var scopes string
+	var packageID sql.NullString
+	if err := row.Scan(&token.ID, &token.Name, &token.UserID, &token.Username, &token.TokenHash, &scopes,
+		&packageID, &token.ExpiresAt, &token.CreatedBy, &token.CreatedAt, &token.LastUsed); err != nil {
+		return nil, err
+	}
+	token.Scopes = strings.Split(scopes, ",")
+	token.PackageID = packageID.String
+	return token, nil
+}
+
+// CreateAPIToken stores a new token. The ID and creation time are filled in by the store.
+func (ds *dbStore) CreateAPIToken(token *APIToken) (*APIToken, error) {
+	if err := ValidateScopes(token.Scopes); err != nil {
+		return nil, err
+	}
+
+	id := uuid.New().String()
+	var packageID sql.NullString
+	if token.PackageID != "" {
+		packageID = sql.NullString{String: token.PackageID, Valid: true}
+	}
+
+	query := `INSERT INTO api_tokens (id, name, user_id, token_hash, scopes, package_id, expires_at, created_by)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := ds.db.Exec(query, id, token.Name, token.UserID, token.TokenHash, strings.Join(token.Scopes, ","),
+		packageID, token.ExpiresAt, token.CreatedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	return ds.GetAPIToken(id)
+}
+
+func (ds *dbStore) GetAPIToken(id string) (*APIToken, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens t JOIN users u ON u.id = t.user_id WHERE t.id = ?`
+	token, err := scanAPIToken(ds.db.QueryRow(query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("API token not found")
+		}
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+	return token, nil
+}
+
+func (ds *dbStore) GetAPITokenByHash(tokenHash string) (*APIToken, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens t JOIN users u ON u.id = t.user_id WHERE t.token_hash = ?`
+	token, err := scanAPIToken(ds.db.QueryRow(query, tokenHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("API token not found")
+		}
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+	return token, nil
+}
+
+// ListAPITokens lists the tokens created by a user, for themselves and for their service accounts
+func (ds *dbStore) ListAPITokens(createdBy string) ([]*APIToken, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens t JOIN users u ON u.id = t.user_id
+			  WHERE t.created_by = ? ORDER BY t.created_at DESC`
+	rows, err := ds.db.Query(query, createdBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*APIToken
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+func (ds *dbStore) UpdateAPITokenLastUsed(id string) error {
+	query := `UPDATE api_tokens SET last_used = CURRENT_TIMESTAMP WHERE id = ?`
+	if _, err := ds.db.Exec(query, id); err != nil {
+		return fmt.Errorf("failed to update API token: %w", err)
+	}
+	return nil
+}
+
+func (ds *dbStore) DeleteAPIToken(id string) error {
+	result, err := ds.db.Exec(`DELETE FROM api_tokens WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete API token: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("API token not found")
+	}
+	return nil
+}
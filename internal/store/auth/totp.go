@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"fmt"
+)
+
+// SetTOTPSecret stores the secret of a two-factor enrollment that is not confirmed yet
+func (ds *dbStore) SetTOTPSecret(userID, secret string) error {
+	return ds.updateUser(`UPDATE users SET totp_secret = ?, totp_enabled = 0, totp_last_step = 0 WHERE id = ?`, secret, userID)
+}
+
+// EnableTOTP makes logins of a user require a code for their stored secret
+func (ds *dbStore) EnableTOTP(userID string) error {
+	return ds.updateUser(`UPDATE users SET totp_enabled = 1 WHERE id = ? AND totp_secret != ''`, userID)
+}
+
+// DisableTOTP removes the secret and recovery codes of a user
+func (ds *dbStore) DisableTOTP(userID string) error {
+	tx, err := ds.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.Exec(`UPDATE users SET totp_secret = '', totp_enabled = 0, totp_last_step = 0 WHERE id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("user not found")
+	}
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// UseTOTPStep records that a code of the given time step was used. It returns false when a code
+// of this or a later step was used before, so every code works only once.
+func (ds *dbStore) UseTOTPStep(userID string, step int64) (bool, error) {
+	result, err := ds.db.Exec(`UPDATE users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?`, step, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to record two-factor code: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record two-factor code: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// ReplaceRecoveryCodes replaces the recovery codes of a user with new ones
+func (ds *dbStore) ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	tx, err := ds.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	for _, codeHash := range codeHashes {
+		if _, err := tx.Exec(`INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?)`, userID, codeHash); err != nil {
+			return fmt.Errorf("failed to store recovery code: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// UseRecoveryCode deletes a recovery code and reports whether the user had it
+func (ds *dbStore) UseRecoveryCode(userID, codeHash string) (bool, error) {
+	result, err := ds.db.Exec(`DELETE FROM recovery_codes WHERE user_id = ? AND code_hash = ?`, userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	return rows == 1, nil
+}
+
+func (ds *dbStore) CountRecoveryCodes(userID string) (int, error) {
+	var count int
+	if err := ds.db.QueryRow(`SELECT COUNT(*) FROM recovery_codes WHERE user_id = ?`, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"database/sql"
 	"fmt"
 )
 
@@ -12,6 +13,12 @@ func (s *dbStore) migrate() error {
 			description TEXT,
 			tags TEXT DEFAULT '[]',
 			owner_id TEXT REFERENCES users(id),
+			compatibility TEXT NOT NULL DEFAULT 'BACKWARD',
+			strict_semver INTEGER NOT NULL DEFAULT 0,
+			bump_policy TEXT NOT NULL DEFAULT 'suggest',
+			org_id TEXT REFERENCES organizations(id),
+			visibility TEXT NOT NULL DEFAULT 'public',
+			required_approvals INTEGER NOT NULL DEFAULT 0,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)`,
 		`CREATE TABLE IF NOT EXISTS schema_versions (
@@ -22,6 +29,9 @@ func (s *dbStore) migrate() error {
 			metadata TEXT DEFAULT '{}',
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			created_by TEXT,
+			yanked INTEGER NOT NULL DEFAULT 0,
+			yank_reason TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'published',
 			UNIQUE(package_id, version)
 		)`,
 		`CREATE TABLE IF NOT EXISTS schema_files (
@@ -33,10 +43,72 @@ func (s *dbStore) migrate() error {
 			size_bytes INTEGER NOT NULL,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)`,
+		`CREATE TABLE IF NOT EXISTS package_aliases (
+			name TEXT PRIMARY KEY,
+			package_id TEXT REFERENCES packages(id) ON DELETE CASCADE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
 		`CREATE TABLE IF NOT EXISTS users (
 			id TEXT PRIMARY KEY,
 			username TEXT UNIQUE NOT NULL,
 			password_hash TEXT NOT NULL,
+			service_account INTEGER NOT NULL DEFAULT 0,
+			created_by TEXT REFERENCES users(id),
+			email TEXT NOT NULL DEFAULT '',
+			is_admin INTEGER NOT NULL DEFAULT 0,
+			disabled INTEGER NOT NULL DEFAULT 0,
+			sso_subject TEXT,
+			totp_secret TEXT NOT NULL DEFAULT '',
+			totp_enabled INTEGER NOT NULL DEFAULT 0,
+			totp_last_step INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS organizations (
+			id TEXT PRIMARY KEY,
+			name TEXT UNIQUE NOT NULL,
+			created_by TEXT REFERENCES users(id),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS org_members (
+			org_id TEXT REFERENCES organizations(id) ON DELETE CASCADE,
+			user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+			role TEXT NOT NULL DEFAULT 'member',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (org_id, user_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS package_collaborators (
+			package_id TEXT REFERENCES packages(id) ON DELETE CASCADE,
+			user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+			role TEXT NOT NULL DEFAULT 'reader',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (package_id, user_id)
+		)`,
+		// A reviewer's latest decision on a draft replaces their earlier one
+		`CREATE TABLE IF NOT EXISTS version_reviews (
+			version_id TEXT REFERENCES schema_versions(id) ON DELETE CASCADE,
+			user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+			decision TEXT NOT NULL,
+			comment TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (version_id, user_id)
+		)`,
+		// Labels are mutable names such as stable or prod pointing at a published version
+		`CREATE TABLE IF NOT EXISTS package_labels (
+			package_id TEXT REFERENCES packages(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			version_id TEXT REFERENCES schema_versions(id),
+			updated_by TEXT REFERENCES users(id),
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (package_id, name)
+		)`,
+		// Every move of a label, versions are kept by name so the history outlives deleted versions
+		`CREATE TABLE IF NOT EXISTS label_moves (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			package_id TEXT REFERENCES packages(id) ON DELETE CASCADE,
+			label TEXT NOT NULL,
+			from_version TEXT NOT NULL DEFAULT '',
+			to_version TEXT NOT NULL DEFAULT '',
+			moved_by TEXT REFERENCES users(id),
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)`,
 		`CREATE TABLE IF NOT EXISTS sessions (
@@ -48,6 +120,88 @@ func (s *dbStore) migrate() error {
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			last_used TIMESTAMP
 		)`,
+		`CREATE TABLE IF NOT EXISTS api_tokens (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+			token_hash TEXT UNIQUE NOT NULL,
+			scopes TEXT NOT NULL,
+			package_id TEXT REFERENCES packages(id) ON DELETE CASCADE,
+			expires_at TIMESTAMP,
+			created_by TEXT REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			last_used TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS invites (
+			id TEXT PRIMARY KEY,
+			code_hash TEXT UNIQUE NOT NULL,
+			email TEXT NOT NULL DEFAULT '',
+			created_by TEXT REFERENCES users(id),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP NOT NULL,
+			used_by TEXT REFERENCES users(id),
+			used_at TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS recovery_codes (
+			user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+			code_hash TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, code_hash)
+		)`,
+		// The audit log is append-only, actors and targets are kept by name without references so
+		// entries outlive the users and packages they mention
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			action TEXT NOT NULL,
+			actor_id TEXT NOT NULL DEFAULT '',
+			actor TEXT NOT NULL DEFAULT '',
+			ip TEXT NOT NULL DEFAULT '',
+			user_agent TEXT NOT NULL DEFAULT '',
+			target_type TEXT NOT NULL DEFAULT '',
+			target TEXT NOT NULL DEFAULT '',
+			details TEXT NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP NOT NULL
+		)`,
+		`CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+		BEGIN
+			SELECT RAISE(ABORT, 'audit log entries cannot be changed');
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+		BEGIN
+			SELECT RAISE(ABORT, 'audit log entries cannot be deleted');
+		END`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log (target_type, target)`,
+		// Registry-wide webhooks have an empty package_id
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id TEXT PRIMARY KEY,
+			package_id TEXT NOT NULL DEFAULT '',
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			events TEXT NOT NULL DEFAULT '[]',
+			created_by TEXT REFERENCES users(id),
+			created_at TIMESTAMP NOT NULL
+		)`,
+		// Deliveries keep the URL they are sent to, so they outlive webhooks of deleted packages
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id TEXT PRIMARY KEY,
+			webhook_id TEXT NOT NULL,
+			event TEXT NOT NULL,
+			url TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			signature TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_status INTEGER NOT NULL DEFAULT 0,
+			response_body TEXT NOT NULL DEFAULT '',
+			error TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL,
+			last_attempt_at TIMESTAMP,
+			next_attempt_at TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhooks_package ON webhooks (package_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at)`,
 	}
 
 	for _, query := range queries {
@@ -55,5 +209,79 @@ func (s *dbStore) migrate() error {
 			return fmt.Errorf("failed to execute migration: %w", err)
 		}
 	}
+
+	// Columns added after the initial schema, applied to databases created by older versions
+	columns := []struct {
+		table      string
+		column     string
+		definition string
+	}{
+		{"packages", "compatibility", "TEXT NOT NULL DEFAULT 'BACKWARD'"},
+		{"packages", "strict_semver", "INTEGER NOT NULL DEFAULT 0"},
+		{"packages", "bump_policy", "TEXT NOT NULL DEFAULT 'suggest'"},
+		{"packages", "org_id", "TEXT REFERENCES organizations(id)"},
+		{"packages", "visibility", "TEXT NOT NULL DEFAULT 'public'"},
+		{"users", "service_account", "INTEGER NOT NULL DEFAULT 0"},
+		{"users", "created_by", "TEXT REFERENCES users(id)"},
+		{"users", "email", "TEXT NOT NULL DEFAULT ''"},
+		{"users", "is_admin", "INTEGER NOT NULL DEFAULT 0"},
+		{"users", "disabled", "INTEGER NOT NULL DEFAULT 0"},
+		{"users", "sso_subject", "TEXT"},
+		{"users", "totp_secret", "TEXT NOT NULL DEFAULT ''"},
+		{"users", "totp_enabled", "INTEGER NOT NULL DEFAULT 0"},
+		{"users", "totp_last_step", "INTEGER NOT NULL DEFAULT 0"},
+		{"schema_versions", "yanked", "INTEGER NOT NULL DEFAULT 0"},
+		{"schema_versions", "yank_reason", "TEXT NOT NULL DEFAULT ''"},
+		{"schema_versions", "status", "TEXT NOT NULL DEFAULT 'published'"},
+		{"packages", "required_approvals", "INTEGER NOT NULL DEFAULT 0"},
+	}
+
+	for _, col := range columns {
+		if err := s.addColumnIfMissing(col.table, col.column, col.definition); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", col.table, col.column, err)
+		}
+	}
+
+	// Indexes on added columns are created once the columns exist
+	indexes := []string{
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_sso_subject ON users (sso_subject)`,
+		`CREATE INDEX IF NOT EXISTS idx_label_moves_package ON label_moves (package_id, label)`,
+	}
+	for _, query := range indexes {
+		if _, err := s.db.Exec(query); err != nil {
+			return fmt.Errorf("failed to create index: %w", err)
+		}
+	}
 	return nil
 }
+
+func (s *dbStore) addColumnIfMissing(table, column, definition string) error {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
@@ -0,0 +1,204 @@
+package org
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles a user can have in an organization. Owners manage members and own every package in the
+// organization, maintainers create and publish packages, members can read them.
+const (
+	RoleOwner      = "owner"
+	RoleMaintainer = "maintainer"
+	RoleMember     = "member"
+)
+
+// ErrNotMember is returned when a user does not belong to an organization
+var ErrNotMember = errors.New("not a member of the organization")
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+type Organization struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Member struct {
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Store interface {
+	CreateOrganization(name, ownerID string) (*Organization, error)
+	GetOrganization(name string) (*Organization, error)
+	ListUserOrganizations(userID string) ([]*Organization, error)
+
+	SetMember(orgID, userID, role string) error
+	RemoveMember(orgID, userID string) error
+	GetMemberRole(orgID, userID string) (string, error)
+	ListMembers(orgID string) ([]*Member, error)
+}
+
+type dbStore struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) Store {
+	return &dbStore{
+		db: db,
+	}
+}
+
+// ValidateName checks an organization name can be used as a package scope
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid organization name %q, use lowercase letters, digits and dashes", name)
+	}
+	return nil
+}
+
+// ValidateRole checks role is a known organization role
+func ValidateRole(role string) error {
+	switch role {
+	case RoleOwner, RoleMaintainer, RoleMember:
+		return nil
+	}
+	return fmt.Errorf("invalid role %q, expected %s, %s or %s", role, RoleOwner, RoleMaintainer, RoleMember)
+}
+
+// CreateOrganization creates an organization with ownerID as its first owner
+func (ds *dbStore) CreateOrganization(name, ownerID string) (*Organization, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	tx, err := ds.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id := uuid.New().String()
+	query := `INSERT INTO organizations (id, name, created_by) VALUES (?, ?, ?)`
+	if _, err := tx.Exec(query, id, name, ownerID); err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("organization %s already exists", name)
+		}
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	query = `INSERT INTO org_members (org_id, user_id, role) VALUES (?, ?, ?)`
+	if _, err := tx.Exec(query, id, ownerID, RoleOwner); err != nil {
+		return nil, fmt.Errorf("failed to add organization owner: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return ds.GetOrganization(name)
+}
+
+func (ds *dbStore) GetOrganization(name string) (*Organization, error) {
+	org := &Organization{}
+
+	query := `SELECT id, name, created_by, created_at FROM organizations WHERE name = ?`
+	err := ds.db.QueryRow(query, name).Scan(&org.ID, &org.Name, &org.CreatedBy, &org.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("organization %s not found", name)
+		}
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	return org, nil
+}
+
+func (ds *dbStore) ListUserOrganizations(userID string) ([]*Organization, error) {
+	query := `SELECT o.id, o.name, o.created_by, o.created_at FROM organizations o
+			  JOIN org_members m ON m.org_id = o.id WHERE m.user_id = ? ORDER BY o.name`
+	rows, err := ds.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+
+	var orgs []*Organization
+	for rows.Next() {
+		org := &Organization{}
+		if err := rows.Scan(&org.ID, &org.Name, &org.CreatedBy, &org.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, rows.Err()
+}
+
+// SetMember adds a user to an organization or changes the role of an existing member
+func (ds *dbStore) SetMember(orgID, userID, role string) error {
+	if err := ValidateRole(role); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO org_members (org_id, user_id, role) VALUES (?, ?, ?)
+			  ON CONFLICT(org_id, user_id) DO UPDATE SET role = excluded.role`
+	if _, err := ds.db.Exec(query, orgID, userID, role); err != nil {
+		return fmt.Errorf("failed to set member: %w", err)
+	}
+	return nil
+}
+
+func (ds *dbStore) RemoveMember(orgID, userID string) error {
+	query := `DELETE FROM org_members WHERE org_id = ? AND user_id = ?`
+	result, err := ds.db.Exec(query, orgID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrNotMember
+	}
+	return nil
+}
+
+// GetMemberRole returns the role of a user in an organization, or ErrNotMember
+func (ds *dbStore) GetMemberRole(orgID, userID string) (string, error) {
+	var role string
+	query := `SELECT role FROM org_members WHERE org_id = ? AND user_id = ?`
+	if err := ds.db.QueryRow(query, orgID, userID).Scan(&role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotMember
+		}
+		return "", fmt.Errorf("failed to get member role: %w", err)
+	}
+	return role, nil
+}
+
+func (ds *dbStore) ListMembers(orgID string) ([]*Member, error) {
+	query := `SELECT m.user_id, u.username, m.role, m.created_at FROM org_members m
+			  JOIN users u ON u.id = m.user_id WHERE m.org_id = ? ORDER BY u.username`
+	rows, err := ds.db.Query(query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*Member
+	for rows.Next() {
+		member := &Member{}
+		if err := rows.Scan(&member.UserID, &member.Username, &member.Role, &member.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan member: %w", err)
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
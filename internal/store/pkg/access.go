@@ -0,0 +1,128 @@
+package pkg
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Roles a user can have on a package. Each role includes the permissions of the ones below it:
+// readers pull versions, maintainers also push and yank them, owners also manage the package
+// settings and its collaborators.
+const (
+	RoleOwner      = "owner"
+	RoleMaintainer = "maintainer"
+	RoleReader     = "reader"
+)
+
+// Package visibilities. Public packages can be read by anyone, including anonymous users, private
+// ones only by users with a role on them.
+const (
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+)
+
+var roleRanks = map[string]int{
+	RoleReader:     1,
+	RoleMaintainer: 2,
+	RoleOwner:      3,
+}
+
+type Collaborator struct {
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ValidateRole checks role is a known package role
+func ValidateRole(role string) error {
+	if _, ok := roleRanks[role]; !ok {
+		return fmt.Errorf("invalid role %q, expected %s, %s or %s", role, RoleOwner, RoleMaintainer, RoleReader)
+	}
+	return nil
+}
+
+// ValidateVisibility checks visibility is a known package visibility
+func ValidateVisibility(visibility string) error {
+	switch visibility {
+	case VisibilityPublic, VisibilityPrivate:
+		return nil
+	}
+	return fmt.Errorf("invalid visibility %q, expected %s or %s", visibility, VisibilityPublic, VisibilityPrivate)
+}
+
+// RoleAllows reports whether role grants the permissions of required. An empty role allows nothing.
+func RoleAllows(role, required string) bool {
+	rank, ok := roleRanks[role]
+	return ok && rank >= roleRanks[required]
+}
+
+// HigherRole returns whichever of a and b grants more permissions
+func HigherRole(a, b string) string {
+	if roleRanks[b] > roleRanks[a] {
+		return b
+	}
+	return a
+}
+
+// SetCollaborator gives a user a role on a package or changes the role they already have
+func (s *packageStore) SetCollaborator(packageID, userID, role string) error {
+	if err := ValidateRole(role); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO package_collaborators (package_id, user_id, role) VALUES (?, ?, ?)
+			  ON CONFLICT(package_id, user_id) DO UPDATE SET role = excluded.role`
+	if _, err := s.db.Exec(query, packageID, userID, role); err != nil {
+		return fmt.Errorf("failed to set collaborator: %w", err)
+	}
+	return nil
+}
+
+func (s *packageStore) RemoveCollaborator(packageID, userID string) error {
+	query := `DELETE FROM package_collaborators WHERE package_id = ? AND user_id = ?`
+	result, err := s.db.Exec(query, packageID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove collaborator: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrNotCollaborator
+	}
+	return nil
+}
+
+// GetCollaboratorRole returns the role granted to a user on a package, or ErrNotCollaborator.
+// It does not account for package ownership or organization membership.
+func (s *packageStore) GetCollaboratorRole(packageID, userID string) (string, error) {
+	var role string
+	query := `SELECT role FROM package_collaborators WHERE package_id = ? AND user_id = ?`
+	if err := s.db.QueryRow(query, packageID, userID).Scan(&role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotCollaborator
+		}
+		return "", fmt.Errorf("failed to get collaborator role: %w", err)
+	}
+	return role, nil
+}
+
+func (s *packageStore) ListCollaborators(packageID string) ([]*Collaborator, error) {
+	query := `SELECT c.user_id, u.username, c.role, c.created_at FROM package_collaborators c
+			  JOIN users u ON u.id = c.user_id WHERE c.package_id = ? ORDER BY u.username`
+	rows, err := s.db.Query(query, packageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collaborators: %w", err)
+	}
+	defer rows.Close()
+
+	var collaborators []*Collaborator
+	for rows.Next() {
+		collaborator := &Collaborator{}
+		if err := rows.Scan(&collaborator.UserID, &collaborator.Username, &collaborator.Role, &collaborator.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan collaborator: %w", err)
+		}
+		collaborators = append(collaborators, collaborator)
+	}
+	return collaborators, rows.Err()
+}
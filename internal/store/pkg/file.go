@@ -3,99 +3,277 @@ package pkg
 import (
 	"crypto/sha256"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// stagingDirName is the directory under the data directory uploads are extracted to before publishing
+const stagingDirName = "tmp"
+
 func (s *packageStore) SaveSchemaFiles(packageID, version string, filePaths []string, createdBy string) (*SchemaVersion, error) {
 	if len(filePaths) == 0 {
 		return nil, fmt.Errorf("at least one file is required")
 	}
 
 	versionID := uuid.New().String()
+	files, checksum, err := s.describeFiles(versionID, filePaths, func(filePath string) string { return filePath })
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil {
+				s.logger.Error().Err(err).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+
+	if err := insertSchemaVersion(tx, versionID, packageID, version, checksum, createdBy, StatusPublished, files); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+
+	return s.GetSchemaVersionWithFiles(packageID, version)
+}
+
+// StagingDir creates an empty directory for an upload on the same filesystem as the published
+// schemas, so PublishSchema can move it into place with a single rename
+func (s *packageStore) StagingDir() (string, error) {
+	root := filepath.Join(s.dataDir, stagingDirName)
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", fmt.Errorf("failed to create staging root: %w", err)
+	}
+	dir, err := os.MkdirTemp(root, "push-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	return dir, nil
+}
+
+// PublishSchema publishes a fully validated staging directory as a new version with the given status,
+// StatusDraft to store it for review. The version rows are inserted and the directory is renamed to
+// GetSchemaPath inside one transaction, so a version is either completely published or not at all.
+// Published versions are immutable: ErrVersionExists is returned if the version already exists and
+// the existing files are left untouched. Drafts and rejected drafts are replaced.
+func (s *packageStore) PublishSchema(packageID, packageName, version, stagingDir, createdBy, status string) (*SchemaVersion, error) {
+	var stagedFiles []string
+	err := filepath.Walk(stagingDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			stagedFiles = append(stagedFiles, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read staging directory: %w", err)
+	}
+	if len(stagedFiles) == 0 {
+		return nil, fmt.Errorf("at least one file is required")
+	}
+
+	// Records point at the final location the staging directory is renamed to. It is checked to be
+	// inside the package directory before anything on disk is removed or replaced.
+	schemaDir, err := s.GetSchemaPath(packageName, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish schema: %w", err)
+	}
+	versionID := uuid.New().String()
+	files, checksum, err := s.describeFiles(versionID, stagedFiles, func(filePath string) string {
+		rel, _ := filepath.Rel(stagingDir, filePath)
+		return filepath.Join(schemaDir, rel)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil {
+				s.logger.Error().Err(err).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+
+	if err := replaceDraft(tx, packageID, version); err != nil {
+		return nil, err
+	}
+
+	// The insert holds the write lock until commit, a concurrent push of the same version fails here
+	// before it can touch the published directory
+	if err := insertSchemaVersion(tx, versionID, packageID, version, checksum, createdBy, status, files); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrVersionExists
+		}
+		return nil, err
+	}
+
+	// A directory without version rows is left over from an interrupted publish and can be replaced
+	if err := os.RemoveAll(schemaDir); err != nil {
+		return nil, fmt.Errorf("failed to clear schema directory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(schemaDir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create package directory: %w", err)
+	}
+	if err := os.Rename(stagingDir, schemaDir); err != nil {
+		return nil, fmt.Errorf("failed to publish schema directory: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		if rmErr := os.RemoveAll(schemaDir); rmErr != nil {
+			s.logger.Error().Err(rmErr).Str("dir", schemaDir).Msg("failed to remove unpublished schema directory")
+		}
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+
+	return s.GetSchemaVersionWithFiles(packageID, version)
+}
+
+// HasVersion reports whether a version has been pushed, including drafts
+func (s *packageStore) HasVersion(packageID, version string) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM schema_versions WHERE package_id = ? AND version = ?`
+	if err := s.db.QueryRow(query, packageID, version).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check version: %w", err)
+	}
+	return count > 0, nil
+}
+
+// CleanStaging removes staging directories older than maxAge, left behind by interrupted uploads
+func (s *packageStore) CleanStaging(maxAge time.Duration) error {
+	root := filepath.Join(s.dataDir, stagingDirName)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read staging root: %w", err)
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(root, entry.Name())); err != nil {
+			return fmt.Errorf("failed to remove staging directory %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
 
+// describeFiles builds the file records of a version. storedPath maps a file on disk to the
+// path it will be served from.
+func (s *packageStore) describeFiles(versionID string, filePaths []string, storedPath func(string) string) ([]SchemaFile, string, error) {
 	var allFiles []SchemaFile
 	var combinedChecksum string
 
 	for _, filePath := range filePaths {
 		fileInfo, err := os.Stat(filePath)
 		if err != nil {
-			return nil, fmt.Errorf("failed to stat file %s: %w", filePath, err)
+			return nil, "", fmt.Errorf("failed to stat file %s: %w", filePath, err)
 		}
 
 		checksum, err := calculateChecksum(filePath)
 		if err != nil {
-			return nil, fmt.Errorf("failed to calculate checksum for %s: %w", filePath, err)
+			return nil, "", fmt.Errorf("failed to calculate checksum for %s: %w", filePath, err)
 		}
 
 		// Store relative path from data directory for consistent storage
-		relPath, err := filepath.Rel(s.dataDir, filePath)
+		finalPath := storedPath(filePath)
+		relPath, err := filepath.Rel(s.dataDir, finalPath)
 		if err != nil {
 			// If we can't get relative path, use the full path
-			relPath = filePath
+			relPath = finalPath
 		}
 
-		schemaFile := SchemaFile{
+		allFiles = append(allFiles, SchemaFile{
 			ID:        uuid.New().String(),
 			VersionID: versionID,
 			Filename:  filepath.Base(filePath),
 			FilePath:  relPath,
 			Checksum:  checksum,
 			SizeBytes: fileInfo.Size(),
-		}
-
-		allFiles = append(allFiles, schemaFile)
+		})
 		combinedChecksum += checksum
 	}
 
-	combinedHash := fmt.Sprintf("%x", sha256.Sum256([]byte(combinedChecksum)))
+	return allFiles, fmt.Sprintf("%x", sha256.Sum256([]byte(combinedChecksum))), nil
+}
 
-	tx, err := s.db.Begin()
+// replaceDraft removes the records of an unpublished version about to be pushed again. It returns
+// ErrVersionExists when the version has been published.
+func replaceDraft(tx *sql.Tx, packageID, version string) error {
+	var versionID, status string
+	query := `SELECT id, status FROM schema_versions WHERE package_id = ? AND version = ?`
+	err := tx.QueryRow(query, packageID, version).Scan(&versionID, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return fmt.Errorf("failed to check version: %w", err)
 	}
-	committed := false
-	defer func() {
-		if !committed {
-			if err := tx.Rollback(); err != nil {
-				s.logger.Error().Err(err).Msg("failed to rollback transaction")
-			}
+	if status == StatusPublished {
+		return ErrVersionExists
+	}
+
+	for _, query := range []string{
+		`DELETE FROM schema_files WHERE version_id = ?`,
+		`DELETE FROM version_reviews WHERE version_id = ?`,
+		`DELETE FROM schema_versions WHERE id = ?`,
+	} {
+		if _, err := tx.Exec(query, versionID); err != nil {
+			return fmt.Errorf("failed to replace draft: %w", err)
 		}
-	}()
+	}
+	return nil
+}
 
-	query := `INSERT INTO schema_versions (id, package_id, version, checksum, created_by) VALUES (?, ?, ?, ?, ?)`
-	_, err = tx.Exec(query, versionID, packageID, version, combinedHash, createdBy)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create schema version: %w", err)
+func insertSchemaVersion(tx *sql.Tx, versionID, packageID, version, checksum, createdBy, status string, files []SchemaFile) error {
+	query := `INSERT INTO schema_versions (id, package_id, version, checksum, created_by, status) VALUES (?, ?, ?, ?, ?, ?)`
+	if _, err := tx.Exec(query, versionID, packageID, version, checksum, createdBy, status); err != nil {
+		return fmt.Errorf("failed to create schema version: %w", err)
 	}
 
-	for _, file := range allFiles {
+	for _, file := range files {
 		query := `INSERT INTO schema_files (id, version_id, filename, file_path, checksum, size_bytes) 
 				  VALUES (?, ?, ?, ?, ?, ?)`
-		_, err = tx.Exec(query, file.ID, file.VersionID, file.Filename, file.FilePath, file.Checksum, file.SizeBytes)
-		if err != nil {
-			return nil, fmt.Errorf("failed to store file %s: %w", file.Filename, err)
+		if _, err := tx.Exec(query, file.ID, file.VersionID, file.Filename, file.FilePath, file.Checksum, file.SizeBytes); err != nil {
+			return fmt.Errorf("failed to store file %s: %w", file.Filename, err)
 		}
 	}
+	return nil
+}
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
-	}
-	committed = true
-
-	return s.GetSchemaVersionWithFiles(packageID, version)
+func isUniqueViolation(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
 }
 
 func (s *packageStore) GetSchemaVersionWithFiles(packageID, version string) (*SchemaVersion, error) {
-	schema := &SchemaVersion{}
-	query := `SELECT id, package_id, version, checksum, metadata, created_at, created_by 
-			  FROM schema_versions WHERE package_id = ? AND version = ?`
-	err := s.db.QueryRow(query, packageID, version).Scan(
-		&schema.ID, &schema.PackageID, &schema.Version, &schema.Checksum,
-		&schema.Metadata, &schema.CreatedAt, &schema.CreatedBy)
+	query := `SELECT ` + versionColumns + ` FROM schema_versions WHERE package_id = ? AND version = ?`
+	schema, err := scanVersion(s.db.QueryRow(query, packageID, version))
 	if err != nil {
 		return nil, fmt.Errorf("schema version not found: %w", err)
 	}
@@ -137,7 +315,32 @@ func (s *packageStore) GetSchemaFiles(versionID string) ([]SchemaFile, error) {
 	return files, nil
 }
 
-func (s *packageStore) DeleteSchemaVersion(packageID, version string) error {
+// SetYanked marks a version as yanked or restores it. Yanked versions stay downloadable by their
+// exact version but are excluded from latest and range resolution.
+func (s *packageStore) SetYanked(packageID, version string, yanked bool, reason string) error {
+	if !yanked {
+		reason = ""
+	}
+	query := `UPDATE schema_versions SET yanked = ?, yank_reason = ? WHERE package_id = ? AND version = ?`
+	result, err := s.db.Exec(query, yanked, reason, packageID, version)
+	if err != nil {
+		return fmt.Errorf("failed to update version: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("schema version not found")
+	}
+	return nil
+}
+
+// DeleteSchemaVersion permanently removes a version, its file records and its schema directory.
+// Labels pointing at the version are removed, and the removals recorded in their history as made
+// by userID. Versions that cannot name a directory inside their package, stored before versions
+// were validated, are refused before anything is deleted.
+func (s *packageStore) DeleteSchemaVersion(packageID, version, userID string) error {
+	if err := ValidateVersion(version); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+
 	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -151,36 +354,30 @@ func (s *packageStore) DeleteSchemaVersion(packageID, version string) error {
 		}
 	}()
 
-	var versionID string
-	query := `SELECT id FROM schema_versions WHERE package_id = ? AND version = ?`
-	err = tx.QueryRow(query, packageID, version).Scan(&versionID)
+	var versionID, packageName string
+	query := `SELECT v.id, p.name FROM schema_versions v JOIN packages p ON p.id = v.package_id
+			  WHERE v.package_id = ? AND v.version = ?`
+	err = tx.QueryRow(query, packageID, version).Scan(&versionID, &packageName)
 	if err != nil {
 		return fmt.Errorf("schema version not found: %w", err)
 	}
-
-	var filePaths []string
-	query = `SELECT file_path FROM schema_files WHERE version_id = ?`
-	rows, err := tx.Query(query, versionID)
+	schemaDir, err := s.GetSchemaPath(packageName, version)
 	if err != nil {
-		return fmt.Errorf("failed to get file paths: %w", err)
+		return fmt.Errorf("failed to delete schema version: %w", err)
 	}
 
-	for rows.Next() {
-		var filePath string
-		if err := rows.Scan(&filePath); err != nil {
-			rows.Close()
-			return fmt.Errorf("failed to scan file path: %w", err)
-		}
-		filePaths = append(filePaths, filePath)
+	query = `DELETE FROM schema_files WHERE version_id = ?`
+	if _, err := tx.Exec(query, versionID); err != nil {
+		return fmt.Errorf("failed to delete schema files: %w", err)
 	}
 
-	if err := rows.Close(); err != nil {
-		return err
+	query = `DELETE FROM version_reviews WHERE version_id = ?`
+	if _, err := tx.Exec(query, versionID); err != nil {
+		return fmt.Errorf("failed to delete reviews: %w", err)
 	}
 
-	query = `DELETE FROM schema_files WHERE version_id = ?`
-	if _, err := tx.Exec(query, versionID); err != nil {
-		return fmt.Errorf("failed to delete schema files: %w", err)
+	if err := deleteVersionLabels(tx, packageID, versionID, version, userID); err != nil {
+		return err
 	}
 
 	query = `DELETE FROM schema_versions WHERE id = ?`
@@ -193,10 +390,9 @@ func (s *packageStore) DeleteSchemaVersion(packageID, version string) error {
 	}
 	committed = true
 
-	for _, filePath := range filePaths {
-		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
-			return fmt.Errorf("failed to delete file %s: %w", filePath, err)
-		}
+	// Every file of a version lives under its schema directory
+	if err := os.RemoveAll(schemaDir); err != nil {
+		return fmt.Errorf("failed to delete schema directory: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,254 @@
+package pkg
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/sirrobot01/protodex/internal/semver"
+)
+
+// maxLabelLength is the longest label name accepted
+const maxLabelLength = 64
+
+var labelPattern = regexp.MustCompile(`^[a-z][a-z0-9._-]*$`)
+
+// Label is a mutable name such as stable or prod pointing at a published version of a package
+type Label struct {
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LabelMove records a label being set, moved or removed. From is empty when the label was
+// created and To when it was removed.
+type LabelMove struct {
+	ID        int64     `json:"id"`
+	Label     string    `json:"label"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+	MovedBy   string    `json:"moved_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ValidateLabel checks a label name can be told apart from the version queries it is resolved
+// alongside: it must not be latest, latest-stable or a valid version range such as v1 or x.
+func ValidateLabel(name string) error {
+	if len(name) > maxLabelLength || !labelPattern.MatchString(name) {
+		return fmt.Errorf("invalid label %q, labels are lowercase letters, digits, dots, dashes and underscores starting with a letter", name)
+	}
+	if name == semver.Latest || name == semver.LatestStable {
+		return fmt.Errorf("invalid label %q, the name is reserved", name)
+	}
+	if _, err := semver.ParseConstraint(name); err == nil {
+		return fmt.Errorf("invalid label %q, labels cannot look like versions", name)
+	}
+	return nil
+}
+
+// SetLabel points a label at a version, creating the label if needed, and records the move. It
+// returns the version the label pointed at before, empty for a new label. Setting a label to the
+// version it already points at changes nothing.
+func (s *packageStore) SetLabel(packageID, name, versionID, userID string) (string, error) {
+	if err := ValidateLabel(name); err != nil {
+		return "", err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil {
+				s.logger.Error().Err(err).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+
+	previous, previousID, err := currentLabel(tx, packageID, name)
+	if err != nil {
+		return "", err
+	}
+	if previousID == versionID {
+		return previous, nil
+	}
+
+	var version string
+	if err := tx.QueryRow(`SELECT version FROM schema_versions WHERE id = ? AND package_id = ?`, versionID, packageID).Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to find version: %w", err)
+	}
+
+	query := `INSERT INTO package_labels (package_id, name, version_id, updated_by) VALUES (?, ?, ?, ?)
+			  ON CONFLICT (package_id, name) DO UPDATE SET version_id = excluded.version_id,
+			  updated_by = excluded.updated_by, updated_at = CURRENT_TIMESTAMP`
+	if _, err := tx.Exec(query, packageID, name, versionID, userID); err != nil {
+		return "", fmt.Errorf("failed to set label: %w", err)
+	}
+	if err := recordLabelMove(tx, packageID, name, previous, version, userID); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+	return previous, nil
+}
+
+// GetLabel returns a label with the version it points at, or ErrLabelNotFound
+func (s *packageStore) GetLabel(packageID, name string) (*Label, error) {
+	query := `SELECT l.name, v.version, COALESCE(u.username, ''), l.updated_at
+			  FROM package_labels l JOIN schema_versions v ON v.id = l.version_id
+			  LEFT JOIN users u ON u.id = l.updated_by
+			  WHERE l.package_id = ? AND l.name = ?`
+	label := &Label{}
+	err := s.db.QueryRow(query, packageID, name).Scan(&label.Name, &label.Version, &label.UpdatedBy, &label.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLabelNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get label: %w", err)
+	}
+	return label, nil
+}
+
+// ListLabels returns the labels of a package sorted by name
+func (s *packageStore) ListLabels(packageID string) ([]*Label, error) {
+	query := `SELECT l.name, v.version, COALESCE(u.username, ''), l.updated_at
+			  FROM package_labels l JOIN schema_versions v ON v.id = l.version_id
+			  LEFT JOIN users u ON u.id = l.updated_by
+			  WHERE l.package_id = ? ORDER BY l.name`
+	rows, err := s.db.Query(query, packageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labels: %w", err)
+	}
+	defer rows.Close()
+
+	var labels []*Label
+	for rows.Next() {
+		label := &Label{}
+		if err := rows.Scan(&label.Name, &label.Version, &label.UpdatedBy, &label.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan label: %w", err)
+		}
+		labels = append(labels, label)
+	}
+	return labels, rows.Err()
+}
+
+// DeleteLabel removes a label and records the removal in its history
+func (s *packageStore) DeleteLabel(packageID, name, userID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil {
+				s.logger.Error().Err(err).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+
+	previous, previousID, err := currentLabel(tx, packageID, name)
+	if err != nil {
+		return err
+	}
+	if previousID == "" {
+		return ErrLabelNotFound
+	}
+
+	if _, err := tx.Exec(`DELETE FROM package_labels WHERE package_id = ? AND name = ?`, packageID, name); err != nil {
+		return fmt.Errorf("failed to delete label: %w", err)
+	}
+	if err := recordLabelMove(tx, packageID, name, previous, "", userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+	return nil
+}
+
+// LabelHistory returns the moves of a label, newest first. The history of a removed label is kept.
+func (s *packageStore) LabelHistory(packageID, name string) ([]*LabelMove, error) {
+	query := `SELECT m.id, m.label, m.from_version, m.to_version, COALESCE(u.username, ''), m.created_at
+			  FROM label_moves m LEFT JOIN users u ON u.id = m.moved_by
+			  WHERE m.package_id = ? AND m.label = ? ORDER BY m.id DESC`
+	rows, err := s.db.Query(query, packageID, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list label history: %w", err)
+	}
+	defer rows.Close()
+
+	var moves []*LabelMove
+	for rows.Next() {
+		move := &LabelMove{}
+		if err := rows.Scan(&move.ID, &move.Label, &move.From, &move.To, &move.MovedBy, &move.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan label move: %w", err)
+		}
+		moves = append(moves, move)
+	}
+	return moves, rows.Err()
+}
+
+// currentLabel returns the version a label points at and its id, both empty when there is no such label
+func currentLabel(tx *sql.Tx, packageID, name string) (version, versionID string, err error) {
+	query := `SELECT v.version, v.id FROM package_labels l JOIN schema_versions v ON v.id = l.version_id
+			  WHERE l.package_id = ? AND l.name = ?`
+	err = tx.QueryRow(query, packageID, name).Scan(&version, &versionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get label: %w", err)
+	}
+	return version, versionID, nil
+}
+
+// deleteVersionLabels removes the labels pointing at a version that is being deleted and records
+// their removal
+func deleteVersionLabels(tx *sql.Tx, packageID, versionID, version, userID string) error {
+	rows, err := tx.Query(`SELECT name FROM package_labels WHERE package_id = ? AND version_id = ?`, packageID, versionID)
+	if err != nil {
+		return fmt.Errorf("failed to list labels: %w", err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan label: %w", err)
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list labels: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM package_labels WHERE package_id = ? AND version_id = ?`, packageID, versionID); err != nil {
+		return fmt.Errorf("failed to delete labels: %w", err)
+	}
+	for _, name := range names {
+		if err := recordLabelMove(tx, packageID, name, version, "", userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func recordLabelMove(tx *sql.Tx, packageID, name, from, to, userID string) error {
+	query := `INSERT INTO label_moves (package_id, label, from_version, to_version, moved_by) VALUES (?, ?, ?, ?, ?)`
+	if _, err := tx.Exec(query, packageID, name, from, to, userID); err != nil {
+		return fmt.Errorf("failed to record label move: %w", err)
+	}
+	return nil
+}
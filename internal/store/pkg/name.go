@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// maxNameLength is the longest package name accepted, including its scope
+const maxNameLength = 128
+
+// maxVersionLength is the longest version accepted
+const maxVersionLength = 128
+
+// namePartPattern matches the scope and the base name of a package name
+var namePartPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// SplitName splits a package name into its organization scope and base name. Unscoped names
+// have an empty scope, acme/payments has the scope acme.
+func SplitName(name string) (scope, base string) {
+	if i := strings.Index(name, "/"); i != -1 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// ValidateName checks a package name is either a plain name or a single scope/name pair. Each part is
+// used as a directory name, so it is limited to letters, digits, dots, dashes and underscores,
+// starts with a letter or digit and can never be . or ..
+func ValidateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("package name is required")
+	}
+	if strings.HasPrefix(name, "@") {
+		return fmt.Errorf("invalid package name %q, names cannot start with @", name)
+	}
+	if strings.Contains(name, `\`) {
+		return fmt.Errorf("invalid package name %q, names cannot contain backslashes", name)
+	}
+	if len(name) > maxNameLength {
+		return fmt.Errorf("invalid package name %q, names cannot be longer than %d characters", name, maxNameLength)
+	}
+	scope, base := SplitName(name)
+	if strings.Contains(name, "/") && (scope == "" || base == "" || strings.Contains(base, "/")) {
+		return fmt.Errorf("invalid package name %q, expected name or organization/name", name)
+	}
+	parts := []string{base}
+	if scope != "" {
+		parts = append(parts, scope)
+	}
+	for _, part := range parts {
+		if strings.Trim(part, ".") == "" {
+			return fmt.Errorf("invalid package name %q, names cannot consist of dots only", name)
+		}
+		if !namePartPattern.MatchString(part) {
+			return fmt.Errorf("invalid package name %q, use letters, digits, dots, dashes and underscores, starting with a letter or digit", name)
+		}
+	}
+	return nil
+}
+
+// ValidateVersion checks a version can be used as the name of its schema directory
+func ValidateVersion(version string) error {
+	if version == "" {
+		return fmt.Errorf("version is required")
+	}
+	if len(version) > maxVersionLength || version == "." || version == ".." ||
+		strings.ContainsAny(version, `/\`) || strings.ContainsFunc(version, unicode.IsControl) {
+		return fmt.Errorf("invalid version %q, versions cannot be . or .., contain slashes or control characters or be longer than %d characters", version, maxVersionLength)
+	}
+	return nil
+}
@@ -4,15 +4,24 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/google/uuid"
+
+	"github.com/sirrobot01/protodex/internal/semver"
 )
 
 func (s *packageStore) CreatePackage(name, description, ownerID string, tags []string) (*Package, error) {
+	// Names of renamed packages keep redirecting to them
+	if _, err := s.aliasTarget(s.db, name); err == nil {
+		return nil, fmt.Errorf("failed to create package: %w", ErrNameTaken)
+	}
+
 	id := uuid.New().String()
 
 	tagsJSON, err := json.Marshal(tags)
@@ -20,32 +29,34 @@ func (s *packageStore) CreatePackage(name, description, ownerID string, tags []s
 		return nil, fmt.Errorf("failed to marshal tags: %w", err)
 	}
 
-	query := `INSERT INTO packages (id, name, description, tags, owner_id) VALUES (?, ?, ?, ?, ?)`
-	_, err = s.db.Exec(query, id, name, description, string(tagsJSON), ownerID)
+	// Scoped packages belong to the organization named by their scope
+	scope, _ := SplitName(name)
+	query := `INSERT INTO packages (id, name, description, tags, owner_id, org_id)
+			  VALUES (?, ?, ?, ?, ?, (SELECT id FROM organizations WHERE name = ?))`
+	_, err = s.db.Exec(query, id, name, description, string(tagsJSON), ownerID, scope)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create package: %w", err)
 	}
 
-	return &Package{
-		ID:          id,
-		Name:        name,
-		Description: description,
-		Tags:        tags,
-		OwnerID:     ownerID,
-	}, nil
+	return s.GetPackageByID(id)
 }
 
-func (s *packageStore) GetPackage(name string) (*Package, error) {
+// packageColumns is the column list read by scanPackage
+const packageColumns = `id, name, description, tags, owner_id, compatibility, strict_semver, bump_policy, org_id, visibility, required_approvals, created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPackage(row rowScanner) (*Package, error) {
 	pkg := &Package{}
 	var tagsJSON string
-	query := `SELECT id, name, description, tags, owner_id, created_at FROM packages WHERE name = ?`
-	err := s.db.QueryRow(query, name).Scan(&pkg.ID, &pkg.Name, &pkg.Description, &tagsJSON, &pkg.OwnerID, &pkg.CreatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("package %s not found", name)
-		}
-		return nil, fmt.Errorf("failed to get package: %w", err)
+	var ownerID, orgID sql.NullString
+	if err := row.Scan(&pkg.ID, &pkg.Name, &pkg.Description, &tagsJSON, &ownerID, &pkg.Compatibility, &pkg.StrictSemver, &pkg.BumpPolicy, &orgID, &pkg.Visibility, &pkg.RequiredApprovals, &pkg.CreatedAt); err != nil {
+		return nil, err
 	}
+	pkg.OwnerID = ownerID.String
+	pkg.OrgID = orgID.String
 
 	// Parse tags from JSON
 	if tagsJSON != "" {
@@ -53,58 +64,87 @@ func (s *packageStore) GetPackage(name string) (*Package, error) {
 			return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
 		}
 	}
-
 	return pkg, nil
 }
 
-func (s *packageStore) GetPackageByID(id string) (*Package, error) {
-	pkg := &Package{}
-	var tagsJSON string
-	query := `SELECT id, name, description, tags, owner_id, created_at FROM packages WHERE id = ?`
-	err := s.db.QueryRow(query, id).Scan(&pkg.ID, &pkg.Name, &pkg.Description, &tagsJSON, &pkg.OwnerID, &pkg.CreatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("package with id %s not found", id)
-		}
-		return nil, fmt.Errorf("failed to get package: %w", err)
-	}
+// versionColumns is the column list read by scanVersion
+const versionColumns = `id, package_id, version, checksum, metadata, created_at, created_by, yanked, yank_reason, status`
 
-	// Parse tags from JSON
-	if tagsJSON != "" {
-		if err := json.Unmarshal([]byte(tagsJSON), &pkg.Tags); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
-		}
+func scanVersion(row rowScanner) (*SchemaVersion, error) {
+	schema := &SchemaVersion{}
+	if err := row.Scan(&schema.ID, &schema.PackageID, &schema.Version, &schema.Checksum,
+		&schema.Metadata, &schema.CreatedAt, &schema.CreatedBy, &schema.Yanked, &schema.YankReason, &schema.Status); err != nil {
+		return nil, err
 	}
-
-	return pkg, nil
+	return schema, nil
 }
 
-func (s *packageStore) ListPackages() ([]*Package, error) {
-	query := `SELECT id, name, description, tags, owner_id, created_at FROM packages ORDER BY name`
-	rows, err := s.db.Query(query)
+func (s *packageStore) queryPackages(query string, args ...any) ([]*Package, error) {
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list packages: %w", err)
+		return nil, err
 	}
 	defer rows.Close()
 
 	var packages []*Package
 	for rows.Next() {
-		pkg := &Package{}
-		var tagsJSON string
-		if err := rows.Scan(&pkg.ID, &pkg.Name, &pkg.Description, &tagsJSON, &pkg.OwnerID, &pkg.CreatedAt); err != nil {
+		pkg, err := scanPackage(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan package: %w", err)
 		}
+		packages = append(packages, pkg)
+	}
+	return packages, rows.Err()
+}
 
-		// Parse tags from JSON
-		if tagsJSON != "" {
-			if err := json.Unmarshal([]byte(tagsJSON), &pkg.Tags); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
+// GetPackage looks a package up by name, following the aliases left behind by renames
+func (s *packageStore) GetPackage(name string) (*Package, error) {
+	query := `SELECT ` + packageColumns + ` FROM packages WHERE name = ?`
+	pkg, err := scanPackage(s.db.QueryRow(query, name))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if packageID, aliasErr := s.aliasTarget(s.db, name); aliasErr == nil {
+				return s.GetPackageByID(packageID)
 			}
+			return nil, fmt.Errorf("package %s not found", name)
 		}
+		return nil, fmt.Errorf("failed to get package: %w", err)
+	}
+	return pkg, nil
+}
 
-		packages = append(packages, pkg)
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// aliasTarget returns the ID of the package an old package name redirects to
+func (s *packageStore) aliasTarget(db queryRower, name string) (string, error) {
+	var packageID string
+	query := `SELECT package_id FROM package_aliases WHERE name = ?`
+	if err := db.QueryRow(query, name).Scan(&packageID); err != nil {
+		return "", err
+	}
+	return packageID, nil
+}
+
+func (s *packageStore) GetPackageByID(id string) (*Package, error) {
+	query := `SELECT ` + packageColumns + ` FROM packages WHERE id = ?`
+	pkg, err := scanPackage(s.db.QueryRow(query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("package with id %s not found", id)
+		}
+		return nil, fmt.Errorf("failed to get package: %w", err)
 	}
+	return pkg, nil
+}
 
+func (s *packageStore) ListPackages() ([]*Package, error) {
+	query := `SELECT ` + packageColumns + ` FROM packages ORDER BY name`
+	packages, err := s.queryPackages(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list packages: %w", err)
+	}
 	return packages, nil
 }
 
@@ -114,16 +154,16 @@ func (s *packageStore) SearchPackages(query string, tags []string) ([]*Package,
 
 	switch {
 	case query != "" && len(tags) > 0:
-		sqlQuery = `SELECT id, name, description, tags, created_at FROM packages 
+		sqlQuery = `SELECT ` + packageColumns + ` FROM packages 
 					WHERE (name LIKE ? OR description LIKE ?) AND tags LIKE ? ORDER BY name`
 		tagsPattern := "%\"" + tags[0] + "\"%"
 		args = []interface{}{"%" + query + "%", "%" + query + "%", tagsPattern}
 	case query != "":
-		sqlQuery = `SELECT id, name, description, tags, created_at FROM packages 
+		sqlQuery = `SELECT ` + packageColumns + ` FROM packages 
 					WHERE name LIKE ? OR description LIKE ? ORDER BY name`
 		args = []interface{}{"%" + query + "%", "%" + query + "%"}
 	case len(tags) > 0:
-		sqlQuery = `SELECT id, name, description, tags, created_at FROM packages 
+		sqlQuery = `SELECT ` + packageColumns + ` FROM packages 
 					WHERE tags LIKE ? ORDER BY name`
 		tagsPattern := "%\"" + tags[0] + "\"%"
 		args = []interface{}{tagsPattern}
@@ -131,31 +171,212 @@ func (s *packageStore) SearchPackages(query string, tags []string) ([]*Package,
 		return s.ListPackages()
 	}
 
-	rows, err := s.db.Query(sqlQuery, args...)
+	packages, err := s.queryPackages(sqlQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search packages: %w", err)
 	}
-	defer rows.Close()
+	return packages, nil
+}
 
-	var packages []*Package
-	for rows.Next() {
-		pkg := &Package{}
-		var tagsJSON string
-		if err := rows.Scan(&pkg.ID, &pkg.Name, &pkg.Description, &tagsJSON, &pkg.CreatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan package: %w", err)
+func (s *packageStore) SetCompatibility(packageID, mode string) error {
+	query := `UPDATE packages SET compatibility = ? WHERE id = ?`
+	result, err := s.db.Exec(query, mode, packageID)
+	if err != nil {
+		return fmt.Errorf("failed to update compatibility: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("package not found")
+	}
+	return nil
+}
+
+func (s *packageStore) SetVisibility(packageID, visibility string) error {
+	if err := ValidateVisibility(visibility); err != nil {
+		return err
+	}
+
+	query := `UPDATE packages SET visibility = ? WHERE id = ?`
+	result, err := s.db.Exec(query, visibility, packageID)
+	if err != nil {
+		return fmt.Errorf("failed to update visibility: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("package not found")
+	}
+	return nil
+}
+
+func (s *packageStore) SetVersioning(packageID string, strictSemver bool, bumpPolicy string, requiredApprovals int) error {
+	if requiredApprovals < 0 {
+		return fmt.Errorf("required approvals cannot be negative")
+	}
+	query := `UPDATE packages SET strict_semver = ?, bump_policy = ?, required_approvals = ? WHERE id = ?`
+	result, err := s.db.Exec(query, strictSemver, bumpPolicy, requiredApprovals, packageID)
+	if err != nil {
+		return fmt.Errorf("failed to update versioning: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("package not found")
+	}
+	return nil
+}
+
+// DeletePackage removes a package with all its versions, aliases and files on disk. Nothing is deleted
+// when the package directory cannot be safely derived from its name.
+func (s *packageStore) DeletePackage(packageID string) error {
+	pkg, err := s.GetPackageByID(packageID)
+	if err != nil {
+		return err
+	}
+	dir, err := s.packageDir(pkg.Name)
+	if err != nil {
+		return fmt.Errorf("failed to delete package: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil {
+				s.logger.Error().Err(err).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+
+	queries := []string{
+		`DELETE FROM package_labels WHERE package_id = ?`,
+		`DELETE FROM label_moves WHERE package_id = ?`,
+		`DELETE FROM schema_files WHERE version_id IN (SELECT id FROM schema_versions WHERE package_id = ?)`,
+		`DELETE FROM version_reviews WHERE version_id IN (SELECT id FROM schema_versions WHERE package_id = ?)`,
+		`DELETE FROM schema_versions WHERE package_id = ?`,
+		`DELETE FROM package_aliases WHERE package_id = ?`,
+		`DELETE FROM package_collaborators WHERE package_id = ?`,
+		`DELETE FROM api_tokens WHERE package_id = ?`,
+		`DELETE FROM packages WHERE id = ?`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, packageID); err != nil {
+			return fmt.Errorf("failed to delete package: %w", err)
 		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to delete package directory: %w", err)
+	}
+	return nil
+}
+
+// RenamePackage gives a package a new name and keeps the old one as an alias, so existing references
+// keep resolving. The package directory is moved and the stored file paths are rewritten in the same
+// transaction.
+func (s *packageStore) RenamePackage(packageID, newName string) (*Package, error) {
+	pkg, err := s.GetPackageByID(packageID)
+	if err != nil {
+		return nil, err
+	}
+	if pkg.Name == newName {
+		return pkg, nil
+	}
+	oldDir, err := s.packageDir(pkg.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename package: %w", err)
+	}
+	newDir, err := s.packageDir(newName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename package: %w", err)
+	}
 
-		// Parse tags from JSON
-		if tagsJSON != "" {
-			if err := json.Unmarshal([]byte(tagsJSON), &pkg.Tags); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal tags: %w", err)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil {
+				s.logger.Error().Err(err).Msg("failed to rollback transaction")
 			}
 		}
+	}()
 
-		packages = append(packages, pkg)
+	// A package can take back one of its own old names, but not the alias of another package
+	if target, err := s.aliasTarget(tx, newName); err == nil {
+		if target != packageID {
+			return nil, ErrNameTaken
+		}
+		if _, err := tx.Exec(`DELETE FROM package_aliases WHERE name = ?`, newName); err != nil {
+			return nil, fmt.Errorf("failed to remove alias: %w", err)
+		}
 	}
 
-	return packages, nil
+	scope, _ := SplitName(newName)
+	query := `UPDATE packages SET name = ?, org_id = (SELECT id FROM organizations WHERE name = ?) WHERE id = ?`
+	if _, err := tx.Exec(query, newName, scope, packageID); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrNameTaken
+		}
+		return nil, fmt.Errorf("failed to rename package: %w", err)
+	}
+
+	query = `INSERT INTO package_aliases (name, package_id) VALUES (?, ?)`
+	if _, err := tx.Exec(query, pkg.Name, packageID); err != nil {
+		return nil, fmt.Errorf("failed to create alias: %w", err)
+	}
+
+	// File paths are stored relative to the data directory and start with the package directory
+	oldPrefix := s.relativePackageDir(pkg.Name) + string(os.PathSeparator)
+	newPrefix := s.relativePackageDir(newName) + string(os.PathSeparator)
+	query = `UPDATE schema_files SET file_path = ? || substr(file_path, ?)
+			 WHERE version_id IN (SELECT id FROM schema_versions WHERE package_id = ?) AND substr(file_path, 1, ?) = ?`
+	if _, err := tx.Exec(query, newPrefix, len(oldPrefix)+1, packageID, len(oldPrefix), oldPrefix); err != nil {
+		return nil, fmt.Errorf("failed to update file paths: %w", err)
+	}
+
+	moved := false
+	if _, err := os.Stat(oldDir); err == nil {
+		if err := os.RemoveAll(newDir); err != nil {
+			return nil, fmt.Errorf("failed to clear package directory: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(newDir), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create package directory: %w", err)
+		}
+		if err := os.Rename(oldDir, newDir); err != nil {
+			return nil, fmt.Errorf("failed to move package directory: %w", err)
+		}
+		moved = true
+	}
+
+	if err := tx.Commit(); err != nil {
+		if moved {
+			if mvErr := os.Rename(newDir, oldDir); mvErr != nil {
+				s.logger.Error().Err(mvErr).Str("dir", newDir).Msg("failed to restore package directory")
+			}
+		}
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	committed = true
+
+	return s.GetPackageByID(packageID)
+}
+
+func (s *packageStore) TransferOwnership(packageID, ownerID string) error {
+	query := `UPDATE packages SET owner_id = ? WHERE id = ?`
+	result, err := s.db.Exec(query, ownerID, packageID)
+	if err != nil {
+		return fmt.Errorf("failed to transfer package: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("package not found")
+	}
+	return nil
 }
 
 func (s *packageStore) StoreSchema(packageID, version, filePath, createdBy string) (*SchemaVersion, error) {
@@ -180,8 +401,7 @@ func (s *packageStore) GetSchemaVersion(packageID, version string) (*SchemaVersi
 }
 
 func (s *packageStore) ListVersions(packageID string) ([]*SchemaVersion, error) {
-	query := `SELECT id, package_id, version, checksum, metadata, created_at, created_by 
-			  FROM schema_versions WHERE package_id = ? ORDER BY created_at DESC`
+	query := `SELECT ` + versionColumns + ` FROM schema_versions WHERE package_id = ? ORDER BY created_at DESC`
 	rows, err := s.db.Query(query, packageID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list versions: %w", err)
@@ -190,9 +410,8 @@ func (s *packageStore) ListVersions(packageID string) ([]*SchemaVersion, error)
 
 	var versions []*SchemaVersion
 	for rows.Next() {
-		schema := &SchemaVersion{}
-		if err := rows.Scan(&schema.ID, &schema.PackageID, &schema.Version,
-			&schema.Checksum, &schema.Metadata, &schema.CreatedAt, &schema.CreatedBy); err != nil {
+		schema, err := scanVersion(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan schema version: %w", err)
 		}
 
@@ -205,11 +424,56 @@ func (s *packageStore) ListVersions(packageID string) ([]*SchemaVersion, error)
 		versions = append(versions, schema)
 	}
 
+	// Semantic versions are ordered by precedence, anything else follows newest first
+	sort.SliceStable(versions, func(i, j int) bool {
+		return semver.Less(versions[j].Version, versions[i].Version)
+	})
 	return versions, nil
 }
 
-func (s *packageStore) GetSchemaPath(packageName, version string) string {
-	return filepath.Join(s.dataDir, "schemas", packageName, version)
+// GetSchemaPath returns the directory of a version. It fails unless the directory is a direct child
+// of the package directory.
+func (s *packageStore) GetSchemaPath(packageName, version string) (string, error) {
+	dir, err := s.packageDir(packageName)
+	if err != nil {
+		return "", err
+	}
+	if err := ValidateVersion(version); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPath, err)
+	}
+	schemaDir := filepath.Join(dir, version)
+	if filepath.Dir(schemaDir) != dir || filepath.Base(schemaDir) != version {
+		return "", fmt.Errorf("%w: version %q", ErrInvalidPath, version)
+	}
+	return schemaDir, nil
+}
+
+// packageDir is the directory holding every version of a package. It fails unless the directory is
+// exactly schemas/name or schemas/@scope/name, so a malformed name can never make a removal or
+// rename reach the schemas root, another package or the data directory.
+func (s *packageStore) packageDir(packageName string) (string, error) {
+	root := filepath.Join(s.dataDir, "schemas")
+	dir := filepath.Join(s.dataDir, s.relativePackageDir(packageName))
+
+	scope, base := SplitName(packageName)
+	parent := root
+	if scope != "" {
+		parent = filepath.Join(root, "@"+scope)
+	}
+	if filepath.Dir(dir) != parent || filepath.Base(dir) != base || (scope == "" && base != packageName) {
+		return "", fmt.Errorf("%w: package %q", ErrInvalidPath, packageName)
+	}
+	return dir, nil
+}
+
+// relativePackageDir keeps scoped packages under schemas/@scope/name, so they never share a
+// directory with an unscoped package named like the organization
+func (s *packageStore) relativePackageDir(packageName string) string {
+	scope, base := SplitName(packageName)
+	if scope != "" {
+		return filepath.Join("schemas", "@"+scope, base)
+	}
+	return filepath.Join("schemas", packageName)
 }
 
 func (s *packageStore) GetDataDir() string {
@@ -2,27 +2,75 @@ package pkg
 
 import (
 	"database/sql"
+	"errors"
+	"time"
+
 	"github.com/rs/zerolog"
 
 	"github.com/sirrobot01/protodex/internal/logger"
 )
 
+// ErrVersionExists is returned when publishing a version that has already been published
+var ErrVersionExists = errors.New("version already exists")
+
+// ErrNotCollaborator is returned when a user has no role of their own on a package
+var ErrNotCollaborator = errors.New("not a collaborator of the package")
+
+// ErrStatusChanged is returned when the status of a version changed before it could be updated
+var ErrStatusChanged = errors.New("version status has changed")
+
+// ErrInvalidPath is returned when a package name or version would place files outside of its
+// directory under the schemas root
+var ErrInvalidPath = errors.New("invalid storage path")
+
+// ErrLabelNotFound is returned when a package has no label with the given name
+var ErrLabelNotFound = errors.New("label not found")
+
+// ErrNameTaken is returned when a package name is used by another package or kept as the alias of a renamed one
+var ErrNameTaken = errors.New("package name is already taken")
+
 type Store interface {
 	CreatePackage(name, description, ownerID string, tags []string) (*Package, error)
 	GetPackage(name string) (*Package, error)
 	GetPackageByID(id string) (*Package, error)
 	ListPackages() ([]*Package, error)
 	SearchPackages(query string, tags []string) ([]*Package, error)
+	SetCompatibility(packageID, mode string) error
+	SetVersioning(packageID string, strictSemver bool, bumpPolicy string, requiredApprovals int) error
+	SetVisibility(packageID, visibility string) error
+	DeletePackage(packageID string) error
+	RenamePackage(packageID, newName string) (*Package, error)
+	TransferOwnership(packageID, ownerID string) error
+
+	SetCollaborator(packageID, userID, role string) error
+	RemoveCollaborator(packageID, userID string) error
+	GetCollaboratorRole(packageID, userID string) (string, error)
+	ListCollaborators(packageID string) ([]*Collaborator, error)
 
 	StoreSchema(packageID, version, filePath, createdBy string) (*SchemaVersion, error)
 	GetSchemaVersion(packageID, version string) (*SchemaVersion, error)
 	ListVersions(packageID string) ([]*SchemaVersion, error)
-	GetSchemaPath(packageName, version string) string
+	GetSchemaPath(packageName, version string) (string, error)
 
 	SaveSchemaFiles(packageID, version string, filePaths []string, createdBy string) (*SchemaVersion, error)
+	StagingDir() (string, error)
+	PublishSchema(packageID, packageName, version, stagingDir, createdBy, status string) (*SchemaVersion, error)
+	HasVersion(packageID, version string) (bool, error)
+	CleanStaging(maxAge time.Duration) error
 	GetSchemaVersionWithFiles(packageID, version string) (*SchemaVersion, error)
 	GetSchemaFiles(versionID string) ([]SchemaFile, error)
-	DeleteSchemaVersion(packageID, version string) error
+	SetYanked(packageID, version string, yanked bool, reason string) error
+	DeleteSchemaVersion(packageID, version, userID string) error
+
+	SetVersionStatus(versionID, from, to string) error
+	ReviewVersion(versionID, userID, decision, comment string) error
+	ListReviews(versionID string) ([]*Review, error)
+
+	SetLabel(packageID, name, versionID, userID string) (previous string, err error)
+	GetLabel(packageID, name string) (*Label, error)
+	ListLabels(packageID string) ([]*Label, error)
+	DeleteLabel(packageID, name, userID string) error
+	LabelHistory(packageID, name string) ([]*LabelMove, error)
 
 	GetDataDir() string
 }
@@ -0,0 +1,82 @@
+package pkg
+
+import (
+	"fmt"
+	"time"
+)
+
+// Statuses of a version. Drafts are stored and can be viewed but are not resolved for consumers
+// until enough maintainers approve them, which publishes them. A rejected draft stays rejected
+// until it is pushed again.
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusRejected  = "rejected"
+)
+
+// Review decisions on a draft
+const (
+	DecisionApprove = "approve"
+	DecisionReject  = "reject"
+)
+
+// Review is the decision of a maintainer on a draft
+type Review struct {
+	VersionID string    `json:"version_id"`
+	UserID    string    `json:"user_id"`
+	Username  string    `json:"username"`
+	Decision  string    `json:"decision"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SetVersionStatus moves a version from one status to another. It returns ErrStatusChanged when the
+// version is no longer in the from status, for example because a concurrent approval published it.
+func (s *packageStore) SetVersionStatus(versionID, from, to string) error {
+	query := `UPDATE schema_versions SET status = ? WHERE id = ? AND status = ?`
+	result, err := s.db.Exec(query, to, versionID, from)
+	if err != nil {
+		return fmt.Errorf("failed to update version status: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
+
+// ReviewVersion records the decision of a user on a draft, replacing their earlier decision
+func (s *packageStore) ReviewVersion(versionID, userID, decision, comment string) error {
+	if decision != DecisionApprove && decision != DecisionReject {
+		return fmt.Errorf("invalid review decision %q", decision)
+	}
+
+	query := `INSERT INTO version_reviews (version_id, user_id, decision, comment) VALUES (?, ?, ?, ?)
+			  ON CONFLICT (version_id, user_id) DO UPDATE SET decision = excluded.decision,
+			  comment = excluded.comment, created_at = CURRENT_TIMESTAMP`
+	if _, err := s.db.Exec(query, versionID, userID, decision, comment); err != nil {
+		return fmt.Errorf("failed to review version: %w", err)
+	}
+	return nil
+}
+
+// ListReviews returns the decisions on a version, oldest first
+func (s *packageStore) ListReviews(versionID string) ([]*Review, error) {
+	query := `SELECT r.version_id, r.user_id, COALESCE(u.username, ''), r.decision, r.comment, r.created_at
+			  FROM version_reviews r LEFT JOIN users u ON u.id = r.user_id
+			  WHERE r.version_id = ? ORDER BY r.created_at, u.username`
+	rows, err := s.db.Query(query, versionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []*Review
+	for rows.Next() {
+		review := &Review{}
+		if err := rows.Scan(&review.VersionID, &review.UserID, &review.Username, &review.Decision, &review.Comment, &review.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan review: %w", err)
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
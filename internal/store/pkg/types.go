@@ -3,23 +3,39 @@ package pkg
 import "time"
 
 type Package struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Tags        []string  `json:"tags,omitempty"`
-	OwnerID     string    `json:"owner_id"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	Tags          []string `json:"tags,omitempty"`
+	OwnerID       string   `json:"owner_id"`
+	Compatibility string   `json:"compatibility"`
+	StrictSemver  bool     `json:"strict_semver"`
+	BumpPolicy    string   `json:"bump_policy"`
+	OrgID         string   `json:"org_id,omitempty"`
+	Visibility    string   `json:"visibility"`
+	// RequiredApprovals is how many maintainers must approve a push before it is published, every
+	// push is stored as a draft when it is set
+	RequiredApprovals int       `json:"required_approvals"`
+	CreatedAt         time.Time `json:"created_at"`
 }
 
 type SchemaVersion struct {
-	ID        string       `json:"id"`
-	PackageID string       `json:"package_id"`
-	Version   string       `json:"version"`
-	Checksum  string       `json:"checksum"`
-	Metadata  string       `json:"metadata"`
-	CreatedAt time.Time    `json:"created_at"`
-	CreatedBy string       `json:"created_by"`
-	Files     []SchemaFile `json:"files,omitempty"`
+	ID         string       `json:"id"`
+	PackageID  string       `json:"package_id"`
+	Version    string       `json:"version"`
+	Checksum   string       `json:"checksum"`
+	Metadata   string       `json:"metadata"`
+	CreatedAt  time.Time    `json:"created_at"`
+	CreatedBy  string       `json:"created_by"`
+	Yanked     bool         `json:"yanked"`
+	YankReason string       `json:"yank_reason,omitempty"`
+	Status     string       `json:"status"`
+	Files      []SchemaFile `json:"files,omitempty"`
+}
+
+// Published reports whether consumers can resolve the version
+func (v *SchemaVersion) Published() bool {
+	return v.Status == StatusPublished
 }
 
 type SchemaFile struct {
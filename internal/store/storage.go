@@ -7,8 +7,11 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/sirrobot01/protodex/internal/logger"
+	"github.com/sirrobot01/protodex/internal/store/audit"
 	"github.com/sirrobot01/protodex/internal/store/auth"
+	"github.com/sirrobot01/protodex/internal/store/org"
 	"github.com/sirrobot01/protodex/internal/store/pkg"
+	"github.com/sirrobot01/protodex/internal/store/webhook"
 	_ "modernc.org/sqlite"
 )
 
@@ -16,13 +19,19 @@ type Store interface {
 	Init() error
 	Auth() auth.Store
 	Package() pkg.Store
+	Org() org.Store
+	Audit() audit.Store
+	Webhook() webhook.Store
 
 	Close() error
 }
 
 type dbStore struct {
-	auth auth.Store
-	pkg  pkg.Store
+	auth    auth.Store
+	pkg     pkg.Store
+	org     org.Store
+	audit   audit.Store
+	webhook webhook.Store
 
 	db     *sql.DB
 	logger zerolog.Logger
@@ -31,19 +40,27 @@ type dbStore struct {
 func New(dataDir string) (Store, error) {
 	dbPath := filepath.Join(dataDir, "protodex.db")
 
-	db, err := sql.Open("sqlite", dbPath)
+	// Wait for concurrent writers instead of failing immediately, publishing holds the write lock
+	// while it moves a version into place
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 	// Create sub-stores
 	authStore := auth.NewStore(db)
 	pkgStore := pkg.NewStore(db, dataDir)
+	orgStore := org.NewStore(db)
+	auditStore := audit.NewStore(db)
+	webhookStore := webhook.NewStore(db)
 
 	return &dbStore{
-		db:     db,
-		logger: logger.Get(),
-		auth:   authStore,
-		pkg:    pkgStore,
+		db:      db,
+		logger:  logger.Get(),
+		auth:    authStore,
+		pkg:     pkgStore,
+		org:     orgStore,
+		audit:   auditStore,
+		webhook: webhookStore,
 	}, nil
 }
 
@@ -55,6 +72,18 @@ func (s *dbStore) Package() pkg.Store {
 	return s.pkg
 }
 
+func (s *dbStore) Org() org.Store {
+	return s.org
+}
+
+func (s *dbStore) Audit() audit.Store {
+	return s.audit
+}
+
+func (s *dbStore) Webhook() webhook.Store {
+	return s.webhook
+}
+
 func (s *dbStore) Close() error {
 	return s.db.Close()
 }
@@ -2,10 +2,18 @@ package store
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	auditstore "github.com/sirrobot01/protodex/internal/store/audit"
+	authstore "github.com/sirrobot01/protodex/internal/store/auth"
+	orgstore "github.com/sirrobot01/protodex/internal/store/org"
+	pkgstore "github.com/sirrobot01/protodex/internal/store/pkg"
+	webhookstore "github.com/sirrobot01/protodex/internal/store/webhook"
 )
 
 func TestNew(t *testing.T) {
@@ -79,7 +87,878 @@ func TestListPackages(t *testing.T) {
 	assert.Equal(t, 2, len(packages))
 }
 
+func TestSetCompatibility(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	pkgStore := storage.Package()
+
+	pkg, err := pkgStore.CreatePackage("test-package", "A test package", "user123", []string{})
+	require.NoError(t, err)
+	assert.Equal(t, "BACKWARD", pkg.Compatibility)
+
+	err = pkgStore.SetCompatibility(pkg.ID, "FULL_TRANSITIVE")
+	require.NoError(t, err)
+
+	pkg, err = pkgStore.GetPackage("test-package")
+	require.NoError(t, err)
+	assert.Equal(t, "FULL_TRANSITIVE", pkg.Compatibility)
+
+	err = pkgStore.SetCompatibility("missing", "FULL")
+	assert.Error(t, err)
+}
+
+func TestListVersionsSemverOrder(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	pkgStore := storage.Package()
+
+	pkg, err := pkgStore.CreatePackage("test-package", "A test package", "user123", []string{})
+	require.NoError(t, err)
+
+	protoFile := filepath.Join(t.TempDir(), "test.proto")
+	require.NoError(t, os.WriteFile(protoFile, []byte(`syntax = "proto3";`), 0644))
+
+	for _, version := range []string{"v1.10.0", "dev", "v1.2.0", "v2.0.0-rc.1", "v1.9.1"} {
+		_, err := pkgStore.SaveSchemaFiles(pkg.ID, version, []string{protoFile}, "user123")
+		require.NoError(t, err)
+	}
+
+	versions, err := pkgStore.ListVersions(pkg.ID)
+	require.NoError(t, err)
+
+	var names []string
+	for _, v := range versions {
+		names = append(names, v.Version)
+	}
+	assert.Equal(t, []string{"v2.0.0-rc.1", "v1.10.0", "v1.9.1", "v1.2.0", "dev"}, names)
+}
+
+func TestSetVersioning(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	pkgStore := storage.Package()
+
+	pkg, err := pkgStore.CreatePackage("test-package", "A test package", "user123", []string{})
+	require.NoError(t, err)
+	assert.False(t, pkg.StrictSemver)
+	assert.Equal(t, "suggest", pkg.BumpPolicy)
+
+	require.NoError(t, pkgStore.SetVersioning(pkg.ID, true, "require", 2))
+
+	pkg, err = pkgStore.GetPackage("test-package")
+	require.NoError(t, err)
+	assert.True(t, pkg.StrictSemver)
+	assert.Equal(t, "require", pkg.BumpPolicy)
+	assert.Equal(t, 2, pkg.RequiredApprovals)
+
+	assert.Error(t, pkgStore.SetVersioning(pkg.ID, true, "require", -1))
+}
+
+func TestPublishSchema(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	pkgStore := storage.Package()
+
+	pkg, err := pkgStore.CreatePackage("test-package", "A test package", "user123", []string{})
+	require.NoError(t, err)
+
+	stage := func(content string) string {
+		dir, err := pkgStore.StagingDir()
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "api"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "api", "test.proto"), []byte(content), 0644))
+		return dir
+	}
+
+	stagingDir := stage(`syntax = "proto3";`)
+	version, err := pkgStore.PublishSchema(pkg.ID, pkg.Name, "v1.0.0", stagingDir, "user123", pkgstore.StatusPublished)
+	require.NoError(t, err)
+	require.Len(t, version.Files, 1)
+	assert.Equal(t, filepath.Join("schemas", "test-package", "v1.0.0", "api", "test.proto"), version.Files[0].FilePath)
+	assert.NoDirExists(t, stagingDir)
+
+	publishedFile := filepath.Join(schemaPath(t, pkgStore, pkg.Name, "v1.0.0"), "api", "test.proto")
+	assert.FileExists(t, publishedFile)
+
+	exists, err := pkgStore.HasVersion(pkg.ID, "v1.0.0")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Re-publishing the same version fails and leaves the published files untouched
+	duplicateDir := stage(`syntax = "proto2";`)
+	_, err = pkgStore.PublishSchema(pkg.ID, pkg.Name, "v1.0.0", duplicateDir, "user123", pkgstore.StatusPublished)
+	assert.ErrorIs(t, err, pkgstore.ErrVersionExists)
+
+	content, err := os.ReadFile(publishedFile)
+	require.NoError(t, err)
+	assert.Equal(t, `syntax = "proto3";`, string(content))
+}
+
+func TestPublishSchemaRejectsUnsafeVersions(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	pkgStore := storage.Package()
+
+	other, err := pkgStore.CreatePackage("other", "", "user123", []string{})
+	require.NoError(t, err)
+	otherDir, err := pkgStore.StagingDir()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(otherDir, "test.proto"), []byte(`syntax = "proto3";`), 0644))
+	_, err = pkgStore.PublishSchema(other.ID, other.Name, "v1.0.0", otherDir, "user123", pkgstore.StatusPublished)
+	require.NoError(t, err)
+
+	pkg, err := pkgStore.CreatePackage("test-package", "", "user123", []string{})
+	require.NoError(t, err)
+
+	for _, version := range []string{"", ".", "..", "../other", "../../protodex.db", `..\other`, "v1\x00", "v1\n"} {
+		stagingDir, err := pkgStore.StagingDir()
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(stagingDir, "test.proto"), []byte(`syntax = "proto3";`), 0644))
+
+		_, err = pkgStore.PublishSchema(pkg.ID, pkg.Name, version, stagingDir, "user123", pkgstore.StatusPublished)
+		assert.ErrorIs(t, err, pkgstore.ErrInvalidPath, version)
+		assert.Error(t, pkgstore.ValidateVersion(version), version)
+	}
+
+	assert.FileExists(t, filepath.Join(schemaPath(t, pkgStore, "other", "v1.0.0"), "test.proto"))
+	assert.FileExists(t, filepath.Join(pkgStore.GetDataDir(), "protodex.db"))
+	assert.NoError(t, pkgstore.ValidateVersion("v1.2.3-rc.1+build.5"))
+}
+
+func TestDraftReviews(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	pkgStore := storage.Package()
+
+	pkg, err := pkgStore.CreatePackage("test-package", "A test package", "user123", []string{})
+	require.NoError(t, err)
+
+	stage := func(content string) string {
+		dir, err := pkgStore.StagingDir()
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "test.proto"), []byte(content), 0644))
+		return dir
+	}
+
+	draft, err := pkgStore.PublishSchema(pkg.ID, pkg.Name, "v1.0.0", stage(`syntax = "proto3";`), "user123", pkgstore.StatusDraft)
+	require.NoError(t, err)
+	assert.Equal(t, pkgstore.StatusDraft, draft.Status)
+	assert.False(t, draft.Published())
+
+	require.NoError(t, pkgStore.ReviewVersion(draft.ID, "user456", pkgstore.DecisionReject, "missing comments"))
+	require.NoError(t, pkgStore.ReviewVersion(draft.ID, "user456", pkgstore.DecisionApprove, "looks good"))
+	assert.Error(t, pkgStore.ReviewVersion(draft.ID, "user456", "maybe", ""))
+	reviews, err := pkgStore.ListReviews(draft.ID)
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+	assert.Equal(t, pkgstore.DecisionApprove, reviews[0].Decision)
+	assert.Equal(t, "looks good", reviews[0].Comment)
+
+	// Pushing a draft again replaces it and drops its reviews
+	replaced, err := pkgStore.PublishSchema(pkg.ID, pkg.Name, "v1.0.0", stage(`syntax = "proto2";`), "user123", pkgstore.StatusDraft)
+	require.NoError(t, err)
+	assert.NotEqual(t, draft.ID, replaced.ID)
+	require.Len(t, replaced.Files, 1)
+	reviews, err = pkgStore.ListReviews(replaced.ID)
+	require.NoError(t, err)
+	assert.Empty(t, reviews)
+
+	require.NoError(t, pkgStore.SetVersionStatus(replaced.ID, pkgstore.StatusDraft, pkgstore.StatusPublished))
+	assert.ErrorIs(t, pkgStore.SetVersionStatus(replaced.ID, pkgstore.StatusDraft, pkgstore.StatusPublished), pkgstore.ErrStatusChanged)
+
+	published, err := pkgStore.GetSchemaVersion(pkg.ID, "v1.0.0")
+	require.NoError(t, err)
+	assert.True(t, published.Published())
+
+	// Once published the version is immutable
+	_, err = pkgStore.PublishSchema(pkg.ID, pkg.Name, "v1.0.0", stage(`syntax = "proto3";`), "user123", pkgstore.StatusDraft)
+	assert.ErrorIs(t, err, pkgstore.ErrVersionExists)
+}
+
+func TestLabels(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	pkgStore := storage.Package()
+
+	pkg, err := pkgStore.CreatePackage("test-package", "A test package", "user123", []string{})
+	require.NoError(t, err)
+
+	publish := func(version string) *pkgstore.SchemaVersion {
+		dir, err := pkgStore.StagingDir()
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "test.proto"), []byte(`syntax = "proto3";`), 0644))
+		schemaVersion, err := pkgStore.PublishSchema(pkg.ID, pkg.Name, version, dir, "user123", pkgstore.StatusPublished)
+		require.NoError(t, err)
+		return schemaVersion
+	}
+	v1, v2 := publish("v1.0.0"), publish("v1.1.0")
+
+	previous, err := pkgStore.SetLabel(pkg.ID, "prod", v1.ID, "user123")
+	require.NoError(t, err)
+	assert.Empty(t, previous)
+	previous, err = pkgStore.SetLabel(pkg.ID, "prod", v2.ID, "user456")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.0", previous)
+	// Setting a label to its current version is not a move
+	previous, err = pkgStore.SetLabel(pkg.ID, "prod", v2.ID, "user456")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0", previous)
+	_, err = pkgStore.SetLabel(pkg.ID, "stable", v1.ID, "user123")
+	require.NoError(t, err)
+
+	label, err := pkgStore.GetLabel(pkg.ID, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0", label.Version)
+
+	labels, err := pkgStore.ListLabels(pkg.ID)
+	require.NoError(t, err)
+	require.Len(t, labels, 2)
+	assert.Equal(t, "prod", labels[0].Name)
+	assert.Equal(t, "stable", labels[1].Name)
+
+	require.NoError(t, pkgStore.DeleteLabel(pkg.ID, "prod", "user123"))
+	_, err = pkgStore.GetLabel(pkg.ID, "prod")
+	assert.ErrorIs(t, err, pkgstore.ErrLabelNotFound)
+	assert.ErrorIs(t, pkgStore.DeleteLabel(pkg.ID, "prod", "user123"), pkgstore.ErrLabelNotFound)
+
+	history, err := pkgStore.LabelHistory(pkg.ID, "prod")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, "v1.1.0", history[0].From)
+	assert.Empty(t, history[0].To)
+	assert.Equal(t, "v1.0.0", history[1].From)
+	assert.Equal(t, "v1.1.0", history[1].To)
+	assert.Empty(t, history[2].From)
+
+	for _, name := range []string{"latest", "latest-stable", "v1", "1.2.x", "x", "Prod", "-beta", ""} {
+		_, err := pkgStore.SetLabel(pkg.ID, name, v1.ID, "user123")
+		assert.Error(t, err, name)
+	}
+}
+
+func TestYankAndDeleteVersion(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	pkgStore := storage.Package()
+
+	pkg, err := pkgStore.CreatePackage("test-package", "A test package", "user123", []string{})
+	require.NoError(t, err)
+
+	stagingDir, err := pkgStore.StagingDir()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(stagingDir, "test.proto"), []byte(`syntax = "proto3";`), 0644))
+	published, err := pkgStore.PublishSchema(pkg.ID, pkg.Name, "v1.0.0", stagingDir, "user123", pkgstore.StatusPublished)
+	require.NoError(t, err)
+	_, err = pkgStore.SetLabel(pkg.ID, "prod", published.ID, "user123")
+	require.NoError(t, err)
+
+	require.NoError(t, pkgStore.SetYanked(pkg.ID, "v1.0.0", true, "broken"))
+	version, err := pkgStore.GetSchemaVersion(pkg.ID, "v1.0.0")
+	require.NoError(t, err)
+	assert.True(t, version.Yanked)
+	assert.Equal(t, "broken", version.YankReason)
+
+	// Restoring a version clears the reason
+	require.NoError(t, pkgStore.SetYanked(pkg.ID, "v1.0.0", false, "ignored"))
+	version, err = pkgStore.GetSchemaVersion(pkg.ID, "v1.0.0")
+	require.NoError(t, err)
+	assert.False(t, version.Yanked)
+	assert.Empty(t, version.YankReason)
+
+	assert.Error(t, pkgStore.SetYanked(pkg.ID, "v9.9.9", true, ""))
+
+	require.NoError(t, pkgStore.DeleteSchemaVersion(pkg.ID, "v1.0.0", "user456"))
+	exists, err := pkgStore.HasVersion(pkg.ID, "v1.0.0")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.NoDirExists(t, schemaPath(t, pkgStore, pkg.Name, "v1.0.0"))
+
+	// Labels of a deleted version are removed with it
+	_, err = pkgStore.GetLabel(pkg.ID, "prod")
+	assert.ErrorIs(t, err, pkgstore.ErrLabelNotFound)
+	var dangling int
+	require.NoError(t, storage.(*dbStore).db.QueryRow(`SELECT COUNT(*) FROM package_labels WHERE package_id = ?`, pkg.ID).Scan(&dangling))
+	assert.Zero(t, dangling)
+	history, err := pkgStore.LabelHistory(pkg.ID, "prod")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "v1.0.0", history[0].From)
+	assert.Empty(t, history[0].To)
+}
+
+func TestDeleteSchemaVersionRejectsUnsafeVersions(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	pkgStore := storage.Package()
+
+	pkg, err := pkgStore.CreatePackage("test-package", "", "user123", []string{})
+	require.NoError(t, err)
+	stagingDir, err := pkgStore.StagingDir()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(stagingDir, "test.proto"), []byte(`syntax = "proto3";`), 0644))
+	_, err = pkgStore.PublishSchema(pkg.ID, pkg.Name, "v1.0.0", stagingDir, "user123", pkgstore.StatusPublished)
+	require.NoError(t, err)
+
+	// A version stored before versions were validated must not take the package directory with it
+	protoFile := filepath.Join(schemaPath(t, pkgStore, pkg.Name, "v1.0.0"), "test.proto")
+	_, err = pkgStore.SaveSchemaFiles(pkg.ID, "..", []string{protoFile}, "user123")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, pkgStore.DeleteSchemaVersion(pkg.ID, "..", "user123"), pkgstore.ErrInvalidPath)
+	assert.FileExists(t, protoFile)
+	exists, err := pkgStore.HasVersion(pkg.ID, "..")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestRenamePackage(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	pkgStore := storage.Package()
+
+	pkg, err := pkgStore.CreatePackage("old-name", "A test package", "user123", []string{})
+	require.NoError(t, err)
+	_, err = pkgStore.CreatePackage("other", "Another package", "user123", []string{})
+	require.NoError(t, err)
+
+	stagingDir, err := pkgStore.StagingDir()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(stagingDir, "test.proto"), []byte(`syntax = "proto3";`), 0644))
+	_, err = pkgStore.PublishSchema(pkg.ID, pkg.Name, "v1.0.0", stagingDir, "user123", pkgstore.StatusPublished)
+	require.NoError(t, err)
+
+	renamed, err := pkgStore.RenamePackage(pkg.ID, "new-name")
+	require.NoError(t, err)
+	assert.Equal(t, "new-name", renamed.Name)
+
+	// The old name redirects to the renamed package and cannot be reused
+	aliased, err := pkgStore.GetPackage("old-name")
+	require.NoError(t, err)
+	assert.Equal(t, pkg.ID, aliased.ID)
+	_, err = pkgStore.CreatePackage("old-name", "", "user456", []string{})
+	assert.ErrorIs(t, err, pkgstore.ErrNameTaken)
+
+	// Files moved along with the stored paths
+	version, err := pkgStore.GetSchemaVersion(pkg.ID, "v1.0.0")
+	require.NoError(t, err)
+	require.Len(t, version.Files, 1)
+	assert.Equal(t, filepath.Join("schemas", "new-name", "v1.0.0", "test.proto"), version.Files[0].FilePath)
+	assert.FileExists(t, filepath.Join(pkgStore.GetDataDir(), version.Files[0].FilePath))
+	assert.NoDirExists(t, schemaPath(t, pkgStore, "old-name", "v1.0.0"))
+
+	_, err = pkgStore.RenamePackage(pkg.ID, "other")
+	assert.ErrorIs(t, err, pkgstore.ErrNameTaken)
+
+	// Taking back an old name replaces its alias
+	renamed, err = pkgStore.RenamePackage(pkg.ID, "old-name")
+	require.NoError(t, err)
+	assert.Equal(t, "old-name", renamed.Name)
+	aliased, err = pkgStore.GetPackage("new-name")
+	require.NoError(t, err)
+	assert.Equal(t, pkg.ID, aliased.ID)
+}
+
+func TestDeletePackage(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	pkgStore := storage.Package()
+
+	pkg, err := pkgStore.CreatePackage("test-package", "A test package", "user123", []string{})
+	require.NoError(t, err)
+
+	stagingDir, err := pkgStore.StagingDir()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(stagingDir, "test.proto"), []byte(`syntax = "proto3";`), 0644))
+	_, err = pkgStore.PublishSchema(pkg.ID, pkg.Name, "v1.0.0", stagingDir, "user123", pkgstore.StatusPublished)
+	require.NoError(t, err)
+
+	require.NoError(t, pkgStore.DeletePackage(pkg.ID))
+
+	_, err = pkgStore.GetPackage("test-package")
+	assert.Error(t, err)
+	exists, err := pkgStore.HasVersion(pkg.ID, "v1.0.0")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.NoDirExists(t, schemaPath(t, pkgStore, "test-package", "v1.0.0"))
+}
+
+func TestPackageDirContainment(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	pkgStore := storage.Package()
+	dataDir := pkgStore.GetDataDir()
+
+	for _, name := range []string{"..", ".", "acme/..", "acme/.", "/payments", "acme/billing/.."} {
+		_, err := pkgStore.GetSchemaPath(name, "v1.0.0")
+		assert.ErrorIs(t, err, pkgstore.ErrInvalidPath, name)
+	}
+
+	// Packages created with such names before names were validated cannot take the data directory with them
+	pkg, err := pkgStore.CreatePackage("..", "", "user123", []string{})
+	require.NoError(t, err)
+	assert.ErrorIs(t, pkgStore.DeletePackage(pkg.ID), pkgstore.ErrInvalidPath)
+	assert.DirExists(t, dataDir)
+	_, err = pkgStore.GetPackageByID(pkg.ID)
+	assert.NoError(t, err)
+
+	victim, err := pkgStore.CreatePackage("victim", "", "user123", []string{})
+	require.NoError(t, err)
+	_, err = pkgStore.RenamePackage(victim.ID, "acme/..")
+	assert.ErrorIs(t, err, pkgstore.ErrInvalidPath)
+}
+
+func TestTransferOwnership(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	pkgStore := storage.Package()
+
+	pkg, err := pkgStore.CreatePackage("test-package", "A test package", "user123", []string{})
+	require.NoError(t, err)
+
+	require.NoError(t, pkgStore.TransferOwnership(pkg.ID, "user456"))
+	pkg, err = pkgStore.GetPackage("test-package")
+	require.NoError(t, err)
+	assert.Equal(t, "user456", pkg.OwnerID)
+
+	assert.Error(t, pkgStore.TransferOwnership("missing", "user456"))
+}
+
+func TestOrganizations(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	alice, err := storage.Auth().CreateUser("alice", "password")
+	require.NoError(t, err)
+	bob, err := storage.Auth().CreateUser("bob", "password")
+	require.NoError(t, err)
+
+	orgStore := storage.Org()
+
+	_, err = orgStore.CreateOrganization("Acme Corp", alice.ID)
+	assert.Error(t, err)
+
+	acme, err := orgStore.CreateOrganization("acme", alice.ID)
+	require.NoError(t, err)
+
+	role, err := orgStore.GetMemberRole(acme.ID, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, orgstore.RoleOwner, role)
+
+	_, err = orgStore.GetMemberRole(acme.ID, bob.ID)
+	assert.ErrorIs(t, err, orgstore.ErrNotMember)
+
+	require.NoError(t, orgStore.SetMember(acme.ID, bob.ID, orgstore.RoleMember))
+	members, err := orgStore.ListMembers(acme.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+
+	orgs, err := orgStore.ListUserOrganizations(bob.ID)
+	require.NoError(t, err)
+	require.Len(t, orgs, 1)
+	assert.Equal(t, "acme", orgs[0].Name)
+
+	require.NoError(t, orgStore.RemoveMember(acme.ID, bob.ID))
+	_, err = orgStore.GetMemberRole(acme.ID, bob.ID)
+	assert.ErrorIs(t, err, orgstore.ErrNotMember)
+}
+
+func TestScopedPackage(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	alice, err := storage.Auth().CreateUser("alice", "password")
+	require.NoError(t, err)
+	acme, err := storage.Org().CreateOrganization("acme", alice.ID)
+	require.NoError(t, err)
+
+	pkgStore := storage.Package()
+
+	pkg, err := pkgStore.CreatePackage("acme/payments", "", alice.ID, []string{})
+	require.NoError(t, err)
+	assert.Equal(t, acme.ID, pkg.OrgID)
+
+	// Unscoped packages with the same base name do not collide on disk
+	plain, err := pkgStore.CreatePackage("payments", "", alice.ID, []string{})
+	require.NoError(t, err)
+	assert.Empty(t, plain.OrgID)
+
+	assert.Equal(t, filepath.Join(pkgStore.GetDataDir(), "schemas", "@acme", "payments", "v1.0.0"), schemaPath(t, pkgStore, "acme/payments", "v1.0.0"))
+	assert.NotEqual(t, schemaPath(t, pkgStore, "payments", "v1.0.0"), schemaPath(t, pkgStore, "acme/payments", "v1.0.0"))
+
+	pkg, err = pkgStore.GetPackage("acme/payments")
+	require.NoError(t, err)
+	assert.Equal(t, "acme/payments", pkg.Name)
+
+	assert.NoError(t, pkgstore.ValidateName("acme/payments"))
+	assert.Error(t, pkgstore.ValidateName("acme/billing/v2"))
+	assert.Error(t, pkgstore.ValidateName("@acme/payments"))
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"payments", "user-service", "acme/payments", "Proto_Types.v2", "a"} {
+		assert.NoError(t, pkgstore.ValidateName(name), name)
+	}
+	for _, name := range []string{"", ".", "..", "...", "acme/..", "../acme", "acme/.", "./payments", `..\payments`, `acme\payments`,
+		"-payments", ".hidden", "pay ments", "pay\x00ments", "acme//payments", "/payments", "payments/"} {
+		assert.Error(t, pkgstore.ValidateName(name), name)
+	}
+}
+
+func TestCollaborators(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	alice, err := storage.Auth().CreateUser("alice", "password")
+	require.NoError(t, err)
+	bob, err := storage.Auth().CreateUser("bob", "password")
+	require.NoError(t, err)
+
+	pkgStore := storage.Package()
+
+	pkg, err := pkgStore.CreatePackage("test-package", "", alice.ID, []string{})
+	require.NoError(t, err)
+
+	_, err = pkgStore.GetCollaboratorRole(pkg.ID, bob.ID)
+	assert.ErrorIs(t, err, pkgstore.ErrNotCollaborator)
+
+	assert.Error(t, pkgStore.SetCollaborator(pkg.ID, bob.ID, "admin"))
+	require.NoError(t, pkgStore.SetCollaborator(pkg.ID, bob.ID, pkgstore.RoleReader))
+	require.NoError(t, pkgStore.SetCollaborator(pkg.ID, bob.ID, pkgstore.RoleMaintainer))
+
+	role, err := pkgStore.GetCollaboratorRole(pkg.ID, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, pkgstore.RoleMaintainer, role)
+
+	collaborators, err := pkgStore.ListCollaborators(pkg.ID)
+	require.NoError(t, err)
+	require.Len(t, collaborators, 1)
+	assert.Equal(t, "bob", collaborators[0].Username)
+
+	require.NoError(t, pkgStore.RemoveCollaborator(pkg.ID, bob.ID))
+	assert.ErrorIs(t, pkgStore.RemoveCollaborator(pkg.ID, bob.ID), pkgstore.ErrNotCollaborator)
+}
+
+func TestRoleAllows(t *testing.T) {
+	assert.True(t, pkgstore.RoleAllows(pkgstore.RoleOwner, pkgstore.RoleMaintainer))
+	assert.True(t, pkgstore.RoleAllows(pkgstore.RoleMaintainer, pkgstore.RoleMaintainer))
+	assert.False(t, pkgstore.RoleAllows(pkgstore.RoleReader, pkgstore.RoleMaintainer))
+	assert.False(t, pkgstore.RoleAllows("", pkgstore.RoleReader))
+	assert.Equal(t, pkgstore.RoleMaintainer, pkgstore.HigherRole(pkgstore.RoleReader, pkgstore.RoleMaintainer))
+}
+
+func TestSetVisibility(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	pkgStore := storage.Package()
+
+	pkg, err := pkgStore.CreatePackage("test-package", "", "user123", []string{})
+	require.NoError(t, err)
+	assert.Equal(t, pkgstore.VisibilityPublic, pkg.Visibility)
+
+	require.NoError(t, pkgStore.SetVisibility(pkg.ID, pkgstore.VisibilityPrivate))
+	pkg, err = pkgStore.GetPackage("test-package")
+	require.NoError(t, err)
+	assert.Equal(t, pkgstore.VisibilityPrivate, pkg.Visibility)
+
+	assert.Error(t, pkgStore.SetVisibility(pkg.ID, "internal"))
+	assert.Error(t, pkgStore.SetVisibility("missing", pkgstore.VisibilityPublic))
+}
+
+func TestSessionExpirySlides(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	authStore := storage.Auth()
+
+	user, err := authStore.CreateUser("alice", "password123")
+	require.NoError(t, err)
+	session, err := authStore.CreateSession("cli", user.ID, "hash")
+	require.NoError(t, err)
+
+	// Pretend the session is about to expire, using it pushes the expiry back
+	_, err = storage.(*dbStore).db.Exec(`UPDATE sessions SET expires_at = ? WHERE id = ?`, time.Now().Add(time.Hour), session.ID)
+	require.NoError(t, err)
+	require.NoError(t, authStore.UpdateSessionLastUsed(session.ID))
+
+	session, err = authStore.GetSession(session.ID)
+	require.NoError(t, err)
+	assert.True(t, session.ExpiresAt.After(time.Now().Add(authstore.SessionTTL-time.Minute)))
+	assert.NotNil(t, session.LastUsed)
+}
+
+func TestSetAdminAndEmail(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	authStore := storage.Auth()
+
+	alice, err := authStore.CreateUser("alice", "password123")
+	require.NoError(t, err)
+	assert.False(t, alice.IsAdmin)
+
+	require.NoError(t, authStore.SetAdmin(alice.ID, true))
+	require.NoError(t, authStore.SetEmail(alice.ID, "alice@example.com"))
+
+	alice, err = authStore.GetUserByID(alice.ID)
+	require.NoError(t, err)
+	assert.True(t, alice.IsAdmin)
+	assert.Equal(t, "alice@example.com", alice.Email)
+
+	assert.Error(t, authStore.SetAdmin("missing", true))
+}
+
+func TestDeleteUser(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	authStore := storage.Auth()
+	pkgStore := storage.Package()
+
+	alice, err := authStore.CreateUser("alice", "password123")
+	require.NoError(t, err)
+	bob, err := authStore.CreateUser("bob", "password123")
+	require.NoError(t, err)
+
+	pkg, err := pkgStore.CreatePackage("test-package", "", alice.ID, []string{})
+	require.NoError(t, err)
+	require.NoError(t, pkgStore.SetCollaborator(pkg.ID, bob.ID, pkgstore.RoleMaintainer))
+	_, err = authStore.CreateSession("cli", bob.ID, "hash")
+	require.NoError(t, err)
+
+	require.NoError(t, authStore.DeleteUser(bob.ID))
+
+	_, err = authStore.GetUserByUsername("bob")
+	assert.Error(t, err)
+	_, err = authStore.GetSessionByHash("hash")
+	assert.Error(t, err)
+	_, err = pkgStore.GetCollaboratorRole(pkg.ID, bob.ID)
+	assert.ErrorIs(t, err, pkgstore.ErrNotCollaborator)
+
+	assert.Error(t, authStore.DeleteUser(bob.ID))
+}
+
+func TestCleanStaging(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	pkgStore := storage.Package()
+
+	stale, err := pkgStore.StagingDir()
+	require.NoError(t, err)
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(stale, old, old))
+
+	fresh, err := pkgStore.StagingDir()
+	require.NoError(t, err)
+
+	require.NoError(t, pkgStore.CleanStaging(time.Hour))
+	assert.NoDirExists(t, stale)
+	assert.DirExists(t, fresh)
+}
+
+func TestAuditLog(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	auditStore := storage.Audit()
+
+	record := func(action, actor, targetType, target string) {
+		require.NoError(t, auditStore.Record(&auditstore.Entry{
+			Action:     action,
+			Actor:      actor,
+			IP:         "192.0.2.1",
+			TargetType: targetType,
+			Target:     target,
+			Details:    map[string]string{"checksum": "abc"},
+		}))
+	}
+	record(auditstore.ActionLogin, "jane", auditstore.TargetUser, "jane")
+	record(auditstore.ActionVersionPush, "jane", auditstore.TargetVersion, "payments@v1.0.0")
+	record(auditstore.ActionVersionYank, "bob", auditstore.TargetVersion, "payments@v1.0.0")
+	record(auditstore.ActionPackageDelete, "bob", auditstore.TargetPackage, "payments")
+
+	entries, err := auditStore.List(auditstore.Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	assert.Equal(t, auditstore.ActionPackageDelete, entries[0].Action)
+	assert.Equal(t, "192.0.2.1", entries[0].IP)
+	assert.Equal(t, map[string]string{"checksum": "abc"}, entries[0].Details)
+	assert.WithinDuration(t, time.Now(), entries[0].CreatedAt, time.Minute)
+
+	entries, err = auditStore.List(auditstore.Filter{Actor: "jane"})
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	// Actions match exactly or by group
+	entries, err = auditStore.List(auditstore.Filter{Action: "version"})
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	entries, err = auditStore.List(auditstore.Filter{Action: auditstore.ActionVersionYank})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	entries, err = auditStore.List(auditstore.Filter{Action: "vers"})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	entries, err = auditStore.List(auditstore.Filter{TargetType: auditstore.TargetVersion, Target: "payments@v1.0.0", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "bob", entries[0].Actor)
+
+	// Paging continues below the last entry
+	older, err := auditStore.List(auditstore.Filter{Before: entries[0].ID})
+	require.NoError(t, err)
+	require.Len(t, older, 2)
+	assert.Equal(t, auditstore.ActionVersionPush, older[0].Action)
+
+	entries, err = auditStore.List(auditstore.Filter{Since: time.Now().Add(-time.Minute), Until: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Len(t, entries, 4)
+	entries, err = auditStore.List(auditstore.Filter{Since: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Entries cannot be changed or deleted
+	db := storage.(*dbStore).db
+	_, err = db.Exec(`UPDATE audit_log SET actor = 'mallory'`)
+	assert.ErrorContains(t, err, "cannot be changed")
+	_, err = db.Exec(`DELETE FROM audit_log`)
+	assert.ErrorContains(t, err, "cannot be deleted")
+}
+
+func TestWebhooks(t *testing.T) {
+	storage := setupTestStorage(t)
+	defer cleanupTestStorage(t, storage)
+
+	webhookStore := storage.Webhook()
+
+	packageHook, err := webhookStore.CreateWebhook(&webhookstore.Webhook{
+		PackageID: "pkg-1",
+		URL:       "https://ci.example.com/hook",
+		Secret:    "package-secret",
+		Events:    []string{webhookstore.EventVersionPushed},
+		CreatedBy: "user-1",
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, packageHook.ID)
+	assert.Equal(t, "package-secret", packageHook.Secret)
+	assert.Equal(t, []string{webhookstore.EventVersionPushed}, packageHook.Events)
+
+	registryHook, err := webhookStore.CreateWebhook(&webhookstore.Webhook{
+		URL:    "https://chat.example.com/hook",
+		Secret: "registry-secret",
+		Events: []string{webhookstore.EventVersionPushed, webhookstore.EventPackageDeleted},
+	})
+	require.NoError(t, err)
+
+	hooks, err := webhookStore.ListWebhooks("pkg-1")
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	assert.Equal(t, packageHook.ID, hooks[0].ID)
+	hooks, err = webhookStore.ListWebhooks("")
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	assert.Equal(t, registryHook.ID, hooks[0].ID)
+
+	// Registry-wide webhooks receive the events of every package
+	subscribers, err := webhookStore.ListSubscribers("pkg-1", webhookstore.EventVersionPushed)
+	require.NoError(t, err)
+	assert.Len(t, subscribers, 2)
+	subscribers, err = webhookStore.ListSubscribers("pkg-2", webhookstore.EventVersionPushed)
+	require.NoError(t, err)
+	assert.Len(t, subscribers, 1)
+	subscribers, err = webhookStore.ListSubscribers("pkg-1", webhookstore.EventVersionYanked)
+	require.NoError(t, err)
+	assert.Empty(t, subscribers)
+
+	first := &webhookstore.Delivery{WebhookID: packageHook.ID, Event: webhookstore.EventVersionPushed, URL: packageHook.URL, Payload: "{}", Signature: "sha256=a"}
+	require.NoError(t, webhookStore.CreateDelivery(first))
+	assert.Equal(t, webhookstore.StatusPending, first.Status)
+	second := &webhookstore.Delivery{WebhookID: packageHook.ID, Event: webhookstore.EventVersionPushed, URL: packageHook.URL, Payload: "{}", Signature: "sha256=b"}
+	require.NoError(t, webhookStore.CreateDelivery(second))
+
+	due, err := webhookStore.DueDeliveries(time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	assert.Equal(t, first.ID, due[0].ID)
+	assert.Equal(t, "sha256=a", due[0].Signature)
+
+	// A failed attempt is retried later, a successful one is done
+	now := time.Now().UTC()
+	next := now.Add(time.Minute)
+	first.Attempts, first.ResponseStatus, first.Error = 1, 500, "webhook responded with status 500"
+	first.LastAttemptAt, first.NextAttemptAt = &now, &next
+	require.NoError(t, webhookStore.UpdateDelivery(first))
+	second.Attempts, second.ResponseStatus, second.Status = 1, 200, webhookstore.StatusSucceeded
+	second.LastAttemptAt, second.NextAttemptAt = &now, nil
+	require.NoError(t, webhookStore.UpdateDelivery(second))
+
+	due, err = webhookStore.DueDeliveries(time.Now(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+	due, err = webhookStore.DueDeliveries(next.Add(time.Second), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, 1, due[0].Attempts)
+
+	deliveries, err := webhookStore.ListDeliveries(packageHook.ID, false, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, second.ID, deliveries[0].ID)
+	deliveries, err = webhookStore.ListDeliveries(packageHook.ID, true, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, 500, deliveries[0].ResponseStatus)
+
+	// Only finished deliveries are pruned
+	pruned, err := webhookStore.PruneDeliveries(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), pruned)
+
+	// Deliveries outlive the webhooks of deleted packages, but not deleted webhooks
+	require.NoError(t, webhookStore.DeletePackageWebhooks("pkg-1"))
+	_, err = webhookStore.GetWebhook(packageHook.ID)
+	assert.ErrorIs(t, err, webhookstore.ErrNotFound)
+	_, err = webhookStore.GetDelivery(first.ID)
+	assert.NoError(t, err)
+
+	third := &webhookstore.Delivery{WebhookID: registryHook.ID, Event: webhookstore.EventPackageDeleted, URL: registryHook.URL, Payload: "{}", Signature: "sha256=c"}
+	require.NoError(t, webhookStore.CreateDelivery(third))
+	require.NoError(t, webhookStore.DeleteWebhook(registryHook.ID))
+	_, err = webhookStore.GetDelivery(third.ID)
+	assert.ErrorIs(t, err, webhookstore.ErrNotFound)
+	assert.ErrorIs(t, webhookStore.DeleteWebhook(registryHook.ID), webhookstore.ErrNotFound)
+
+	_, err = webhookstore.ValidateEvents(nil)
+	assert.Error(t, err)
+	_, err = webhookstore.ValidateEvents([]string{"version.deleted"})
+	assert.Error(t, err)
+	events, err := webhookstore.ValidateEvents([]string{webhookstore.EventVersionPushed, webhookstore.EventVersionPushed})
+	require.NoError(t, err)
+	assert.Equal(t, []string{webhookstore.EventVersionPushed}, events)
+}
+
 // Helper functions for test setup
+func schemaPath(t *testing.T, pkgStore pkgstore.Store, packageName, version string) string {
+	dir, err := pkgStore.GetSchemaPath(packageName, version)
+	require.NoError(t, err)
+	return dir
+}
+
 func setupTestStorage(t *testing.T) Store {
 	tmpDir, err := os.MkdirTemp("", "protodex-test-")
 	require.NoError(t, err)
@@ -0,0 +1,335 @@
+package webhook
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Events webhooks can subscribe to
+const (
+	EventPackageCreated = "package.created"
+	EventPackageDeleted = "package.deleted"
+	EventVersionPushed  = "version.pushed"
+	EventVersionYanked  = "version.yanked"
+)
+
+// Events lists every event, in the order they are documented
+var Events = []string{EventPackageCreated, EventPackageDeleted, EventVersionPushed, EventVersionYanked}
+
+// Delivery statuses. Pending deliveries are waiting for their first attempt or a retry, failed
+// deliveries ran out of attempts.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// ErrNotFound is returned when a webhook or delivery does not exist
+var ErrNotFound = errors.New("not found")
+
+// Webhook sends the events of a package to a URL. Webhooks without a package are registry-wide
+// and receive the events of every package.
+type Webhook struct {
+	ID        string `json:"id"`
+	PackageID string `json:"package_id,omitempty"`
+	URL       string `json:"url"`
+	// Secret signs the payloads, it is only returned when the webhook is created
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Subscribes reports whether the webhook receives event
+func (w *Webhook) Subscribes(event string) bool {
+	return slices.Contains(w.Events, event)
+}
+
+// Delivery is one event sent to one webhook. The URL, payload and signature are kept as they were
+// when the event happened, so deliveries complete after their webhook changes or its package is
+// deleted.
+type Delivery struct {
+	ID        string `json:"id"`
+	WebhookID string `json:"webhook_id"`
+	Event     string `json:"event"`
+	URL       string `json:"url"`
+	Payload   string `json:"payload"`
+	Signature string `json:"-"`
+	Status    string `json:"status"`
+	Attempts  int    `json:"attempts"`
+	// ResponseStatus, ResponseBody and Error describe the last attempt
+	ResponseStatus int        `json:"response_status,omitempty"`
+	ResponseBody   string     `json:"response_body,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+}
+
+type Store interface {
+	CreateWebhook(webhook *Webhook) (*Webhook, error)
+	GetWebhook(id string) (*Webhook, error)
+	// ListWebhooks lists the webhooks of a package, or the registry-wide webhooks when packageID is empty
+	ListWebhooks(packageID string) ([]*Webhook, error)
+	// ListSubscribers lists the webhooks of a package and the registry-wide webhooks receiving event
+	ListSubscribers(packageID, event string) ([]*Webhook, error)
+	// DeleteWebhook removes a webhook with its deliveries, pending ones are not attempted again
+	DeleteWebhook(id string) error
+	// DeletePackageWebhooks removes the webhooks of a deleted package. Their pending deliveries are
+	// still attempted, so they learn about the deletion.
+	DeletePackageWebhooks(packageID string) error
+
+	CreateDelivery(delivery *Delivery) error
+	GetDelivery(id string) (*Delivery, error)
+	// ListDeliveries lists the deliveries of a webhook, newest first. With failedOnly it only lists
+	// the deliveries whose last attempt failed.
+	ListDeliveries(webhookID string, failedOnly bool, limit int) ([]*Delivery, error)
+	// DueDeliveries lists the pending deliveries whose next attempt is due at now, oldest first
+	DueDeliveries(now time.Time, limit int) ([]*Delivery, error)
+	// UpdateDelivery stores the outcome of an attempt
+	UpdateDelivery(delivery *Delivery) error
+	// PruneDeliveries removes finished deliveries created before the given time
+	PruneDeliveries(before time.Time) (int64, error)
+}
+
+type dbStore struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) Store {
+	return &dbStore{
+		db: db,
+	}
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+const webhookColumns = `id, package_id, url, secret, events, created_by, created_at`
+
+func scanWebhook(row rowScanner) (*Webhook, error) {
+	webhook := &Webhook{}
+	var events string
+	if err := row.Scan(&webhook.ID, &webhook.PackageID, &webhook.URL, &webhook.Secret, &events,
+		&webhook.CreatedBy, &webhook.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(events), &webhook.Events); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook events: %w", err)
+	}
+	return webhook, nil
+}
+
+// CreateWebhook stores a new webhook. The ID and creation time are filled in by the store.
+func (ds *dbStore) CreateWebhook(webhook *Webhook) (*Webhook, error) {
+	events, err := json.Marshal(webhook.Events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook events: %w", err)
+	}
+	id := uuid.New().String()
+
+	query := `INSERT INTO webhooks (id, package_id, url, secret, events, created_by, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	if _, err := ds.db.Exec(query, id, webhook.PackageID, webhook.URL, webhook.Secret, string(events),
+		webhook.CreatedBy, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return ds.GetWebhook(id)
+}
+
+func (ds *dbStore) GetWebhook(id string) (*Webhook, error) {
+	webhook, err := scanWebhook(ds.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE id = ?`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("webhook %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+func (ds *dbStore) ListWebhooks(packageID string) ([]*Webhook, error) {
+	return ds.listWebhooks(`package_id = ?`, packageID)
+}
+
+func (ds *dbStore) ListSubscribers(packageID, event string) ([]*Webhook, error) {
+	webhooks, err := ds.listWebhooks(`package_id = ? OR package_id = ''`, packageID)
+	if err != nil {
+		return nil, err
+	}
+
+	subscribers := webhooks[:0]
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(event) {
+			subscribers = append(subscribers, webhook)
+		}
+	}
+	return subscribers, nil
+}
+
+func (ds *dbStore) listWebhooks(where string, args ...any) ([]*Webhook, error) {
+	rows, err := ds.db.Query(`SELECT `+webhookColumns+` FROM webhooks WHERE `+where+` ORDER BY created_at`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []*Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func (ds *dbStore) DeleteWebhook(id string) error {
+	tx, err := ds.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(`DELETE FROM webhook_deliveries WHERE webhook_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	result, err := tx.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("webhook %w", ErrNotFound)
+	}
+	return tx.Commit()
+}
+
+func (ds *dbStore) DeletePackageWebhooks(packageID string) error {
+	if packageID == "" {
+		return fmt.Errorf("package ID is required")
+	}
+	if _, err := ds.db.Exec(`DELETE FROM webhooks WHERE package_id = ?`, packageID); err != nil {
+		return fmt.Errorf("failed to delete webhooks: %w", err)
+	}
+	return nil
+}
+
+const deliveryColumns = `id, webhook_id, event, url, payload, signature, status, attempts, response_status,
+	response_body, error, created_at, last_attempt_at, next_attempt_at`
+
+func scanDelivery(row rowScanner) (*Delivery, error) {
+	delivery := &Delivery{}
+	if err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &delivery.URL, &delivery.Payload,
+		&delivery.Signature, &delivery.Status, &delivery.Attempts, &delivery.ResponseStatus, &delivery.ResponseBody,
+		&delivery.Error, &delivery.CreatedAt, &delivery.LastAttemptAt, &delivery.NextAttemptAt); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// CreateDelivery stores a pending delivery that is due right away. The ID, status and creation
+// time are filled in by the store.
+func (ds *dbStore) CreateDelivery(delivery *Delivery) error {
+	now := time.Now().UTC()
+	delivery.ID = uuid.New().String()
+	delivery.Status = StatusPending
+	delivery.CreatedAt = now
+	delivery.NextAttemptAt = &now
+
+	query := `INSERT INTO webhook_deliveries (id, webhook_id, event, url, payload, signature, status, created_at, next_attempt_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if _, err := ds.db.Exec(query, delivery.ID, delivery.WebhookID, delivery.Event, delivery.URL, delivery.Payload,
+		delivery.Signature, delivery.Status, delivery.CreatedAt, delivery.NextAttemptAt); err != nil {
+		return fmt.Errorf("failed to create delivery: %w", err)
+	}
+	return nil
+}
+
+func (ds *dbStore) GetDelivery(id string) (*Delivery, error) {
+	delivery, err := scanDelivery(ds.db.QueryRow(`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = ?`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("delivery %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+func (ds *dbStore) ListDeliveries(webhookID string, failedOnly bool, limit int) ([]*Delivery, error) {
+	where := `webhook_id = ?`
+	if failedOnly {
+		where += ` AND error != ''`
+	}
+	return ds.listDeliveries(where+` ORDER BY created_at DESC, rowid DESC LIMIT ?`, webhookID, limit)
+}
+
+func (ds *dbStore) DueDeliveries(now time.Time, limit int) ([]*Delivery, error) {
+	return ds.listDeliveries(`status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at, rowid LIMIT ?`,
+		StatusPending, now.UTC(), limit)
+}
+
+func (ds *dbStore) listDeliveries(where string, args ...any) ([]*Delivery, error) {
+	rows, err := ds.db.Query(`SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*Delivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (ds *dbStore) UpdateDelivery(delivery *Delivery) error {
+	query := `UPDATE webhook_deliveries SET status = ?, attempts = ?, response_status = ?, response_body = ?, error = ?,
+		last_attempt_at = ?, next_attempt_at = ? WHERE id = ?`
+	result, err := ds.db.Exec(query, delivery.Status, delivery.Attempts, delivery.ResponseStatus, delivery.ResponseBody,
+		delivery.Error, delivery.LastAttemptAt, delivery.NextAttemptAt, delivery.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update delivery: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("delivery %w", ErrNotFound)
+	}
+	return nil
+}
+
+func (ds *dbStore) PruneDeliveries(before time.Time) (int64, error) {
+	result, err := ds.db.Exec(`DELETE FROM webhook_deliveries WHERE status != ? AND created_at < ?`, StatusPending, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune deliveries: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ValidateEvents checks events are known and removes duplicates
+func ValidateEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return nil, fmt.Errorf("at least one event is required, one of %s", strings.Join(Events, ", "))
+	}
+	var valid []string
+	for _, event := range events {
+		if !slices.Contains(Events, event) {
+			return nil, fmt.Errorf("unknown event %q, expected one of %s", event, strings.Join(Events, ", "))
+		}
+		if !slices.Contains(valid, event) {
+			valid = append(valid, event)
+		}
+	}
+	return valid, nil
+}
@@ -6,6 +6,7 @@ import { Toaster } from './components/ui/toaster.tsx';
 import Layout from './components/layout/layout.tsx';
 import LoginPage from './pages/auth/login.tsx';
 import RegisterPage from './pages/auth/register.tsx';
+import SSOCallbackPage from './pages/auth/sso.tsx';
 import HomePage from './pages/home.tsx';
 import DashboardPage from './pages/dashboard.tsx';
 import PackageDetailPage from './pages/package/[name].tsx';
@@ -25,6 +26,7 @@ function App() {
               <Routes>
                 <Route path="/login" element={<LoginPage />} />
                 <Route path="/register" element={<RegisterPage />} />
+                <Route path="/sso" element={<SSOCallbackPage />} />
                 <Route path="/" element={<HomePage />} />
                 <Route path="/package/:name" element={<PackageDetailPage />} />
                 <Route
@@ -6,10 +6,18 @@ interface User {
     username: string;
 }
 
+export interface RegisterDetails {
+    email?: string;
+    invite?: string;
+}
+
 interface AuthContextType {
     user: User | null;
-    login: (username: string, password: string) => Promise<void>;
-    register: (username: string, password: string) => Promise<void>;
+    // login resolves with a two-factor challenge when the user has two-factor authentication enabled
+    login: (username: string, password: string) => Promise<string | undefined>;
+    completeTwoFactor: (challenge: string, code: string) => Promise<void>;
+    loginWithToken: (token: string) => Promise<void>;
+    register: (username: string, password: string, details?: RegisterDetails) => Promise<void>;
     logout: () => Promise<void>;
     loading: boolean;
     authenticatedFetch: (url: string, options?: RequestInit) => Promise<Response>;
@@ -74,6 +82,9 @@ export function AuthProvider({ children }: { children: ReactNode }) {
             }
 
             const data = await response.json();
+            if (data.two_factor_challenge) {
+                return data.two_factor_challenge as string;
+            }
             setToken(data.token);
             setUser(data.user);
             localStorage.setItem('auth_token', data.token);
@@ -92,12 +103,59 @@ export function AuthProvider({ children }: { children: ReactNode }) {
         }
     };
 
-    const register = async (username: string, password: string) => {
+    // completeTwoFactor finishes a login with a code from the authenticator app or a recovery code
+    const completeTwoFactor = async (challenge: string, code: string) => {
+        try {
+            const response = await fetch('/api/auth/login/2fa', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ challenge, code }),
+            });
+
+            if (!response.ok) {
+                const errorData = await response.json();
+                throw new Error(errorData.error || 'Login failed');
+            }
+
+            const data = await response.json();
+            setToken(data.token);
+            setUser(data.user);
+            localStorage.setItem('auth_token', data.token);
+
+            toast({
+                title: "Success",
+                description: "Logged in successfully!",
+            });
+        } catch (error) {
+            toast({
+                title: "Error",
+                description: error instanceof Error ? error.message : 'Login failed',
+                variant: "destructive",
+            });
+            throw error;
+        }
+    };
+
+    // loginWithToken signs in with a session token created by a single sign-on login
+    const loginWithToken = async (sessionToken: string) => {
+        const response = await fetch('/api/auth/me', {
+            headers: { Authorization: `Bearer ${sessionToken}` },
+        });
+        if (!response.ok) {
+            throw new Error('Single sign-on login failed');
+        }
+
+        setToken(sessionToken);
+        setUser(await response.json());
+        localStorage.setItem('auth_token', sessionToken);
+    };
+
+    const register = async (username: string, password: string, details: RegisterDetails = {}) => {
         try {
             const response = await fetch('/api/auth/register', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
-                body: JSON.stringify({ username, password }),
+                body: JSON.stringify({ username, password, ...details }),
             });
 
             if (!response.ok) {
@@ -125,6 +183,11 @@ export function AuthProvider({ children }: { children: ReactNode }) {
     };
 
     const logout = async () => {
+        try {
+            await authenticatedFetch('/api/auth/logout', { method: 'POST' });
+        } catch (error) {
+            console.error('Failed to end session:', error);
+        }
         localStorage.removeItem('auth_token');
         setToken(null);
         setUser(null);
@@ -137,6 +200,8 @@ export function AuthProvider({ children }: { children: ReactNode }) {
     const value = {
         user,
         login,
+        completeTwoFactor,
+        loginWithToken,
         register,
         logout,
         loading,
@@ -1,5 +1,5 @@
-import { useState } from 'react';
-import { Link, useNavigate, useLocation } from 'react-router-dom';
+import { FormEvent, useEffect, useState } from 'react';
+import { Link, useNavigate, useLocation, useSearchParams } from 'react-router-dom';
 import { useForm } from 'react-hook-form';
 import { Button } from '@/components/ui/button.tsx';
 import { Input } from '@/components/ui/input.tsx';
@@ -13,13 +13,31 @@ interface LoginFormData {
   password: string;
 }
 
+interface SSOInfo {
+  enabled: boolean;
+  name?: string;
+}
+
 export default function LoginPage() {
   const [isLoading, setIsLoading] = useState(false);
-  const { login } = useAuth();
+  const { login, completeTwoFactor } = useAuth();
   const navigate = useNavigate();
   const location = useLocation();
-  
+  const [searchParams] = useSearchParams();
+  const [sso, setSSO] = useState<SSOInfo>({ enabled: false });
+  // challenge is set once the password was accepted and the user has to enter a two-factor code
+  const [challenge, setChallenge] = useState<string | undefined>(location.state?.challenge);
+  const [code, setCode] = useState('');
+
   const from = location.state?.from?.pathname || '/dashboard';
+  const ssoError = searchParams.get('sso_error');
+
+  useEffect(() => {
+    fetch('/api/auth/sso')
+      .then((response) => (response.ok ? response.json() : null))
+      .then((info) => info && setSSO(info))
+      .catch((error) => console.error('Failed to load single sign-on settings:', error));
+  }, []);
   
   const {
     register,
@@ -30,14 +48,86 @@ export default function LoginPage() {
   const onSubmit = async (data: LoginFormData) => {
     try {
       setIsLoading(true);
-      await login(data.username, data.password);
+      const twoFactorChallenge = await login(data.username, data.password);
+      if (twoFactorChallenge) {
+        setChallenge(twoFactorChallenge);
+        return;
+      }
+      navigate(from, { replace: true });
+    } catch (error) {
+    } finally {
+      setIsLoading(false);
+    }
+  };
+
+  const onSubmitCode = async (event: FormEvent) => {
+    event.preventDefault();
+    if (!challenge || !code.trim()) {
+      return;
+    }
+    try {
+      setIsLoading(true);
+      await completeTwoFactor(challenge, code.trim());
       navigate(from, { replace: true });
     } catch (error) {
+      setCode('');
+      if (error instanceof Error && error.message.includes('expired')) {
+        setChallenge(undefined);
+      }
     } finally {
       setIsLoading(false);
     }
   };
 
+  if (challenge) {
+    return (
+      <div className="container flex items-center justify-center min-h-[calc(100vh-200px)]">
+        <Card className="w-full max-w-md">
+          <CardHeader className="text-center">
+            <div className="flex justify-center mb-4">
+              <Package className="h-10 w-10" />
+            </div>
+            <CardTitle>Two-factor authentication</CardTitle>
+            <CardDescription>
+              Enter the code from your authenticator app or one of your recovery codes
+            </CardDescription>
+          </CardHeader>
+          <CardContent>
+            <form onSubmit={onSubmitCode} className="space-y-4">
+              <div className="space-y-2">
+                <Label htmlFor="code">Code</Label>
+                <Input
+                  id="code"
+                  type="text"
+                  inputMode="numeric"
+                  autoComplete="one-time-code"
+                  autoFocus
+                  placeholder="123456"
+                  value={code}
+                  onChange={(event) => setCode(event.target.value)}
+                />
+              </div>
+
+              <Button type="submit" className="w-full" disabled={isLoading || !code.trim()}>
+                {isLoading ? 'Verifying...' : 'Verify'}
+              </Button>
+            </form>
+
+            <div className="mt-6 text-center">
+              <button
+                type="button"
+                className="text-sm text-muted-foreground hover:underline"
+                onClick={() => setChallenge(undefined)}
+              >
+                Back to sign in
+              </button>
+            </div>
+          </CardContent>
+        </Card>
+      </div>
+    );
+  }
+
   return (
     <div className="container flex items-center justify-center min-h-[calc(100vh-200px)]">
       <Card className="w-full max-w-md">
@@ -51,6 +141,19 @@ export default function LoginPage() {
           </CardDescription>
         </CardHeader>
         <CardContent>
+          {ssoError && (
+            <p className="mb-4 text-sm text-center text-red-500">{ssoError}</p>
+          )}
+          {sso.enabled && (
+            <>
+              <Button asChild variant="outline" className="w-full">
+                <a href={`/api/auth/sso/login?redirect=${encodeURIComponent(from)}`}>
+                  Sign in with {sso.name || 'SSO'}
+                </a>
+              </Button>
+              <div className="my-4 text-center text-xs text-muted-foreground">or</div>
+            </>
+          )}
           <form onSubmit={handleSubmit(onSubmit)} className="space-y-4">
             <div className="space-y-2">
               <Label htmlFor="username">Username</Label>
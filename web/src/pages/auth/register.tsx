@@ -1,5 +1,5 @@
-import { useState } from 'react';
-import { Link, useNavigate } from 'react-router-dom';
+import { useEffect, useState } from 'react';
+import { Link, useNavigate, useSearchParams } from 'react-router-dom';
 import { useForm } from 'react-hook-form';
 import { Button } from '@/components/ui/button.tsx';
 import { Input } from '@/components/ui/input.tsx';
@@ -10,28 +10,50 @@ import { Package } from 'lucide-react';
 
 interface RegisterFormData {
   username: string;
+  email: string;
+  invite: string;
   password: string;
   confirmPassword: string;
 }
 
+interface RegistrationInfo {
+  mode: 'open' | 'invite' | 'closed';
+  allowed_email_domains?: string[];
+}
+
 export default function RegisterPage() {
   const [isLoading, setIsLoading] = useState(false);
   const { register: registerUser } = useAuth();
   const navigate = useNavigate();
-  
+  const [searchParams] = useSearchParams();
+  const [registration, setRegistration] = useState<RegistrationInfo>({ mode: 'open' });
+
   const {
     register,
     handleSubmit,
     formState: { errors },
     watch,
-  } = useForm<RegisterFormData>();
+  } = useForm<RegisterFormData>({
+    defaultValues: { invite: searchParams.get('invite') ?? '' },
+  });
 
   const password = watch('password');
+  const emailDomains = registration.allowed_email_domains ?? [];
+
+  useEffect(() => {
+    fetch('/api/auth/registration')
+      .then((response) => (response.ok ? response.json() : null))
+      .then((info) => info && setRegistration(info))
+      .catch((error) => console.error('Failed to load registration settings:', error));
+  }, []);
 
   const onSubmit = async (data: RegisterFormData) => {
     try {
       setIsLoading(true);
-      await registerUser(data.username, data.password);
+      await registerUser(data.username, data.password, {
+        email: data.email || undefined,
+        invite: data.invite || undefined,
+      });
       navigate('/dashboard');
     } catch (error) {
     } finally {
@@ -52,6 +74,11 @@ export default function RegisterPage() {
           </CardDescription>
         </CardHeader>
         <CardContent>
+          {registration.mode === 'closed' ? (
+            <p className="text-sm text-center text-muted-foreground">
+              Registration is closed on this registry. Ask an administrator to create an account for you.
+            </p>
+          ) : (
           <form onSubmit={handleSubmit(onSubmit)} className="space-y-4">
             <div className="space-y-2">
               <Label htmlFor="username">Username</Label>
@@ -76,6 +103,40 @@ export default function RegisterPage() {
               )}
             </div>
             
+            <div className="space-y-2">
+              <Label htmlFor="email">Email{emailDomains.length === 0 && ' (optional)'}</Label>
+              <Input
+                id="email"
+                type="email"
+                placeholder={emailDomains.length > 0 ? `you@${emailDomains[0]}` : 'Enter your email'}
+                {...register('email', {
+                  required: emailDomains.length > 0 ? 'Email is required' : false,
+                  validate: (value) =>
+                    emailDomains.length === 0 ||
+                    emailDomains.some((domain) => value.toLowerCase().endsWith(`@${domain.toLowerCase()}`)) ||
+                    `Email must be in ${emailDomains.join(', ')}`,
+                })}
+              />
+              {errors.email && (
+                <p className="text-sm text-red-500">{errors.email.message}</p>
+              )}
+            </div>
+
+            {registration.mode === 'invite' && (
+              <div className="space-y-2">
+                <Label htmlFor="invite">Invite code</Label>
+                <Input
+                  id="invite"
+                  type="text"
+                  placeholder="Enter your invite code"
+                  {...register('invite', { required: 'An invite code is required' })}
+                />
+                {errors.invite && (
+                  <p className="text-sm text-red-500">{errors.invite.message}</p>
+                )}
+              </div>
+            )}
+
             <div className="space-y-2">
               <Label htmlFor="password">Password</Label>
               <Input
@@ -85,8 +146,8 @@ export default function RegisterPage() {
                 {...register('password', { 
                   required: 'Password is required',
                   minLength: {
-                    value: 6,
-                    message: 'Password must be at least 6 characters'
+                    value: 8,
+                    message: 'Password must be at least 8 characters'
                   }
                 })}
               />
@@ -115,6 +176,7 @@ export default function RegisterPage() {
               {isLoading ? 'Creating account...' : 'Create account'}
             </Button>
           </form>
+          )}
 
           <div className="mt-6 text-center">
             <p className="text-sm text-muted-foreground">
@@ -0,0 +1,39 @@
+import { useEffect } from 'react';
+import { useNavigate } from 'react-router-dom';
+import { useAuth } from '@/contexts/auth-context.tsx';
+
+// SSOCallbackPage finishes a single sign-on login. The registry passes the session token, or the
+// two-factor challenge of users with two-factor authentication, in the URL fragment, which never
+// reaches a server.
+export default function SSOCallbackPage() {
+  const { loginWithToken } = useAuth();
+  const navigate = useNavigate();
+
+  useEffect(() => {
+    const params = new URLSearchParams(window.location.hash.slice(1));
+    const token = params.get('token');
+    const redirect = params.get('redirect') || '/dashboard';
+    const challenge = params.get('challenge');
+    window.history.replaceState(null, '', window.location.pathname);
+
+    if (challenge) {
+      navigate('/login', { replace: true, state: { challenge, from: { pathname: redirect } } });
+      return;
+    }
+
+    if (!token) {
+      navigate('/login?sso_error=' + encodeURIComponent('Single sign-on login failed'), { replace: true });
+      return;
+    }
+
+    loginWithToken(token)
+      .then(() => navigate(redirect.startsWith('/') && !redirect.startsWith('//') ? redirect : '/dashboard', { replace: true }))
+      .catch((error: Error) => navigate('/login?sso_error=' + encodeURIComponent(error.message), { replace: true }));
+  }, []);
+
+  return (
+    <div className="container flex items-center justify-center min-h-[calc(100vh-200px)]">
+      <p className="text-sm text-muted-foreground">Signing in...</p>
+    </div>
+  );
+}